@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/xuewentao/argus-ota-platform/internal/chart"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/messaging"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+var logger = logging.Component("gather-worker")
+
+// topErrorCodeLimit 报告和事件中保留的异常码个数
+const topErrorCodeLimit = 10
+
+// GatherWorker 聚合 Worker：读取所有文件的解析产物 → 流式统计 → 写报告 → 发布 GatheringCompleted
+type GatherWorker struct {
+	batchRepo   domain.BatchRepository
+	reportRepo  domain.ReportRepository
+	storage     domain.ObjectStore
+	kafka       messaging.KafkaEventPublisher
+	concurrency int
+}
+
+func NewGatherWorker(
+	batchRepo domain.BatchRepository,
+	reportRepo domain.ReportRepository,
+	storage domain.ObjectStore,
+	kafka messaging.KafkaEventPublisher,
+	concurrency int,
+) *GatherWorker {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &GatherWorker{
+		batchRepo:   batchRepo,
+		reportRepo:  reportRepo,
+		storage:     storage,
+		kafka:       kafka,
+		concurrency: concurrency,
+	}
+}
+
+// gatherRequestedMessage GatherRequested 命令（Orchestrator 在 Barrier 完成后发布）
+type gatherRequestedMessage struct {
+	EventType   string                    `json:"event_type"`
+	BatchID     string                    `json:"batch_id"`
+	TenantID    string                    `json:"tenant_id"`
+	Priority    domain.BatchPriority      `json:"priority"` // GatheringCompleted 沿用同一条车道
+	TotalFiles  int                       `json:"total_files"`
+	ParsedFiles []domain.ParsedFileOutput `json:"parsed_files"`
+}
+
+// HandleMessage 处理 Kafka 消息（只关心 GatherRequested）
+func (w *GatherWorker) HandleMessage(ctx context.Context, data []byte) error {
+	var msg gatherRequestedMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.ErrorContext(ctx, "failed to unmarshal event", "error", err)
+		return err
+	}
+	if msg.EventType != "GatherRequested" {
+		return nil
+	}
+
+	batchID, err := uuid.Parse(msg.BatchID)
+	if err != nil {
+		return fmt.Errorf("invalid batch_id: %w", err)
+	}
+	// 多租户改造之前发布的命令没有 tenant_id，属于 default 租户
+	if msg.TenantID == "" {
+		msg.TenantID = domain.DefaultTenantID
+	}
+	// 图表使用租户自己的主密钥加密
+	return w.handleGatherRequested(envelope.WithScope(ctx, msg.TenantID), batchID, msg)
+}
+
+// handleGatherRequested 聚合一个 Batch
+//
+// 任一文件读取失败则整个聚合失败、不发布 GatheringCompleted：
+// 部分文件的统计结果会误导诊断，由补偿任务按 SLA 重新下发 GatherRequested
+func (w *GatherWorker) handleGatherRequested(ctx context.Context, batchID uuid.UUID, msg gatherRequestedMessage) error {
+	start := time.Now()
+	logger.InfoContext(ctx, "GatherRequested received", "batch_id", batchID, "files", len(msg.ParsedFiles))
+
+	total, err := w.aggregate(ctx, msg.ParsedFiles)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate batch %s: %w", batchID, err)
+	}
+
+	cpu, ram := total.CPUStats(), total.RAMStats()
+	topErrorCodes := total.TopErrorCodes(topErrorCodeLimit)
+
+	report, err := w.loadReport(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	report.ApplyStatistics(cpu, ram, total.Records, topErrorCodes)
+
+	chartFiles, err := w.renderCharts(ctx, msg.TenantID, batchID, total)
+	if err != nil {
+		return fmt.Errorf("failed to render charts: %w", err)
+	}
+	report.SetChartFiles(chartFiles)
+	if err := w.reportRepo.Save(ctx, report); err != nil {
+		return fmt.Errorf("failed to save report: %w", err)
+	}
+
+	event := domain.GatheringCompleted{
+		Version:       "v1.0",
+		BatchID:       batchID,
+		TenantID:      msg.TenantID,
+		VIN:           report.VIN,
+		Priority:      msg.Priority,
+		TotalFiles:    msg.TotalFiles,
+		ChartFiles:    pngOnly(chartFiles),
+		RecordCount:   total.Records,
+		CPUStats:      cpu,
+		RAMStats:      ram,
+		TopErrorCodes: topErrorCodes,
+		OccurredAt:    time.Now(),
+	}
+	if err := w.kafka.PublishEvents(ctx, []domain.DomainEvent{event}); err != nil {
+		return fmt.Errorf("failed to publish GatheringCompleted: %w", err)
+	}
+
+	logger.InfoContext(ctx, "batch aggregated",
+		"batch_id", batchID,
+		"records", total.Records,
+		"error_codes", len(total.ErrorCodes),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return nil
+}
+
+// aggregate 按文件并行统计后合并
+func (w *GatherWorker) aggregate(ctx context.Context, files []domain.ParsedFileOutput) (*stats.Aggregate, error) {
+	total := stats.NewAggregate()
+	var mu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(w.concurrency)
+	for _, file := range files {
+		file := file
+		g.Go(func() error {
+			agg, err := w.aggregateFile(gctx, file.OutputPath)
+			if err != nil {
+				return fmt.Errorf("file %s: %w", file.FileID, err)
+			}
+			mu.Lock()
+			total.Merge(agg)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+// aggregateFile 流式读取单个解析产物（规范 CSV）
+func (w *GatherWorker) aggregateFile(ctx context.Context, outputPath string) (*stats.Aggregate, error) {
+	if outputPath == "" {
+		return nil, fmt.Errorf("missing output path")
+	}
+	src, err := w.storage.GetObject(ctx, outputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	agg := stats.NewAggregate()
+	err = parser.NewCSVDecoder().Decode(ctx, src, func(record parser.Record) error {
+		agg.Add(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// renderCharts 渲染标准图表集（PNG + SVG）并上传到 Batch 前缀下的 charts/，返回所有对象路径
+//
+// 单张图只有几十 KB，先渲染到内存再上传（已知大小，MinIO 单次 PUT）
+func (w *GatherWorker) renderCharts(ctx context.Context, tenantID string, batchID uuid.UUID, total *stats.Aggregate) ([]string, error) {
+	var paths []string
+	for _, named := range chart.BatchCharts(total) {
+		for _, format := range chart.Formats {
+			var buf bytes.Buffer
+			if err := chart.Render(&buf, named.Chart, format); err != nil {
+				return nil, fmt.Errorf("chart %s.%s: %w", named.Name, format, err)
+			}
+			path := domain.ChartObjectPath(tenantID, batchID, named.Name+"."+string(format))
+			if err := w.storage.PutObject(ctx, path, &buf, int64(buf.Len()), format.ContentType()); err != nil {
+				return nil, fmt.Errorf("upload chart %s: %w", path, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// pngOnly GatheringCompleted 只带 PNG（诊断阶段的多模态模型不接受 SVG）
+func pngOnly(paths []string) []string {
+	out := make([]string, 0, len(paths)/2)
+	for _, p := range paths {
+		if strings.HasSuffix(p, "."+string(chart.FormatPNG)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// loadReport 复用已有报告（保留 ID），没有则按 Batch 新建
+func (w *GatherWorker) loadReport(ctx context.Context, batchID uuid.UUID) (*domain.Report, error) {
+	report, err := w.reportRepo.FindByBatchID(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find report: %w", err)
+	}
+	if report != nil {
+		return report, nil
+	}
+
+	batch, err := w.batchRepo.FindByID(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batch: %w", err)
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("batch not found: %s", batchID)
+	}
+	return domain.NewReport(batch), nil
+}
@@ -17,17 +17,27 @@ import (
 	_ "github.com/lib/pq"
 
 	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/auth"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/config"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/kafka"
-	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/minio"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/localfs"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/postgres"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+	redisinfra "github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
 	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/handlers"
+	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/middleware"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 type Config struct {
 	Server 	 ServerConfig
 	Database DatabaseConfig
-	MinIO    MinIOConfig
+	Storage  config.StorageConfig
 	Kafka    KafkaConfig
+	Auth     config.AuthConfig
 }
 
 type ServerConfig struct {
@@ -41,17 +51,12 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 }
-type MinIOConfig struct {
-	Endpoint  string
-	AccessKey string
-	SecretKey string
-	Bucket    string
-	UseSSL    bool
-}
 
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
+	Brokers       []string
+	Topic         string
+	DLQTopic      string
+	PriorityTopic string // 紧急 Batch 的 BatchCreated 投递到高优先级车道
 }
 func getEnv(key , defaultValue string) string {
 	if value := os.Getenv(key);value != "" {
@@ -66,10 +71,6 @@ func mustAtoi(s string, field string) int  {
     }
     return i
 }
-func parseBool(s string) bool {
-	b, _ := strconv.ParseBool(s)
-	return b
-}
 func loadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -83,17 +84,14 @@ func loadConfig() *Config {
 			Password: 	getEnv("DB_PASSWORD",""),
 			DBName: 	getEnv("DB_NAME","argus_ota"),
 		},
-		MinIO: MinIOConfig{
-			Endpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
-			AccessKey: getEnv("MINIO_ACCESS_KEY", ""),
-			SecretKey: getEnv("MINIO_SECRET_KEY", ""),
-			Bucket:    getEnv("MINIO_BUCKET", "argus-files"),
-			UseSSL:    parseBool(getEnv("MINIO_USE_SSL", "false")),
-		},
+		Storage: config.StorageConfigFromEnv(getEnv),
 		Kafka: KafkaConfig{
 			Brokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 			Topic:   getEnv("KAFKA_TOPIC", "batch-events"),
+			DLQTopic:      getEnv("KAFKA_DLQ_TOPIC", "batch-events-dlq"),
+			PriorityTopic: getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority"),
 		},
+		Auth: config.AuthConfigFromEnv(getEnv),
 	}
 }
 func initDB(cfg *Config) *sql.DB {
@@ -105,7 +103,7 @@ func initDB(cfg *Config) *sql.DB {
 		cfg.Database.Password,
 		cfg.Database.DBName,
 	)
-	db,err := sql.Open("postgres",dsn)
+	db,err := postgres.Open(dsn)
 	if err != nil {
 		log.Fatal("Failed to open database : ",err)
 	}
@@ -120,25 +118,22 @@ func initDB(cfg *Config) *sql.DB {
 	log.Println("[DB] Database connected successfully")
     return db
 }
-func initMinIO(cfg *Config) *minio.MinIOClient {
-	client, err := minio.NewMinIOClient(
-		cfg.MinIO.Endpoint,
-		cfg.MinIO.Bucket,
-		cfg.MinIO.AccessKey,
-		cfg.MinIO.SecretKey,
-		cfg.MinIO.UseSSL,
-	)
+// initStorage 初始化对象存储（STORAGE_BACKEND=minio|local）
+func initStorage(cfg *Config) domain.ObjectStore {
+	store, err := config.NewObjectStore(cfg.Storage)
 	if err != nil {
-		log.Fatal("Failed to init MinIO:", err)
+		log.Fatal("Failed to init object storage:", err)
 	}
-
-	log.Println("[MinIO] Client initialized successfully")
-	return client
+	log.Printf("[Storage] %s backend initialized successfully", cfg.Storage.Backend)
+	return store
 }
-func initKafkaProducer(cfg *Config) (messaging.KafkaEventPublisher, error) {
+func initKafkaProducer(cfg *Config, pseudonymizer *domain.Pseudonymizer) (messaging.KafkaEventPublisher, error) {
 	producer, err := kafka.NewKafkaEventProducer(
 		cfg.Kafka.Brokers,
 		cfg.Kafka.Topic,
+		cfg.Kafka.DLQTopic,
+		kafka.WithPriorityTopic(cfg.Kafka.PriorityTopic),
+		kafka.WithPseudonymizer(pseudonymizer),
 	)
 	if err != nil {
 		return nil, err
@@ -147,15 +142,45 @@ func initKafkaProducer(cfg *Config) (messaging.KafkaEventPublisher, error) {
 	log.Println("[Kafka] Producer initialized successfully")
 	return producer, nil
 }
-func initRouter(batchService *application.BatchService, minioClient *minio.MinIOClient) *gin.Engine {
-	router := gin.Default()
+func initRouter(batchService *application.BatchService, vehicleService *application.VehicleService, campaignService *application.CampaignService, storage domain.ObjectStore, authenticator *auth.Authenticator, accessPolicy *domain.AccessPolicy, auditRepo domain.AccessAuditRepository, tenants *domain.TenantRegistry, quotaService *application.QuotaService, checker *health.Checker) *gin.Engine {
+	// gin.Logger 会打印原始路径（含 VIN），访问日志由 logging.Middleware 按路由模板输出
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.Middleware(), metrics.Middleware(), tracing.Middleware())
+	metrics.RegisterRoutes(router)
+	health.RegisterRoutes(router, checker)
 
-	handler := handlers.NewBatchHandler(batchService, minioClient)
-	handler.RegisterRoutes(router)
+	// 车端接口：车辆与运维人员都可以访问，车辆只能操作自己的 VIN，运维人员按角色授权
+	devices := router.Group("",
+		middleware.Audit(auditRepo),
+		middleware.Authenticate(authenticator),
+		middleware.Tenant(tenants, nil),
+		middleware.Authorize(accessPolicy),
+	)
+	// 管理接口（车辆注册表、OTA 活动）：仅运维人员
+	operators := router.Group("",
+		middleware.Audit(auditRepo),
+		middleware.Authenticate(authenticator, domain.PrincipalOperator),
+		middleware.Tenant(tenants, nil),
+		middleware.Authorize(accessPolicy),
+	)
+
+	handler := handlers.NewBatchHandler(batchService, storage)
+	handler.RegisterRoutes(devices)
+	handlers.NewQuotaHandler(quotaService).RegisterRoutes(devices)
+	handlers.NewVehicleHandler(vehicleService).RegisterRoutes(operators)
+	campaignHandler := handlers.NewCampaignHandler(campaignService)
+	campaignHandler.RegisterRoutes(operators)
+	campaignHandler.RegisterVehicleRoutes(devices.Group("", middleware.RequireOwnVIN("vin")))
+
+	// 本地存储没有 MinIO 服务端，预签名链接由 Ingestor 自己提供下载（LOCAL_STORAGE_BASE_URL 指向这里）
+	// 启用加密时 storage 是 envelope.Store，加密对象不支持预签名，不挂载该路由
+	if local, ok := storage.(*localfs.Store); ok {
+		router.GET("/storage/*key", gin.WrapH(local.PresignHandler("/storage")))
+	}
 
 	return router
 }
-func startServer(router *gin.Engine,port string) *http.Server {
+func startServer(router *gin.Engine, port string, authCfg config.AuthConfig, clientCAs *auth.ClientCAs) *http.Server {
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      router,
@@ -164,15 +189,93 @@ func startServer(router *gin.Engine,port string) *http.Server {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// 配置了证书时启用 TLS；配置了客户端 CA 时同时校验车辆客户端证书（mTLS）
+	if authCfg.TLSCertFile != "" {
+		tlsConfig, err := auth.ServerTLSConfig(authCfg.TLSCertFile, authCfg.TLSKeyFile, clientCAs)
+		if err != nil {
+			log.Fatal("Failed to init TLS:", err)
+		}
+		server.TLSConfig = tlsConfig
+	}
+
 	go func() {
-		log.Printf("[Server] Starting on port %s", port)
-          if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-              log.Fatal("Server failed:", err)
-          }
+		log.Printf("[Server] Starting on port %s (tls=%t)", port, server.TLSConfig != nil)
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed:", err)
+		}
 	}()
 	return server
 }
-func gracefulShutdown(server *http.Server, db *sql.DB, kafkaProducer messaging.KafkaEventPublisher) {
+// initHealth 注册 PostgreSQL、对象存储与 Kafka 检查（HEALTH_CHECK_TIMEOUT 为单项超时），三者都是上传链路的关键依赖
+func initHealth(cfg *Config, db *sql.DB, storage domain.ObjectStore) (*health.Checker, *kafka.MetadataCheck) {
+	timeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		log.Fatal("Invalid HEALTH_CHECK_TIMEOUT:", err)
+	}
+	checker := health.New("ingestor", timeout)
+	checker.Add("postgres", health.KindCritical, db.PingContext)
+	if pinger, ok := storage.(domain.ObjectStorePinger); ok {
+		checker.Add("storage", health.KindCritical, pinger.Ping)
+	}
+	kafkaCheck := kafka.NewMetadataCheck(cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.PriorityTopic)
+	checker.Add("kafka", health.KindCritical, kafkaCheck.Check)
+	return checker, kafkaCheck
+}
+
+// initRedis Redis 是可选依赖（限流、每日配额、Batch 锁）；未配置 REDIS_ADDR 时返回 nil
+//
+// Redis 不可用时限流放行、Batch 锁降级为行锁，就绪检查中只标记 degraded
+func initRedis(checker *health.Checker) *redisinfra.RedisClient {
+	redisAddr := getEnv("REDIS_ADDR", "")
+	if redisAddr == "" {
+		log.Println("[Redis] REDIS_ADDR not set, rate limits and daily quotas are disabled, batch lock uses PostgreSQL row locks")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	redisClient, err := redisinfra.NewRedisClient(ctx, redisAddr, getEnv("REDIS_PASSWORD", ""), 0)
+	if err != nil {
+		log.Fatalf("Failed to create Redis client: %v", err)
+	}
+	checker.Add("redis", health.KindOptional, redisClient.Ping)
+	return redisClient
+}
+
+// initQuotaService 限流与每日配额依赖 Redis；redisClient 为 nil 时只校验单文件大小与存储总量
+func initQuotaService(tenants *domain.TenantRegistry, db *sql.DB, pseudonymizer *domain.Pseudonymizer, redisClient *redisinfra.RedisClient) *application.QuotaService {
+	usage := postgres.NewPostgresTenantUsageRepository(db)
+	if redisClient == nil {
+		return application.NewQuotaService(tenants, usage, nil, nil, pseudonymizer)
+	}
+	return application.NewQuotaService(tenants, usage,
+		redisinfra.NewRateLimiter(redisClient), redisinfra.NewUsageLedger(redisClient), pseudonymizer)
+}
+
+// initBatchLocker 与 Orchestrator 相同的 Batch 锁（Redis 锁，不可用时降级为行锁），
+// CompleteUpload 与 Orchestrator 的事件处理、补偿任务才能互斥
+func initBatchLocker(db *sql.DB, redisClient *redisinfra.RedisClient) domain.BatchLocker {
+	rowLocker := postgres.NewRowBatchLocker(db)
+	if redisClient == nil {
+		return rowLocker
+	}
+	lockTTL, err := time.ParseDuration(getEnv("BATCH_LOCK_TTL", "30s"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_LOCK_TTL: %v", err)
+	}
+	lockWait, err := time.ParseDuration(getEnv("BATCH_LOCK_WAIT", "5s"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_LOCK_WAIT: %v", err)
+	}
+	return application.NewFallbackBatchLocker(redisinfra.NewBatchLocker(redisClient, postgres.NewFencingTokenIssuer(db), lockTTL, lockWait), rowLocker)
+}
+func gracefulShutdown(server *http.Server, db *sql.DB, kafkaProducer messaging.KafkaEventPublisher, kafkaCheck *kafka.MetadataCheck, shutdownTracing func(context.Context) error) {
 	// 监听系统信号
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -198,34 +301,87 @@ func gracefulShutdown(server *http.Server, db *sql.DB, kafkaProducer messaging.K
 	if err := kafkaProducer.Close(); err != nil {
 		log.Println("[Shutdown] Kafka close error:", err)
 	}
+	kafkaCheck.Close()
+
+	// 刷出未导出的 Span
+	if err := shutdownTracing(ctx); err != nil {
+		log.Println("[Shutdown] Trace flush error:", err)
+	}
 
 	log.Println("[Shutdown] Graceful shutdown completed")
 }
 func main() {
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("ingestor", getEnv); err != nil {
+		log.Fatal("Failed to init logging:", err)
+	}
+
 	// 1. 加载配置
 	cfg := loadConfig()
 
+	// 链路追踪（OTEL_TRACES_EXPORTER=otlp|stdout，默认只透传 trace context）
+	shutdownTracing, err := tracing.Init(context.Background(), "ingestor", getEnv)
+	if err != nil {
+		log.Fatal("Failed to init tracing:", err)
+	}
+
 	// 2. 初始化基础设施
 	db := initDB(cfg)
-	minioClient := initMinIO(cfg)
-	kafkaProducer, err := initKafkaProducer(cfg)
+	storage := initStorage(cfg)
+	// VIN 假名化：Kafka 事件与 Redis Key 只使用令牌
+	pseudonymizer, err := config.NewPseudonymizer(getEnv)
+	if err != nil {
+		log.Fatal("Failed to init pseudonymizer:", err)
+	}
+	kafkaProducer, err := initKafkaProducer(cfg, pseudonymizer)
 	if err != nil {
 		log.Fatal("Failed to init Kafka:", err)
 	}
+	// 健康检查：/healthz 存活、/readyz 依赖就绪、/status 状态页
+	checker, kafkaCheck := initHealth(cfg, db, storage)
 
 	// 3. 初始化 Repository
 	batchRepo := postgres.NewPostgresBatchRepository(db)
 	fileRepo := postgres.NewPostgresFileRepository(db)
+	vehicleRepo := postgres.NewPostgresVehicleRepository(db)
 
 	// 4. 初始化 Service
-	batchService := application.NewBatchService(batchRepo, fileRepo, kafkaProducer)
+	// 租户配置（配额、暂停）；未配置时只有不限配额的 default 租户
+	tenants, err := config.LoadTenants(getEnv("TENANT_CONFIG_FILE", ""))
+	if err != nil {
+		log.Fatal("Failed to load tenants:", err)
+	}
+	redisClient := initRedis(checker)
+	quotaService := initQuotaService(tenants, db, pseudonymizer, redisClient)
+	batchService := application.NewBatchService(batchRepo, fileRepo, vehicleRepo, kafkaProducer, initBatchLocker(db, redisClient),
+		tenants, quotaService)
+	vehicleService := application.NewVehicleService(vehicleRepo, batchRepo)
+	// OTA 活动管理与车端升级接口（门禁推进由 Orchestrator Leader 负责）
+	campaignService := application.NewCampaignService(
+		postgres.NewPostgresCampaignRepository(db),
+		postgres.NewPostgresDeploymentRepository(db),
+		vehicleRepo,
+	)
 
-	// 5. 初始化 Router
-	router := initRouter(batchService, minioClient)
+	// 5. 初始化 Router（车辆 mTLS / JWT 与运维人员 OIDC 认证）
+	clientCAs, err := config.LoadClientCAs(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to init authentication:", err)
+	}
+	authenticator, err := config.NewAuthenticator(cfg.Auth, auth.WithClientCAs(clientCAs))
+	if err != nil {
+		log.Fatal("Failed to init authentication:", err)
+	}
+	accessPolicy, err := config.LoadAccessPolicy(getEnv("ACCESS_POLICY_FILE", ""))
+	if err != nil {
+		log.Fatal("Failed to load access policy:", err)
+	}
+	router := initRouter(batchService, vehicleService, campaignService, storage,
+		authenticator, accessPolicy, postgres.NewPostgresAccessAuditRepository(db), tenants, quotaService, checker)
 
 	// 6. 启动 HTTP Server
-	server := startServer(router, strconv.Itoa(cfg.Server.Port))
+	server := startServer(router, strconv.Itoa(cfg.Server.Port), cfg.Auth, clientCAs)
 
 	// 7. 优雅关闭
-	gracefulShutdown(server, db, kafkaProducer)
+	gracefulShutdown(server, db, kafkaProducer, kafkaCheck, shutdownTracing)
 }
@@ -15,8 +15,12 @@ import (
 	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/google/uuid"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/kafka"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/postgres"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
@@ -116,7 +120,9 @@ func (w *Worker) handleBatchCreated(ctx context.Context, event map[string]interf
 	for i := 0;i < batch.TotalFiles;i ++{
 		fileParsedEvents = append(fileParsedEvents,domain.FileParsed{
 			BatchID:   batchID,
+			TenantID:  batch.TenantID,
 			FileID:	   uuid.New(),
+			Priority:  batch.Priority,
 			OccurredAt:time.Now(),
 		})
 	}
@@ -144,7 +150,7 @@ func initDB(cfg *Config) *sql.DB {
 		cfg.Database.Password,
 		cfg.Database.DBName,
 	)
-	db,err := sql.Open("postgres",dsn)
+	db,err := postgres.Open(dsn)
 	if err != nil {
 		log.Fatal("Failed to open database : ",err)
 	}
@@ -162,13 +168,41 @@ func initDB(cfg *Config) *sql.DB {
 func main() {
 	ctx := context.Background()
 
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("mock-cpp-worker", getEnv); err != nil {
+		log.Fatalf("Failed to init logging: %v", err)
+	}
+
+	// 0. 链路追踪（OTEL_TRACES_EXPORTER=otlp|stdout，默认只透传 trace context）
+	shutdownTracing, err := tracing.Init(ctx, "mock-cpp-worker", getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
+	// 健康检查：/healthz 存活（Consumer 处理卡死时由容器编排重启）、/readyz 依赖就绪、/status 状态页
+	checkTimeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT: %v", err)
+	}
+	checker := health.New("mock-cpp-worker", checkTimeout)
+
 	// 1. 初始化 Kafka Producer（发布事件）
 	kafkaProducer := initKafkaProducer()
 
-	// 2. 初始化 Kafka Consumer（消费事件）
+	// 2. 初始化 Kafka Consumer（消费事件，优先处理高优先级车道）
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
+	topics := []string{"batch-events", priorityTopic}
+	kafkaCheck := kafka.NewMetadataCheck([]string{"localhost:9092"}, topics...)
+	checker.Add("kafka", health.KindCritical, kafkaCheck.Check)
+	stallTimeout, err := time.ParseDuration(getEnv("CONSUMER_STALL_TIMEOUT", "15m"))
+	if err != nil {
+		log.Fatalf("Invalid CONSUMER_STALL_TIMEOUT: %v", err)
+	}
 	kafkaConsumer, err := kafka.NewKafkaEventConsumer(
 		[]string{"localhost:9092"},
 		"cpp-worker-group-v2", // Consumer Group ID (new for testing)
+		kafka.WithPriorityTopics(priorityTopic),
+		kafka.WithHealthChecks(checker, stallTimeout),
 	)
 	if err != nil {
 		log.Fatalf("Failed to create Kafka consumer: %v", err)
@@ -184,19 +218,21 @@ func main() {
 		},
 	}
 	db := initDB(cfg)
+	checker.Add("postgres", health.KindCritical, db.PingContext)
 	batchRepo := postgres.NewPostgresBatchRepository(db)
 	// 3. 创建 Worker
 	worker := NewWorker(kafkaProducer,batchRepo,db)
 
 	// 4. 启动 Kafka Consumer
-	topics := []string{"batch-events"}
 
 	log.Println("========================================")
 	log.Println("🚀 Mock C++ Worker started successfully!")
-	log.Printf("📡 Consuming topic: %s", topics[0])
+	log.Printf("📡 Consuming topics: %v", topics)
 	log.Printf("📦 Consumer Group: cpp-worker-group-v2")
 	log.Println("========================================")
 
+	metricsServer := metrics.Serve(getEnv("METRICS_PORT", "9103"), checker.RegisterMux)
+
 	// 5. 优雅关闭
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -211,6 +247,9 @@ func main() {
 	// 等待系统信号
 	<-sigCh
 	log.Println("\n🛑 Shutting down Worker...")
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
 	if worker.db != nil {
 		if err := worker.db.Close(); err != nil {
 			log.Printf("Failed to close database: %v", err)
@@ -220,11 +259,18 @@ func main() {
 	if err := kafkaConsumer.Close(); err != nil {
 		log.Printf("Failed to close Kafka consumer: %v", err)
 	}
+	kafkaCheck.Close()
 
 	// 关闭 Kafka Producer
 	if err := kafkaProducer.Close(); err != nil {
 		log.Printf("Failed to close Kafka producer: %v", err)
 	}
+	// 刷出未导出的 Span
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	flushCancel()
 
 	log.Println("✅ Worker stopped gracefully")
 }
@@ -233,8 +279,11 @@ func main() {
 func initKafkaProducer() messaging.KafkaEventPublisher {
 	brokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
 	topic := getEnv("KAFKA_TOPIC", "batch-events")
+	dlqTopic := getEnv("KAFKA_DLQ_TOPIC", "batch-events-dlq")
+
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
 
-	producer, err := kafka.NewKafkaEventProducer(brokers, topic)
+	producer, err := kafka.NewKafkaEventProducer(brokers, topic, dlqTopic, kafka.WithPriorityTopic(priorityTopic))
 	if err != nil {
 		log.Fatalf("Failed to create Kafka producer: %v", err)
 	}
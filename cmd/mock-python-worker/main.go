@@ -1,5 +1,5 @@
 // 1. 订阅 Kafka topic（batch-events）
-// 2. 消费 AllFilesScattered 事件
+// 2. 消费 GatherRequested 命令（Orchestrator 在 Barrier 完成后发布）
 // 3. 模拟数据聚合（sleep 1 秒）
 // 4. 发布 GatheringCompleted 事件
 package main
\ No newline at end of file
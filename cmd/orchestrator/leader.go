@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/auth"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	redisinfra "github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/handlers"
+	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/middleware"
+)
+
+// leaderDuties 只能由单个副本执行的定时任务（新增的 retention、报告重建等都挂在这里）
+type leaderDuties struct {
+	compensation func(ctx context.Context)
+	retention    func(ctx context.Context)
+	regression   func(ctx context.Context)
+	campaignGate func(ctx context.Context)
+}
+
+// run 成为 Leader 后启动所有单例任务，ctx 取消（失去 Leader）时全部退出
+func (d leaderDuties) run(ctx context.Context) {
+	duties := []func(ctx context.Context){
+		d.compensation,
+		d.retention,
+		d.regression,
+		d.campaignGate,
+	}
+	for _, duty := range duties {
+		if duty != nil {
+			go duty(ctx)
+		}
+	}
+}
+
+// newLeaderElector 创建 Orchestrator 的 Leader 选举器
+// Leader 任务的 context 携带 fencing token，Repository 写入时据此拒绝旧 Leader 的延迟写入
+func newLeaderElector(redisClient *redisinfra.RedisClient, tokens domain.FencingTokenIssuer, ttl time.Duration, duties leaderDuties) *redisinfra.LeaderElector {
+	var elector *redisinfra.LeaderElector
+	elector = redisinfra.NewLeaderElector(redisClient, tokens, "orchestrator", instanceID(), ttl, redisinfra.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context, fencingToken int64) {
+			log.Printf("[Leader] Starting singleton duties (fencing token %d)", fencingToken)
+			duties.run(domain.WithFencingToken(ctx, elector.FencingResource(), fencingToken))
+		},
+		OnStoppedLeading: func() {
+			log.Printf("[Leader] Singleton duties stopped")
+		},
+	})
+	return elector
+}
+
+// compensationJob 补偿任务：定期检查并处理卡住的批次（仅 Leader 运行）
+func compensationJob(ctx context.Context, s *application.OrchestrateService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[Compensation] Stopped")
+			return
+		case <-ticker.C:
+		}
+
+		log.Printf("[Compensation] Checking for stuck batches...")
+		handled, err := s.RunCompensation(ctx)
+		if err != nil {
+			log.Printf("[Compensation] %v", err)
+		} else if handled == 0 {
+			log.Printf("[Compensation] No stuck batches found")
+		} else {
+			log.Printf("[Compensation] Handled %d stuck batches", handled)
+		}
+	}
+}
+
+// retentionJob 数据生命周期任务：按保留策略清理到期的原始日志、派生数据和 Batch（仅 Leader 运行）
+func retentionJob(ctx context.Context, s *application.RetentionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[Retention] Stopped")
+			return
+		case <-ticker.C:
+		}
+
+		result, err := s.RunRetention(ctx)
+		if err != nil {
+			log.Printf("[Retention] %v", err)
+			continue
+		}
+		if result.Evaluated > 0 {
+			log.Printf("[Retention] evaluated=%d raw_purged=%d derived_purged=%d batches_deleted=%d objects_deleted=%d held=%d failed=%d",
+				result.Evaluated, result.RawPurged, result.DerivedPurged, result.BatchesDeleted,
+				result.ObjectsDeleted, result.Held, result.Failed)
+		}
+	}
+}
+
+// regressionJob 版本回归检测：各平台最新整车版本对比上一个版本（仅 Leader 运行，避免重复检测）
+func regressionJob(ctx context.Context, s *application.ReleaseAnalysisService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[RegressionDetection] Stopped")
+			return
+		case <-ticker.C:
+		}
+
+		detected, err := s.DetectRegressions(ctx)
+		if err != nil {
+			log.Printf("[RegressionDetection] %v", err)
+			continue
+		}
+		if detected > 0 {
+			log.Printf("[RegressionDetection] %d new regressions", detected)
+		}
+	}
+}
+
+// campaignGateJob OTA 活动门禁：统计已升级车辆的诊断与异常码，决定推进波次还是自动暂停（仅 Leader 运行）
+func campaignGateJob(ctx context.Context, s *application.CampaignService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[CampaignGate] Stopped")
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.EvaluateGates(ctx); err != nil {
+			log.Printf("[CampaignGate] %v", err)
+		}
+	}
+}
+
+// startStatusServer 启动状态 HTTP 接口
+// GET /api/v1/leader 返回当前 Leader、本副本是否为 Leader 以及 fencing token
+// GET /api/v1/locks  返回本副本 Batch 锁的争用、超时与回退统计
+// GET /metrics       Prometheus 指标
+// GET /healthz、/readyz、/status 存活、就绪与状态页
+// /api/v1/legal-holds、/api/v1/tombstones 法务保留与删除记录
+// /api/v1/privacy/erasures VIN 数据删除请求
+//
+// 法务保留会阻止数据清理、数据删除不可恢复，只对运维人员开放；Leader / 锁状态供探活与排障，不需要认证
+func startStatusServer(port string, checker *health.Checker, elector *redisinfra.LeaderElector, locker *application.FallbackBatchLocker, retention *application.RetentionService, erasure *application.ErasureService, authenticator *auth.Authenticator, accessPolicy *domain.AccessPolicy, auditRepo domain.AccessAuditRepository) *http.Server {
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.Middleware(), metrics.Middleware(), tracing.Middleware())
+	metrics.RegisterRoutes(router)
+	health.RegisterRoutes(router, checker)
+	operators := router.Group("",
+		middleware.Audit(auditRepo),
+		middleware.Authenticate(authenticator, domain.PrincipalOperator),
+		middleware.Authorize(accessPolicy),
+	)
+	handlers.NewRetentionHandler(retention).RegisterRoutes(operators)
+	handlers.NewPrivacyHandler(erasure).RegisterRoutes(operators)
+	router.GET("/api/v1/leader", func(c *gin.Context) {
+		status, err := elector.Status(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error(), "status": status})
+			return
+		}
+		c.JSON(200, status)
+	})
+	router.GET("/api/v1/locks", func(c *gin.Context) {
+		c.JSON(200, locker.Stats())
+	})
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+	go func() {
+		log.Printf("[Server] Status server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Status server failed: %v", err)
+		}
+	}()
+	return server
+}
+
+// instanceID 当前副本标识（hostname + pid）
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
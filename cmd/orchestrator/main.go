@@ -13,28 +13,60 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/xuewentao/argus-ota-platform/internal/application"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/config"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/kafka"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/postgres"
 	redisinfra "github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
 func main() {
 	ctx := context.Background()
 
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("orchestrator", getEnv); err != nil {
+		log.Fatalf("Failed to init logging: %v", err)
+	}
+
+	// 0. 链路追踪（OTEL_TRACES_EXPORTER=otlp|stdout，默认只透传 trace context）
+	shutdownTracing, err := tracing.Init(ctx, "orchestrator", getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	// 1. 初始化 PostgreSQL
 	db := initDB()
 
 	// 2. 初始化 Redis
 	redisClient := initRedis(ctx)
 
-	// 3. 初始化 Kafka Producer（发布事件）
-	kafkaProducer := initKafkaProducer()
+	// 健康检查：/healthz 存活（Consumer 处理卡死时由容器编排重启）、/readyz 依赖就绪、/status 状态页
+	checker := initHealth(db, redisClient)
 
-	// 4. 初始化 Kafka Consumer（消费事件）
+	// 3. 初始化 Kafka Producer（发布事件，VIN 以令牌发布）
+	pseudonymizer, err := config.NewPseudonymizer(getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init pseudonymizer: %v", err)
+	}
+	kafkaProducer := initKafkaProducer(pseudonymizer)
+
+	// 4. 初始化 Kafka Consumer（消费事件，高优先级车道有积压时普通车道让路）
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
+	topics := []string{"batch-events", priorityTopic}
+	kafkaCheck := kafka.NewMetadataCheck([]string{"localhost:9092"}, topics...)
+	checker.Add("kafka", health.KindCritical, kafkaCheck.Check)
+	stallTimeout, err := time.ParseDuration(getEnv("CONSUMER_STALL_TIMEOUT", "15m"))
+	if err != nil {
+		log.Fatalf("Invalid CONSUMER_STALL_TIMEOUT: %v", err)
+	}
 	kafkaConsumer, err := kafka.NewKafkaEventConsumer(
 		[]string{"localhost:9092"},
 		"orchestrator-group", // Consumer Group ID
+		kafka.WithPriorityTopics(priorityTopic),
+		kafka.WithHealthChecks(checker, stallTimeout),
 	)
 	if err != nil {
 		log.Fatalf("Failed to create Kafka consumer: %v", err)
@@ -43,19 +75,78 @@ func main() {
 	// 5. 初始化 Repository
 	batchRepo := postgres.NewPostgresBatchRepository(db)
 
-	// 6. 初始化 OrchestrateService
+	// 6. 初始化 OrchestrateService（分阶段 SLA 可通过 SLA_CONFIG_FILE 配置）
+	slaPolicy, err := config.LoadSLAPolicy(getEnv("SLA_CONFIG_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load SLA config: %v", err)
+	}
+	// Batch 锁：Redis 为主，Redis 不可用时回退到 PostgreSQL 行锁（SELECT ... FOR UPDATE）
+	lockTTL, err := time.ParseDuration(getEnv("BATCH_LOCK_TTL", "30s"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_LOCK_TTL: %v", err)
+	}
+	lockWait, err := time.ParseDuration(getEnv("BATCH_LOCK_WAIT", "5s"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_LOCK_WAIT: %v", err)
+	}
+	batchLocker := application.NewFallbackBatchLocker(
+		redisinfra.NewBatchLocker(redisClient, postgres.NewFencingTokenIssuer(db), lockTTL, lockWait),
+		postgres.NewRowBatchLocker(db),
+	)
 	orchestrateService := application.NewOrchestrateService(
 		batchRepo,
 		redisClient,
 		kafkaProducer,
+		slaPolicy,
+		batchLocker,
 	)
 
-	// 7. 启动 Kafka Consumer
-	topics := []string{"batch-events"}
+	// 数据生命周期（保留策略可通过 RETENTION_CONFIG_FILE 配置）
+	retentionPolicy, err := config.LoadRetentionPolicy(getEnv("RETENTION_CONFIG_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load retention config: %v", err)
+	}
+	storage, err := config.NewObjectStore(config.StorageConfigFromEnv(getEnv))
+	if err != nil {
+		log.Fatalf("Failed to init object storage: %v", err)
+	}
+	// 存储只用于数据清理，不可用时清理任务失败重试，不影响事件编排
+	if pinger, ok := storage.(domain.ObjectStorePinger); ok {
+		checker.Add("storage", health.KindOptional, pinger.Ping)
+	}
+	retentionService := application.NewRetentionService(
+		batchRepo,
+		postgres.NewPostgresRetentionRepository(db),
+		postgres.NewPostgresLegalHoldRepository(db),
+		storage,
+		redisClient,
+		retentionPolicy,
+		batchLocker,
+	)
+	// VIN 数据删除请求（复用生命周期任务的 Batch 删除流程）
+	erasureService := application.NewErasureService(
+		retentionService,
+		postgres.NewPostgresErasureRepository(db),
+		pseudonymizer,
+	)
+
+	// 版本回归检测（结果由 Query Service 的 /api/v1/firmware/regressions 提供给发布经理）
+	releaseService := application.NewReleaseAnalysisService(
+		postgres.NewPostgresFirmwareRepository(db),
+		domain.DefaultRegressionThresholds(),
+	)
+
+	// OTA 活动门禁（活动的创建与人工操作在 Ingestor 的 /api/v1/campaigns）
+	campaignService := application.NewCampaignService(
+		postgres.NewPostgresCampaignRepository(db),
+		postgres.NewPostgresDeploymentRepository(db),
+		postgres.NewPostgresVehicleRepository(db),
+	)
 
+	// 7. 启动 Kafka Consumer
 	log.Println("========================================")
 	log.Println("🚀 Orchestrator started successfully!")
-	log.Printf("📡 Consuming topic: %s", topics[0])
+	log.Printf("📡 Consuming topics: %v", topics)
 	log.Printf("📦 Consumer Group: orchestrator-group")
 	log.Println("========================================")
 
@@ -70,23 +161,90 @@ func main() {
 		}
 	}()
 
-	// 启动补偿任务（后台 goroutine）
-	go compensationJob(ctx, orchestrateService, batchRepo)
+	// 9. Leader 选举：补偿等单例任务只在 Leader 上运行
+	interval, err := time.ParseDuration(getEnv("COMPENSATION_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("Invalid COMPENSATION_INTERVAL: %v", err)
+	}
+	retentionInterval, err := time.ParseDuration(getEnv("RETENTION_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("Invalid RETENTION_INTERVAL: %v", err)
+	}
+	regressionInterval, err := time.ParseDuration(getEnv("REGRESSION_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("Invalid REGRESSION_INTERVAL: %v", err)
+	}
+	campaignGateInterval, err := time.ParseDuration(getEnv("CAMPAIGN_GATE_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid CAMPAIGN_GATE_INTERVAL: %v", err)
+	}
+	leaseTTL, err := time.ParseDuration(getEnv("LEADER_LEASE_TTL", "15s"))
+	if err != nil {
+		log.Fatalf("Invalid LEADER_LEASE_TTL: %v", err)
+	}
+	elector := newLeaderElector(redisClient, postgres.NewFencingTokenIssuer(db), leaseTTL, leaderDuties{
+		compensation: func(ctx context.Context) {
+			compensationJob(ctx, orchestrateService, interval)
+		},
+		retention: func(ctx context.Context) {
+			retentionJob(ctx, retentionService, retentionInterval)
+		},
+		regression: func(ctx context.Context) {
+			regressionJob(ctx, releaseService, regressionInterval)
+		},
+		campaignGate: func(ctx context.Context) {
+			campaignGateJob(ctx, campaignService, campaignGateInterval)
+		},
+	})
+	electionCtx, stopElection := context.WithCancel(ctx)
+	electionDone := make(chan struct{})
+	go func() {
+		defer close(electionDone)
+		elector.Run(electionCtx)
+	}()
+
+	// 10. 状态接口（当前 Leader、锁统计等）
+	authenticator, err := config.NewAuthenticator(config.AuthConfigFromEnv(getEnv))
+	if err != nil {
+		log.Fatalf("Failed to init authentication: %v", err)
+	}
+	accessPolicy, err := config.LoadAccessPolicy(getEnv("ACCESS_POLICY_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load access policy: %v", err)
+	}
+	server := startStatusServer(getEnv("HTTP_PORT", "8082"), checker, elector, batchLocker, retentionService, erasureService,
+		authenticator, accessPolicy, postgres.NewPostgresAccessAuditRepository(db))
 
 	// 等待系统信号
 	<-sigCh
 	log.Println("\n🛑 Shutting down Orchestrator...")
 
+	// 让出 Leader（主动释放租约，其他副本无需等待 TTL 过期）
+	stopElection()
+	<-electionDone
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shutdown status server: %v", err)
+	}
+
 	// 关闭 Kafka Consumer
 	if err := kafkaConsumer.Close(); err != nil {
 		log.Printf("Failed to close Kafka consumer: %v", err)
 	}
+	kafkaCheck.Close()
 
 	// 关闭 Kafka Producer
 	if err := kafkaProducer.Close(); err != nil {
 		log.Printf("Failed to close Kafka producer: %v", err)
 	}
 
+	// 刷出未导出的 Span（包括终态 Batch 的根 Span）
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+
 	// 关闭 Redis
 	if err := redisClient.Close(); err != nil {
 		log.Printf("Failed to close Redis: %v", err)
@@ -114,7 +272,7 @@ func initDB() *sql.DB {
 		dbHost, dbPort, dbUser, dbPassword, dbName)
 
 	// 连接数据库
-	db, err := sql.Open("postgres", dsn)
+	db, err := postgres.Open(dsn)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -137,6 +295,20 @@ func initDB() *sql.DB {
 	return db
 }
 
+// initHealth 注册 PostgreSQL 与 Redis 检查（HEALTH_CHECK_TIMEOUT 为单项超时）；Kafka 检查在创建 Consumer 时注册
+//
+// Redis 是 Barrier 计数的唯一来源（Batch 锁可以回退到 PostgreSQL，Barrier 不行），所以是关键依赖
+func initHealth(db *sql.DB, redisClient *redisinfra.RedisClient) *health.Checker {
+	timeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT: %v", err)
+	}
+	checker := health.New("orchestrator", timeout)
+	checker.Add("postgres", health.KindCritical, db.PingContext)
+	checker.Add("redis", health.KindCritical, redisClient.Ping)
+	return checker
+}
+
 // initRedis 初始化 Redis 连接
 func initRedis(ctx context.Context) *redisinfra.RedisClient {
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
@@ -151,12 +323,14 @@ func initRedis(ctx context.Context) *redisinfra.RedisClient {
 }
 
 // initKafkaProducer 初始化 Kafka Producer
-func initKafkaProducer() messaging.KafkaEventPublisher {
+func initKafkaProducer(pseudonymizer *domain.Pseudonymizer) messaging.KafkaEventPublisher {
 	brokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
 	topic := getEnv("KAFKA_TOPIC", "batch-events")
 	dlqTopic := getEnv("KAFKA_DLQ_TOPIC", "batch-events-dlq")
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
 
-	producer, err := kafka.NewKafkaEventProducer(brokers, topic, dlqTopic)
+	producer, err := kafka.NewKafkaEventProducer(brokers, topic, dlqTopic,
+		kafka.WithPriorityTopic(priorityTopic), kafka.WithPseudonymizer(pseudonymizer))
 	if err != nil {
 		log.Fatalf("Failed to create Kafka producer: %v", err)
 	}
@@ -171,34 +345,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
-// compensationJob 补偿任务：定期检查并处理卡住的批次
-func compensationJob(ctx context.Context, s *application.OrchestrateService, repo domain.BatchRepository) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		log.Printf("[Compensation] Checking for stuck batches...")
-
-		// 1. 查询状态卡住的 Batch（通过 Repository）
-		stuckBatches, err := repo.FindStuckBatches(ctx)
-		if err != nil {
-			log.Printf("[Compensation] Failed to find stuck batches: %v", err)
-			continue
-		}
-
-		if len(stuckBatches) == 0 {
-			log.Printf("[Compensation] No stuck batches found")
-			continue
-		}
-
-		log.Printf("[Compensation] Found %d stuck batches", len(stuckBatches))
-
-		// 2. 处理每个卡住的 Batch（通过 Service）
-		for _, batch := range stuckBatches {
-			if err := s.HandleStuckBatch(ctx, batch); err != nil {
-				log.Printf("[Compensation] Failed to handle stuck batch %s: %v", batch.ID, err)
-				// 继续处理下一个，不中断整个循环
-			}
-		}
-	}
-}
\ No newline at end of file
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/config"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/kafka"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/postgres"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+	"github.com/xuewentao/argus-ota-platform/internal/messaging"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("parse-worker", getEnv); err != nil {
+		log.Fatalf("Failed to init logging: %v", err)
+	}
+
+	// 0. 链路追踪（OTEL_TRACES_EXPORTER=otlp|stdout，默认只透传 trace context）
+	shutdownTracing, err := tracing.Init(ctx, "parse-worker", getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
+	// 1. 初始化 PostgreSQL
+	db := initDB()
+
+	// 2. 初始化对象存储
+	storage := initStorage()
+
+	// 健康检查：/healthz 存活（Consumer 处理卡死时由容器编排重启）、/readyz 依赖就绪、/status 状态页
+	checker := initHealth(db, storage)
+
+	// 3. 初始化 Kafka Producer（发布 FileParsed）
+	kafkaProducer := initKafkaProducer()
+
+	// 4. 初始化 Kafka Consumer（高优先级车道优先）
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
+	groupID := getEnv("KAFKA_GROUP_ID", "parse-worker-group")
+	topics := []string{getEnv("KAFKA_TOPIC", "batch-events"), priorityTopic}
+	brokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
+	kafkaCheck := kafka.NewMetadataCheck(brokers, topics...)
+	checker.Add("kafka", health.KindCritical, kafkaCheck.Check)
+	stallTimeout, err := time.ParseDuration(getEnv("CONSUMER_STALL_TIMEOUT", "15m"))
+	if err != nil {
+		log.Fatalf("Invalid CONSUMER_STALL_TIMEOUT: %v", err)
+	}
+	kafkaConsumer, err := kafka.NewKafkaEventConsumer(
+		brokers,
+		groupID,
+		kafka.WithPriorityTopics(priorityTopic),
+		kafka.WithHealthChecks(checker, stallTimeout),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka consumer: %v", err)
+	}
+
+	// 5. 解码器：内置 csv / jsonl，配置 PARSER_BINARY 后 rec 等二进制格式交给外部 C++ 解析器
+	decoders := initDecoders()
+
+	concurrency, err := strconv.Atoi(getEnv("PARSE_CONCURRENCY", "4"))
+	if err != nil {
+		log.Fatalf("Invalid PARSE_CONCURRENCY: %v", err)
+	}
+	redactor := initRedactor()
+	worker := NewParseWorker(postgres.NewPostgresFileRepository(db), storage, kafkaProducer, decoders, redactor, concurrency)
+
+	// 6. 启动 Kafka Consumer
+	if err := kafkaConsumer.Subscribe(ctx, topics, worker.HandleMessage); err != nil {
+		log.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	log.Println("========================================")
+	log.Println("🚀 Parse Worker started successfully!")
+	log.Printf("📡 Consuming topics: %v", topics)
+	log.Printf("📦 Consumer Group: %s", groupID)
+	log.Println("========================================")
+
+	// Worker 没有业务 HTTP 接口，/metrics 与健康检查单独监听 METRICS_PORT（为空时不启动）
+	metricsServer := metrics.Serve(getEnv("METRICS_PORT", "9101"), checker.RegisterMux)
+
+	// 7. 优雅关闭
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	log.Println("\n🛑 Shutting down Parse Worker...")
+	cancel()
+
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+
+	if err := kafkaConsumer.Close(); err != nil {
+		log.Printf("Failed to close Kafka consumer: %v", err)
+	}
+	kafkaCheck.Close()
+	if err := kafkaProducer.Close(); err != nil {
+		log.Printf("Failed to close Kafka producer: %v", err)
+	}
+	// 刷出未导出的 Span
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	flushCancel()
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close PostgreSQL: %v", err)
+	}
+
+	log.Println("✅ Parse Worker stopped gracefully")
+}
+
+// initDecoders 注册解码器
+//
+// PARSER_BINARY      外部解析器路径（为空时不启用）
+// PARSER_ARGS        外部解析器参数（空格分隔）
+// PARSER_FILE_TYPES  交给外部解析器的文件类型（逗号分隔，默认 rec）
+// PARSER_TIMEOUT     单个文件的解析超时
+func initDecoders() *parser.Registry {
+	registry := parser.NewDefaultRegistry()
+
+	binary := getEnv("PARSER_BINARY", "")
+	if binary == "" {
+		return registry
+	}
+	timeout, err := time.ParseDuration(getEnv("PARSER_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid PARSER_TIMEOUT: %v", err)
+	}
+	decoder := parser.NewSubprocessDecoder(binary, strings.Fields(getEnv("PARSER_ARGS", "")), timeout)
+	for _, fileType := range strings.Split(getEnv("PARSER_FILE_TYPES", "rec"), ",") {
+		if fileType = strings.TrimSpace(fileType); fileType != "" {
+			registry.Register(fileType, decoder)
+			log.Printf("[Parser] File type %q → %s", fileType, binary)
+		}
+	}
+	return registry
+}
+
+// initRedactor 解析产物脱敏：VIN 替换为令牌，GPS 坐标按 GPS_REDACTION_DECIMALS 截断
+func initRedactor() *parser.Redactor {
+	pseudonymizer, err := config.NewPseudonymizer(getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init pseudonymizer: %v", err)
+	}
+	decimals, err := config.GPSDecimalsFromEnv(getEnv, parser.DefaultGPSDecimals)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return parser.NewRedactor(decimals, pseudonymizer.VINToken)
+}
+
+// initDB 初始化 PostgreSQL 连接
+func initDB() *sql.DB {
+	dbHost := getEnv("DB_HOST", "localhost")
+	dbPort := getEnv("DB_PORT", "5432")
+	dbUser := getEnv("DB_USER", "argus")
+	dbPassword := getEnv("DB_PASSWORD", "argus_password")
+	dbName := getEnv("DB_NAME", "argus_ota")
+
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		dbHost, dbPort, dbUser, dbPassword, dbName)
+
+	db, err := postgres.Open(dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	log.Printf("[PostgreSQL] Connected to %s:%s/%s", dbHost, dbPort, dbName)
+	return db
+}
+
+// initHealth 注册 PostgreSQL 与对象存储检查（HEALTH_CHECK_TIMEOUT 为单项超时）；Kafka 检查在创建 Consumer 时注册
+func initHealth(db *sql.DB, storage domain.ObjectStore) *health.Checker {
+	timeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT: %v", err)
+	}
+	checker := health.New("parse-worker", timeout)
+	checker.Add("postgres", health.KindCritical, db.PingContext)
+	if pinger, ok := storage.(domain.ObjectStorePinger); ok {
+		checker.Add("storage", health.KindCritical, pinger.Ping)
+	}
+	return checker
+}
+
+// initStorage 初始化对象存储（STORAGE_BACKEND=minio|local）
+func initStorage() domain.ObjectStore {
+	store, err := config.NewObjectStore(config.StorageConfigFromEnv(getEnv))
+	if err != nil {
+		log.Fatalf("Failed to init object storage: %v", err)
+	}
+	return store
+}
+
+// initKafkaProducer 初始化 Kafka Producer
+func initKafkaProducer() messaging.KafkaEventPublisher {
+	brokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
+	topic := getEnv("KAFKA_TOPIC", "batch-events")
+	dlqTopic := getEnv("KAFKA_DLQ_TOPIC", "batch-events-dlq")
+
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
+
+	producer, err := kafka.NewKafkaEventProducer(brokers, topic, dlqTopic, kafka.WithPriorityTopic(priorityTopic))
+	if err != nil {
+		log.Fatalf("Failed to create Kafka producer: %v", err)
+	}
+	return producer
+}
+
+// getEnv 读取环境变量，提供默认值
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
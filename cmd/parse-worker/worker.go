@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/messaging"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+var logger = logging.Component("parse-worker")
+
+// ParseWorker 解析 Worker：下载原始文件 → 按 FileType 选择解码器 → 脱敏 → 规范化 CSV 写回 MinIO → 发布 FileParsed
+type ParseWorker struct {
+	fileRepo    domain.FileRepository
+	storage     domain.ObjectStore
+	kafka       messaging.KafkaEventPublisher
+	decoders    *parser.Registry
+	redactor    *parser.Redactor
+	concurrency int // 单个 Batch 内并行解析的文件数
+}
+
+func NewParseWorker(
+	fileRepo domain.FileRepository,
+	storage domain.ObjectStore,
+	kafka messaging.KafkaEventPublisher,
+	decoders *parser.Registry,
+	redactor *parser.Redactor,
+	concurrency int,
+) *ParseWorker {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &ParseWorker{
+		fileRepo:    fileRepo,
+		storage:     storage,
+		kafka:       kafka,
+		decoders:    decoders,
+		redactor:    redactor,
+		concurrency: concurrency,
+	}
+}
+
+// HandleMessage 处理 Kafka 消息（只关心 BatchCreated）
+func (w *ParseWorker) HandleMessage(ctx context.Context, data []byte) error {
+	var event struct {
+		EventType string `json:"event_type"`
+		BatchID   string `json:"batch_id"`
+		Priority  string `json:"priority"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		logger.ErrorContext(ctx, "failed to unmarshal event", "error", err)
+		return err
+	}
+	if event.EventType != "BatchCreated" {
+		return nil
+	}
+
+	batchID, err := uuid.Parse(event.BatchID)
+	if err != nil {
+		return fmt.Errorf("invalid batch_id: %w", err)
+	}
+	// FileParsed 沿用 BatchCreated 的优先级车道（未知值按 routine 处理）
+	priority, err := domain.ParseBatchPriority(event.Priority)
+	if err != nil {
+		logger.WarnContext(ctx, "unknown batch priority, using routine", "batch_id", batchID, "priority", event.Priority)
+		priority = domain.BatchPriorityRoutine
+	}
+	return w.handleBatchCreated(ctx, batchID, priority)
+}
+
+// handleBatchCreated 并行解析 Batch 内的所有文件
+//
+// 单个文件失败不影响其他文件：失败文件不会发布 FileParsed，Barrier 不会完成，
+// 由 Orchestrator 的补偿任务按 SLA 重试或标记 Batch 失败
+func (w *ParseWorker) handleBatchCreated(ctx context.Context, batchID uuid.UUID, priority domain.BatchPriority) error {
+	files, err := w.fileRepo.FindByBatchID(ctx, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to find files: %w", err)
+	}
+	logger.InfoContext(ctx, "BatchCreated received", "batch_id", batchID, "files", len(files))
+
+	var g errgroup.Group
+	g.SetLimit(w.concurrency)
+	for _, file := range files {
+		file := file
+		g.Go(func() error {
+			ctx := logging.With(ctx, "batch_id", batchID.String(), "file_id", file.ID.String())
+			if err := w.processFile(ctx, file, priority); err != nil {
+				logger.ErrorContext(ctx, "failed to parse file", "error", err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// processFile 解析单个文件（按文件状态保证幂等）
+func (w *ParseWorker) processFile(ctx context.Context, file *domain.File, priority domain.BatchPriority) error {
+	switch file.ProcessingStatus {
+	case domain.FileStatusParsed, domain.FileStatusAggregating, domain.FileStatusCompleted:
+		// 重复投递（补偿任务重发 BatchCreated）：产物已存在，重新发布 FileParsed 即可（Barrier 基于 Set 天然幂等）
+		return w.publishFileParsed(ctx, file, priority)
+	case domain.FileStatusFailed:
+		// 只有文件内容本身无法解析才会标记失败，重新投递也不会成功
+		logger.InfoContext(ctx, "skipping failed file", "file_error", file.ErrorMessage)
+		return nil
+	case domain.FileStatusPending:
+		if err := file.TransitionTo(domain.FileStatusParsing); err != nil {
+			return err
+		}
+		if err := w.fileRepo.UpdateProcessingStatus(ctx, file.ID, file.ProcessingStatus); err != nil {
+			return fmt.Errorf("failed to mark file parsing: %w", err)
+		}
+	}
+	// FileStatusParsing：上一个 Worker 解析中途崩溃，直接重新解析（输出路径确定，会覆盖半成品）
+
+	start := time.Now()
+	count, err := w.parseFile(ctx, file)
+	file.ParseDurationMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		file.ErrorMessage = err.Error()
+		if isTransient(err) {
+			// 保持 parsing 状态：补偿任务重发 BatchCreated 时重新解析
+			if saveErr := w.fileRepo.Save(context.WithoutCancel(ctx), file); saveErr != nil {
+				logger.ErrorContext(ctx, "failed to save file error", "error", saveErr)
+			}
+			return fmt.Errorf("transient parse failure, will retry on redelivery: %w", err)
+		}
+		if tErr := file.TransitionTo(domain.FileStatusFailed); tErr != nil {
+			return tErr
+		}
+		if saveErr := w.fileRepo.Save(ctx, file); saveErr != nil {
+			logger.ErrorContext(ctx, "failed to save failed file", "error", saveErr)
+		}
+		return err
+	}
+
+	if err := file.TransitionTo(domain.FileStatusParsed); err != nil {
+		return err
+	}
+	file.RecordCount = count
+	file.ErrorMessage = ""
+	if err := w.fileRepo.Save(ctx, file); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	logger.InfoContext(ctx, "file parsed", "file_type", file.FileType, "records", count, "duration_ms", file.ParseDurationMs)
+	return w.publishFileParsed(ctx, file, priority)
+}
+
+// parseFile 流式解析：MinIO 下载 → 解码 → CSV → MinIO 上传，全程不落盘、不整体加载到内存
+func (w *ParseWorker) parseFile(ctx context.Context, file *domain.File) (int, error) {
+	decoder, err := w.decoders.For(file.FileType)
+	if err != nil {
+		return 0, err
+	}
+
+	// 解析产物使用租户自己的主密钥加密
+	ctx = envelope.WithScope(ctx, file.TenantID)
+	src, err := w.storage.GetObject(ctx, file.MinIOPath)
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			return 0, err
+		}
+		return 0, transient(err)
+	}
+	defer src.Close()
+
+	// io.Pipe 连接解码与上传：上传以 multipart 方式边读边传（size = -1）
+	pr, pw := io.Pipe()
+	writer := parser.NewCSVWriter(pw)
+	decoded := make(chan struct{})
+	uploaded := make(chan uploadResult, 1)
+	go func() {
+		err := w.storage.PutObject(ctx, domain.ParsedOutputPath(file.TenantID, file.BatchID, file.ID), pr, -1, writer.ContentType())
+		// 上传提前失败时让解码端的写入立即报错，而不是阻塞在管道上
+		pr.CloseWithError(err)
+		result := uploadResult{err: err}
+		select {
+		case <-decoded:
+		default:
+			result.early = err != nil
+		}
+		uploaded <- result
+	}()
+
+	// 产物是聚合与诊断的唯一数据源，写出之前去掉 GPS 精确位置与个人信息
+	decodeErr := decoder.Decode(ctx, storageReader{src}, w.redactor.Wrap(writer.Write))
+	if decodeErr == nil {
+		decodeErr = writer.Close()
+	}
+	close(decoded)
+	// decodeErr 为 nil 时上传端读到 EOF 正常结束；否则上传失败，MinIO 会丢弃未完成的 multipart
+	pw.CloseWithError(decodeErr)
+	upload := <-uploaded
+
+	// 上传先失败时，解码端的错误只是写入已关闭管道的结果，按上传失败（可重试）处理
+	if upload.err != nil && (decodeErr == nil || upload.early) {
+		return 0, transient(fmt.Errorf("upload failed: %w", upload.err))
+	}
+	if decodeErr != nil {
+		return 0, fmt.Errorf("decode failed: %w", decodeErr)
+	}
+	return writer.Count(), nil
+}
+
+// uploadResult 解析产物上传的结果；early 表示解码结束之前上传就已失败
+type uploadResult struct {
+	err   error
+	early bool
+}
+
+// transientError 与文件内容无关、重试可能成功的错误（对象存储读写失败）
+type transientError struct{ err error }
+
+func transient(err error) error { return &transientError{err: err} }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// isTransient 对象存储失败、解析超时与 Worker 关闭时的取消都可重试；其余（格式错误、不支持的类型、对象不存在）重试也不会成功
+func isTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// storageReader 把下载过程中的读错误标记为可重试（解码器会把它包进自己的错误里返回）
+type storageReader struct{ r io.Reader }
+
+func (s storageReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF && !errors.Is(err, envelope.ErrCorrupted) {
+		err = transient(err)
+	}
+	return n, err
+}
+
+func (w *ParseWorker) publishFileParsed(ctx context.Context, file *domain.File, priority domain.BatchPriority) error {
+	event := domain.FileParsed{
+		BatchID:    file.BatchID,
+		TenantID:   file.TenantID,
+		FileID:     file.ID,
+		OutputPath: domain.ParsedOutputPath(file.TenantID, file.BatchID, file.ID),
+		Priority:   priority,
+		OccurredAt: time.Now(),
+	}
+	if err := w.kafka.PublishEvents(ctx, []domain.DomainEvent{event}); err != nil {
+		return fmt.Errorf("failed to publish FileParsed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+type memoryFileRepo struct {
+	files map[uuid.UUID]domain.File
+}
+
+func (r *memoryFileRepo) Save(ctx context.Context, file *domain.File) error {
+	r.files[file.ID] = *file
+	return nil
+}
+
+func (r *memoryFileRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.File, error) {
+	file := r.files[id]
+	return &file, nil
+}
+
+func (r *memoryFileRepo) FindByBatchID(ctx context.Context, batchID uuid.UUID) ([]*domain.File, error) {
+	return nil, nil
+}
+
+func (r *memoryFileRepo) UpdateProcessingStatus(ctx context.Context, id uuid.UUID, status domain.ProcessingStatus) error {
+	file := r.files[id]
+	file.ProcessingStatus = status
+	r.files[id] = file
+	return nil
+}
+
+// flakyStore 上传读取 failAfter 字节后失败（模拟 MinIO 中途断开）；failAfter < 0 表示上传正常
+type flakyStore struct {
+	domain.ObjectStore
+	source    string
+	failAfter int64
+}
+
+func (s *flakyStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(s.source)), nil
+}
+
+func (s *flakyStore) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	if s.failAfter < 0 {
+		_, err := io.Copy(io.Discard, reader)
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, reader, s.failAfter); err != nil {
+		return err
+	}
+	return errors.New("connection reset by peer")
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishEvents(ctx context.Context, events []domain.DomainEvent) error { return nil }
+func (nopPublisher) Close() error                                                         { return nil }
+
+func largeCSV(rows int) string {
+	var b bytes.Buffer
+	b.WriteString("timestamp,cpu_usage,memory_usage\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "2026-01-01T00:00:%02dZ,%d,%d\n", i%60, i%100, i%4096)
+	}
+	return b.String()
+}
+
+func parseOnce(t *testing.T, store *flakyStore) (domain.File, error) {
+	file := &domain.File{
+		ID:               uuid.New(),
+		BatchID:          uuid.New(),
+		TenantID:         domain.DefaultTenantID,
+		FileType:         "csv",
+		MinIOPath:        "raw/log.csv",
+		ProcessingStatus: domain.FileStatusPending,
+	}
+	repo := &memoryFileRepo{files: map[uuid.UUID]domain.File{file.ID: *file}}
+	worker := NewParseWorker(repo, store, nopPublisher{}, parser.NewDefaultRegistry(), parser.NewRedactor(3, nil), 1)
+
+	err := worker.processFile(context.Background(), file, domain.BatchPriorityRoutine)
+	return repo.files[file.ID], err
+}
+
+// TestParseFile_UploadFailsMidStream - 上传中途失败时文件保持可重试，不能标记为 Failed
+func TestParseFile_UploadFailsMidStream(t *testing.T) {
+	saved, err := parseOnce(t, &flakyStore{source: largeCSV(100000), failAfter: 4096})
+	require.Error(t, err)
+	assert.True(t, isTransient(err), "upload failure must be retryable: %v", err)
+	assert.Equal(t, domain.FileStatusParsing, saved.ProcessingStatus)
+}
+
+// TestParseFile_MalformedContent - 文件内容本身无法解析时标记为 Failed
+func TestParseFile_MalformedContent(t *testing.T) {
+	saved, err := parseOnce(t, &flakyStore{source: "timestamp,cpu_usage\n\"unterminated,1\n", failAfter: -1})
+	require.Error(t, err)
+	assert.False(t, isTransient(err))
+	assert.Equal(t, domain.FileStatusFailed, saved.ProcessingStatus)
+}
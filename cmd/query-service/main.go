@@ -13,37 +13,134 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"github.com/xuewentao/argus-ota-platform/internal/application"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/config"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/kafka"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/postgres"
 	redisinfra "github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/handlers"
+	"github.com/xuewentao/argus-ota-platform/internal/interfaces/http/middleware"
 )
 
 func main() {
 	ctx := context.Background()
 
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("query-service", getEnv); err != nil {
+		log.Fatalf("Failed to init logging: %v", err)
+	}
+
+	// 0. 链路追踪（OTEL_TRACES_EXPORTER=otlp|stdout，默认只透传 trace context）
+	shutdownTracing, err := tracing.Init(ctx, "query-service", getEnv)
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	// 1. 初始化 PostgreSQL
 	db := initDB()
 
 	// 2. 初始化 Redis
 	redisClient := initRedis(ctx)
 
-	// 3. 初始化 Repository
+	// 3. 初始化对象存储（读取图表）
+	storage := initStorage()
+
+	// 健康检查：/healthz 存活（Consumer 处理卡死时由容器编排重启）、/readyz 依赖就绪、/status 状态页
+	checker := initHealth(db, redisClient, storage)
+
+	// 初始化 Repository
 	batchRepo := postgres.NewPostgresBatchRepository(db)
-	reportRepo := &mockReportRepository{} // TODO: 替换为真实的 ReportRepository
+	reportRepo := postgres.NewPostgresReportRepository(db)
+	vehicleRepo := postgres.NewPostgresVehicleRepository(db)
 
 	// 4. 初始化 QueryService
-	queryService := application.NewQueryService(batchRepo, reportRepo, redisClient)
+	queryService := application.NewQueryService(batchRepo, reportRepo, vehicleRepo, redisClient)
+
+	// 车队分析：独立的 Consumer Group 消费终态事件，增量维护汇总表
+	fleetService := application.NewFleetService(
+		batchRepo,
+		reportRepo,
+		vehicleRepo,
+		postgres.NewPostgresFleetRollupRepository(db),
+	)
+	priorityTopic := getEnv("KAFKA_PRIORITY_TOPIC", "batch-events-priority")
+	topics := []string{getEnv("KAFKA_TOPIC", "batch-events"), priorityTopic}
+	brokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
+	// Kafka 只用于车队汇总的增量更新（定时补漏兜底），不可用时查询接口照常服务
+	kafkaCheck := kafka.NewMetadataCheck(brokers, topics...)
+	checker.Add("kafka", health.KindOptional, kafkaCheck.Check)
+	stallTimeout, err := time.ParseDuration(getEnv("CONSUMER_STALL_TIMEOUT", "15m"))
+	if err != nil {
+		log.Fatalf("Invalid CONSUMER_STALL_TIMEOUT: %v", err)
+	}
+	kafkaConsumer, err := kafka.NewKafkaEventConsumer(
+		brokers,
+		"fleet-analytics-group",
+		kafka.WithPriorityTopics(priorityTopic),
+		kafka.WithHealthChecks(checker, stallTimeout),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka consumer: %v", err)
+	}
+	reconcileInterval, err := time.ParseDuration(getEnv("FLEET_RECONCILE_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid FLEET_RECONCILE_INTERVAL: %v", err)
+	}
+	fleetCtx, stopFleet := context.WithCancel(ctx)
+	go func() {
+		if err := kafkaConsumer.Subscribe(fleetCtx, topics, fleetService.HandleMessage); err != nil {
+			log.Printf("Consumer error: %v", err)
+		}
+	}()
+	go fleetReconcileJob(fleetCtx, fleetService, reconcileInterval)
 
 	// 5. 初始化 HTTP Server
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.Middleware(), metrics.Middleware(), tracing.Middleware())
+	metrics.RegisterRoutes(router)
+	health.RegisterRoutes(router, checker)
 	queryHandler := handlers.NewQueryHandler(queryService)
+	chartHandler := handlers.NewChartHandler(queryService, storage)
 
-	router.GET("/api/v1/batches/:id/report", queryHandler.GetReport)
-	router.GET("/api/v1/batches/:id/progress", queryHandler.GetProgress)
+	// 查询接口只对运维 / 分析人员开放（OIDC JWT），按角色与车队 / 平台范围授权，拒绝的访问写入审计表
+	authenticator, err := config.NewAuthenticator(config.AuthConfigFromEnv(getEnv))
+	if err != nil {
+		log.Fatalf("Failed to init authentication: %v", err)
+	}
+	accessPolicy, err := config.LoadAccessPolicy(getEnv("ACCESS_POLICY_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load access policy: %v", err)
+	}
+	tenants, err := config.LoadTenants(getEnv("TENANT_CONFIG_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load tenants: %v", err)
+	}
+	auditRepo := postgres.NewPostgresAccessAuditRepository(db)
+	// 每个请求在租户事务内执行，batches / files / reports 的查询受 RLS 约束
+	api := router.Group("",
+		middleware.Audit(auditRepo),
+		middleware.Authenticate(authenticator, domain.PrincipalOperator),
+		middleware.Tenant(tenants, postgres.NewPostgresTenantScoper(db)),
+		middleware.Authorize(accessPolicy),
+	)
+
+	api.GET("/api/v1/batches", queryHandler.ListBatches)
+	api.GET("/api/v1/batches/:id/report", queryHandler.GetReport)
+	api.GET("/api/v1/batches/:id/progress", queryHandler.GetProgress)
+	api.GET("/api/v1/batches/:id/charts", chartHandler.ListCharts)
+	api.GET("/api/v1/batches/:id/charts/:name", chartHandler.GetChart)
+	handlers.NewFleetHandler(fleetService).RegisterRoutes(api)
+	handlers.NewReleaseHandler(application.NewReleaseAnalysisService(
+		postgres.NewPostgresFirmwareRepository(db),
+		domain.DefaultRegressionThresholds(),
+	)).RegisterRoutes(api)
+	handlers.NewAuditHandler(auditRepo).RegisterRoutes(api)
 
 	server := &http.Server{
 		Addr:    ":8081",
@@ -68,12 +165,52 @@ func main() {
 	defer cancel()
 
 	server.Shutdown(ctx)
+	stopFleet()
+	if err := kafkaConsumer.Close(); err != nil {
+		log.Printf("Failed to close Kafka consumer: %v", err)
+	}
+	kafkaCheck.Close()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
 	db.Close()
 	redisClient.Close()
 
 	log.Println("✅ Query Service stopped gracefully")
 }
 
+// fleetReconcileJob 定期补漏汇总表（启动时先跑一次，覆盖汇总表上线前的存量 Batch）
+//
+// 多个副本同时运行是安全的：Apply 以 ledger 主键保证每个 Batch 只累加一次
+func fleetReconcileJob(ctx context.Context, fleetService *application.FleetService, interval time.Duration) {
+	const batchSize = 500
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// 一轮补满 batchSize 说明还有积压，继续处理
+		for {
+			applied, err := fleetService.Reconcile(ctx, batchSize)
+			if err != nil {
+				log.Printf("[FleetReconcile] Failed: %v", err)
+				break
+			}
+			if applied > 0 {
+				log.Printf("[FleetReconcile] Applied %d batches", applied)
+			}
+			if applied < batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // initDB 初始化 PostgreSQL 连接（复用 Ingestor 的代码）
 func initDB() *sql.DB {
 	dbHost := getEnv("DB_HOST", "localhost")
@@ -85,7 +222,7 @@ func initDB() *sql.DB {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		dbHost, dbPort, dbUser, dbPassword, dbName)
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := postgres.Open(dsn)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -107,6 +244,23 @@ func initDB() *sql.DB {
 	return db
 }
 
+// initHealth 注册 PostgreSQL、Redis 与对象存储检查（HEALTH_CHECK_TIMEOUT 为单项超时）；Kafka 检查在创建 Consumer 时注册
+//
+// 只有 PostgreSQL 是关键依赖：Redis 是报告缓存（故障时按未命中回源），对象存储只影响图表接口
+func initHealth(db *sql.DB, redisClient *redisinfra.RedisClient, storage domain.ObjectStore) *health.Checker {
+	timeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT: %v", err)
+	}
+	checker := health.New("query-service", timeout)
+	checker.Add("postgres", health.KindCritical, db.PingContext)
+	checker.Add("redis", health.KindOptional, redisClient.Ping)
+	if pinger, ok := storage.(domain.ObjectStorePinger); ok {
+		checker.Add("storage", health.KindOptional, pinger.Ping)
+	}
+	return checker
+}
+
 // initRedis 初始化 Redis 连接（复用 Orchestrator 的代码）
 func initRedis(ctx context.Context) *redisinfra.RedisClient {
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
@@ -121,6 +275,15 @@ func initRedis(ctx context.Context) *redisinfra.RedisClient {
 	return redisClient
 }
 
+// initStorage 初始化对象存储（STORAGE_BACKEND=minio|local）
+func initStorage() domain.ObjectStore {
+	store, err := config.NewObjectStore(config.StorageConfigFromEnv(getEnv))
+	if err != nil {
+		log.Fatalf("Failed to init object storage: %v", err)
+	}
+	return store
+}
+
 // getEnv 读取环境变量，提供默认值
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -129,20 +292,4 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// mockReportRepository Mock ReportRepository（临时实现）
-// TODO: 替换为真实的 PostgresReportRepository
-type mockReportRepository struct{}
-
-func (m *mockReportRepository) Save(ctx context.Context, report *domain.Report) error {
-	log.Printf("[MockReportRepository] Save: %s (batchID=%s)", report.ID, report.BatchID)
-	return nil
-}
-
-func (m *mockReportRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Report, error) {
-	return nil, nil
-}
-
-func (m *mockReportRepository) FindByBatchID(ctx context.Context, batchID uuid.UUID) (*domain.Report, error) {
-	return nil, nil
-}
 
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/config"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+)
+
+// rotate-keys 主密钥轮换后重新包裹存量对象的数据密钥
+//
+// 轮换步骤：
+// 1. 在 ENCRYPTION_KEY_FILE 中新增主密钥并修改 active（各服务 30s 内自动加载，新对象使用新密钥）
+// 2. 运行本工具：旧密钥包裹的对象改用新密钥，启用加密前的明文对象与 v1 对象改写为当前格式
+// 3. 确认 failed=0 后，从密钥文件删除旧密钥，各服务去掉 ENCRYPTION_ALLOW_LEGACY_OBJECTS
+func main() {
+	prefix := flag.String("prefix", "", "only rewrap objects under this prefix")
+	flag.Parse()
+	// 结构化日志（LOG_FORMAT / LOG_LEVEL / LOG_LEVELS），标准库 log 的输出也经过它
+	if err := logging.Init("rotate-keys", getEnv); err != nil {
+		log.Fatalf("Failed to init logging: %v", err)
+	}
+
+	cfg := config.StorageConfigFromEnv(getEnv)
+	if cfg.EncryptionKeyFile == "" {
+		log.Fatal("ENCRYPTION_KEY_FILE is not set")
+	}
+	store, err := config.NewObjectStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to init object storage: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := store.(*envelope.Store).RotateKeys(ctx, *prefix)
+	if err != nil {
+		log.Fatalf("Key rotation aborted: %v", err)
+	}
+	if result.Failed > 0 {
+		log.Printf("❌ %d objects failed, keep the old master keys and rerun", result.Failed)
+		os.Exit(1)
+	}
+	log.Printf("✅ Key rotation completed: rewrapped=%d, encrypted=%d", result.Rewrapped, result.Encrypted)
+}
+
+// getEnv 读取环境变量，提供默认值
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,18 @@
+-- Argus OTA Platform - Per-stage SLA compensation
+-- Version: 2.2
+-- Description: 补偿任务按车型平台区分 SLA，并记录每个状态下的重试次数
+
+-- ============================================================================
+-- Batches: 车型平台 + 补偿重试次数
+-- ============================================================================
+
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS vehicle_platform VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS compensation_attempts INTEGER NOT NULL DEFAULT 0
+    CHECK (compensation_attempts >= 0);
+
+-- 补偿任务按 (status, updated_at) 粗筛非终态批次
+CREATE INDEX IF NOT EXISTS idx_batches_status_updated_at ON batches(status, updated_at);
+CREATE INDEX IF NOT EXISTS idx_batches_vehicle_platform ON batches(vehicle_platform);
+
+COMMENT ON COLUMN batches.vehicle_platform IS 'Vehicle platform/model, used for per-platform SLA and RAG filtering';
+COMMENT ON COLUMN batches.compensation_attempts IS 'Compensation retries spent in the current status, reset on every transition';
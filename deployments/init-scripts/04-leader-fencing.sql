@@ -0,0 +1,12 @@
+-- Argus OTA Platform - Leader election fencing
+-- Version: 2.3
+-- Description: 记录每个受保护资源已见过的最大 fencing token，拒绝旧 Leader 的延迟写入
+
+CREATE TABLE IF NOT EXISTS fencing_tokens (
+    resource VARCHAR(255) PRIMARY KEY,
+        -- Example: 'leader:orchestrator'
+    token BIGINT NOT NULL CHECK (token >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE fencing_tokens IS 'Highest fencing token observed per resource; writes with a lower token are rejected';
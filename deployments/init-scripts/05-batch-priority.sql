@@ -0,0 +1,11 @@
+-- Argus OTA Platform - Batch priority lanes
+-- Version: 2.4
+-- Description: 紧急诊断（安全事件）优先处理：Kafka 独立车道 + SLA 缩放 + 查询过滤
+
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS priority VARCHAR(20) NOT NULL DEFAULT 'routine'
+    CHECK (priority IN ('routine', 'elevated', 'safety_critical'));
+
+-- 查询接口按优先级 + 状态过滤，按创建时间倒序
+CREATE INDEX IF NOT EXISTS idx_batches_priority_status_created_at ON batches(priority, status, created_at DESC);
+
+COMMENT ON COLUMN batches.priority IS 'Processing priority: routine | elevated | safety_critical';
@@ -0,0 +1,12 @@
+-- Argus OTA Platform - Aggregated reports
+-- Version: 2.5
+-- Description: Gather Worker 重复聚合同一个 Batch 时覆盖报告（每个 Batch 每种报告类型一份）
+
+-- 清理历史重复数据（保留最新的一份）
+DELETE FROM reports a
+USING reports b
+WHERE a.batch_id = b.batch_id
+  AND a.report_type = b.report_type
+  AND a.updated_at < b.updated_at;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reports_batch_id_report_type ON reports(batch_id, report_type);
@@ -0,0 +1,53 @@
+-- Argus OTA Platform - Data retention and lifecycle
+-- Version: 2.6
+-- Description: 按平台/终态的保留策略、法务保留（legal hold）与删除墓碑
+
+-- 每个终态 Batch 的清理进度；随 Batch 一起级联删除
+CREATE TABLE IF NOT EXISTS retention_state (
+    batch_id UUID PRIMARY KEY REFERENCES batches(id) ON DELETE CASCADE,
+    raw_purged_at TIMESTAMP,
+    derived_purged_at TIMESTAMP,
+    next_action_at TIMESTAMP,
+        -- NULL：已评估且没有后续阶段（永久保留）
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_state_next_action_at ON retention_state(next_action_at)
+    WHERE next_action_at IS NOT NULL;
+
+-- 终态 Batch 按完成时间扫描（首次评估）
+CREATE INDEX IF NOT EXISTS idx_batches_terminal_completed_at ON batches(COALESCE(completed_at, updated_at))
+    WHERE status IN ('completed', 'failed');
+
+CREATE TABLE IF NOT EXISTS legal_holds (
+    id UUID PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('vin', 'batch')),
+    value VARCHAR(255) NOT NULL,
+        -- VIN（大写）或 Batch ID
+    reason TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    released_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_holds_active ON legal_holds(scope, value) WHERE released_at IS NULL;
+
+-- 墓碑不引用 batches（Batch 删除后仍需保留）
+CREATE TABLE IF NOT EXISTS batch_tombstones (
+    batch_id UUID PRIMARY KEY,
+    vehicle_id VARCHAR(255) NOT NULL,
+    vin VARCHAR(255) NOT NULL,
+    vehicle_platform VARCHAR(100),
+    status VARCHAR(50) NOT NULL,
+    total_files INTEGER NOT NULL DEFAULT 0,
+    objects_deleted INTEGER NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL,
+    batch_created_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_batch_tombstones_vin ON batch_tombstones(vin);
+
+COMMENT ON TABLE retention_state IS 'Lifecycle progress per terminal batch; next_action_at drives the retention scheduler';
+COMMENT ON TABLE legal_holds IS 'Active holds (released_at IS NULL) exempt a VIN or batch from retention';
+COMMENT ON TABLE batch_tombstones IS 'Audit record of batches deleted by retention';
@@ -0,0 +1,27 @@
+-- Argus OTA Platform - Vehicle registry
+-- Version: 2.7
+-- Description: 车辆注册表（VIN、平台、ECU 清单、所属车队），创建 Batch 时校验车辆在役
+
+CREATE TABLE IF NOT EXISTS vehicles (
+    vin CHAR(17) PRIMARY KEY,
+        -- ISO 3779，大写，已校验第 9 位校验位
+    vehicle_id VARCHAR(50) NOT NULL UNIQUE,
+    platform VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    model_year INTEGER NOT NULL DEFAULT 0,
+    manufacturer VARCHAR(100) NOT NULL DEFAULT '',
+    firmware_version VARCHAR(100) NOT NULL DEFAULT '',
+    ecus JSONB NOT NULL DEFAULT '[]'::jsonb,
+        -- [{"name": "ADAS", "part_number": "...", "hardware_version": "...", "software_version": "..."}]
+    owner_fleet VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'decommissioned')),
+    decommissioned_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicles_platform ON vehicles(platform);
+CREATE INDEX IF NOT EXISTS idx_vehicles_owner_fleet ON vehicles(owner_fleet);
+
+COMMENT ON TABLE vehicles IS 'Vehicle registry, single source of truth for platform and ECU inventory';
+COMMENT ON COLUMN vehicles.platform IS 'Vehicle platform (J6, J7, ...), copied onto batches for SLA and RAG filtering';
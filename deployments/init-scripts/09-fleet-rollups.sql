@@ -0,0 +1,59 @@
+-- Argus OTA Platform - Fleet analytics rollups
+-- Version: 2.8
+-- Description: 车队维度的预聚合汇总表，Batch 进入终态时增量累加（Query Service 消费事件）
+
+-- 已累加的 Batch（幂等：重复投递的事件不会重复计数）
+CREATE TABLE IF NOT EXISTS fleet_rollup_ledger (
+    batch_id UUID PRIMARY KEY REFERENCES batches(id) ON DELETE CASCADE,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 吞吐量与失败率（按小时）
+CREATE TABLE IF NOT EXISTS fleet_throughput_hourly (
+    bucket TIMESTAMP NOT NULL,
+    vehicle_platform VARCHAR(50) NOT NULL,
+    completed INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    total_files BIGINT NOT NULL DEFAULT 0,
+    duration_seconds_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, vehicle_platform)
+);
+
+-- 异常码（按天、平台、整车软件版本）
+CREATE TABLE IF NOT EXISTS fleet_error_codes_daily (
+    day DATE NOT NULL,
+    vehicle_platform VARCHAR(50) NOT NULL,
+    firmware_version VARCHAR(100) NOT NULL,
+    error_code VARCHAR(100) NOT NULL,
+    occurrences BIGINT NOT NULL DEFAULT 0,
+    batches INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, vehicle_platform, firmware_version, error_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_error_codes_platform_day ON fleet_error_codes_daily(vehicle_platform, day);
+
+-- 单车每日汇总（资源趋势、异常排行榜）
+CREATE TABLE IF NOT EXISTS fleet_vehicle_daily (
+    day DATE NOT NULL,
+    vin VARCHAR(255) NOT NULL,
+    vehicle_platform VARCHAR(50) NOT NULL,
+    batches INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error_occurrences BIGINT NOT NULL DEFAULT 0,
+    critical_diagnoses INTEGER NOT NULL DEFAULT 0,
+    stats_batches INTEGER NOT NULL DEFAULT 0,
+        -- 有 CPU/RAM 统计的 Batch 数（平均值的分母）
+    cpu_avg_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cpu_p95_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cpu_p99_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cpu_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ram_avg_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ram_p95_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ram_p99_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ram_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, vin)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_vehicle_daily_vin_day ON fleet_vehicle_daily(vin, day);
+
+COMMENT ON TABLE fleet_rollup_ledger IS 'Batches already folded into fleet rollups; deleting a batch does not subtract its contribution';
@@ -0,0 +1,36 @@
+-- Argus OTA Platform - Firmware version tracking
+-- Version: 2.9
+-- Description: Batch 记录上传时的软件版本（整车 + 各 ECU），并保存版本对比检测到的回归
+
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS firmware_version VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS ecu_versions JSONB NOT NULL DEFAULT '{}';
+
+-- 按平台 + 版本取样本
+CREATE INDEX IF NOT EXISTS idx_batches_platform_firmware ON batches(vehicle_platform, firmware_version, updated_at DESC);
+-- 按 ECU 版本取样本（ecu_versions ->> 'ECU名'）
+CREATE INDEX IF NOT EXISTS idx_batches_ecu_versions ON batches USING GIN (ecu_versions);
+
+-- 检测到的版本回归（同一对版本、同一指标只保留一条，重复检测刷新数值）
+CREATE TABLE IF NOT EXISTS firmware_regressions (
+    id UUID PRIMARY KEY,
+    vehicle_platform VARCHAR(50) NOT NULL,
+    ecu VARCHAR(100) NOT NULL DEFAULT '',
+    baseline_version VARCHAR(100) NOT NULL,
+    candidate_version VARCHAR(100) NOT NULL,
+    metric VARCHAR(30) NOT NULL,
+    error_code VARCHAR(100) NOT NULL DEFAULT '',
+    baseline_value DOUBLE PRECISION NOT NULL,
+    candidate_value DOUBLE PRECISION NOT NULL,
+    relative_change DOUBLE PRECISION NOT NULL,
+    p_value DOUBLE PRECISION NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    acknowledged_by VARCHAR(100) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    acknowledged_at TIMESTAMP,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_firmware_regression UNIQUE (vehicle_platform, ecu, baseline_version, candidate_version, metric, error_code),
+    CONSTRAINT chk_regression_status CHECK (status IN ('open', 'acknowledged'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_firmware_regressions_status ON firmware_regressions(status, detected_at DESC);
@@ -0,0 +1,48 @@
+-- Argus OTA Platform - OTA campaigns
+-- Version: 2.10
+-- Description: OTA 升级活动与单车升级记录，按波次放量，波次推进由诊断结果门禁（Orchestrator Leader 执行）
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    id UUID PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    vehicle_platform VARCHAR(50) NOT NULL,
+    target_firmware VARCHAR(100) NOT NULL,
+    cohort JSONB NOT NULL DEFAULT '{}',
+    waves JSONB NOT NULL,
+    gate JSONB NOT NULL,
+    current_wave INTEGER NOT NULL DEFAULT -1,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    status_reason TEXT NOT NULL DEFAULT '',
+    last_gate JSONB,
+    wave_started_at TIMESTAMP,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_campaign_status CHECK (status IN ('draft', 'running', 'paused', 'completed', 'aborted'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_status ON campaigns(status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS deployments (
+    id UUID PRIMARY KEY,
+    campaign_id UUID NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    vin VARCHAR(17) NOT NULL REFERENCES vehicles(vin),
+    wave INTEGER NOT NULL,
+    from_version VARCHAR(100) NOT NULL DEFAULT '',
+    target_version VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error_message TEXT NOT NULL DEFAULT '',
+    released_at TIMESTAMP,
+    installed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_deployment_campaign_vin UNIQUE (campaign_id, vin),
+    CONSTRAINT chk_deployment_status CHECK (status IN ('pending', 'scheduled', 'installed', 'failed', 'cancelled'))
+);
+
+-- 一辆车同一时间只能处于一个进行中的升级（创建时冲突的车辆被跳过）
+CREATE UNIQUE INDEX IF NOT EXISTS uq_deployments_open_vin ON deployments(vin) WHERE status IN ('pending', 'scheduled');
+CREATE INDEX IF NOT EXISTS idx_deployments_campaign_status ON deployments(campaign_id, status, wave);
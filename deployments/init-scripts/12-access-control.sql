@@ -0,0 +1,22 @@
+-- Argus OTA Platform - Access control
+-- Version: 2.11
+-- Description: 角色与数据范围授权的审计：记录所有被拒绝的访问（401 / 403）
+
+CREATE TABLE IF NOT EXISTS access_denials (
+    id UUID PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    principal_kind VARCHAR(20) NOT NULL DEFAULT '', -- vehicle / operator，未认证时为空
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    vin VARCHAR(17) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    permission VARCHAR(50) NOT NULL DEFAULT '',
+    status INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_denials_time ON access_denials(occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_access_denials_subject ON access_denials(subject, occurred_at DESC);
+
@@ -0,0 +1,46 @@
+-- Argus OTA Platform - Tenants
+-- Version: 2.12
+-- Description: 多租户：batches / files / reports 增加 tenant_id，并以行级安全（RLS）隔离租户
+
+-- 存量数据全部归属 default 租户
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE files ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- 列表查询与配额统计（最近 24 小时的 Batch 数、原始文件总大小）
+CREATE INDEX IF NOT EXISTS idx_batches_tenant_created ON batches(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_files_tenant ON files(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_reports_tenant ON reports(tenant_id);
+
+-- 租户会话使用的角色：服务连接在事务内 SET LOCAL ROLE argus_tenant 后受 RLS 约束
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'argus_tenant') THEN
+        CREATE ROLE argus_tenant NOLOGIN;
+    END IF;
+END
+$$;
+GRANT argus_tenant TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO argus_tenant;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO argus_tenant;
+
+-- 不使用 FORCE：表的 owner（Worker、Orchestrator 等系统任务）需要跨租户处理，不受策略约束
+-- current_setting(..., true) 在未设置时返回 NULL，策略不匹配任何行（默认拒绝）
+ALTER TABLE batches ENABLE ROW LEVEL SECURITY;
+ALTER TABLE files ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reports ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON batches;
+CREATE POLICY tenant_isolation ON batches
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON files;
+CREATE POLICY tenant_isolation ON files
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON reports;
+CREATE POLICY tenant_isolation ON reports
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,17 @@
+-- Argus OTA Platform - Privacy
+-- Version: 2.13
+-- Description: VIN 数据删除请求（GDPR 第 17 条）的执行记录；记录只保存 VIN 令牌，不保存 VIN
+
+CREATE TABLE IF NOT EXISTS vin_erasures (
+    id UUID PRIMARY KEY,
+    vin_token VARCHAR(64) NOT NULL, -- vt_ + HMAC-SHA256 前 96 位
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    batches_deleted INTEGER NOT NULL DEFAULT 0,
+    objects_deleted INTEGER NOT NULL DEFAULT 0,
+    erased_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vin_erasures_token ON vin_erasures(vin_token, erased_at DESC);
+
+COMMENT ON TABLE vin_erasures IS 'Audit record of VIN erasure requests; batch tombstones, access denials and released legal holds are anonymized with the same token';
@@ -0,0 +1,13 @@
+-- Argus OTA Platform - Batch status clock
+-- Version: 2.14
+-- Description: batches 增加 status_changed_at（进入当前状态的时间），用于分阶段耗时指标
+
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+
+-- 存量数据没有状态时间，以 updated_at 近似
+UPDATE batches SET status_changed_at = updated_at WHERE status_changed_at IS NULL;
+
+ALTER TABLE batches ALTER COLUMN status_changed_at SET DEFAULT NOW();
+ALTER TABLE batches ALTER COLUMN status_changed_at SET NOT NULL;
+
+COMMENT ON COLUMN batches.status_changed_at IS 'When the batch entered its current status (updated_at also moves within a status)';
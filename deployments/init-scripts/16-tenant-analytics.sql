@@ -0,0 +1,132 @@
+-- Argus OTA Platform - Tenant-scoped registry, campaigns and analytics
+-- Version: 2.15
+-- Description: 车辆注册表、OTA 活动、车队汇总、版本回归、访问审计与 AI 诊断增加 tenant_id，并以 RLS 隔离租户
+
+-- 车队汇总表按租户重建：存量汇总混合了所有租户，无法拆分。清空后由 Query Service 的补漏任务
+-- （FindUnapplied → Apply）按 Batch 的租户重新累加；只在首次加列时执行，重复运行本脚本不会再次清空
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'fleet_throughput_hourly' AND column_name = 'tenant_id'
+    ) THEN
+        TRUNCATE fleet_rollup_ledger, fleet_throughput_hourly, fleet_error_codes_daily, fleet_vehicle_daily;
+
+        ALTER TABLE fleet_rollup_ledger ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+        ALTER TABLE fleet_throughput_hourly ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+        ALTER TABLE fleet_error_codes_daily ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+        ALTER TABLE fleet_vehicle_daily ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+        ALTER TABLE fleet_throughput_hourly DROP CONSTRAINT fleet_throughput_hourly_pkey;
+        ALTER TABLE fleet_throughput_hourly ADD PRIMARY KEY (tenant_id, bucket, vehicle_platform);
+        ALTER TABLE fleet_error_codes_daily DROP CONSTRAINT fleet_error_codes_daily_pkey;
+        ALTER TABLE fleet_error_codes_daily ADD PRIMARY KEY (tenant_id, day, vehicle_platform, firmware_version, error_code);
+        ALTER TABLE fleet_vehicle_daily DROP CONSTRAINT fleet_vehicle_daily_pkey;
+        ALTER TABLE fleet_vehicle_daily ADD PRIMARY KEY (tenant_id, day, vin);
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_fleet_error_codes_platform_day;
+CREATE INDEX IF NOT EXISTS idx_fleet_error_codes_tenant_platform_day ON fleet_error_codes_daily(tenant_id, vehicle_platform, day);
+DROP INDEX IF EXISTS idx_fleet_vehicle_daily_vin_day;
+CREATE INDEX IF NOT EXISTS idx_fleet_vehicle_daily_tenant_vin_day ON fleet_vehicle_daily(tenant_id, vin, day);
+
+-- 车辆注册表：VIN 全局唯一，存量车辆归属 default 租户
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_vehicles_tenant_platform ON vehicles(tenant_id, platform);
+
+-- OTA 活动与单车升级记录（升级记录的租户与所属活动一致）
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+UPDATE deployments d SET tenant_id = c.tenant_id FROM campaigns c WHERE d.campaign_id = c.id AND d.tenant_id <> c.tenant_id;
+CREATE INDEX IF NOT EXISTS idx_campaigns_tenant_status ON campaigns(tenant_id, status, created_at DESC);
+
+-- 版本回归：同一（平台, 版本对, 指标）在不同租户下是不同的回归
+ALTER TABLE firmware_regressions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE firmware_regressions DROP CONSTRAINT IF EXISTS uq_firmware_regression;
+ALTER TABLE firmware_regressions ADD CONSTRAINT uq_firmware_regression
+    UNIQUE (tenant_id, vehicle_platform, ecu, baseline_version, candidate_version, metric, error_code);
+CREATE INDEX IF NOT EXISTS idx_firmware_regressions_tenant_status ON firmware_regressions(tenant_id, status, detected_at DESC);
+
+-- 访问审计：记录被拒绝请求的凭证所属租户（认证失败时为 default）
+ALTER TABLE access_denials ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_access_denials_tenant_time ON access_denials(tenant_id, occurred_at DESC);
+
+-- AI 诊断由外部诊断服务写入，不知道租户：插入时从所属 Batch 继承
+ALTER TABLE ai_diagnoses ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+UPDATE ai_diagnoses a SET tenant_id = b.tenant_id FROM batches b WHERE a.batch_id = b.id AND a.tenant_id <> b.tenant_id;
+
+CREATE OR REPLACE FUNCTION ai_diagnoses_inherit_tenant() RETURNS TRIGGER AS $$
+BEGIN
+    SELECT tenant_id INTO NEW.tenant_id FROM batches WHERE id = NEW.batch_id;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ai_diagnoses_tenant ON ai_diagnoses;
+CREATE TRIGGER trg_ai_diagnoses_tenant
+    BEFORE INSERT ON ai_diagnoses
+    FOR EACH ROW EXECUTE FUNCTION ai_diagnoses_inherit_tenant();
+
+-- 行级安全（与 13-tenants.sql 相同：只约束 RunAsTenant 切换到 argus_tenant 的请求）
+ALTER TABLE fleet_rollup_ledger ENABLE ROW LEVEL SECURITY;
+ALTER TABLE fleet_throughput_hourly ENABLE ROW LEVEL SECURITY;
+ALTER TABLE fleet_error_codes_daily ENABLE ROW LEVEL SECURITY;
+ALTER TABLE fleet_vehicle_daily ENABLE ROW LEVEL SECURITY;
+ALTER TABLE vehicles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE campaigns ENABLE ROW LEVEL SECURITY;
+ALTER TABLE deployments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE firmware_regressions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE access_denials ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ai_diagnoses ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON fleet_rollup_ledger;
+CREATE POLICY tenant_isolation ON fleet_rollup_ledger
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON fleet_throughput_hourly;
+CREATE POLICY tenant_isolation ON fleet_throughput_hourly
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON fleet_error_codes_daily;
+CREATE POLICY tenant_isolation ON fleet_error_codes_daily
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON fleet_vehicle_daily;
+CREATE POLICY tenant_isolation ON fleet_vehicle_daily
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON vehicles;
+CREATE POLICY tenant_isolation ON vehicles
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON campaigns;
+CREATE POLICY tenant_isolation ON campaigns
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON deployments;
+CREATE POLICY tenant_isolation ON deployments
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON firmware_regressions;
+CREATE POLICY tenant_isolation ON firmware_regressions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON access_denials;
+CREATE POLICY tenant_isolation ON access_denials
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON ai_diagnoses;
+CREATE POLICY tenant_isolation ON ai_diagnoses
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// authorizeVehicleData 单条数据（Batch、报告、单车趋势）的范围校验：车辆的平台与所属车队都要在调用方范围内
+//
+// 只有按车队授权时才查询车辆注册表；不限范围（内部任务、未启用认证）时不产生额外查询
+func authorizeVehicleData(ctx context.Context, vehicleRepo domain.VehicleRepository, platform, vin string) error {
+	scope := domain.DataScopeFromContext(ctx)
+	if !scope.Restricted() {
+		return nil
+	}
+	fleet := ""
+	if scope.NeedsFleet() {
+		vehicle, err := vehicleRepo.FindByVIN(ctx, vin)
+		if err != nil {
+			return fmt.Errorf("failed to find vehicle: %w", err)
+		}
+		if vehicle != nil {
+			fleet = vehicle.OwnerFleet
+			if platform == "" {
+				platform = vehicle.Platform
+			}
+		}
+	}
+	if !scope.Allows(platform, fleet) {
+		return fmt.Errorf("%w: vehicle %s is outside of your access scope", domain.ErrForbidden, vin)
+	}
+	return nil
+}
+
+// authorizePlatformData 平台级汇总（版本对比、平台吞吐）要求对整个平台有访问权，只按车队授权不够
+func authorizePlatformData(ctx context.Context, platform string) error {
+	if !domain.DataScopeFromContext(ctx).AllowsPlatform(platform) {
+		return fmt.Errorf("%w: platform %s is outside of your access scope", domain.ErrForbidden, platform)
+	}
+	return nil
+}
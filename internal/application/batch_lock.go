@@ -0,0 +1,110 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// batchLockStatser 能提供竞争统计的锁实现（如 Redis 锁）
+type batchLockStatser interface {
+	Stats() domain.BatchLockStats
+}
+
+// FallbackBatchLocker 主锁（Redis）不可用时降级到备用锁（数据库行锁）
+//
+// 只有 ErrLockUnavailable 会触发降级；ErrLockTimeout 说明锁正被别人持有，
+// 此时降级反而会破坏互斥，直接返回错误
+type FallbackBatchLocker struct {
+	primary   domain.BatchLocker
+	fallback  domain.BatchLocker
+	fallbacks atomic.Int64
+}
+
+func NewFallbackBatchLocker(primary, fallback domain.BatchLocker) *FallbackBatchLocker {
+	return &FallbackBatchLocker{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (l *FallbackBatchLocker) LockBatch(ctx context.Context, batchID uuid.UUID) (context.Context, domain.BatchLock, error) {
+	lockCtx, lock, err := l.primary.LockBatch(ctx, batchID)
+	if err == nil || !errors.Is(err, domain.ErrLockUnavailable) || l.fallback == nil {
+		return lockCtx, lock, err
+	}
+
+	l.fallbacks.Add(1)
+	log.Printf("[Lock] Primary locker unavailable (%v), falling back to row lock for batch %s", err, batchID)
+	return l.fallback.LockBatch(ctx, batchID)
+}
+
+// Stats 合并主锁统计与降级次数
+func (l *FallbackBatchLocker) Stats() domain.BatchLockStats {
+	var stats domain.BatchLockStats
+	if s, ok := l.primary.(batchLockStatser); ok {
+		stats = s.Stats()
+	}
+	stats.Fallbacks = l.fallbacks.Load()
+	return stats
+}
+
+// withBatchLock 在 Batch 锁内执行 fn；locker 为 nil 时不加锁（单测、单实例部署）
+//
+// fn 必须使用传入的 ctx 调用 Repository（其中携带 fencing token 或数据库事务）。
+// fn 内用 afterUnlock 登记的操作（发布事件）在释放锁之后执行：降级为行锁时释放锁即提交事务，
+// 在此之前发出的事件可能描述一个最终被回滚的状态
+func withBatchLock(ctx context.Context, locker domain.BatchLocker, batchID uuid.UUID, fn func(ctx context.Context) error) error {
+	if locker == nil {
+		return fn(ctx)
+	}
+
+	lockCtx, lock, err := locker.LockBatch(ctx, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to lock batch %s: %w", batchID, err)
+	}
+
+	hooks := &unlockHooks{}
+	opErr := fn(context.WithValue(lockCtx, unlockHooksKey{}, hooks))
+	if err := lock.Release(ctx, opErr); err != nil {
+		log.Printf("[Lock] Failed to release lock for batch %s: %v", batchID, err)
+		if opErr == nil {
+			return fmt.Errorf("failed to release lock for batch %s: %w", batchID, err)
+		}
+	}
+	if opErr != nil {
+		return opErr
+	}
+	return hooks.run(ctx)
+}
+
+type unlockHooksKey struct{}
+
+// unlockHooks 锁内登记、释放锁后按顺序执行的操作
+type unlockHooks struct {
+	fns []func(ctx context.Context) error
+}
+
+func (h *unlockHooks) run(ctx context.Context) error {
+	var errs []error
+	for _, fn := range h.fns {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// afterUnlock 在锁内调用时推迟到释放锁之后执行（fn 拿到的是不带锁事务的 ctx），否则立即执行
+func afterUnlock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if hooks, ok := ctx.Value(unlockHooksKey{}).(*unlockHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return nil
+	}
+	return fn(ctx)
+}
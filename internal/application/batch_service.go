@@ -3,40 +3,90 @@ package application
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
 type BatchService struct {
-	batchRepo domain.BatchRepository
-	fileRepo  domain.FileRepository
-	kafka     messaging.KafkaEventPublisher
+	batchRepo   domain.BatchRepository
+	fileRepo    domain.FileRepository
+	vehicleRepo domain.VehicleRepository
+	kafka       messaging.KafkaEventPublisher
+	locker      domain.BatchLocker
+	tenants     *domain.TenantRegistry
+	quotas      *QuotaService
 }
 
 func NewBatchService(
 	batchRepo domain.BatchRepository,
 	fileRepo domain.FileRepository,
+	vehicleRepo domain.VehicleRepository,
 	kafka messaging.KafkaEventPublisher,
+	locker domain.BatchLocker,
+	tenants *domain.TenantRegistry,
+	quotas *QuotaService,
 ) *BatchService {
 	return &BatchService{
-		batchRepo: batchRepo,
-		fileRepo:  fileRepo,
-		kafka:     kafka,
+		batchRepo:   batchRepo,
+		fileRepo:    fileRepo,
+		vehicleRepo: vehicleRepo,
+		kafka:       kafka,
+		locker:      locker,
+		tenants:     tenants,
+		quotas:      quotas,
 	}
 }
 
 func (s *BatchService) CreateBatch (
 	ctx context.Context,
-	vehicleID, vin string,
-	expectedWorkers int,
+	req dto.CreateBatchRequest,
 ) (*domain.Batch,error) {
-	batch, err := domain.NewBatch(vehicleID,vin,expectedWorkers)
+	priority, err := domain.ParseBatchPriority(req.Priority)
 	if err != nil {
 		return nil,err
 	}
+	// 车辆只能为自己的 VIN 创建 Batch（运维人员与未启用认证时不受限制）
+	if err := domain.AuthorizeVIN(ctx, req.VIN); err != nil {
+		return nil, err
+	}
+	tenant, err := s.tenants.Get(callerTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	vehicle, err := s.findAcceptingVehicle(ctx, req.VehicleID, req.VIN)
+	if err != nil {
+		return nil, err
+	}
+	// 限流与每日配额放在车辆校验之后：未注册的 VIN 不占用计数，也不会挤占真实车辆的令牌
+	if err := s.quotas.AdmitBatch(ctx, tenant, vehicle.VIN); err != nil {
+		return nil, err
+	}
+	batch, err := domain.NewBatch(req.VehicleID,vehicle.VIN,req.ExpectedWorkers)
+	if err != nil {
+		return nil,err
+	}
+	batch.TenantID = tenant.ID
+	// 平台以注册表为准（请求里的 vehicle_platform 仅用于兼容旧客户端，不一致时记录日志）
+	if req.VehiclePlatform != "" && req.VehiclePlatform != vehicle.Platform {
+		log.Printf("[BatchService] Platform mismatch for VIN %s: request=%s, registry=%s",
+			domain.MaskVIN(vehicle.VIN), req.VehiclePlatform, vehicle.Platform)
+	}
+	batch.VehiclePlatform = vehicle.Platform
+	batch.Priority = priority
+	// 软件版本快照：车端上报优先（上传可能滞后于 OTA 升级），否则取注册表的当前清单
+	firmwareVersion, ecuVersions := req.FirmwareVersion, req.ECUVersions
+	if firmwareVersion == "" {
+		firmwareVersion = vehicle.FirmwareVersion
+	}
+	if len(ecuVersions) == 0 {
+		ecuVersions = vehicle.ECUVersions()
+	}
+	batch.RecordSoftwareVersions(firmwareVersion, ecuVersions)
 	if err := s.batchRepo.Save(ctx,batch); err != nil {
 		return nil,err
 	}
@@ -47,36 +97,88 @@ func (s *BatchService) CreateBatch (
 	return batch,nil
 }
 
-func (s *BatchService)TransitionBatchStatus(
-	ctx context.Context,
-	batchID uuid.UUID,
-	newStatus domain.BatchStatus,
-) error {
-	batch,err := s.batchRepo.FindByID(ctx,batchID) 
+// CheckUploadQuota 写对象存储之前校验单文件大小、存储总量、上传限流，并按 fileSize 预占每日上传字节数
+func (s *BatchService) CheckUploadQuota(ctx context.Context, batch *domain.Batch, fileSize int64) (*UploadReservation, error) {
+	return s.quotas.AdmitUpload(ctx, batch, fileSize)
+}
+
+// SettleUploadQuota 上传结束后按实际大小结算 CheckUploadQuota 的预占（失败时 actual 为 0）
+func (s *BatchService) SettleUploadQuota(ctx context.Context, reservation *UploadReservation, actual int64) {
+	s.quotas.SettleUpload(ctx, reservation, actual)
+}
+
+// findAcceptingVehicle 校验 VIN 并确认车辆已注册、在役
+func (s *BatchService) findAcceptingVehicle(ctx context.Context, vehicleID, vin string) (*domain.Vehicle, error) {
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return nil, err
+	}
+	vehicle, err := s.vehicleRepo.FindByVIN(ctx, vin)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to find vehicle: %w", err)
 	}
-	if batch == nil {
-		return fmt.Errorf("batch not found %s",batchID)
+	if vehicle == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVehicleNotRegistered, vin)
 	}
+	if err := vehicle.AcceptBatch(vehicleID); err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
 
-	if err := batch.TransitionTo(newStatus); err != nil {
-		return err
+// AuthorizeBatch 上传文件、完成上传前校验 Batch 归属（Batch 不存在返回 nil, nil）
+func (s *BatchService) AuthorizeBatch(ctx context.Context, batchID uuid.UUID) (*domain.Batch, error) {
+	batch, err := s.batchRepo.FindByID(ctx, batchID)
+	if err != nil || batch == nil {
+		return nil, err
 	}
-	if err := s.batchRepo.Save(ctx,batch); err != nil {
-		return err
+	if err := domain.AuthorizeTenant(ctx, batch.TenantID); err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeVIN(ctx, batch.VIN); err != nil {
+		return nil, err
 	}
+	return batch, nil
+}
 
-	events := batch.GetEvents()
-	if len(events) > 0 {
-		if err := s.kafka.PublishEvents(ctx,events);err != nil {
-			fmt.Printf("Failed to publish events: %v\n", err)
+func (s *BatchService)TransitionBatchStatus(
+	ctx context.Context,
+	batchID uuid.UUID,
+	newStatus domain.BatchStatus,
+) error {
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		batch,err := s.batchRepo.FindByID(ctx,batchID) 
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return fmt.Errorf("batch not found %s",batchID)
 		}
-	}
-	batch.ClearEvents()
 
-	return s.batchRepo.Save(ctx,batch)
+		if err := batch.TransitionTo(newStatus); err != nil {
+			return err
+		}
+		if err := s.batchRepo.Save(ctx,batch); err != nil {
+			return err
+		}
 
+		events := batch.GetEvents()
+		observeBatchEvents(ctx, batch, events)
+		batch.ClearEvents()
+		if err := s.batchRepo.Save(ctx,batch); err != nil {
+			return err
+		}
+		// 释放锁（行锁降级时即提交事务）之后再发布
+		return afterUnlock(ctx, func(ctx context.Context) error {
+			if len(events) == 0 {
+				return nil
+			}
+			if err := s.kafka.PublishEvents(ctx, events); err != nil {
+				batchLogger.ErrorContext(ctx, "failed to publish events", "batch_id", batch.ID, "error", err)
+			}
+			return nil
+		})
+	})
 }
 
 func (s *BatchService) AddFile(
@@ -87,45 +189,48 @@ func (s *BatchService) AddFile(
 	fileSize int64,
 	minioPath string,
 ) error {
-	// 1. 验证 Batch 存在
-	batch, err := s.batchRepo.FindByID(ctx, batchID)
-	if err != nil {
-		return err
-	}
-	if batch == nil {
-		return fmt.Errorf("batch not found %s", batchID)
-	}
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		// 1. 验证 Batch 存在
+		batch, err := s.batchRepo.FindByID(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return fmt.Errorf("batch not found %s", batchID)
+		}
 
-	// 2. 创建 File 记录
-	now := time.Now()
-	file := &domain.File{
-		ID:               fileID,
-		BatchID:          batchID,
-		Filename:         fileID.String(), // 使用 fileID 作为 filename
-		OriginalFilename: originalFilename,
-		FileSize:         fileSize,
-		FileType:         "", // 可选：从文件扩展名推断
-		UploadTime:       now,
-		MinIOPath:        minioPath,
-		MinIOETag:        "", // MinIO SDK 返回的 ETag
-		ProcessingStatus: domain.FileStatusPending,
-		ParseDurationMs:  0,
-		RecordCount:      0,
-		ErrorMessage:     "",
-		CreatedAt:        now,
-		UpdatedAt:        now,
-	}
+		// 2. 创建 File 记录
+		now := time.Now()
+		file := &domain.File{
+			ID:               fileID,
+			BatchID:          batchID,
+			TenantID:         batch.TenantID,
+			Filename:         fileID.String(), // 使用 fileID 作为 filename
+			OriginalFilename: originalFilename,
+			FileSize:         fileSize,
+			FileType:         domain.FileTypeFromFilename(originalFilename), // 决定解析时使用的解码器
+			UploadTime:       now,
+			MinIOPath:        minioPath,
+			MinIOETag:        "", // MinIO SDK 返回的 ETag
+			ProcessingStatus: domain.FileStatusPending,
+			ParseDurationMs:  0,
+			RecordCount:      0,
+			ErrorMessage:     "",
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
 
-	// 3. 保存 File 到数据库
-	if err := s.fileRepo.Save(ctx, file); err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
-	}
+		// 3. 保存 File 到数据库
+		if err := s.fileRepo.Save(ctx, file); err != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
 
-	// 4. 增加 Batch 的 TotalFiles 计数
-	if err := batch.AddFile(fileID); err != nil {
-		return err
-	}
+		// 4. 增加 Batch 的 TotalFiles 计数
+		if err := batch.AddFile(fileID); err != nil {
+			return err
+		}
 
-	// 5. 保存 Batch
-	return s.batchRepo.Save(ctx, batch)
+		// 5. 保存 Batch
+		return s.batchRepo.Save(ctx, batch)
+	})
 }
\ No newline at end of file
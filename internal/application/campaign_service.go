@@ -0,0 +1,361 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// cohortPageSize 选择目标车辆时分页读取注册表
+const cohortPageSize = 500
+
+// CampaignService OTA 活动：创建、分波次放量、人工暂停 / 恢复 / 中止，以及门禁自动推进
+type CampaignService struct {
+	campaigns   domain.CampaignRepository
+	deployments domain.DeploymentRepository
+	vehicleRepo domain.VehicleRepository
+}
+
+func NewCampaignService(
+	campaigns domain.CampaignRepository,
+	deployments domain.DeploymentRepository,
+	vehicleRepo domain.VehicleRepository,
+) *CampaignService {
+	return &CampaignService{
+		campaigns:   campaigns,
+		deployments: deployments,
+		vehicleRepo: vehicleRepo,
+	}
+}
+
+// CampaignDetail 活动及各状态的车辆数
+type CampaignDetail struct {
+	Campaign    *domain.Campaign
+	Deployments map[domain.DeploymentStatus]int
+}
+
+func (s *CampaignService) CreateCampaign(ctx context.Context, req dto.CreateCampaignRequest) (*domain.Campaign, error) {
+	waves := make([]domain.RolloutWave, 0, len(req.Waves))
+	for _, percent := range req.Waves {
+		waves = append(waves, domain.RolloutWave{Percent: percent})
+	}
+	campaign, err := domain.NewCampaign(req.Name, req.Platform, req.TargetFirmware, req.Cohort, waves, gatePolicy(req.Gate), req.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	campaign.TenantID = callerTenant(ctx)
+	if err := s.campaigns.Save(ctx, campaign); err != nil {
+		return nil, fmt.Errorf("failed to save campaign: %w", err)
+	}
+	log.Printf("[CampaignService] Created campaign %s (%s → %s, waves=%v)",
+		campaign.ID, campaign.Platform, campaign.TargetFirmware, req.Waves)
+	return campaign, nil
+}
+
+// gatePolicy 请求中未传的阈值使用默认值
+func gatePolicy(req dto.CampaignGateRequest) domain.GatePolicy {
+	gate := domain.DefaultGatePolicy()
+	if req.MinBatches != nil {
+		gate.MinBatches = *req.MinBatches
+	}
+	if req.SoakHours != nil {
+		gate.SoakDuration = time.Duration(*req.SoakHours * float64(time.Hour))
+	}
+	if req.MaxCriticalDiagnoses != nil {
+		gate.MaxCriticalDiagnoses = *req.MaxCriticalDiagnoses
+	}
+	if req.MaxFailureRate != nil {
+		gate.MaxFailureRate = *req.MaxFailureRate
+	}
+	if req.MaxErrorRateIncrease != nil {
+		gate.MaxErrorRateIncrease = *req.MaxErrorRateIncrease
+	}
+	if req.MaxInstallFailureRate != nil {
+		gate.MaxInstallFailureRate = *req.MaxInstallFailureRate
+	}
+	return gate
+}
+
+// StartCampaign 选出目标车辆、按哈希分配波次，然后放量第一个波次
+func (s *CampaignService) StartCampaign(ctx context.Context, id uuid.UUID) (*domain.Campaign, error) {
+	campaign, err := s.findCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != domain.CampaignStatusDraft {
+		return nil, fmt.Errorf("%w: cannot start %s campaign", domain.ErrInvalidTransition, campaign.Status)
+	}
+
+	deployments, err := s.selectCohort(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+	if len(deployments) == 0 {
+		return nil, fmt.Errorf("%w: no active vehicle matches the cohort", domain.ErrInvalidCampaign)
+	}
+	// 重复调用时已创建的记录会被跳过；已在其他进行中活动里的车辆同样跳过
+	created, err := s.deployments.CreateAll(ctx, deployments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployments: %w", err)
+	}
+
+	if err := campaign.Start(); err != nil {
+		return nil, err
+	}
+	if err := s.campaigns.Save(ctx, campaign); err != nil {
+		return nil, err
+	}
+	released, err := s.deployments.ReleaseWave(ctx, campaign.ID, campaign.CurrentWave)
+	if err != nil {
+		// 门禁任务每轮都会补放当前波次，这里失败不影响活动状态
+		log.Printf("[CampaignService] Failed to release wave 0 of %s: %v", campaign.ID, err)
+	}
+	log.Printf("[CampaignService] Started campaign %s: cohort=%d, deployments=%d, released=%d",
+		campaign.ID, len(deployments), created, released)
+	return campaign, nil
+}
+
+// selectCohort 活动所属租户在平台上在役、满足筛选条件、且尚未处于目标版本的车辆
+func (s *CampaignService) selectCohort(ctx context.Context, campaign *domain.Campaign) ([]*domain.Deployment, error) {
+	ctx = domain.WithTenant(ctx, campaign.TenantID)
+	platform, status := campaign.Platform, string(domain.VehicleStatusActive)
+	var deployments []*domain.Deployment
+	for offset := 0; ; offset += cohortPageSize {
+		vehicles, err := s.vehicleRepo.List(ctx, domain.VehicleListOptions{
+			Limit:    cohortPageSize,
+			Offset:   offset,
+			Platform: &platform,
+			Status:   &status,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vehicles: %w", err)
+		}
+		for _, v := range vehicles {
+			if v.FirmwareVersion != campaign.TargetFirmware && campaign.Cohort.Matches(v) {
+				deployments = append(deployments, domain.NewDeployment(campaign, v))
+			}
+		}
+		if len(vehicles) < cohortPageSize {
+			return deployments, nil
+		}
+	}
+}
+
+func (s *CampaignService) PauseCampaign(ctx context.Context, id uuid.UUID, reason string) (*domain.Campaign, error) {
+	return s.transition(ctx, id, func(c *domain.Campaign) error {
+		return c.Pause(reason)
+	})
+}
+
+func (s *CampaignService) ResumeCampaign(ctx context.Context, id uuid.UUID) (*domain.Campaign, error) {
+	return s.transition(ctx, id, func(c *domain.Campaign) error {
+		return c.Resume()
+	})
+}
+
+// AbortCampaign 中止活动并取消所有未完成的升级（已安装的车辆不回滚，回滚需要新建活动）
+func (s *CampaignService) AbortCampaign(ctx context.Context, id uuid.UUID, reason string) (*domain.Campaign, error) {
+	campaign, err := s.transition(ctx, id, func(c *domain.Campaign) error {
+		return c.Abort(reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := s.deployments.CancelOpen(ctx, campaign.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel deployments: %w", err)
+	}
+	log.Printf("[CampaignService] Aborted campaign %s (%s), cancelled %d deployments", campaign.ID, reason, cancelled)
+	return campaign, nil
+}
+
+func (s *CampaignService) transition(ctx context.Context, id uuid.UUID, apply func(*domain.Campaign) error) (*domain.Campaign, error) {
+	campaign, err := s.findCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(campaign); err != nil {
+		return nil, err
+	}
+	if err := s.campaigns.Save(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *CampaignService) findCampaign(ctx context.Context, id uuid.UUID) (*domain.Campaign, error) {
+	campaign, err := s.campaigns.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find campaign: %w", err)
+	}
+	if campaign == nil {
+		return nil, domain.ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+func (s *CampaignService) GetCampaign(ctx context.Context, id uuid.UUID) (*CampaignDetail, error) {
+	campaign, err := s.findCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.deployments.CountByStatus(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count deployments: %w", err)
+	}
+	return &CampaignDetail{Campaign: campaign, Deployments: counts}, nil
+}
+
+func (s *CampaignService) ListCampaigns(ctx context.Context, status domain.CampaignStatus, limit int) ([]*domain.Campaign, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.campaigns.List(ctx, status, limit)
+}
+
+func (s *CampaignService) ListDeployments(ctx context.Context, id uuid.UUID, status domain.DeploymentStatus, limit, offset int) ([]*domain.Deployment, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.deployments.ListByCampaign(ctx, id, status, limit, offset)
+}
+
+// PendingDeployment 车端轮询：已下发且活动仍在进行中的升级（活动暂停时不下发，返回 nil）
+func (s *CampaignService) PendingDeployment(ctx context.Context, vin string) (*domain.Deployment, error) {
+	deployment, err := s.deployments.FindActiveByVIN(ctx, domain.NormalizeVIN(vin))
+	if err != nil || deployment == nil {
+		return nil, err
+	}
+	campaign, err := s.findCampaign(ctx, deployment.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != domain.CampaignStatusRunning {
+		return nil, nil
+	}
+	return deployment, nil
+}
+
+// ReportDeployment 车端上报安装结果；成功时同步更新车辆注册表的软件版本
+func (s *CampaignService) ReportDeployment(ctx context.Context, vin string, success bool, message string) (*domain.Deployment, error) {
+	vin = domain.NormalizeVIN(vin)
+	deployment, err := s.deployments.FindActiveByVIN(ctx, vin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deployment: %w", err)
+	}
+	if deployment == nil {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	if err := deployment.ReportResult(success, message); err != nil {
+		return nil, err
+	}
+	if err := s.deployments.Save(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	if success {
+		vehicle, err := s.vehicleRepo.FindByVIN(ctx, vin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find vehicle: %w", err)
+		}
+		if vehicle != nil {
+			if err := vehicle.UpdateInventory(deployment.TargetVersion, vehicle.ECUs); err != nil {
+				return nil, err
+			}
+			if err := s.vehicleRepo.Save(ctx, vehicle); err != nil {
+				return nil, fmt.Errorf("failed to update vehicle firmware: %w", err)
+			}
+		}
+	}
+	log.Printf("[CampaignService] Deployment %s for %s: %s", deployment.ID, domain.MaskVIN(vin), deployment.Status)
+	return deployment, nil
+}
+
+// EvaluateGates 对所有进行中的活动做一次门禁评估（仅 Orchestrator Leader 运行）
+func (s *CampaignService) EvaluateGates(ctx context.Context) error {
+	campaigns, err := s.campaigns.List(ctx, domain.CampaignStatusRunning, 200)
+	if err != nil {
+		return fmt.Errorf("failed to list running campaigns: %w", err)
+	}
+	for _, campaign := range campaigns {
+		if err := s.evaluateGate(ctx, campaign); err != nil {
+			if errors.Is(err, domain.ErrCampaignConflict) {
+				// 评估期间被人工暂停 / 中止，下一轮基于最新状态重新评估
+				log.Printf("[CampaignGate] Campaign %s changed during evaluation, skipped", campaign.ID)
+				continue
+			}
+			log.Printf("[CampaignGate] Failed to evaluate campaign %s: %v", campaign.ID, err)
+		}
+	}
+	return nil
+}
+
+// evaluateGate 门禁失败自动暂停（由发布经理决定恢复还是中止），通过则放量下一个波次
+//
+// Leader 任务跨租户运行，评估单个活动时限定在活动所属租户内
+func (s *CampaignService) evaluateGate(ctx context.Context, campaign *domain.Campaign) error {
+	ctx = domain.WithTenant(ctx, campaign.TenantID)
+	// 每轮都补放当前波次：上次推进后放量失败、或恢复后的活动都能自愈（ReleaseWave 幂等）
+	if _, err := s.deployments.ReleaseWave(ctx, campaign.ID, campaign.CurrentWave); err != nil {
+		return fmt.Errorf("failed to release wave: %w", err)
+	}
+	if _, err := s.deployments.SyncInstalled(ctx, campaign.ID); err != nil {
+		return fmt.Errorf("failed to sync installed deployments: %w", err)
+	}
+	metrics, err := s.deployments.GateMetrics(ctx, campaign)
+	if err != nil {
+		return fmt.Errorf("failed to collect gate metrics: %w", err)
+	}
+
+	now := time.Now()
+	waveStartedAt := now
+	if campaign.WaveStartedAt != nil {
+		waveStartedAt = *campaign.WaveStartedAt
+	}
+	decision, reasons := campaign.Gate.Evaluate(metrics, waveStartedAt, now)
+	campaign.RecordGate(domain.GateResult{
+		Wave:        campaign.CurrentWave,
+		Decision:    decision,
+		Reasons:     reasons,
+		Metrics:     metrics,
+		EvaluatedAt: now,
+	})
+
+	nextWave := -1
+	switch decision {
+	case domain.GateFail:
+		if err := campaign.Pause("gate failed: " + strings.Join(reasons, "; ")); err != nil {
+			return err
+		}
+	case domain.GatePass:
+		if nextWave, err = campaign.AdvanceWave(); err != nil {
+			return err
+		}
+	}
+	if err := s.campaigns.Save(ctx, campaign); err != nil {
+		return err
+	}
+
+	switch {
+	case decision == domain.GateFail:
+		log.Printf("[CampaignGate] ⚠️ Campaign %s paused at wave %d: %s", campaign.ID, campaign.CurrentWave, campaign.StatusReason)
+	case campaign.Status == domain.CampaignStatusCompleted:
+		log.Printf("[CampaignGate] ✅ Campaign %s completed", campaign.ID)
+	case nextWave >= 0:
+		released, err := s.deployments.ReleaseWave(ctx, campaign.ID, nextWave)
+		if err != nil {
+			return fmt.Errorf("failed to release wave %d: %w", nextWave, err)
+		}
+		log.Printf("[CampaignGate] Campaign %s advanced to wave %d (%d%%), released %d vehicles",
+			campaign.ID, nextWave, campaign.Waves[nextWave].Percent, released)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+)
+
+// RunCompensation 执行一轮补偿：按分阶段 SLA 找出卡住的批次并逐个处理
+//
+// 数据库只按最短超时粗筛，精确判断（平台覆盖、按文件数缩放）在内存中完成
+func (s *OrchestrateService) RunCompensation(ctx context.Context) (int, error) {
+	candidates, err := s.batchRepo.FindStuckBatches(ctx, s.sla.MinTimeout())
+	if err != nil {
+		return 0, fmt.Errorf("failed to find stuck batches: %w", err)
+	}
+
+	now := time.Now()
+	stuck := make([]*domain.Batch, 0, len(candidates))
+	for _, batch := range candidates {
+		if s.sla.IsStuck(batch, now) {
+			stuck = append(stuck, batch)
+		}
+	}
+	metrics.SetStuckBatches(len(stuck))
+
+	// 高优先级先处理（同优先级保持 updated_at 升序）
+	sort.SliceStable(stuck, func(i, j int) bool {
+		return stuck[i].Priority.Rank() > stuck[j].Priority.Rank()
+	})
+
+	handled := 0
+	for _, batch := range stuck {
+		handled++
+		if err := s.HandleStuckBatch(logging.With(ctx, "batch_id", batch.ID.String()), batch); err != nil {
+			log.Printf("[Compensation] Failed to handle stuck batch %s: %v", batch.ID, err)
+			// 继续处理下一个，不中断整个循环
+		}
+	}
+	return handled, nil
+}
+
+// HandleStuckBatch 处理卡住的批次（补偿任务）
+//
+// 候选列表是加锁前查出来的，期间可能已被事件处理推进，所以加锁后重新加载并再次判断
+func (s *OrchestrateService) HandleStuckBatch(ctx context.Context, batch *domain.Batch) error {
+	return withBatchLock(ctx, s.locker, batch.ID, func(ctx context.Context) error {
+		current, err := s.batchRepo.FindByID(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil || !s.sla.IsStuck(current, time.Now()) {
+			log.Printf("[Compensation] Batch %s progressed since it was selected, skipping", batch.ID)
+			metrics.ObserveCompensation(batch.Status, metrics.CompensationSkip)
+			return nil
+		}
+		return s.escalateStuckBatch(ctx, current)
+	})
+}
+
+// escalateStuckBatch 升级策略：当前状态下已重试 MaxRetries 次仍未推进 → 标记 failed；否则重试该阶段
+func (s *OrchestrateService) escalateStuckBatch(ctx context.Context, batch *domain.Batch) error {
+	stage, ok := s.sla.StageFor(batch.VehiclePlatform, batch.Status)
+	if !ok {
+		log.Printf("[Compensation] No SLA for status %s (batch %s), skipping", batch.Status, batch.ID)
+		return nil
+	}
+
+	log.Printf("[Compensation] Handling stuck batch: id=%s, status=%s, platform=%s, attempts=%d/%d, updated_at=%s",
+		batch.ID, batch.Status, batch.VehiclePlatform, batch.CompensationAttempts, stage.MaxRetries, batch.UpdatedAt)
+
+	if batch.CompensationAttempts >= stage.MaxRetries {
+		reason := fmt.Sprintf("%s timeout after %s (%d retries)",
+			batch.Status, stage.TimeoutFor(batch.TotalFiles), batch.CompensationAttempts)
+		metrics.ObserveCompensation(batch.Status, metrics.CompensationFail)
+		return s.failStuckBatch(ctx, batch, reason)
+	}
+
+	// 先持久化重试次数（同时刷新 updated_at，相当于给下一次重试留出一个完整的 SLA 窗口）
+	batch.CompensationAttempts++
+	if err := s.batchRepo.Save(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save compensation attempt: %w", err)
+	}
+
+	metrics.ObserveCompensation(batch.Status, metrics.CompensationRetry)
+	return s.retryStage(ctx, batch)
+}
+
+// retryStage 按状态重新触发下游
+func (s *OrchestrateService) retryStage(ctx context.Context, batch *domain.Batch) error {
+	switch batch.Status {
+	case domain.BatchStatusUploaded:
+		// Orchestrator 没收到 BatchCreated（或处理失败），重新发布
+		return s.republishBatchCreated(ctx, batch)
+
+	case domain.BatchStatusScattering:
+		// 以 Redis Barrier 为准，而不是 DB 中的 processed_files（处理中途不持久化）
+		count, err := s.redis.SCARD(ctx, barrierKey(batch.TenantID, batch.ID))
+		if err != nil {
+			return fmt.Errorf("failed to get Redis set size: %w", err)
+		}
+		if count >= int64(batch.TotalFiles) {
+			log.Printf("[Compensation] Re-running barrier completion for batch %s", batch.ID)
+			return s.completeScatterBarrier(ctx, batch)
+		}
+		// 部分文件未解析：重新下发解析任务，Barrier 基于 Set 天然幂等
+		log.Printf("[Compensation] Batch %s still missing files (%d/%d), re-dispatching parse",
+			batch.ID, count, batch.TotalFiles)
+		return s.republishBatchCreated(ctx, batch)
+
+	case domain.BatchStatusScattered:
+		return s.completeScatterBarrier(ctx, batch)
+
+	case domain.BatchStatusGathering:
+		parsedFiles, err := s.loadParsedOutputs(ctx, batch)
+		if err != nil {
+			return err
+		}
+		log.Printf("[Compensation] Re-publishing GatherRequested for batch %s", batch.ID)
+		return s.publishGatherRequested(ctx, batch, parsedFiles)
+
+	case domain.BatchStatusGathered:
+		if err := batch.TransitionTo(domain.BatchStatusDiagnosing); err != nil {
+			return fmt.Errorf("failed to transition to diagnosing: %w", err)
+		}
+		if err := s.batchRepo.Save(ctx, batch); err != nil {
+			return fmt.Errorf("failed to save batch: %w", err)
+		}
+		s.publishBatchEvents(ctx, batch)
+		return nil
+
+	case domain.BatchStatusDiagnosing:
+		// AI Worker 以 gathered → diagnosing 的 StatusChanged 作为触发信号，重放该事件
+		log.Printf("[Compensation] Re-triggering diagnosis for batch %s", batch.ID)
+		event := domain.BatchStatusChanged{
+			BatchID:    batch.ID,
+			TenantID:   batch.TenantID,
+			OldStatus:  domain.BatchStatusGathered,
+			NewStatus:  domain.BatchStatusDiagnosing,
+			Priority:   batch.Priority,
+			OccurredAt: time.Now(),
+		}
+		return afterUnlock(ctx, func(ctx context.Context) error {
+			return s.kafka.PublishEvents(ctx, []domain.DomainEvent{event})
+		})
+
+	default:
+		log.Printf("[Compensation] Nothing to retry for batch %s in status %s", batch.ID, batch.Status)
+		return nil
+	}
+}
+
+// failStuckBatch 重试耗尽，标记为失败
+func (s *OrchestrateService) failStuckBatch(ctx context.Context, batch *domain.Batch, reason string) error {
+	log.Printf("[Compensation] Giving up on batch %s: %s", batch.ID, reason)
+
+	if err := batch.TransitionTo(domain.BatchStatusFailed); err != nil {
+		return fmt.Errorf("failed to transition to failed: %w", err)
+	}
+	batch.ErrorMessage = reason
+
+	if err := s.batchRepo.Save(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save batch: %w", err)
+	}
+	s.publishBatchEvents(ctx, batch)
+
+	log.Printf("[Compensation] Batch %s marked as failed", batch.ID)
+	return nil
+}
+
+func (s *OrchestrateService) republishBatchCreated(ctx context.Context, batch *domain.Batch) error {
+	event := domain.BatchCreated{
+		BatchID:         batch.ID,
+		TenantID:        batch.TenantID,
+		VehicleID:       batch.VehicleID,
+		VIN:             batch.VIN,
+		VehiclePlatform: batch.VehiclePlatform,
+		Priority:        batch.Priority,
+		OccurredAt:      time.Now(),
+	}
+	return afterUnlock(ctx, func(ctx context.Context) error {
+		return s.kafka.PublishEvents(ctx, []domain.DomainEvent{event})
+	})
+}
+
+// publishBatchEvents 发布并清空 Batch 上累积的领域事件（在锁内调用时释放锁后才发布，发布失败只记录日志）
+func (s *OrchestrateService) publishBatchEvents(ctx context.Context, batch *domain.Batch) {
+	events := batch.GetEvents()
+	observeBatchEvents(ctx, batch, events)
+	if len(events) == 0 {
+		return
+	}
+	batch.ClearEvents()
+	batchID := batch.ID
+	afterUnlock(ctx, func(ctx context.Context) error {
+		if err := s.kafka.PublishEvents(ctx, events); err != nil {
+			orchestratorLogger.ErrorContext(ctx, "failed to publish events", "batch_id", batchID, "error", err)
+		}
+		return nil
+	})
+}
@@ -1 +1,41 @@
-package dto
\ No newline at end of file
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// BatchResponse Batch 查询接口的返回结构（不直接暴露领域对象）
+type BatchResponse struct {
+	ID              uuid.UUID  `json:"batch_id"`
+	VehicleID       string     `json:"vehicle_id"`
+	VIN             string     `json:"vin"`
+	VehiclePlatform string     `json:"vehicle_platform"`
+	Priority        string     `json:"priority"`
+	Status          string     `json:"status"`
+	TotalFiles      int        `json:"total_files"`
+	ProcessedFiles  int        `json:"processed_files"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+func NewBatchResponse(batch *domain.Batch) BatchResponse {
+	return BatchResponse{
+		ID:              batch.ID,
+		VehicleID:       batch.VehicleID,
+		VIN:             batch.VIN,
+		VehiclePlatform: batch.VehiclePlatform,
+		Priority:        batch.Priority.String(),
+		Status:          batch.Status.String(),
+		TotalFiles:      batch.TotalFiles,
+		ProcessedFiles:  batch.ProcessedFiles,
+		ErrorMessage:    batch.ErrorMessage,
+		CreatedAt:       batch.CreatedAt,
+		UpdatedAt:       batch.UpdatedAt,
+		CompletedAt:     batch.CompletedAt,
+	}
+}
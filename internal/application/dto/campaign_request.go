@@ -0,0 +1,35 @@
+package dto
+
+import "github.com/xuewentao/argus-ota-platform/internal/domain"
+
+// CreateCampaignRequest 创建 OTA 活动
+type CreateCampaignRequest struct {
+	Name           string                `json:"name" binding:"required"`
+	Platform       string                `json:"platform" binding:"required"`
+	TargetFirmware string                `json:"target_firmware" binding:"required"`
+	Cohort         domain.CohortSelector `json:"cohort"`
+	Waves          []int                 `json:"waves"` // 累计放量百分比，例如 [1, 10, 50, 100]；不传使用默认波次
+	Gate           CampaignGateRequest   `json:"gate"`
+	CreatedBy      string                `json:"created_by"`
+}
+
+// CampaignGateRequest 门禁阈值，未传的字段使用默认值
+type CampaignGateRequest struct {
+	MinBatches            *int     `json:"min_batches" binding:"omitempty,min=0"`
+	SoakHours             *float64 `json:"soak_hours" binding:"omitempty,min=0"`
+	MaxCriticalDiagnoses  *int     `json:"max_critical_diagnoses" binding:"omitempty,min=0"`
+	MaxFailureRate        *float64 `json:"max_failure_rate" binding:"omitempty,min=0,max=1"`
+	MaxErrorRateIncrease  *float64 `json:"max_error_rate_increase" binding:"omitempty,min=0"`
+	MaxInstallFailureRate *float64 `json:"max_install_failure_rate" binding:"omitempty,min=0,max=1"`
+}
+
+// CampaignActionRequest 暂停 / 中止时填写原因
+type CampaignActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DeploymentResultRequest 车端上报安装结果
+type DeploymentResultRequest struct {
+	Success *bool  `json:"success" binding:"required"`
+	Message string `json:"message"`
+}
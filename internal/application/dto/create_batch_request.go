@@ -1 +1,14 @@
-package dto
\ No newline at end of file
+package dto
+
+// CreateBatchRequest 创建 Batch 的请求参数（HTTP 层直接绑定）
+type CreateBatchRequest struct {
+	VehicleID       string `json:"vehicle_id" binding:"required"`
+	VIN             string `json:"vin" binding:"required"`
+	ExpectedWorkers int    `json:"expected_workers" binding:"required"`
+	VehiclePlatform string `json:"vehicle_platform"`                                                    // 可选：车型平台，用于分平台 SLA
+	Priority        string `json:"priority" binding:"omitempty,oneof=routine elevated safety_critical"` // 可选：默认 routine
+
+	// 可选：日志产生时的软件版本（车端上报）；不传则取车辆注册表中的当前版本
+	FirmwareVersion string            `json:"firmware_version"`
+	ECUVersions     map[string]string `json:"ecu_versions"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// EraseVINRequest VIN 数据删除请求；requested_by 仅在未启用认证时使用，启用后以认证身份为准
+type EraseVINRequest struct {
+	VIN         string `json:"vin" binding:"required"`
+	Reason      string `json:"reason" binding:"required"`
+	RequestedBy string `json:"requested_by"`
+}
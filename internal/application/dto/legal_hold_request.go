@@ -0,0 +1,9 @@
+package dto
+
+// CreateLegalHoldRequest 法务保留请求（scope=vin 时 value 为 VIN，scope=batch 时为 Batch ID）
+type CreateLegalHoldRequest struct {
+	Scope     string `json:"scope" binding:"required,oneof=vin batch"`
+	Value     string `json:"value" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+	CreatedBy string `json:"created_by"`
+}
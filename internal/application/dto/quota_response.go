@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// QuotaUsage 一个计数对象（租户 / 车辆）当天的用量与上限（上限 0 表示不限）
+type QuotaUsage struct {
+	VIN            string `json:"vin,omitempty"`
+	Batches        int64  `json:"batches"`
+	MaxBatches     int64  `json:"max_batches"`
+	UploadBytes    int64  `json:"upload_bytes"`
+	MaxUploadBytes int64  `json:"max_upload_bytes"`
+}
+
+func NewQuotaUsage(usage domain.DailyUsage, limits domain.DailyLimits) QuotaUsage {
+	return QuotaUsage{
+		Batches:        usage.Batches,
+		MaxBatches:     limits.MaxBatches,
+		UploadBytes:    usage.Bytes,
+		MaxUploadBytes: limits.MaxBytes,
+	}
+}
+
+// QuotaUsageResponse 配额查询接口的返回结构
+type QuotaUsageResponse struct {
+	TenantID        string      `json:"tenant_id"`
+	Day             string      `json:"day"` // UTC 日期
+	ResetsAt        time.Time   `json:"resets_at"`
+	DailyEnforced   bool        `json:"daily_enforced"` // false 表示未配置 Redis，每日配额与限流未生效
+	Tenant          QuotaUsage  `json:"tenant"`
+	Vehicle         *QuotaUsage `json:"vehicle,omitempty"`
+	StorageBytes    int64       `json:"storage_bytes"`
+	MaxStorageBytes int64       `json:"max_storage_bytes"`
+}
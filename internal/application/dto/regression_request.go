@@ -0,0 +1,7 @@
+package dto
+
+// AcknowledgeRegressionRequest 发布经理确认版本回归
+type AcknowledgeRegressionRequest struct {
+	AcknowledgedBy string `json:"acknowledged_by" binding:"required"`
+	Note           string `json:"note"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/xuewentao/argus-ota-platform/internal/domain"
+
+// RegisterVehicleRequest 注册车辆
+type RegisterVehicleRequest struct {
+	VIN             string       `json:"vin" binding:"required,len=17"`
+	VehicleID       string       `json:"vehicle_id" binding:"required"`
+	Platform        string       `json:"platform" binding:"required"`
+	Model           string       `json:"model"`
+	ModelYear       int          `json:"model_year" binding:"omitempty,min=1980"`
+	FirmwareVersion string       `json:"firmware_version"`
+	ECUs            []domain.ECU `json:"ecus"`
+	OwnerFleet      string       `json:"owner_fleet"`
+}
+
+// UpdateVehicleRequest 部分更新：未传的字段保持不变
+type UpdateVehicleRequest struct {
+	FirmwareVersion *string      `json:"firmware_version"`
+	ECUs            []domain.ECU `json:"ecus"`
+	OwnerFleet      *string      `json:"owner_fleet"`
+}
@@ -0,0 +1,137 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// ErasureService VIN 数据删除请求（GDPR 第 17 条"被遗忘权"）
+//
+// Batch（对象存储 + 数据库）复用生命周期任务的删除流程，其余记录由 ErasureRepository 在一个事务内处理：
+//   - 删除：车辆注册表、升级记录、车队每日汇总
+//   - 匿名化（VIN 替换为令牌）：墓碑、访问审计、已解除的法务保留
+//
+// 墓碑和审计记录是删除 / 拒绝访问本身的证据，只匿名化不删除；替换为令牌后不再指向可识别的个人，
+// 仍能按令牌关联同一辆车的全部删除记录
+type ErasureService struct {
+	retention *RetentionService
+	erasures  domain.ErasureRepository
+	vins      *domain.Pseudonymizer
+}
+
+func NewErasureService(
+	retention *RetentionService,
+	erasures domain.ErasureRepository,
+	vins *domain.Pseudonymizer,
+) *ErasureService {
+	return &ErasureService{
+		retention: retention,
+		erasures:  erasures,
+		vins:      vins,
+	}
+}
+
+// EraseVIN 删除或匿名化 VIN 的全部数据
+//
+// 有生效中的法务保留或未结束的 Batch 时返回 ErrErasureBlocked，不做任何删除（不会只删一半）。
+// 执行中途失败可以直接重试：已删除的 Batch 不会再被找到，车辆记录的处理是幂等的
+func (s *ErasureService) EraseVIN(ctx context.Context, vin, requestedBy, reason string) (*domain.VINErasure, error) {
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return nil, err
+	}
+	if p := domain.PrincipalFromContext(ctx); p != nil {
+		requestedBy = p.Subject
+		if p.Name != "" {
+			requestedBy = p.Name
+		}
+	}
+	token := s.vins.VINToken(vin)
+
+	batches, err := s.retention.batchRepo.FindByVIN(ctx, vin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batches: %w", err)
+	}
+	if err := s.checkErasable(ctx, vin, batches); err != nil {
+		return nil, err
+	}
+
+	erasure := domain.NewVINErasure(token, requestedBy, reason)
+	for _, batch := range batches {
+		if err := s.eraseBatch(ctx, batch, erasure); err != nil {
+			return nil, fmt.Errorf("failed to erase batch %s: %w", batch.ID, err)
+		}
+	}
+
+	erasure.ErasedAt = time.Now()
+	if err := s.erasures.EraseVehicleRecords(ctx, vin, erasure); err != nil {
+		return nil, fmt.Errorf("failed to erase vehicle records: %w", err)
+	}
+	log.Printf("[Privacy] VIN %s erased by %q: %d batches, %d objects deleted",
+		token, erasure.RequestedBy, erasure.BatchesDeleted, erasure.ObjectsDeleted)
+	return erasure, nil
+}
+
+// checkErasable 删除开始前检查全部 Batch（处理中的 Batch 会继续写对象存储和报告，删除后又被写回来）
+func (s *ErasureService) checkErasable(ctx context.Context, vin string, batches []*domain.Batch) error {
+	held, err := s.retention.holdRepo.IsHeld(ctx, uuid.Nil, vin)
+	if err != nil {
+		return fmt.Errorf("failed to check legal hold: %w", err)
+	}
+	if held {
+		return fmt.Errorf("%w: VIN %s is under legal hold", domain.ErrErasureBlocked, domain.MaskVIN(vin))
+	}
+	for _, batch := range batches {
+		if !batch.Status.IsTerminal() {
+			return fmt.Errorf("%w: batch %s is still %s", domain.ErrErasureBlocked, batch.ID, batch.Status)
+		}
+		held, err := s.retention.holdRepo.IsHeld(ctx, batch.ID, vin)
+		if err != nil {
+			return fmt.Errorf("failed to check legal hold: %w", err)
+		}
+		if held {
+			return fmt.Errorf("%w: batch %s is under legal hold", domain.ErrErasureBlocked, batch.ID)
+		}
+	}
+	return nil
+}
+
+// eraseBatch 持有 Batch 锁删除；墓碑中的 VIN 与车辆 ID 替换为令牌
+func (s *ErasureService) eraseBatch(ctx context.Context, batch *domain.Batch, erasure *domain.VINErasure) error {
+	return withBatchLock(ctx, s.retention.locker, batch.ID, func(ctx context.Context) error {
+		current, err := s.retention.batchRepo.FindByID(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return nil // 生命周期任务刚刚删除
+		}
+		reason := "privacy erasure " + erasure.ID.String()
+		deleted, err := s.retention.removeBatch(ctx, current, func(objectsDeleted int) *domain.BatchTombstone {
+			tombstone := domain.NewBatchTombstone(current, objectsDeleted, reason)
+			tombstone.VIN = erasure.VINToken
+			tombstone.VehicleID = erasure.VINToken
+			return tombstone
+		})
+		if err != nil {
+			return err
+		}
+		erasure.BatchesDeleted++
+		erasure.ObjectsDeleted += deleted
+		return nil
+	})
+}
+
+// ListErasures 按 VIN 查询删除记录（记录中只有令牌，查询时用同一密钥计算）
+func (s *ErasureService) ListErasures(ctx context.Context, vin string) ([]*domain.VINErasure, error) {
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return nil, err
+	}
+	return s.erasures.FindByToken(ctx, s.vins.VINToken(vin))
+}
@@ -0,0 +1,161 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// FleetService 车队维度分析：消费事件增量维护汇总表，并提供跨 Batch 查询
+type FleetService struct {
+	batchRepo   domain.BatchRepository
+	reportRepo  domain.ReportRepository
+	vehicleRepo domain.VehicleRepository
+	rollups     domain.FleetRollupRepository
+}
+
+func NewFleetService(
+	batchRepo domain.BatchRepository,
+	reportRepo domain.ReportRepository,
+	vehicleRepo domain.VehicleRepository,
+	rollups domain.FleetRollupRepository,
+) *FleetService {
+	return &FleetService{
+		batchRepo:   batchRepo,
+		reportRepo:  reportRepo,
+		vehicleRepo: vehicleRepo,
+		rollups:     rollups,
+	}
+}
+
+// HandleMessage 只关心进入终态的 StatusChanged
+func (s *FleetService) HandleMessage(ctx context.Context, data []byte) error {
+	var event struct {
+		EventType string `json:"event_type"`
+		BatchID   string `json:"batch_id"`
+		NewStatus string `json:"new_status"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("[FleetService] Failed to unmarshal event: %v", err)
+		return err
+	}
+	if event.EventType != "StatusChanged" || !domain.BatchStatus(event.NewStatus).IsTerminal() {
+		return nil
+	}
+
+	batchID, err := uuid.Parse(event.BatchID)
+	if err != nil {
+		return fmt.Errorf("invalid batch_id: %w", err)
+	}
+	_, err = s.ApplyBatch(ctx, batchID)
+	return err
+}
+
+// ApplyBatch 把一个终态 Batch 累加进汇总表；返回是否实际累加（重复事件、已删除的 Batch 返回 false）
+func (s *FleetService) ApplyBatch(ctx context.Context, batchID uuid.UUID) (bool, error) {
+	batch, err := s.batchRepo.FindByID(ctx, batchID)
+	if err != nil {
+		return false, fmt.Errorf("failed to find batch: %w", err)
+	}
+	if batch == nil || !batch.Status.IsTerminal() {
+		return false, nil
+	}
+	report, err := s.reportRepo.FindByBatchID(ctx, batchID)
+	if err != nil {
+		return false, fmt.Errorf("failed to find report: %w", err)
+	}
+
+	rollup, err := domain.NewBatchRollup(batch, report)
+	if err != nil {
+		return false, err
+	}
+	// 版本以上传时的快照为准；快照上线前的存量 Batch 没有版本，退回注册表的当前版本
+	if rollup.FirmwareVersion == "" {
+		vehicle, err := s.vehicleRepo.FindByVIN(domain.WithTenant(ctx, batch.TenantID), batch.VIN)
+		if err != nil {
+			return false, fmt.Errorf("failed to find vehicle: %w", err)
+		}
+		if vehicle != nil {
+			rollup.FirmwareVersion = vehicle.FirmwareVersion
+		}
+	}
+
+	applied, err := s.rollups.Apply(ctx, rollup)
+	if err != nil {
+		return false, err
+	}
+	if applied {
+		log.Printf("[FleetService] Applied batch %s to rollups (platform=%s, failed=%v)", batchID, rollup.Platform, rollup.Failed)
+	}
+	return applied, nil
+}
+
+// Reconcile 补漏：累加还没有进入汇总表的终态 Batch（事件丢失、汇总表上线前的存量数据）
+func (s *FleetService) Reconcile(ctx context.Context, limit int) (int, error) {
+	ids, err := s.rollups.FindUnapplied(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unapplied batches: %w", err)
+	}
+	applied := 0
+	for _, id := range ids {
+		ok, err := s.ApplyBatch(ctx, id)
+		if err != nil {
+			log.Printf("[FleetService] Failed to apply batch %s: %v", id, err)
+			continue
+		}
+		if ok {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+func (s *FleetService) Throughput(ctx context.Context, q domain.FleetQuery) ([]domain.ThroughputPoint, error) {
+	if err := s.scopeAggregate(ctx, &q); err != nil {
+		return nil, err
+	}
+	return s.rollups.Throughput(ctx, q)
+}
+
+func (s *FleetService) TopErrorCodes(ctx context.Context, q domain.FleetQuery) ([]domain.ErrorCodeRank, error) {
+	if err := s.scopeAggregate(ctx, &q); err != nil {
+		return nil, err
+	}
+	return s.rollups.TopErrorCodes(ctx, q)
+}
+
+func (s *FleetService) VehicleTrend(ctx context.Context, vin string, q domain.FleetQuery) ([]domain.VehicleResourcePoint, error) {
+	if err := q.Normalize(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := authorizeVehicleData(ctx, s.vehicleRepo, "", domain.NormalizeVIN(vin)); err != nil {
+		return nil, err
+	}
+	return s.rollups.VehicleTrend(ctx, vin, q)
+}
+
+func (s *FleetService) Anomalies(ctx context.Context, metric domain.AnomalyMetric, q domain.FleetQuery) ([]domain.VehicleAnomaly, error) {
+	if err := q.Normalize(time.Now()); err != nil {
+		return nil, err
+	}
+	q.Scope = domain.DataScopeFromContext(ctx)
+	return s.rollups.Anomalies(ctx, metric, q)
+}
+
+// scopeAggregate 吞吐与异常码汇总表只有平台维度：指定平台时要求平台级权限，否则只汇总可完整访问的平台
+func (s *FleetService) scopeAggregate(ctx context.Context, q *domain.FleetQuery) error {
+	if err := q.Normalize(time.Now()); err != nil {
+		return err
+	}
+	if q.Platform != "" {
+		return authorizePlatformData(ctx, q.Platform)
+	}
+	q.Scope = domain.DataScopeFromContext(ctx)
+	return nil
+}
@@ -0,0 +1,35 @@
+package application
+
+import (
+	"context"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+)
+
+var batchLogger = logging.Component("batch")
+
+// observeBatchEvents Batch 保存成功、发布事件之前调用：记录状态转换日志与指标，进入终态时补发 Batch 根 Span
+func observeBatchEvents(ctx context.Context, batch *domain.Batch, events []domain.DomainEvent) {
+	metrics.ObserveBatchEvents(events)
+	terminal := false
+	for _, event := range events {
+		e, ok := event.(domain.BatchStatusChanged)
+		if !ok {
+			continue
+		}
+		batchLogger.InfoContext(ctx, "batch status changed",
+			"batch_id", e.BatchID,
+			"tenant_id", e.TenantID,
+			"from", e.OldStatus.String(),
+			"to", e.NewStatus.String(),
+			"in_status_ms", e.StatusDuration.Milliseconds(),
+		)
+		terminal = terminal || e.NewStatus.IsTerminal()
+	}
+	if terminal {
+		tracing.RecordBatchSpan(ctx, batch)
+	}
+}
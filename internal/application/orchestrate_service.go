@@ -4,30 +4,44 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
+// orchestratorLogger 事件处理日志；Kafka Consumer 已在 ctx 中附带消息位置与 batch_id
+var orchestratorLogger = logging.Component("orchestrator")
+
 type OrchestrateService struct {
 	batchRepo domain.BatchRepository
 	redis     *redis.RedisClient
 	kafka     messaging.KafkaEventPublisher
+	sla       *domain.SLAPolicy
+	locker    domain.BatchLocker
 }
 
 func NewOrchestrateService(
 	batchRepo domain.BatchRepository,
 	redis *redis.RedisClient,
 	kafka messaging.KafkaEventPublisher,
+	sla *domain.SLAPolicy,
+	locker domain.BatchLocker,
 ) *OrchestrateService {
+	if sla == nil {
+		sla = domain.DefaultSLAPolicy()
+	}
 	return &OrchestrateService{
 		batchRepo: batchRepo,
 		redis:     redis,
 		kafka:     kafka,
+		sla:       sla,
+		locker:    locker,
 	}
 }
 
@@ -37,6 +51,7 @@ func (s *OrchestrateService) HandleMessage(ctx context.Context, data []byte) err
 		return err
 	}
 	eventType := event["event_type"].(string)
+	ctx = logging.With(ctx, "event_type", eventType)
 
 	switch eventType {
 	case "BatchCreated":
@@ -45,6 +60,10 @@ func (s *OrchestrateService) HandleMessage(ctx context.Context, data []byte) err
 	case "FileParsed":
 		return s.handleFileParsed(ctx, event)
 
+	case "GatherRequested":
+		// GatherRequested 是 Orchestrator 自己发给 Worker 的命令，无需处理
+		return nil
+
 	case "GatheringCompleted":
 		return s.handleGatheringCompleted(ctx, event)
 
@@ -55,7 +74,7 @@ func (s *OrchestrateService) HandleMessage(ctx context.Context, data []byte) err
 		return s.handleStatusChanged(ctx, event)
 
 	default:
-		log.Printf("Unknown event type: %s", eventType)
+		orchestratorLogger.WarnContext(ctx, "unknown event type")
 	}
 
 	return nil
@@ -65,86 +84,208 @@ func (s *OrchestrateService) handleBatchCreated(ctx context.Context, event map[s
 	batchIDStr := event["batch_id"].(string)
 	batchID, _ := uuid.Parse(batchIDStr)
 
-	batch, err := s.batchRepo.FindByID(ctx, batchID)
-	if err != nil {
-		return err
-	}
-	if batch == nil {  // ← 添加这个检查
-		return fmt.Errorf("batch not found: %s", batchID)
-	}
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		batch, err := s.batchRepo.FindByID(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {  // ← 添加这个检查
+			return fmt.Errorf("batch not found: %s", batchID)
+		}
   
   
-	switch batch.Status {
-	case domain.BatchStatusPending:
-		batch.TransitionTo(domain.BatchStatusUploaded)
-		batch.TransitionTo(domain.BatchStatusScattering)
-	case domain.BatchStatusUploaded:
-		batch.TransitionTo(domain.BatchStatusScattering)
-	}
+		switch batch.Status {
+		case domain.BatchStatusPending:
+			batch.TransitionTo(domain.BatchStatusUploaded)
+			batch.TransitionTo(domain.BatchStatusScattering)
+		case domain.BatchStatusUploaded:
+			batch.TransitionTo(domain.BatchStatusScattering)
+		}
   
   
   
-	// 5. 保存状态
-	if err := s.batchRepo.Save(ctx, batch); err != nil {
-		return err
-	}
+		// 5. 保存状态
+		if err := s.batchRepo.Save(ctx, batch); err != nil {
+			return err
+		}
 	
-	events := batch.GetEvents()
-	if err := s.kafka.PublishEvents(ctx, events); err != nil {
-		log.Printf("Failed to publish events: %v", err)
-	}
-	batch.ClearEvents()
-	log.Printf("[Orchestrator] Batch %s transitioned to scattering", batchID)
-	return nil
+		s.publishBatchEvents(ctx, batch)
+		orchestratorLogger.InfoContext(ctx, "batch transitioned to scattering", "batch_id", batchID)
+		return nil
+	})
 }
 
 func (s *OrchestrateService) handleFileParsed(ctx context.Context, event map[string]interface{}) error {
 	batchIDStr := event["batch_id"].(string)
 	batchID, _ := uuid.Parse(batchIDStr)
 	fileIDStr := event["file_id"].(string)
+	ctx = logging.With(ctx, "batch_id", batchIDStr, "file_id", fileIDStr)
+	outputPath, _ := event["output_path"].(string)
+	tenantID := eventTenant(event)
 
 	// Redis Barrier 计数（使用 Set，天然幂等）
-	key := fmt.Sprintf("batch:%s:processed_files", batchID)
-	added, err := s.redis.SADD(ctx, key, fileIDStr)
+	barrierStart := time.Now()
+	count, err := s.updateBarrier(ctx, tenantID, batchID, fileIDStr, outputPath)
+	metrics.ObserveBarrier(time.Since(barrierStart), err)
+	if err != nil {
+		return err
+	}
+
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		// 查询 Batch 信息
+		batch, err := s.batchRepo.FindByID(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return fmt.Errorf("batch not found: %s", batchID)
+		}
+
+		// 更新处理进度（仅内存，Barrier 完成时才持久化）
+		batch.ProcessedFiles = int(count)
+		orchestratorLogger.InfoContext(ctx, "barrier progress", "processed", count, "total", batch.TotalFiles)
+
+		// 检查是否所有文件都已处理（重复投递可能让 count 超过 TotalFiles）
+		if count >= int64(batch.TotalFiles) {
+			return s.completeScatterBarrier(ctx, batch)
+		}
+
+		return nil
+	})
+}
+
+// updateBarrier 登记已解析文件与解析产物路径，返回已解析的文件数
+func (s *OrchestrateService) updateBarrier(ctx context.Context, tenantID string, batchID uuid.UUID, fileID, outputPath string) (int64, error) {
+	key := barrierKey(tenantID, batchID)
+	added, err := s.redis.SADD(ctx, key, fileID)
 	if err != nil {
-		return fmt.Errorf("failed to add to Redis set: %w", err)
+		return 0, fmt.Errorf("failed to add to Redis set: %w", err)
+	}
+
+	// 记录解析产物路径，Barrier 完成后随 GatherRequested 一起下发
+	outputsKey := parsedOutputsKey(tenantID, batchID)
+	if err := s.redis.HSET(ctx, outputsKey, fileID, outputPath); err != nil {
+		return 0, fmt.Errorf("failed to record parsed output: %w", err)
 	}
 
 	// 设置过期时间（只在新添加时设置，避免重复操作）
 	if added > 0 {
 		s.redis.EXPIRE(ctx, key, 24*time.Hour)
+		s.redis.EXPIRE(ctx, outputsKey, 24*time.Hour)
 	}
 
 	// 获取已处理文件数
 	count, err := s.redis.SCARD(ctx, key)
 	if err != nil {
-		return fmt.Errorf("failed to get Redis set size: %w", err)
+		return 0, fmt.Errorf("failed to get Redis set size: %w", err)
+	}
+	return count, nil
+}
+
+// completeScatterBarrier Barrier 完成：scattering → scattered → gathering，并发布 GatherRequested
+//
+// 幂等：只有 scattering / scattered 状态的 Batch 会被推进，重复的 FileParsed 事件直接忽略
+// （scattered 是上次推进到一半失败留下的中间态，由补偿任务重入）
+func (s *OrchestrateService) completeScatterBarrier(ctx context.Context, batch *domain.Batch) error {
+	if batch.Status != domain.BatchStatusScattering && batch.Status != domain.BatchStatusScattered {
+		orchestratorLogger.InfoContext(ctx, "barrier completion skipped", "batch_id", batch.ID, "status", batch.Status.String())
+		return nil
 	}
 
-	// 查询 Batch 信息
-	batch, err := s.batchRepo.FindByID(ctx, batchID)
+	parsedFiles, err := s.loadParsedOutputs(ctx, batch)
 	if err != nil {
 		return err
 	}
-	if batch == nil {
-		return fmt.Errorf("batch not found: %s", batchID)
+
+	// processed_files 受 DB 约束不能超过 total_files
+	batch.ProcessedFiles = len(parsedFiles)
+	if batch.ProcessedFiles > batch.TotalFiles {
+		batch.ProcessedFiles = batch.TotalFiles
 	}
 
-	// 更新处理进度（仅内存，不持久化）
-	batch.ProcessedFiles = int(count)
-	log.Printf("[Orchestrator] Progress: %d/%d files processed", count, batch.TotalFiles)
-
-	// 检查是否所有文件都已处理
-	if count == int64(batch.TotalFiles) {
-		log.Printf("[Orchestrator] All files processed for batch %s, waiting for GatheringCompleted event", batchID)
-		// ✅ 修改：不再立即转换状态，等待 GatheringCompleted 事件
-		// 清理 Redis Barrier（避免内存泄漏）
-		s.redis.DEL(ctx, key)
-	} else {
-		log.Printf("[Orchestrator] Waiting for more files (%d/%d)", count, batch.TotalFiles)
+	if batch.Status == domain.BatchStatusScattering {
+		if err := batch.TransitionTo(domain.BatchStatusScattered); err != nil {
+			return fmt.Errorf("failed to transition to scattered: %w", err)
+		}
 	}
 
-	return nil
+	if err := batch.TransitionTo(domain.BatchStatusGathering); err != nil {
+		return fmt.Errorf("failed to transition to gathering: %w", err)
+	}
+
+	if err := s.batchRepo.Save(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save batch: %w", err)
+	}
+
+	// 发布状态变更事件
+	s.publishBatchEvents(ctx, batch)
+
+	return s.publishGatherRequested(ctx, batch, parsedFiles)
+}
+
+// publishGatherRequested 发布聚合命令（Barrier 完成和补偿任务共用，在锁内调用时释放锁后才发布）
+func (s *OrchestrateService) publishGatherRequested(ctx context.Context, batch *domain.Batch, parsedFiles []domain.ParsedFileOutput) error {
+	command := domain.GatherRequested{
+		Version:     "v1.0",
+		BatchID:     batch.ID,
+		TenantID:    batch.TenantID,
+		Priority:    batch.Priority,
+		TotalFiles:  batch.TotalFiles,
+		ParsedFiles: parsedFiles,
+		OccurredAt:  time.Now(),
+	}
+	return afterUnlock(ctx, func(ctx context.Context) error {
+		if err := s.kafka.PublishEvents(ctx, []domain.DomainEvent{command}); err != nil {
+			return fmt.Errorf("failed to publish GatherRequested: %w", err)
+		}
+		orchestratorLogger.InfoContext(ctx, "GatherRequested published", "batch_id", command.BatchID, "parsed_files", len(parsedFiles))
+		return nil
+	})
+}
+
+// loadParsedOutputs 从 Redis Barrier 中读取已解析文件及其产物路径
+func (s *OrchestrateService) loadParsedOutputs(ctx context.Context, batch *domain.Batch) ([]domain.ParsedFileOutput, error) {
+	fileIDs, err := s.redis.SMEMBERS(ctx, barrierKey(batch.TenantID, batch.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read barrier members: %w", err)
+	}
+	outputs, err := s.redis.HGETALL(ctx, parsedOutputsKey(batch.TenantID, batch.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parsed outputs: %w", err)
+	}
+
+	sort.Strings(fileIDs)
+	parsedFiles := make([]domain.ParsedFileOutput, 0, len(fileIDs))
+	for _, idStr := range fileIDs {
+		fileID, err := uuid.Parse(idStr)
+		if err != nil {
+			orchestratorLogger.WarnContext(ctx, "skipping invalid file id in barrier", "batch_id", batch.ID, "file_id", idStr)
+			continue
+		}
+		parsedFiles = append(parsedFiles, domain.ParsedFileOutput{
+			FileID:     fileID,
+			OutputPath: outputs[idStr],
+		})
+	}
+	return parsedFiles, nil
+}
+
+// barrierKey Redis Barrier 的 Key（已解析文件 ID 集合）
+func barrierKey(tenantID string, batchID uuid.UUID) string {
+	return domain.TenantCacheKey(tenantID, fmt.Sprintf("batch:%s:processed_files", batchID))
+}
+
+// parsedOutputsKey 已解析文件产物路径的 Hash Key（fileID → outputPath）
+func parsedOutputsKey(tenantID string, batchID uuid.UUID) string {
+	return domain.TenantCacheKey(tenantID, fmt.Sprintf("batch:%s:parsed_outputs", batchID))
+}
+
+// eventTenant 事件所属租户；多租户改造之前发布的事件没有 tenant_id，属于 default 租户
+func eventTenant(event map[string]interface{}) string {
+	if tenantID, _ := event["tenant_id"].(string); tenantID != "" {
+		return tenantID
+	}
+	return domain.DefaultTenantID
 }
 
 func (s *OrchestrateService) handleStatusChanged(ctx context.Context, event map[string]interface{}) error {
@@ -157,7 +298,7 @@ func (s *OrchestrateService) handleStatusChanged(ctx context.Context, event map[
 	oldStatus, _ := event["old_status"].(string)
 	newStatus, _ := event["new_status"].(string)
 
-	log.Printf("[Orchestrator] StatusChanged: Batch %s, %s -> %s", batchIDStr, oldStatus, newStatus)
+	orchestratorLogger.InfoContext(ctx, "status changed", "batch_id", batchIDStr, "old_status", oldStatus, "new_status", newStatus)
 	return nil
 }
 
@@ -169,61 +310,55 @@ func (s *OrchestrateService) handleGatheringCompleted(ctx context.Context, event
 		return fmt.Errorf("invalid batch_id: %w", err)
 	}
 
-	// 查询 Batch
-	batch, err := s.batchRepo.FindByID(ctx, batchID)
-	if err != nil {
-		return err
-	}
-	if batch == nil {
-		return fmt.Errorf("batch not found: %s", batchID)
-	}
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		// 查询 Batch
+		batch, err := s.batchRepo.FindByID(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return fmt.Errorf("batch not found: %s", batchID)
+		}
 
-	log.Printf("[Orchestrator] GatheringCompleted received for batch %s, current status: %s",
-		batchID, batch.Status)
+		orchestratorLogger.InfoContext(ctx, "GatheringCompleted received", "batch_id", batchID, "status", batch.Status.String())
+
+		// 状态转换：gathering → gathered → diagnosing
+		// Kafka 至少投递一次：已经越过 gathering 的 Batch 收到的是重复事件，直接确认；
+		// 还没到 gathering 的事件是过期或伪造的（GatherRequested 由 Barrier 完成触发）
+		switch batch.Status {
+		case domain.BatchStatusGathering:
+		case domain.BatchStatusGathered, domain.BatchStatusDiagnosing, domain.BatchStatusCompleted, domain.BatchStatusFailed:
+			orchestratorLogger.DebugContext(ctx, "duplicate GatheringCompleted ignored", "batch_id", batchID, "status", batch.Status.String())
+			return nil
+		default:
+			return fmt.Errorf("unexpected batch status: %s, expected gathering", batch.Status)
+		}
 
-	// 状态转换：scattering → gathered → diagnosing
-	// 注意：可能状态已经是 scattered（Mock Worker 模拟），需要检查当前状态
-	switch batch.Status {
-	case domain.BatchStatusScattering:
-		// scattering → gathered
 		if err := batch.TransitionTo(domain.BatchStatusGathered); err != nil {
 			return fmt.Errorf("failed to transition to gathered: %w", err)
 		}
-		log.Printf("[Orchestrator] Status: scattering → gathered")
 
-		// gathered → diagnosing
 		if err := batch.TransitionTo(domain.BatchStatusDiagnosing); err != nil {
 			return fmt.Errorf("failed to transition to diagnosing: %w", err)
 		}
-		log.Printf("[Orchestrator] Status: gathered → diagnosing")
 
-	case domain.BatchStatusScattered:
-		// scattered → diagnosing（兼容 Mock Worker 的状态）
-		if err := batch.TransitionTo(domain.BatchStatusDiagnosing); err != nil {
-			return fmt.Errorf("failed to transition to diagnosing: %w", err)
+		// 保存到数据库
+		if err := s.batchRepo.Save(ctx, batch); err != nil {
+			return fmt.Errorf("failed to save batch: %w", err)
 		}
-		log.Printf("[Orchestrator] Status: scattered → diagnosing")
-
-	default:
-		return fmt.Errorf("unexpected batch status: %s, expected scattering or scattered", batch.Status)
-	}
 
-	// 保存到数据库
-	if err := s.batchRepo.Save(ctx, batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
+		// 发布状态变更事件
+		s.publishBatchEvents(ctx, batch)
 
-	// 发布状态变更事件
-	events := batch.GetEvents()
-	if len(events) > 0 {
-		if err := s.kafka.PublishEvents(ctx, events); err != nil {
-			log.Printf("Failed to publish events: %v", err)
-		}
-		batch.ClearEvents()
-	}
+		// 聚合完成后清理 Redis Barrier（避免内存泄漏）
+		s.redis.DEL(ctx, barrierKey(batch.TenantID, batchID))
+		s.redis.DEL(ctx, parsedOutputsKey(batch.TenantID, batchID))
+		// 报告已写入统计结果，让 Query Service 的缓存失效
+		s.redis.DEL(ctx, reportCacheKey(batch.TenantID, batchID))
 
-	log.Printf("[Orchestrator] Batch %s is now in diagnosing status", batchID)
-	return nil
+		orchestratorLogger.InfoContext(ctx, "batch is now diagnosing", "batch_id", batchID)
+		return nil
+	})
 }
 
 // handleDiagnosisCompleted - 处理 AI Agent 完成诊断事件
@@ -240,95 +375,30 @@ func (s *OrchestrateService) handleDiagnosisCompleted(ctx context.Context, event
 		return fmt.Errorf("invalid diagnosis_id: %w", err)
 	}
 
-	// 查询 Batch
-	batch, err := s.batchRepo.FindByID(ctx, batchID)
-	if err != nil {
-		return err
-	}
-	if batch == nil {
-		return fmt.Errorf("batch not found: %s", batchID)
-	}
-
-	log.Printf("[Orchestrator] DiagnosisCompleted received for batch %s, diagnosis %s",
-		batchID, diagnosisID)
-
-	// 状态转换：diagnosing → completed
-	if batch.Status != domain.BatchStatusDiagnosing {
-		return fmt.Errorf("unexpected batch status: %s, expected diagnosing", batch.Status)
-	}
-
-	if err := batch.TransitionTo(domain.BatchStatusCompleted); err != nil {
-		return fmt.Errorf("failed to transition to completed: %w", err)
-	}
-	log.Printf("[Orchestrator] Status: diagnosing → completed")
-
-	// 设置完成时间
-	now := time.Now()
-	batch.CompletedAt = &now
-
-	// 保存到数据库
-	if err := s.batchRepo.Save(ctx, batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// 发布状态变更事件
-	events := batch.GetEvents()
-	if len(events) > 0 {
-		if err := s.kafka.PublishEvents(ctx, events); err != nil {
-			log.Printf("Failed to publish events: %v", err)
+	return withBatchLock(ctx, s.locker, batchID, func(ctx context.Context) error {
+		// 查询 Batch
+		batch, err := s.batchRepo.FindByID(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return fmt.Errorf("batch not found: %s", batchID)
 		}
-		batch.ClearEvents()
-	}
 
-	log.Printf("[Orchestrator] ✅ Batch %s processing completed! Final status: %s",
-		batchID, batch.Status)
-	return nil
-}
+		orchestratorLogger.InfoContext(ctx, "DiagnosisCompleted received", "batch_id", batchID, "diagnosis_id", diagnosisID)
 
-// HandleStuckBatch 处理卡住的批次（补偿任务）
-func (s *OrchestrateService) HandleStuckBatch(ctx context.Context, batch *domain.Batch) error {
-	log.Printf("[Compensation] Handling stuck batch: id=%s, status=%s, updated_at=%s",
-		batch.ID, batch.Status, batch.UpdatedAt)
-
-	switch batch.Status {
-	case domain.BatchStatusScattering:
-		// ✅ 所有文件已处理，但未收到 GatheringCompleted 事件
-		// 检查是否所有文件都已处理
-		if batch.ProcessedFiles >= batch.TotalFiles {
-			// 模拟发布 GatheringCompleted 事件（重新触发 Python Worker）
-			log.Printf("[Compensation] Re-triggering GatheringCompleted for batch %s", batch.ID)
-
-			// 构造事件数据
-			event := map[string]interface{}{
-				"event_type":  "GatheringCompleted",
-				"version":     "1.0",
-				"batch_id":    batch.ID.String(),
-				"total_files": batch.TotalFiles,
-				"chart_files": []string{}, // 空列表，让 Python Worker 重新聚合
-				"timestamp":   time.Now().Format(time.RFC3339),
-			}
-
-			// 直接调用 handleGatheringCompleted
-			if err := s.handleGatheringCompleted(ctx, event); err != nil {
-				log.Printf("[Compensation] Failed to handle GatheringCompleted: %v", err)
-				return fmt.Errorf("failed to handle gathering completed: %w", err)
-			}
-
-			log.Printf("[Compensation] Successfully re-triggered GatheringCompleted for batch %s", batch.ID)
-		} else {
-			log.Printf("[Compensation] Batch %s still waiting for files (%d/%d), skipping compensation",
-				batch.ID, batch.ProcessedFiles, batch.TotalFiles)
+		// 状态转换：diagnosing → completed
+		if batch.Status != domain.BatchStatusDiagnosing {
+			return fmt.Errorf("unexpected batch status: %s, expected diagnosing", batch.Status)
 		}
 
-	case domain.BatchStatusDiagnosing:
-		// ✅ 诊断超时，标记为失败
-		log.Printf("[Compensation] Diagnosis timeout for batch %s, marking as failed", batch.ID)
-
-		if err := batch.TransitionTo(domain.BatchStatusFailed); err != nil {
-			return fmt.Errorf("failed to transition to failed: %w", err)
+		if err := batch.TransitionTo(domain.BatchStatusCompleted); err != nil {
+			return fmt.Errorf("failed to transition to completed: %w", err)
 		}
 
-		batch.ErrorMessage = "Diagnosis timeout after 10 minutes"
+		// 设置完成时间
+		now := time.Now()
+		batch.CompletedAt = &now
 
 		// 保存到数据库
 		if err := s.batchRepo.Save(ctx, batch); err != nil {
@@ -336,19 +406,9 @@ func (s *OrchestrateService) HandleStuckBatch(ctx context.Context, batch *domain
 		}
 
 		// 发布状态变更事件
-		events := batch.GetEvents()
-		if len(events) > 0 {
-			if err := s.kafka.PublishEvents(ctx, events); err != nil {
-				log.Printf("Failed to publish events: %v", err)
-			}
-			batch.ClearEvents()
-		}
-
-		log.Printf("[Compensation] Batch %s marked as failed", batch.ID)
+		s.publishBatchEvents(ctx, batch)
 
-	default:
-		log.Printf("[Compensation] Unexpected batch status %s for batch %s, skipping", batch.Status, batch.ID)
-	}
-
-	return nil
-}
\ No newline at end of file
+		orchestratorLogger.InfoContext(ctx, "batch processing completed", "batch_id", batchID, "status", batch.Status.String())
+		return nil
+	})
+}
@@ -11,24 +11,28 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
 	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
 )
 
 type QueryService struct {
 	batchRepo  		domain.BatchRepository
 	reportRepo		domain.ReportRepository
+	vehicleRepo		domain.VehicleRepository // 按车队授权时查询车辆所属车队
 	cache			*redis.RedisClient
 	sf				singleflight.Group
 }
 func NewQueryService(
 	batchRepo		domain.BatchRepository,
 	reportRepo		domain.ReportRepository,
+	vehicleRepo		domain.VehicleRepository,
 	cache			*redis.RedisClient,
 ) *QueryService {
 	return &QueryService{ 
-		batchRepo:  batchRepo,
-		reportRepo: reportRepo,
-		cache: 		cache,
+		batchRepo:   batchRepo,
+		reportRepo:  reportRepo,
+		vehicleRepo: vehicleRepo,
+		cache: 		 cache,
 	}
 }
 // GetReport 获取报告（使用 Singleflight 防缓存击穿）
@@ -37,17 +41,25 @@ func NewQueryService(
 // Q: Singleflight 如何防止缓存击穿？
 // A: 100 个并发请求查询同一个 batchID，sf.Do() 会将它们合并为 1 次执行
 func (s *QueryService) GetReport(ctx context.Context, batchID uuid.UUID) (*domain.Report, error) {
-    key := batchID.String()
+    // 合并与缓存都按租户区分：不同租户的请求不会共享结果
+    tenantID := callerTenant(ctx)
+    key := reportCacheKey(tenantID, batchID)
 
     v, err, shared := s.sf.Do(key, func() (interface{}, error) {
         log.Printf("[QueryService] Singleflight executing, key=%s", key)
 
         // 1. 先查缓存
-        report, err := s.getReportFromCache(ctx, batchID)
+        report, err := s.getReportFromCache(ctx, tenantID, batchID)
         if err == nil && report != nil {
             log.Printf("[QueryService] Cache HIT: batchID=%s", batchID)
+            metrics.ObserveReportCache(metrics.CacheHit)
             return report, nil
         }
+        if err != nil {
+            metrics.ObserveReportCache(metrics.CacheError)
+        } else {
+            metrics.ObserveReportCache(metrics.CacheMiss)
+        }
 
         log.Printf("[QueryService] Cache MISS: batchID=%s, querying database...", batchID)
 
@@ -70,17 +82,25 @@ func (s *QueryService) GetReport(ctx context.Context, batchID uuid.UUID) (*domai
     }
 
     // shared = true 表示这个请求的结果被其他请求共享了
+    metrics.ObserveSingleflight(shared)
     if shared {
         log.Printf("[QueryService] Request was shared (merged with other concurrent requests)")
     }
 
-    return v.(*domain.Report), nil
+    // 范围校验放在 Singleflight 之外：合并的请求可能来自权限不同的调用方
+    report := v.(*domain.Report)
+    if err := domain.AuthorizeTenant(ctx, report.TenantID); err != nil {
+        return nil, err
+    }
+    if err := authorizeVehicleData(ctx, s.vehicleRepo, report.VehiclePlatform, report.VIN); err != nil {
+        return nil, err
+    }
+    return report, nil
 }
 
 // getReportFromCache 从缓存获取报告（私有方法）
-func (s *QueryService) getReportFromCache(ctx context.Context, batchID uuid.UUID) (*domain.Report, error) {
-    key := fmt.Sprintf("report:%s", batchID)
-    data, err := s.cache.GET(ctx, key)
+func (s *QueryService) getReportFromCache(ctx context.Context, tenantID string, batchID uuid.UUID) (*domain.Report, error) {
+    data, err := s.cache.GET(ctx, reportCacheKey(tenantID, batchID))
     if err != nil {
         return nil, err // Redis 错误
     }
@@ -100,7 +120,7 @@ func (s *QueryService) getReportFromCache(ctx context.Context, batchID uuid.UUID
 
 // setReportToCache 设置缓存（私有方法）
 func (s *QueryService) setReportToCache(ctx context.Context, report *domain.Report, ttl time.Duration) error {
-	key := fmt.Sprintf("report:%s", report.BatchID)
+	key := reportCacheKey(report.TenantID, report.BatchID)
 
 	// 1. 序列化 Report → JSON
 	data, err := json.Marshal(report)
@@ -121,6 +141,9 @@ func (s *QueryService) getReportFromDatabase(ctx context.Context, batchID uuid.U
 	if err != nil {
 		return nil,fmt.Errorf("batch not found : %w",err)
 	}
+	if batch == nil {
+		return nil, fmt.Errorf("batch not found %s", batchID)
+	}
 
 	report = domain.NewReport(batch)
 	if err := s.reportRepo.Save(ctx,report);err != nil {
@@ -133,10 +156,20 @@ func (s *QueryService) GetProgress(ctx context.Context, batchID uuid.UUID) (map[
 	if err != nil {
 		return nil, err
 	}
+	if batch == nil {
+		return nil, fmt.Errorf("batch not found %s", batchID)
+	}
+	if err := domain.AuthorizeTenant(ctx, batch.TenantID); err != nil {
+		return nil, err
+	}
+	if err := authorizeVehicleData(ctx, s.vehicleRepo, batch.VehiclePlatform, batch.VIN); err != nil {
+		return nil, err
+	}
 
 	progress := map[string]interface{}{
 		"batch_id":        batch.ID,
 		"status":          batch.Status,
+		"priority":        batch.Priority,
 		"total_files":     batch.TotalFiles,
 		"processed_files": batch.ProcessedFiles,
 		"progress_percent": float64(batch.ProcessedFiles) / float64(batch.TotalFiles) * 100,
@@ -151,6 +184,32 @@ func (s *QueryService) GetProgress(ctx context.Context, batchID uuid.UUID) (map[
 	return progress, nil
 }
 
+// ListBatches 按条件查询 Batch 列表（例如优先查看 safety_critical 的诊断进度）
+// 调用方的数据范围在 SQL 中过滤，分页结果不会因为事后过滤而变少
+func (s *QueryService) ListBatches(ctx context.Context, opts domain.ListOptions) ([]*domain.Batch, error) {
+	opts.Scope = domain.DataScopeFromContext(ctx)
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		opts.TenantID = &tenantID
+	}
+	batches, err := s.batchRepo.List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+	return batches, nil
+}
+
+// reportCacheKey 报告缓存的 Key（Orchestrator、保留策略在报告变更时按同一个 Key 失效）
+func reportCacheKey(tenantID string, batchID uuid.UUID) string {
+	return domain.TenantCacheKey(tenantID, fmt.Sprintf("report:%s", batchID))
+}
+
+// callerTenant 调用方租户；内部调用没有租户时按 default 处理
+func callerTenant(ctx context.Context) string {
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		return tenantID
+	}
+	return domain.DefaultTenantID
+}
 
 func serialize(v any) (string, error) {
     b, err := json.Marshal(v)
@@ -0,0 +1,222 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// quotaLedgerTTL 每日计数保留两天（覆盖跨零点的请求和时钟偏差），之后由 Redis 自动删除
+const quotaLedgerTTL = 48 * time.Hour
+
+// QuotaService 创建 Batch / 上传文件前的准入控制：令牌桶限流 + 每日配额 + 存储总量
+//
+// Redis 不可用时放行（fail open）并记录日志：限流和配额防的是个别车辆失控，不应因为它自身故障
+// 而丢掉整个车队的日志（车端缓存有限）；由 Postgres 与对象存储的容量告警兜底
+type QuotaService struct {
+	tenants *domain.TenantRegistry
+	usage   domain.TenantUsageRepository
+	limiter domain.RateLimiter // nil 表示未配置 Redis，不限流
+	ledger  domain.UsageLedger // nil 表示未配置 Redis，不做每日配额
+	vins    *domain.Pseudonymizer
+}
+
+func NewQuotaService(
+	tenants *domain.TenantRegistry,
+	usage domain.TenantUsageRepository,
+	limiter domain.RateLimiter,
+	ledger domain.UsageLedger,
+	vins *domain.Pseudonymizer,
+) *QuotaService {
+	return &QuotaService{
+		tenants: tenants,
+		usage:   usage,
+		limiter: limiter,
+		ledger:  ledger,
+		vins:    vins,
+	}
+}
+
+func rateLimitKey(tenantID, endpoint, scope string) string {
+	return domain.TenantCacheKey(tenantID, "ratelimit:"+endpoint+":"+scope)
+}
+
+func vehicleQuotaKey(tenantID, vehicle, day string) string {
+	return domain.TenantCacheKey(tenantID, "quota:vehicle:"+vehicle+":"+day)
+}
+
+// vehicleKey Redis Key 中用 VIN 令牌代替 VIN（Key 会出现在监控、慢查询日志和备份里）
+func (s *QuotaService) vehicleKey(vin string) string {
+	return s.vins.VINToken(vin)
+}
+
+func tenantQuotaKey(tenantID, day string) string {
+	return domain.TenantCacheKey(tenantID, "quota:tenant:"+day)
+}
+
+// AdmitBatch 创建 Batch 前调用：先限流，再计入车辆与租户的每日 Batch 数
+//
+// 记账后如果 Batch 保存失败，当天计数会多 1；配额用于防止滥用，不需要精确到个位
+func (s *QuotaService) AdmitBatch(ctx context.Context, tenant *domain.Tenant, vin string) error {
+	limits := tenant.Quotas.RateLimits.CreateBatch
+	if err := s.take(ctx, "create_batch", vin, []domain.RateLimitRule{
+		{Key: rateLimitKey(tenant.ID, "create_batch", "vehicle:"+s.vehicleKey(vin)), Bucket: limits.Vehicle},
+		{Key: rateLimitKey(tenant.ID, "create_batch", "tenant"), Bucket: limits.Tenant},
+	}); err != nil {
+		return err
+	}
+	_, err := s.consume(ctx, tenant, vin, 1, 0)
+	return err
+}
+
+// UploadReservation AdmitUpload 预占的每日上传字节数，上传结束后由 SettleUpload 按实际大小结算
+type UploadReservation struct {
+	keys  []string // 预占时的车辆与租户计数（跨零点结算时仍退回到预占的那一天）
+	bytes int64
+}
+
+// AdmitUpload 写对象存储之前调用：单文件大小与租户存储总量、上传限流，并按 fileSize 预占每日上传字节数
+//
+// fileSize 是上传前能拿到的上界（请求体长度），实际大小要等上传结束才知道，所以先预占、再结算；
+// 未配置 Redis 或记账失败（放行）时返回 nil 预占
+func (s *QuotaService) AdmitUpload(ctx context.Context, batch *domain.Batch, fileSize int64) (*UploadReservation, error) {
+	tenant, err := s.tenants.Get(batch.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	var stored int64
+	if tenant.Quotas.MaxStorageBytes > 0 {
+		if stored, err = s.usage.StorageBytes(ctx, tenant.ID); err != nil {
+			return nil, fmt.Errorf("failed to sum tenant storage: %w", err)
+		}
+	}
+	if err := tenant.CheckUpload(fileSize, stored); err != nil {
+		return nil, err
+	}
+
+	limits := tenant.Quotas.RateLimits.UploadFile
+	if err := s.take(ctx, "upload_file", batch.VIN, []domain.RateLimitRule{
+		{Key: rateLimitKey(tenant.ID, "upload_file", "vehicle:"+s.vehicleKey(batch.VIN)), Bucket: limits.Vehicle},
+		{Key: rateLimitKey(tenant.ID, "upload_file", "tenant"), Bucket: limits.Tenant},
+	}); err != nil {
+		return nil, err
+	}
+	return s.consume(ctx, tenant, batch.VIN, 0, fileSize)
+}
+
+// SettleUpload 上传结束后按实际写入的字节数结算预占：多占的部分退回；上传失败时 actual 传 0，全部退回
+func (s *QuotaService) SettleUpload(ctx context.Context, reservation *UploadReservation, actual int64) {
+	if reservation == nil || actual >= reservation.bytes {
+		return
+	}
+	if err := s.ledger.Refund(ctx, reservation.keys, 0, reservation.bytes-actual); err != nil {
+		log.Printf("[QuotaService] Failed to refund %d reserved upload bytes: %v", reservation.bytes-actual, err)
+	}
+}
+
+// take 令牌不足返回 RetryAfterError（等待时间即 Retry-After）
+func (s *QuotaService) take(ctx context.Context, endpoint, vin string, rules []domain.RateLimitRule) error {
+	if s.limiter == nil {
+		return nil
+	}
+	wait, err := s.limiter.Take(ctx, rules, 1)
+	if err != nil {
+		log.Printf("[QuotaService] Rate limiter unavailable, allowing %s for VIN %s: %v", endpoint, domain.MaskVIN(vin), err)
+		return nil
+	}
+	if wait > 0 {
+		return &domain.RetryAfterError{
+			Err:        fmt.Errorf("%w: %s for VIN %s", domain.ErrRateLimited, endpoint, domain.MaskVIN(vin)),
+			RetryAfter: wait,
+		}
+	}
+	return nil
+}
+
+// consume 车辆与租户的每日计数一起记账，返回记账的计数（可用于退回）；超限时 Retry-After 为距离 UTC 零点的时间
+func (s *QuotaService) consume(ctx context.Context, tenant *domain.Tenant, vin string, batches, bytes int64) (*UploadReservation, error) {
+	if s.ledger == nil {
+		return nil, nil
+	}
+	now := time.Now()
+	day := domain.QuotaDay(now)
+	vehicleKey := vehicleQuotaKey(tenant.ID, s.vehicleKey(vin), day)
+	tenantKey := tenantQuotaKey(tenant.ID, day)
+	exceeded, err := s.ledger.Consume(ctx, []domain.UsageCharge{
+		{Key: vehicleKey, Limits: tenant.Quotas.VehicleDailyLimits()},
+		{Key: tenantKey, Limits: tenant.Quotas.DailyLimits()},
+	}, batches, bytes, quotaLedgerTTL)
+	if err != nil {
+		log.Printf("[QuotaService] Usage ledger unavailable, allowing VIN %s: %v", domain.MaskVIN(vin), err)
+		return nil, nil
+	}
+	if exceeded == "" {
+		return &UploadReservation{keys: []string{vehicleKey, tenantKey}, bytes: bytes}, nil
+	}
+
+	who := "tenant " + tenant.ID
+	if exceeded == vehicleKey {
+		who = "vehicle " + domain.MaskVIN(vin)
+	}
+	what := "upload bytes"
+	if batches > 0 {
+		what = "batches"
+	}
+	return nil, &domain.RetryAfterError{
+		Err:        fmt.Errorf("%w: daily %s limit reached for %s", domain.ErrQuotaExceeded, what, who),
+		RetryAfter: domain.UntilQuotaReset(now),
+	}
+}
+
+// Usage 调用方租户当天的用量；vin 非空时附带该车辆的用量（车辆只能查询自己）
+func (s *QuotaService) Usage(ctx context.Context, vin string) (*dto.QuotaUsageResponse, error) {
+	tenant, err := s.tenants.Get(callerTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	day := domain.QuotaDay(now)
+	resp := &dto.QuotaUsageResponse{
+		TenantID:        tenant.ID,
+		Day:             day,
+		ResetsAt:        now.Add(domain.UntilQuotaReset(now)).UTC().Truncate(time.Second),
+		DailyEnforced:   s.ledger != nil,
+		MaxStorageBytes: tenant.Quotas.MaxStorageBytes,
+	}
+	if resp.StorageBytes, err = s.usage.StorageBytes(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to sum tenant storage: %w", err)
+	}
+
+	var tenantUsage domain.DailyUsage
+	if s.ledger != nil {
+		if tenantUsage, err = s.ledger.Get(ctx, tenantQuotaKey(tenant.ID, day)); err != nil {
+			return nil, fmt.Errorf("failed to read tenant usage: %w", err)
+		}
+	}
+	resp.Tenant = dto.NewQuotaUsage(tenantUsage, tenant.Quotas.DailyLimits())
+
+	if vin == "" {
+		return resp, nil
+	}
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeVIN(ctx, vin); err != nil {
+		return nil, err
+	}
+	var vehicleUsage domain.DailyUsage
+	if s.ledger != nil {
+		if vehicleUsage, err = s.ledger.Get(ctx, vehicleQuotaKey(tenant.ID, s.vehicleKey(vin), day)); err != nil {
+			return nil, fmt.Errorf("failed to read vehicle usage: %w", err)
+		}
+	}
+	vehicle := dto.NewQuotaUsage(vehicleUsage, tenant.Quotas.VehicleDailyLimits())
+	vehicle.VIN = vin
+	resp.Vehicle = &vehicle
+	return resp, nil
+}
@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// maxReleaseSamples 每个版本最多取最近的多少个 Batch 参与对比（控制单次对比的内存与耗时）
+const maxReleaseSamples = 5000
+
+// ReleaseAnalysisService 版本回归分析：比较两个软件版本的异常码率与资源占用分布
+type ReleaseAnalysisService struct {
+	firmware   domain.FirmwareRepository
+	thresholds domain.RegressionThresholds
+}
+
+func NewReleaseAnalysisService(firmware domain.FirmwareRepository, thresholds domain.RegressionThresholds) *ReleaseAnalysisService {
+	return &ReleaseAnalysisService{
+		firmware:   firmware,
+		thresholds: thresholds,
+	}
+}
+
+func (s *ReleaseAnalysisService) ListVersions(ctx context.Context, platform, ecu string) ([]*domain.FirmwareVersionSummary, error) {
+	if platform == "" {
+		return nil, fmt.Errorf("%w: platform is required", domain.ErrInvalidVersionSelector)
+	}
+	if err := authorizePlatformData(ctx, platform); err != nil {
+		return nil, err
+	}
+	return s.firmware.ListVersions(ctx, callerTenant(ctx), platform, ecu)
+}
+
+// Compare 对比同一平台（同一 ECU）上的两个版本；API 调用只能对比自己租户的 Batch
+func (s *ReleaseAnalysisService) Compare(ctx context.Context, baseline, candidate domain.VersionSelector) (*domain.VersionComparison, error) {
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		baseline.TenantID, candidate.TenantID = tenantID, tenantID
+	}
+	if err := baseline.Validate(); err != nil {
+		return nil, err
+	}
+	if err := candidate.Validate(); err != nil {
+		return nil, err
+	}
+	if baseline.TenantID != candidate.TenantID || baseline.Platform != candidate.Platform || baseline.ECU != candidate.ECU {
+		return nil, fmt.Errorf("%w: baseline and candidate must share tenant, platform and ecu", domain.ErrInvalidVersionSelector)
+	}
+	if err := authorizePlatformData(ctx, baseline.Platform); err != nil {
+		return nil, err
+	}
+
+	baselineSamples, err := s.firmware.FindSamples(ctx, baseline, maxReleaseSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find baseline samples: %w", err)
+	}
+	candidateSamples, err := s.firmware.FindSamples(ctx, candidate, maxReleaseSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find candidate samples: %w", err)
+	}
+
+	metrics, insufficient := stats.CompareReleases(baselineSamples, candidateSamples, s.thresholds)
+	return &domain.VersionComparison{
+		TenantID:         baseline.TenantID,
+		Platform:         baseline.Platform,
+		ECU:              baseline.ECU,
+		Baseline:         baseline.Version,
+		Candidate:        candidate.Version,
+		BaselineSamples:  len(baselineSamples),
+		CandidateSamples: len(candidateSamples),
+		Insufficient:     insufficient,
+		Thresholds:       s.thresholds,
+		Metrics:          metrics,
+		ComparedAt:       time.Now(),
+	}, nil
+}
+
+// DetectRegressions 每个租户的每个平台用最新发布的整车版本对比上一个版本，回归写入 firmware_regressions
+//
+// 周期执行而不是每个 Batch 完成时检测：显著性检验需要累积足够样本；
+// 按版本对去重，重复检测只刷新数值
+func (s *ReleaseAnalysisService) DetectRegressions(ctx context.Context) (int, error) {
+	platforms, err := s.firmware.ListPlatforms(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list platforms: %w", err)
+	}
+
+	detected := 0
+	for _, tp := range platforms {
+		tenantID, platform := tp.TenantID, tp.Platform
+		versions, err := s.firmware.ListVersions(ctx, tenantID, platform, "")
+		if err != nil {
+			log.Printf("[ReleaseAnalysis] Failed to list versions of %s/%s: %v", tenantID, platform, err)
+			continue
+		}
+		if len(versions) < 2 {
+			continue
+		}
+		candidate := domain.VersionSelector{TenantID: tenantID, Platform: platform, Version: versions[0].Version}
+		baseline := domain.VersionSelector{TenantID: tenantID, Platform: platform, Version: versions[1].Version}
+
+		comparison, err := s.Compare(ctx, baseline, candidate)
+		if err != nil {
+			log.Printf("[ReleaseAnalysis] Failed to compare %s/%s %s → %s: %v", tenantID, platform, baseline.Version, candidate.Version, err)
+			continue
+		}
+		for _, m := range comparison.Regressions() {
+			regression := domain.NewFirmwareRegression(comparison, m)
+			created, err := s.firmware.SaveRegression(ctx, regression)
+			if err != nil {
+				log.Printf("[ReleaseAnalysis] Failed to save regression: %v", err)
+				continue
+			}
+			if created {
+				detected++
+				log.Printf("[ReleaseAnalysis] ⚠️ Regression detected: tenant=%s, platform=%s, %s → %s, metric=%s %s, change=%+.1f%%, p=%.4f",
+					tenantID, platform, baseline.Version, candidate.Version, m.Metric, m.ErrorCode, m.RelativeChange*100, m.AdjustedPValue)
+			}
+		}
+	}
+	return detected, nil
+}
+
+func (s *ReleaseAnalysisService) ListRegressions(ctx context.Context, opts domain.RegressionListOptions) ([]*domain.FirmwareRegression, error) {
+	if opts.Limit <= 0 || opts.Limit > 200 {
+		opts.Limit = 50
+	}
+	opts.Scope = domain.DataScopeFromContext(ctx)
+	return s.firmware.ListRegressions(ctx, opts)
+}
+
+// Acknowledge 发布经理确认回归
+func (s *ReleaseAnalysisService) Acknowledge(ctx context.Context, id uuid.UUID, by, note string) (*domain.FirmwareRegression, error) {
+	regression, err := s.firmware.FindRegression(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find regression: %w", err)
+	}
+	if regression == nil {
+		return nil, domain.ErrRegressionNotFound
+	}
+	if err := authorizePlatformData(ctx, regression.Platform); err != nil {
+		return nil, err
+	}
+	if err := regression.Acknowledge(by, note); err != nil {
+		return nil, err
+	}
+	if err := s.firmware.UpdateRegression(ctx, regression); err != nil {
+		return nil, err
+	}
+	return regression, nil
+}
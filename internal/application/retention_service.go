@@ -0,0 +1,262 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/redis"
+)
+
+// defaultRetentionBatchSize 每轮最多处理的 Batch 数（删除对象较慢，避免单轮占用 Leader 太久）
+const defaultRetentionBatchSize = 200
+
+// RetentionResult 一轮生命周期任务的执行结果
+type RetentionResult struct {
+	Evaluated      int `json:"evaluated"`
+	RawPurged      int `json:"raw_purged"`
+	DerivedPurged  int `json:"derived_purged"`
+	BatchesDeleted int `json:"batches_deleted"`
+	ObjectsDeleted int `json:"objects_deleted"`
+	Held           int `json:"held"`
+	Failed         int `json:"failed"`
+}
+
+// RetentionService 数据生命周期：按策略清理对象存储与数据库，支持法务保留
+type RetentionService struct {
+	batchRepo     domain.BatchRepository
+	retentionRepo domain.RetentionRepository
+	holdRepo      domain.LegalHoldRepository
+	storage       domain.ObjectStore
+	redis         *redis.RedisClient
+	policy        *domain.RetentionPolicy
+	locker        domain.BatchLocker
+	batchSize     int
+}
+
+func NewRetentionService(
+	batchRepo domain.BatchRepository,
+	retentionRepo domain.RetentionRepository,
+	holdRepo domain.LegalHoldRepository,
+	storage domain.ObjectStore,
+	redis *redis.RedisClient,
+	policy *domain.RetentionPolicy,
+	locker domain.BatchLocker,
+) *RetentionService {
+	if policy == nil {
+		policy = domain.DefaultRetentionPolicy()
+	}
+	return &RetentionService{
+		batchRepo:     batchRepo,
+		retentionRepo: retentionRepo,
+		holdRepo:      holdRepo,
+		storage:       storage,
+		redis:         redis,
+		policy:        policy,
+		locker:        locker,
+		batchSize:     defaultRetentionBatchSize,
+	}
+}
+
+// RunRetention 执行一轮：取出到期的终态 Batch，逐个执行到期的清理阶段
+//
+// 单个 Batch 失败不中断整轮（状态未推进，下一轮会再次取出重试）
+func (s *RetentionService) RunRetention(ctx context.Context) (RetentionResult, error) {
+	var result RetentionResult
+	candidates, err := s.retentionRepo.FindDue(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		return result, fmt.Errorf("failed to find retention candidates: %w", err)
+	}
+
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Evaluated++
+		if err := s.applyRetention(ctx, candidate, &result); err != nil {
+			result.Failed++
+			log.Printf("[Retention] Failed to apply retention to batch %s: %v", candidate.Batch.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// applyRetention 在 Batch 锁内重新检查法务保留后执行
+//
+// 查询和删除之间可能刚好加了保留，删除不可逆，所以加锁后再确认一次
+func (s *RetentionService) applyRetention(ctx context.Context, candidate *domain.RetentionCandidate, result *RetentionResult) error {
+	return withBatchLock(ctx, s.locker, candidate.Batch.ID, func(ctx context.Context) error {
+		batch, err := s.batchRepo.FindByID(ctx, candidate.Batch.ID)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+		held, err := s.holdRepo.IsHeld(ctx, batch.ID, batch.VIN)
+		if err != nil {
+			return fmt.Errorf("failed to check legal hold: %w", err)
+		}
+		if held {
+			result.Held++
+			log.Printf("[Retention] Batch %s is under legal hold, skipping", batch.ID)
+			return nil
+		}
+
+		now := time.Now()
+		state := candidate.State
+		due, next := s.policy.Evaluate(batch, state, now)
+		for _, stage := range due {
+			switch stage {
+			case domain.RetentionStageBatch:
+				return s.deleteBatch(ctx, batch, result)
+			case domain.RetentionStageRawLogs:
+				n, err := s.purgeRawLogs(ctx, batch)
+				if err != nil {
+					return err
+				}
+				result.RawPurged++
+				result.ObjectsDeleted += n
+			case domain.RetentionStageDerived:
+				n, err := s.purgeDerived(ctx, batch)
+				if err != nil {
+					return err
+				}
+				result.DerivedPurged++
+				result.ObjectsDeleted += n
+				s.invalidateReport(ctx, batch)
+			}
+			state.MarkDone(stage, now)
+			log.Printf("[Retention] Batch %s: %s purged", batch.ID, stage)
+		}
+
+		state.BatchID = batch.ID
+		state.NextActionAt = next
+		return s.retentionRepo.SaveState(ctx, &state)
+	})
+}
+
+// purgeRawLogs 原始文件直接位于 Batch 前缀下（子目录是派生数据）
+func (s *RetentionService) purgeRawLogs(ctx context.Context, batch *domain.Batch) (int, error) {
+	objects, err := s.storage.ListObjects(ctx, domain.BatchObjectPrefix(batch.TenantID, batch.ID), false)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, "/") {
+			keys = append(keys, obj.Key)
+		}
+	}
+	return len(keys), s.storage.RemoveObjects(ctx, keys)
+}
+
+// purgeDerived 解析产物与图表（报告本身保留在数据库中）
+func (s *RetentionService) purgeDerived(ctx context.Context, batch *domain.Batch) (int, error) {
+	var keys []string
+	for _, sub := range []string{"parsed/", "charts/"} {
+		objects, err := s.storage.ListObjects(ctx, domain.BatchObjectPrefix(batch.TenantID, batch.ID)+sub, true)
+		if err != nil {
+			return 0, err
+		}
+		for _, obj := range objects {
+			keys = append(keys, obj.Key)
+		}
+	}
+	return len(keys), s.storage.RemoveObjects(ctx, keys)
+}
+
+// deleteBatch 整体删除：对象 → 墓碑 → 数据库（files / reports / ai_diagnoses / retention_state 级联删除）
+func (s *RetentionService) deleteBatch(ctx context.Context, batch *domain.Batch, result *RetentionResult) error {
+	rule, _ := s.policy.RuleFor(batch.VehiclePlatform, batch.Status)
+	reason := fmt.Sprintf("retention: %s batch older than %s", batch.Status, rule.Batch)
+	deleted, err := s.removeBatch(ctx, batch, func(objectsDeleted int) *domain.BatchTombstone {
+		return domain.NewBatchTombstone(batch, objectsDeleted, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	result.BatchesDeleted++
+	result.ObjectsDeleted += deleted
+	log.Printf("[Retention] Batch %s deleted (%d objects), tombstone recorded", batch.ID, deleted)
+	return nil
+}
+
+// removeBatch 删除 Batch 的全部对象和数据库记录，返回删除的对象数；tombstone 构造墓碑（数据删除请求需要匿名化）
+//
+// 先删对象再删数据库：中途失败时数据库记录还在，下一轮还能找到并重试；反过来会留下无人引用的孤儿对象
+func (s *RetentionService) removeBatch(ctx context.Context, batch *domain.Batch, tombstone func(objectsDeleted int) *domain.BatchTombstone) (int, error) {
+	objects, err := s.storage.ListObjects(ctx, domain.BatchObjectPrefix(batch.TenantID, batch.ID), true)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	if err := s.storage.RemoveObjects(ctx, keys); err != nil {
+		return 0, err
+	}
+
+	if err := s.retentionRepo.SaveTombstone(ctx, tombstone(len(keys))); err != nil {
+		return 0, fmt.Errorf("failed to save tombstone: %w", err)
+	}
+	if err := s.batchRepo.Delete(ctx, batch.ID); err != nil {
+		return 0, fmt.Errorf("failed to delete batch: %w", err)
+	}
+	s.invalidateReport(ctx, batch)
+	return len(keys), nil
+}
+
+// invalidateReport 报告缓存中的 ChartFiles 可能指向已删除的对象
+func (s *RetentionService) invalidateReport(ctx context.Context, batch *domain.Batch) {
+	if s.redis == nil {
+		return
+	}
+	if err := s.redis.DEL(ctx, reportCacheKey(batch.TenantID, batch.ID)); err != nil {
+		log.Printf("[Retention] Warning: failed to invalidate report cache for %s: %v", batch.ID, err)
+	}
+}
+
+// PlaceLegalHold 对 VIN 或 Batch 加法务保留
+func (s *RetentionService) PlaceLegalHold(ctx context.Context, scope domain.LegalHoldScope, value, reason, createdBy string) (*domain.LegalHold, error) {
+	hold, err := domain.NewLegalHold(scope, value, reason, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.holdRepo.Save(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to save legal hold: %w", err)
+	}
+	log.Printf("[Retention] Legal hold placed: %s=%s by %q (%s)", hold.Scope, hold.Value, hold.CreatedBy, hold.Reason)
+	return hold, nil
+}
+
+// ReleaseLegalHold 解除法务保留；返回 nil, nil 表示不存在
+func (s *RetentionService) ReleaseLegalHold(ctx context.Context, id uuid.UUID) (*domain.LegalHold, error) {
+	hold, err := s.holdRepo.FindByID(ctx, id)
+	if err != nil || hold == nil {
+		return nil, err
+	}
+	if err := hold.Release(); err != nil {
+		return nil, err
+	}
+	if err := s.holdRepo.Save(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to save legal hold: %w", err)
+	}
+	log.Printf("[Retention] Legal hold released: %s=%s", hold.Scope, hold.Value)
+	return hold, nil
+}
+
+func (s *RetentionService) ListLegalHolds(ctx context.Context) ([]*domain.LegalHold, error) {
+	return s.holdRepo.ListActive(ctx)
+}
+
+// GetTombstone 查询已删除 Batch 的墓碑；返回 nil, nil 表示没有
+func (s *RetentionService) GetTombstone(ctx context.Context, batchID uuid.UUID) (*domain.BatchTombstone, error) {
+	return s.retentionRepo.FindTombstone(ctx, batchID)
+}
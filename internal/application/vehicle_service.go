@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// VehicleService 车辆注册表
+type VehicleService struct {
+	vehicleRepo domain.VehicleRepository
+	batchRepo   domain.BatchRepository
+}
+
+func NewVehicleService(vehicleRepo domain.VehicleRepository, batchRepo domain.BatchRepository) *VehicleService {
+	return &VehicleService{
+		vehicleRepo: vehicleRepo,
+		batchRepo:   batchRepo,
+	}
+}
+
+// RegisterVehicle 注册车辆；VIN 已注册时返回错误（修改信息走 UpdateInventory / AssignFleet）
+func (s *VehicleService) RegisterVehicle(ctx context.Context, req dto.RegisterVehicleRequest) (*domain.Vehicle, error) {
+	vehicle, err := domain.NewVehicle(req.VIN, req.VehicleID, req.Platform, req.Model, req.ModelYear)
+	if err != nil {
+		return nil, err
+	}
+	vehicle.TenantID = callerTenant(ctx)
+	existing, err := s.vehicleRepo.FindByVIN(ctx, vehicle.VIN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vehicle: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVehicleExists, vehicle.VIN)
+	}
+
+	vehicle.AssignFleet(req.OwnerFleet)
+	if err := vehicle.UpdateInventory(req.FirmwareVersion, req.ECUs); err != nil {
+		return nil, err
+	}
+	if err := s.vehicleRepo.Save(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("failed to save vehicle: %w", err)
+	}
+	log.Printf("[VehicleService] Registered vehicle %s (%s, platform=%s, manufacturer=%s)",
+		vehicle.VIN, vehicle.VehicleID, vehicle.Platform, vehicle.Manufacturer)
+	return vehicle, nil
+}
+
+// GetVehicle 不存在时返回 nil, nil
+func (s *VehicleService) GetVehicle(ctx context.Context, vin string) (*domain.Vehicle, error) {
+	return s.vehicleRepo.FindByVIN(ctx, vin)
+}
+
+func (s *VehicleService) ListVehicles(ctx context.Context, opts domain.VehicleListOptions) ([]*domain.Vehicle, error) {
+	vehicles, err := s.vehicleRepo.List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vehicles: %w", err)
+	}
+	return vehicles, nil
+}
+
+// ListVehicleBatches 该车辆的历史 Batch
+func (s *VehicleService) ListVehicleBatches(ctx context.Context, vin string) ([]*domain.Batch, error) {
+	return s.batchRepo.FindByVIN(ctx, vin)
+}
+
+// UpdateVehicle 同步 ECU 清单 / 软件版本，或变更所属车队
+func (s *VehicleService) UpdateVehicle(ctx context.Context, vin string, req dto.UpdateVehicleRequest) (*domain.Vehicle, error) {
+	return s.modify(ctx, vin, func(v *domain.Vehicle) error {
+		if req.OwnerFleet != nil {
+			v.AssignFleet(*req.OwnerFleet)
+		}
+		if req.ECUs != nil || req.FirmwareVersion != nil {
+			firmware, ecus := v.FirmwareVersion, v.ECUs
+			if req.FirmwareVersion != nil {
+				firmware = *req.FirmwareVersion
+			}
+			if req.ECUs != nil {
+				ecus = req.ECUs
+			}
+			return v.UpdateInventory(firmware, ecus)
+		}
+		return nil
+	})
+}
+
+// DecommissionVehicle 退役车辆，之后该 VIN 的新 Batch 会被拒绝
+func (s *VehicleService) DecommissionVehicle(ctx context.Context, vin string) (*domain.Vehicle, error) {
+	vehicle, err := s.modify(ctx, vin, (*domain.Vehicle).Decommission)
+	if err == nil && vehicle != nil {
+		log.Printf("[VehicleService] Decommissioned vehicle %s", domain.MaskVIN(vehicle.VIN))
+	}
+	return vehicle, err
+}
+
+// modify 读取 → 修改 → 保存；车辆不存在时返回 nil, nil
+func (s *VehicleService) modify(ctx context.Context, vin string, fn func(*domain.Vehicle) error) (*domain.Vehicle, error) {
+	vehicle, err := s.vehicleRepo.FindByVIN(ctx, vin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vehicle: %w", err)
+	}
+	if vehicle == nil {
+		return nil, nil
+	}
+	if err := fn(vehicle); err != nil {
+		return nil, err
+	}
+	if err := s.vehicleRepo.Save(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("failed to save vehicle: %w", err)
+	}
+	return vehicle, nil
+}
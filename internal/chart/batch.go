@@ -0,0 +1,78 @@
+package chart
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// 标准图表名（也是对象存储中的文件名前缀）
+const (
+	NameCPUUtilization  = "cpu_utilization"
+	NameRAMUsage        = "ram_usage"
+	NameCPUDistribution = "cpu_distribution"
+	NameRAMDistribution = "ram_distribution"
+	NameErrorCodes      = "error_codes"
+)
+
+const (
+	distributionBins = 20
+	errorCodeLimit   = 15
+)
+
+// Named 带名字的图表
+type Named struct {
+	Name  string
+	Chart Chart
+}
+
+// BatchCharts 由一个 Batch 的聚合结果生成标准图表集
+func BatchCharts(agg *stats.Aggregate) []Named {
+	points := agg.Series.Points()
+	times := make([]time.Time, len(points))
+	avgCPU, maxCPU := make([]float64, len(points)), make([]float64, len(points))
+	avgRAM, maxRAM := make([]float64, len(points)), make([]float64, len(points))
+	for i, p := range points {
+		times[i] = p.Time
+		avgCPU[i], maxCPU[i] = p.AvgCPU, p.MaxCPU
+		avgRAM[i], maxRAM[i] = p.AvgRAM, p.MaxRAM
+	}
+
+	return []Named{
+		{NameCPUUtilization, &LineChart{
+			Title:  "CPU UTILIZATION (1 MIN)",
+			YLabel: "%",
+			Times:  times,
+			Series: []Series{{Name: "AVG", Values: avgCPU}, {Name: "MAX", Values: maxCPU}},
+		}},
+		{NameRAMUsage, &LineChart{
+			Title:  "RAM USAGE (1 MIN)",
+			YLabel: "MB",
+			Times:  times,
+			Series: []Series{{Name: "AVG", Values: avgRAM}, {Name: "MAX", Values: maxRAM}},
+		}},
+		{NameCPUDistribution, distributionChart("CPU UTILIZATION DISTRIBUTION", "CPU %", agg.CPU)},
+		{NameRAMDistribution, distributionChart("RAM USAGE DISTRIBUTION", "RAM MB", agg.RAM)},
+		{NameErrorCodes, errorCodeChart(agg)},
+	}
+}
+
+func distributionChart(title, xLabel string, h *stats.Histogram) *BarChart {
+	bins := h.Distribution(distributionBins)
+	chart := &BarChart{Title: title, XLabel: xLabel, YLabel: "RECORDS"}
+	for _, b := range bins {
+		chart.Labels = append(chart.Labels, strconv.FormatFloat(b.Low, 'f', 1, 64))
+		chart.Values = append(chart.Values, float64(b.Count))
+	}
+	return chart
+}
+
+func errorCodeChart(agg *stats.Aggregate) *BarChart {
+	chart := &BarChart{Title: "ERROR CODE FREQUENCY", XLabel: "ERROR CODE", YLabel: "COUNT"}
+	for _, s := range agg.TopErrorCodes(errorCodeLimit) {
+		chart.Labels = append(chart.Labels, s.Code)
+		chart.Values = append(chart.Values, float64(s.Count))
+	}
+	return chart
+}
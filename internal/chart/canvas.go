@@ -0,0 +1,143 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+// canvas 两种输出格式共用的绘图原语，图表布局只写一遍
+//
+// 图表布局只对 canvas 编程，PNG（光栅）和 SVG（矢量）各自实现这几个原语，两种格式的图完全一致
+type canvas interface {
+	fillRect(x, y, w, h float64, c color.RGBA)
+	line(x1, y1, x2, y2 float64, c color.RGBA)
+	polyline(xs, ys []float64, c color.RGBA)
+	// text y 为文字垂直中心
+	text(x, y float64, s string, c color.RGBA, align textAlign)
+}
+
+// ========== PNG ==========
+
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func newRasterCanvas(width, height int) *rasterCanvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: colorBackground}, image.Point{}, draw.Src)
+	return &rasterCanvas{img: img}
+}
+
+func (r *rasterCanvas) fillRect(x, y, w, h float64, c color.RGBA) {
+	rect := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(r.img, rect.Intersect(r.img.Bounds()), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// line DDA 画 1 像素宽的线段
+func (r *rasterCanvas) line(x1, y1, x2, y2 float64, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1)))
+	if steps == 0 {
+		r.img.SetRGBA(int(math.Round(x1)), int(math.Round(y1)), c)
+		return
+	}
+	dx, dy := (x2-x1)/float64(steps), (y2-y1)/float64(steps)
+	for i := 0; i <= steps; i++ {
+		r.img.SetRGBA(int(math.Round(x1+dx*float64(i))), int(math.Round(y1+dy*float64(i))), c)
+	}
+}
+
+// polyline 数据线画 2 像素宽，和网格线区分开
+func (r *rasterCanvas) polyline(xs, ys []float64, c color.RGBA) {
+	for i := 1; i < len(xs); i++ {
+		r.line(xs[i-1], ys[i-1], xs[i], ys[i], c)
+		r.line(xs[i-1], ys[i-1]+1, xs[i], ys[i]+1, c)
+	}
+	if len(xs) == 1 {
+		r.fillRect(xs[0]-1, ys[0]-1, 3, 3, c)
+	}
+}
+
+func (r *rasterCanvas) text(x, y float64, s string, c color.RGBA, align textAlign) {
+	runes := []rune(s)
+	width := float64(len(runes)*(glyphWidth+1) - 1)
+	switch align {
+	case alignCenter:
+		x -= width / 2
+	case alignRight:
+		x -= width
+	}
+	x0, y0 := int(math.Round(x)), int(math.Round(y-glyphHeight/2))
+	for i, ch := range runes {
+		g := glyph(ch)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row][col] == '#' {
+					r.img.SetRGBA(x0+i*(glyphWidth+1)+col, y0+row, c)
+				}
+			}
+		}
+	}
+}
+
+// ========== SVG ==========
+
+type svgCanvas struct {
+	b strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	s := &svgCanvas{}
+	fmt.Fprintf(&s.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		width, height, width, height)
+	s.fillRect(0, 0, float64(width), float64(height), colorBackground)
+	return s
+}
+
+func (s *svgCanvas) fillRect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&s.b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, hexColor(c))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, c color.RGBA) {
+	fmt.Fprintf(&s.b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`+"\n", x1, y1, x2, y2, hexColor(c))
+}
+
+func (s *svgCanvas) polyline(xs, ys []float64, c color.RGBA) {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+	}
+	fmt.Fprintf(&s.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), hexColor(c))
+}
+
+func (s *svgCanvas) text(x, y float64, str string, c color.RGBA, align textAlign) {
+	anchor := "start"
+	switch align {
+	case alignCenter:
+		anchor = "middle"
+	case alignRight:
+		anchor = "end"
+	}
+	fmt.Fprintf(&s.b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="%s" dominant-baseline="middle">%s</text>`+"\n",
+		x, y, hexColor(c), anchor, html.EscapeString(str))
+}
+
+func (s *svgCanvas) bytes() []byte {
+	return []byte(s.b.String() + "</svg>\n")
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
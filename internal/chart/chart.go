@@ -0,0 +1,302 @@
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Format 输出格式
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// Formats 图表默认同时输出两种格式：PNG 给报告/诊断，SVG 给前端缩放
+var Formats = []Format{FormatPNG, FormatSVG}
+
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 400
+
+	marginLeft   = 64
+	marginRight  = 24
+	marginTop    = 40
+	marginBottom = 48
+)
+
+var (
+	colorBackground = color.RGBA{255, 255, 255, 255}
+	colorAxis       = color.RGBA{68, 68, 68, 255}
+	colorGrid       = color.RGBA{225, 225, 225, 255}
+	colorText       = color.RGBA{34, 34, 34, 255}
+
+	// Palette 多条序列依次取色
+	Palette = []color.RGBA{
+		{31, 119, 180, 255},
+		{214, 39, 40, 255},
+		{44, 160, 44, 255},
+		{255, 127, 14, 255},
+	}
+)
+
+// Chart 可渲染的图表（布局只依赖 canvas，与输出格式无关）
+type Chart interface {
+	size() (width, height int)
+	draw(c canvas)
+}
+
+// Render 按格式渲染图表
+func Render(w io.Writer, ch Chart, format Format) error {
+	width, height := ch.size()
+	switch format {
+	case FormatPNG:
+		rc := newRasterCanvas(width, height)
+		ch.draw(rc)
+		return png.Encode(w, rc.img)
+	case FormatSVG:
+		sc := newSVGCanvas(width, height)
+		ch.draw(sc)
+		_, err := w.Write(sc.bytes())
+		return err
+	default:
+		return fmt.Errorf("unsupported chart format: %s", format)
+	}
+}
+
+// Series 折线图中的一条序列
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// LineChart 时间序列折线图（所有序列共用 Times 作为横轴）
+type LineChart struct {
+	Title  string
+	YLabel string
+	Times  []time.Time
+	Series []Series
+	Width  int
+	Height int
+}
+
+// BarChart 柱状图（分布图、异常码频次）
+type BarChart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Labels []string
+	Values []float64
+	Width  int
+	Height int
+}
+
+func (l *LineChart) size() (int, int) { return sizeOrDefault(l.Width, l.Height) }
+func (b *BarChart) size() (int, int)  { return sizeOrDefault(b.Width, b.Height) }
+
+func sizeOrDefault(w, h int) (int, int) {
+	if w <= 0 {
+		w = defaultWidth
+	}
+	if h <= 0 {
+		h = defaultHeight
+	}
+	return w, h
+}
+
+// plotArea 坐标区域及 y 轴映射
+type plotArea struct {
+	x, y, w, h float64
+	yMin, yMax float64
+}
+
+func (p plotArea) mapY(v float64) float64 {
+	if p.yMax == p.yMin {
+		return p.y + p.h
+	}
+	return p.y + p.h - (v-p.yMin)/(p.yMax-p.yMin)*p.h
+}
+
+// drawFrame 标题、y 轴刻度、网格线和坐标轴，返回坐标区域
+func drawFrame(c canvas, width, height int, title, yLabel string, maxValue float64) plotArea {
+	area := plotArea{
+		x: marginLeft,
+		y: marginTop,
+		w: float64(width - marginLeft - marginRight),
+		h: float64(height - marginTop - marginBottom),
+	}
+	ticks := niceTicks(0, maxValue, 5)
+	area.yMin, area.yMax = ticks[0], ticks[len(ticks)-1]
+
+	c.text(float64(width)/2, marginTop/2, title, colorText, alignCenter)
+	if yLabel != "" {
+		c.text(4, marginTop-14, yLabel, colorText, alignLeft)
+	}
+	step := 0.0
+	if len(ticks) > 1 {
+		step = ticks[1] - ticks[0]
+	}
+	for _, t := range ticks {
+		y := area.mapY(t)
+		c.line(area.x, y, area.x+area.w, y, colorGrid)
+		c.text(area.x-6, y, formatTick(t, step), colorText, alignRight)
+	}
+	c.line(area.x, area.y, area.x, area.y+area.h, colorAxis)
+	c.line(area.x, area.y+area.h, area.x+area.w, area.y+area.h, colorAxis)
+	return area
+}
+
+func (l *LineChart) draw(c canvas) {
+	width, height := l.size()
+	maxValue := 0.0
+	for _, s := range l.Series {
+		for _, v := range s.Values {
+			if !math.IsNaN(v) {
+				maxValue = math.Max(maxValue, v)
+			}
+		}
+	}
+	area := drawFrame(c, width, height, l.Title, l.YLabel, maxValue)
+	if len(l.Times) == 0 {
+		c.text(area.x+area.w/2, area.y+area.h/2, "NO DATA", colorAxis, alignCenter)
+		return
+	}
+
+	start, end := l.Times[0], l.Times[len(l.Times)-1]
+	span := end.Sub(start).Seconds()
+	mapX := func(t time.Time) float64 {
+		if span <= 0 {
+			return area.x + area.w/2
+		}
+		return area.x + t.Sub(start).Seconds()/span*area.w
+	}
+
+	// 横轴最多 6 个时间刻度
+	const xTicks = 6
+	for i := 0; i < xTicks && i < len(l.Times); i++ {
+		idx := i * (len(l.Times) - 1) / max(xTicks-1, 1)
+		x := mapX(l.Times[idx])
+		c.line(x, area.y+area.h, x, area.y+area.h+4, colorAxis)
+		c.text(x, area.y+area.h+14, l.Times[idx].UTC().Format("15:04"), colorText, alignCenter)
+	}
+	c.text(area.x+area.w/2, float64(height)-12, "TIME (UTC) "+start.UTC().Format("2006-01-02"), colorText, alignCenter)
+
+	legendX := area.x + area.w
+	for i, s := range l.Series {
+		col := Palette[i%len(Palette)]
+		xs := make([]float64, 0, len(s.Values))
+		ys := make([]float64, 0, len(s.Values))
+		for j, v := range s.Values {
+			if j >= len(l.Times) {
+				break
+			}
+			if math.IsNaN(v) { // 该窗口没有这项指标，折线直接连到下一个有值的点
+				continue
+			}
+			xs = append(xs, mapX(l.Times[j]))
+			ys = append(ys, area.mapY(v))
+		}
+		c.polyline(xs, ys, col)
+
+		// 图例从右往左排
+		legendX -= float64(len(s.Name)*6 + 24)
+		c.fillRect(legendX, marginTop-16, 10, 4, col)
+		c.text(legendX+14, marginTop-14, s.Name, colorText, alignLeft)
+	}
+}
+
+func (b *BarChart) draw(c canvas) {
+	width, height := b.size()
+	maxValue := 0.0
+	for _, v := range b.Values {
+		maxValue = math.Max(maxValue, v)
+	}
+	area := drawFrame(c, width, height, b.Title, b.YLabel, maxValue)
+	if len(b.Values) == 0 {
+		c.text(area.x+area.w/2, area.y+area.h/2, "NO DATA", colorAxis, alignCenter)
+		return
+	}
+
+	slot := area.w / float64(len(b.Values))
+	barWidth := math.Max(slot*0.8, 1)
+	// 标签过密时隔几个显示一个，避免重叠
+	labelEvery := 1
+	if maxLabel := maxLen(b.Labels)*6 + 4; float64(maxLabel) > slot {
+		labelEvery = int(math.Ceil(float64(maxLabel) / slot))
+	}
+	for i, v := range b.Values {
+		x := area.x + slot*float64(i) + (slot-barWidth)/2
+		y := area.mapY(v)
+		c.fillRect(x, y, barWidth, area.y+area.h-y, Palette[0])
+		if i < len(b.Labels) && i%labelEvery == 0 {
+			c.text(x+barWidth/2, area.y+area.h+14, b.Labels[i], colorText, alignCenter)
+		}
+	}
+	if b.XLabel != "" {
+		c.text(area.x+area.w/2, float64(height)-12, b.XLabel, colorText, alignCenter)
+	}
+}
+
+// niceTicks 把 [min, max] 扩展为 1/2/5×10^n 步长的整齐刻度
+func niceTicks(min, max float64, count int) []float64 {
+	if max <= min {
+		max = min + 1
+	}
+	step := niceNumber((max - min) / float64(count))
+	lo := math.Floor(min/step) * step
+	hi := math.Ceil(max/step) * step
+	ticks := make([]float64, 0, count+2)
+	for v := lo; v <= hi+step/2; v += step {
+		ticks = append(ticks, v)
+	}
+	return ticks
+}
+
+func niceNumber(x float64) float64 {
+	exp := math.Floor(math.Log10(x))
+	frac := x / math.Pow(10, exp)
+	var nice float64
+	switch {
+	case frac <= 1:
+		nice = 1
+	case frac <= 2:
+		nice = 2
+	case frac <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+// formatTick 按步长决定小数位数
+func formatTick(v, step float64) string {
+	decimals := 0
+	if step > 0 && step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func maxLen(labels []string) int {
+	n := 0
+	for _, l := range labels {
+		if len(l) > n {
+			n = len(l)
+		}
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package chart_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/chart"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// TestBatchCharts_RenderAllFormats - 标准图表集可以同时渲染为 PNG 和 SVG
+func TestBatchCharts_RenderAllFormats(t *testing.T) {
+	agg := stats.NewAggregate()
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 600; i++ {
+		agg.Add(parser.Record{
+			Timestamp:      start.Add(time.Duration(i) * time.Second),
+			CPUUtilization: float64(i%100) + 0.5,
+			RAMUsageMB:     1024 + float64(i),
+			HasCPU:         true,
+			HasRAM:         true,
+			ErrorCode:      []string{"", "E100", "E200"}[i%3],
+		})
+	}
+
+	charts := chart.BatchCharts(agg)
+	require.Len(t, charts, 5)
+	for _, named := range charts {
+		var pngBuf, svgBuf bytes.Buffer
+		require.NoError(t, chart.Render(&pngBuf, named.Chart, chart.FormatPNG), named.Name)
+		require.NoError(t, chart.Render(&svgBuf, named.Chart, chart.FormatSVG), named.Name)
+
+		img, err := png.Decode(&pngBuf)
+		require.NoError(t, err)
+		assert.Equal(t, 800, img.Bounds().Dx())
+		assert.True(t, strings.HasPrefix(svgBuf.String(), "<svg"))
+	}
+}
+
+// TestLineChart_EmptySeries - 没有时间戳的数据也能出图（显示 NO DATA）
+func TestLineChart_EmptySeries(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, chart.Render(&buf, &chart.LineChart{Title: "CPU"}, chart.FormatSVG))
+	assert.Contains(t, buf.String(), "NO DATA")
+}
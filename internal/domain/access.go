@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAccessPolicy 权限策略配置错误
+var ErrInvalidAccessPolicy = errors.New("invalid access policy")
+
+// Role 运维人员的角色（由低到高，高角色包含低角色的全部权限）
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // 查看 Batch 列表、处理进度与车队统计
+	RoleAnalyst  Role = "analyst"  // + 诊断报告、图表、版本对比与回归确认
+	RoleOperator Role = "operator" // + 车辆注册表、OTA 活动管理
+	RoleAdmin    Role = "admin"    // + 法务保留、访问审计、数据删除请求
+)
+
+// Permission 接口级权限
+type Permission string
+
+const (
+	PermBatchesRead      Permission = "batches:read"
+	PermBatchesWrite     Permission = "batches:write" // 运维人员代车辆上传
+	PermFleetRead        Permission = "fleet:read"
+	PermReportsRead      Permission = "reports:read" // 报告包含 AI 诊断
+	PermFirmwareRead     Permission = "firmware:read"
+	PermRegressionsWrite Permission = "regressions:write"
+	PermVehiclesRead     Permission = "vehicles:read"
+	PermVehiclesWrite    Permission = "vehicles:write"
+	PermCampaignsRead    Permission = "campaigns:read"
+	PermCampaignsWrite   Permission = "campaigns:write"
+	PermLegalHoldsRead   Permission = "legal_holds:read"
+	PermLegalHoldsWrite  Permission = "legal_holds:write"
+	PermAuditRead        Permission = "audit:read"
+	PermPrivacyErase     Permission = "privacy:erase" // VIN 数据删除请求（不可恢复）
+)
+
+// roleOrder 角色等级
+var roleOrder = []Role{RoleViewer, RoleAnalyst, RoleOperator, RoleAdmin}
+
+// rolePermissions 每个角色新增的权限（ParseRole 之后通过 Permissions 展开继承关系）
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:   {PermBatchesRead, PermFleetRead},
+	RoleAnalyst:  {PermReportsRead, PermFirmwareRead, PermRegressionsWrite},
+	RoleOperator: {PermBatchesWrite, PermVehiclesRead, PermVehiclesWrite, PermCampaignsRead, PermCampaignsWrite},
+	RoleAdmin:    {PermLegalHoldsRead, PermLegalHoldsWrite, PermAuditRead, PermPrivacyErase},
+}
+
+func ParseRole(s string) (Role, error) {
+	for _, r := range roleOrder {
+		if Role(s) == r {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown role %q", ErrInvalidAccessPolicy, s)
+}
+
+// Permissions 角色拥有的全部权限（含低等级角色）
+func (r Role) Permissions() map[Permission]bool {
+	perms := make(map[Permission]bool)
+	for _, role := range roleOrder {
+		for _, p := range rolePermissions[role] {
+			perms[p] = true
+		}
+		if role == r {
+			break
+		}
+	}
+	return perms
+}
+
+// AccessScope 一条授权的数据范围（车队与平台都为空表示不限）
+type AccessScope struct {
+	Fleets    []string `json:"fleets,omitempty"`
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// Unrestricted 是否不限范围
+func (s AccessScope) Unrestricted() bool {
+	return len(s.Fleets) == 0 && len(s.Platforms) == 0
+}
+
+// Allows 平台与车队都在范围内
+func (s AccessScope) Allows(platform, fleet string) bool {
+	return matchAny(s.Platforms, platform) && matchAny(s.Fleets, fleet)
+}
+
+// DataScope 调用方可以访问的数据范围：多条规则之间是"或"；nil 表示不限
+//
+// 规则必须整条保留，查询时拼成 (A AND B) OR (C AND D)：把 "north 车队的 J7" 和 "south 车队的 J6"
+// 的车队、平台各自取并集，会多出 north 的 J6 和 south 的 J7
+type DataScope []AccessScope
+
+// Restricted 是否有范围限制
+func (d DataScope) Restricted() bool { return d != nil }
+
+// NeedsFleet 是否有规则按车队限制（需要查车辆注册表才能判断）
+func (d DataScope) NeedsFleet() bool {
+	for _, s := range d {
+		if len(s.Fleets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows 某辆车（平台 + 所属车队）是否在范围内
+func (d DataScope) Allows(platform, fleet string) bool {
+	if d == nil {
+		return true
+	}
+	for _, s := range d {
+		if s.Allows(platform, fleet) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPlatform 是否可以访问整个平台的数据（平台级汇总、版本对比不区分车队）
+func (d DataScope) AllowsPlatform(platform string) bool {
+	if d == nil {
+		return true
+	}
+	for _, s := range d {
+		if len(s.Fleets) == 0 && matchAny(s.Platforms, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// Platforms 可以完整访问的平台；nil 表示不限，空切片表示没有任何平台级权限
+func (d DataScope) Platforms() []string {
+	if d == nil {
+		return nil
+	}
+	platforms := []string{}
+	for _, s := range d {
+		if len(s.Fleets) == 0 {
+			platforms = append(platforms, s.Platforms...)
+		}
+	}
+	return platforms
+}
+
+// AccessGrant 策略中的一条授权：持有 Claim（JWT 的 roles / groups）的运维人员在 Scope 内拥有 Role
+type AccessGrant struct {
+	Claim string
+	Role  Role
+	Scope AccessScope
+}
+
+// AccessPolicy 角色授权策略（由配置文件加载）
+type AccessPolicy struct {
+	Grants []AccessGrant
+}
+
+// DefaultAccessPolicy 未配置策略文件时：JWT 中直接携带角色名（viewer/analyst/operator/admin）即获得该角色，不限范围
+func DefaultAccessPolicy() *AccessPolicy {
+	policy := &AccessPolicy{}
+	for _, role := range roleOrder {
+		policy.Grants = append(policy.Grants, AccessGrant{Claim: string(role), Role: role})
+	}
+	return policy
+}
+
+// Access 调用方针对某个权限的授权结果
+type Access struct {
+	Allowed bool
+	Scope   DataScope // Allowed 时有效
+}
+
+// Authorize 计算运维人员对某个权限的访问范围：任一授权规则不限范围时整体不限
+func (p *AccessPolicy) Authorize(principal *Principal, perm Permission) Access {
+	var scope DataScope
+	allowed := false
+	for _, grant := range p.Grants {
+		if !principal.HasRole(grant.Claim) || !grant.Role.Permissions()[perm] {
+			continue
+		}
+		if grant.Scope.Unrestricted() {
+			return Access{Allowed: true}
+		}
+		allowed = true
+		scope = append(scope, grant.Scope)
+	}
+	return Access{Allowed: allowed, Scope: scope}
+}
+
+// HasRole 是否持有 JWT 中的某个角色 / 组
+func (p *Principal) HasRole(claim string) bool {
+	for _, r := range p.Roles {
+		if r == claim {
+			return true
+		}
+	}
+	return false
+}
+
+type dataScopeKey struct{}
+
+// WithDataScope 把数据范围附加到 context（由授权中间件设置，应用层与 Repository 据此过滤）
+func WithDataScope(ctx context.Context, scope DataScope) context.Context {
+	return context.WithValue(ctx, dataScopeKey{}, scope)
+}
+
+// DataScopeFromContext 未经过授权中间件（内部任务、未启用认证）时返回 nil，即不限
+func DataScopeFromContext(ctx context.Context) DataScope {
+	scope, _ := ctx.Value(dataScopeKey{}).(DataScope)
+	return scope
+}
+
+// AccessDenial 一次被拒绝的访问（401 / 403），用于安全审计
+type AccessDenial struct {
+	ID         uuid.UUID
+	TenantID   string // 凭证所属租户（认证失败时为空，按 default 记录）
+	OccurredAt time.Time
+	Kind       PrincipalKind // 未认证时为空
+	Subject    string
+	Name       string
+	VIN        string
+	Method     string
+	Path       string
+	Permission Permission
+	Status     int
+	Reason     string
+	ClientIP   string
+}
+
+// AccessAuditRepository 访问拒绝审计记录
+type AccessAuditRepository interface {
+	RecordDenial(ctx context.Context, denial *AccessDenial) error
+	// ListDenials 最新的在前；subject 为空不过滤
+	ListDenials(ctx context.Context, subject string, since time.Time, limit int) ([]*AccessDenial, error)
+}
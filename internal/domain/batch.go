@@ -8,6 +8,7 @@ import (
 )
 type Batch struct {
 	ID                  uuid.UUID
+	TenantID            string // 所属租户（OEM 客户）
 	VehicleID           string
 	VIN                 string
 	Status              BatchStatus
@@ -18,8 +19,14 @@ type Batch struct {
 	CompletedWorkerCount int
 	MinIOBucket         string
 	MiniIOPrefix        string
+	VehiclePlatform     string // 车型平台（用于分平台 SLA、RAG 过滤）
+	FirmwareVersion     string            // 上传时的整车软件版本（用于版本回归分析）
+	ECUVersions         map[string]string // 上传时各 ECU 的软件版本（ECU 名 → 版本）
+	Priority            BatchPriority // 处理优先级（决定 Kafka 车道和 SLA 缩放）
+	CompensationAttempts int   // 当前状态下补偿任务的重试次数，状态变更时清零
 	ErrorMessage        string
 	CompletedAt         *time.Time
+	StatusChangedAt     time.Time // 进入当前状态的时间（分阶段耗时指标；updated_at 在状态内也会刷新）
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 	eventlog            []DomainEvent
@@ -45,6 +52,7 @@ func NewBatch(vehicleID, vin string, expectedWorkers int) (*Batch, error) {
 	}
 	return &Batch{
 		ID:                  id,
+		TenantID:            DefaultTenantID,
 		VehicleID:           vehicleID,
 		VIN:                 vin,
 		Status:              BatchStatusPending,
@@ -53,10 +61,12 @@ func NewBatch(vehicleID, vin string, expectedWorkers int) (*Batch, error) {
 		ProcessedFiles:      0,
 		ExpectedWorkerCount: expectedWorkers,
 		CompletedWorkerCount: 0,
+		Priority:            BatchPriorityRoutine,
 		MinIOBucket:         "",
 		MiniIOPrefix:        "",
 		ErrorMessage:        "",
 		CompletedAt:         nil,
+		StatusChangedAt:     now,
 		CreatedAt:           now,
 		UpdatedAt:           now,
 		eventlog:            []DomainEvent{event},
@@ -64,30 +74,56 @@ func NewBatch(vehicleID, vin string, expectedWorkers int) (*Batch, error) {
 
 
 }
+// RecordSoftwareVersions 记录日志产生时车辆的软件版本
+//
+// 注册表只有车辆当前的版本，OTA 升级后就对应不上旧 Batch；回归分析按日志产生时的版本分组，所以上传时固化到 Batch 上
+func (b *Batch) RecordSoftwareVersions(firmwareVersion string, ecuVersions map[string]string) {
+	b.FirmwareVersion = firmwareVersion
+	b.ECUVersions = ecuVersions
+	b.UpdatedAt = time.Now()
+}
+
 func (b *Batch) TransitionTo(status BatchStatus) error {
 	oldStatus := b.Status
 	if !b.Status.CanTransitionTo(status) {
 		return errors.New("invalid status transition from " + b.Status.String() + " to " + status.String())
 	}
+	now := time.Now()
+	var statusDuration time.Duration
+	if !b.StatusChangedAt.IsZero() {
+		statusDuration = now.Sub(b.StatusChangedAt)
+	}
 	b.Status = status
-	b.UpdatedAt = time.Now()
+	b.UpdatedAt = now
+	if oldStatus != status {
+		b.CompensationAttempts = 0
+		b.StatusChangedAt = now
+	}
 
 	// 记录状态转换事件
 	// 两阶段上传设计：pending → uploaded 时发布 BatchCreated 事件
 	if oldStatus == BatchStatusPending && status == BatchStatusUploaded {
 		event := BatchCreated{
-			BatchID:    b.ID,
-			VIN:        b.VIN,
-			OccurredAt: time.Now(),
+			BatchID:         b.ID,
+			TenantID:        b.TenantID,
+			VehicleID:       b.VehicleID,
+			VIN:             b.VIN,
+			VehiclePlatform: b.VehiclePlatform,
+			Priority:        b.Priority,
+			StatusDuration:  statusDuration,
+			OccurredAt:      now,
 		}
 		b.eventlog = append(b.eventlog, event)
 	} else {
 		// 其他状态转换记录 BatchStatusChanged 事件
 		event := BatchStatusChanged{
-			BatchID:    b.ID,
-			OldStatus:  oldStatus,
-			NewStatus:  status,
-			OccurredAt: time.Now(),
+			BatchID:        b.ID,
+			TenantID:       b.TenantID,
+			OldStatus:      oldStatus,
+			NewStatus:      status,
+			Priority:       b.Priority,
+			StatusDuration: statusDuration,
+			OccurredAt:     now,
 		}
 		b.eventlog = append(b.eventlog, event)
 	}
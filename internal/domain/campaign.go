@@ -0,0 +1,421 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCampaign    = errors.New("invalid campaign")
+	ErrCampaignNotFound   = errors.New("campaign not found")
+	ErrCampaignConflict   = errors.New("campaign was modified concurrently")
+	ErrInvalidTransition  = errors.New("invalid campaign transition")
+	ErrDeploymentNotFound = errors.New("deployment not found")
+)
+
+// CampaignStatus OTA 活动状态
+//
+// draft → running ⇄ paused → completed / aborted
+type CampaignStatus string
+
+const (
+	CampaignStatusDraft     CampaignStatus = "draft"
+	CampaignStatusRunning   CampaignStatus = "running"
+	CampaignStatusPaused    CampaignStatus = "paused"
+	CampaignStatusCompleted CampaignStatus = "completed"
+	CampaignStatusAborted   CampaignStatus = "aborted"
+)
+
+func (s CampaignStatus) IsTerminal() bool {
+	return s == CampaignStatusCompleted || s == CampaignStatusAborted
+}
+
+// CohortSelector 目标车辆的筛选条件（平台必填，其余为空表示不限）
+type CohortSelector struct {
+	Models           []string `json:"models,omitempty"`
+	OwnerFleets      []string `json:"owner_fleets,omitempty"`
+	FirmwareVersions []string `json:"firmware_versions,omitempty"` // 只升级当前处于这些版本的车辆
+	VINs             []string `json:"vins,omitempty"`              // 指定车辆（灰度白名单）
+}
+
+// Matches 车辆是否属于目标人群（平台与在役状态由调用方保证）
+func (c CohortSelector) Matches(v *Vehicle) bool {
+	return matchAny(c.Models, v.Model) &&
+		matchAny(c.OwnerFleets, v.OwnerFleet) &&
+		matchAny(c.FirmwareVersions, v.FirmwareVersion) &&
+		matchAny(c.VINs, v.VIN)
+}
+
+func matchAny(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// RolloutWave 发布波次：Percent 是累计覆盖比例（例如 1 → 10 → 50 → 100）
+type RolloutWave struct {
+	Percent int `json:"percent"`
+}
+
+// GatePolicy 波次推进的门禁阈值
+//
+// 对照组是同一时间窗口内尚未升级的车辆，而不是历史数据：排除季节、路况、平台整体问题的干扰
+type GatePolicy struct {
+	MinBatches            int           `json:"min_batches"`              // 已升级车辆至少上传多少个 Batch 才做判断
+	SoakDuration          time.Duration `json:"soak_duration"`            // 每个波次至少观察多久
+	MaxCriticalDiagnoses  int           `json:"max_critical_diagnoses"`   // 已升级车辆的 critical 诊断上限
+	MaxFailureRate        float64       `json:"max_failure_rate"`         // Batch 处理失败率上限
+	MaxErrorRateIncrease  float64       `json:"max_error_rate_increase"`  // 异常码率相对对照组的最大增幅（0.2 = +20%）
+	MaxInstallFailureRate float64       `json:"max_install_failure_rate"` // 安装失败率上限
+}
+
+func DefaultGatePolicy() GatePolicy {
+	return GatePolicy{
+		MinBatches:            20,
+		SoakDuration:          24 * time.Hour,
+		MaxCriticalDiagnoses:  0,
+		MaxFailureRate:        0.05,
+		MaxErrorRateIncrease:  0.2,
+		MaxInstallFailureRate: 0.05,
+	}
+}
+
+// GateMetrics 门禁评估的输入（由 DeploymentRepository 统计）
+type GateMetrics struct {
+	Installed         int // 已安装的车辆
+	InstallFailed     int // 安装失败的车辆
+	Batches           int // 已升级车辆在升级后上传的终态 Batch
+	FailedBatches     int
+	Records           int // 已升级车辆的记录数
+	ErrorOccurrences  int
+	CriticalDiagnoses int
+	ControlRecords    int // 对照组（同活动中尚未升级的车辆）
+	ControlErrors     int
+}
+
+// ErrorRate 每千条记录的异常码次数
+func (m GateMetrics) ErrorRate() float64 {
+	if m.Records == 0 {
+		return 0
+	}
+	return float64(m.ErrorOccurrences) * 1000 / float64(m.Records)
+}
+
+func (m GateMetrics) ControlErrorRate() float64 {
+	if m.ControlRecords == 0 {
+		return 0
+	}
+	return float64(m.ControlErrors) * 1000 / float64(m.ControlRecords)
+}
+
+// GateDecision 门禁结论
+type GateDecision string
+
+const (
+	GatePass GateDecision = "pass" // 推进到下一波次
+	GateHold GateDecision = "hold" // 数据不足或观察期未满，继续等待
+	GateFail GateDecision = "fail" // 超出阈值，自动暂停活动
+)
+
+// GateResult 一次门禁评估的结果（保存在活动上，便于发布经理查看）
+type GateResult struct {
+	Wave        int          `json:"wave"`
+	Decision    GateDecision `json:"decision"`
+	Reasons     []string     `json:"reasons,omitempty"`
+	Metrics     GateMetrics  `json:"metrics"`
+	EvaluatedAt time.Time    `json:"evaluated_at"`
+}
+
+// Evaluate critical 诊断是绝对数量，任何时候超标都立即失败；各项比率在样本量达到 MinBatches 之后才判断
+// （前几个 Batch 里碰巧有一个失败就是 100%，不能据此暂停活动），最后检查观察期
+func (p GatePolicy) Evaluate(m GateMetrics, waveStartedAt, now time.Time) (GateDecision, []string) {
+	if m.CriticalDiagnoses > p.MaxCriticalDiagnoses {
+		return GateFail, []string{fmt.Sprintf("critical diagnoses %d > %d", m.CriticalDiagnoses, p.MaxCriticalDiagnoses)}
+	}
+
+	var reasons []string
+	if attempted := m.Installed + m.InstallFailed; attempted > 0 && attempted >= p.MinBatches {
+		if rate := float64(m.InstallFailed) / float64(attempted); rate > p.MaxInstallFailureRate {
+			reasons = append(reasons, fmt.Sprintf("install failure rate %.1f%% > %.1f%%", rate*100, p.MaxInstallFailureRate*100))
+		}
+	}
+	if m.Batches < p.MinBatches {
+		if len(reasons) > 0 {
+			return GateFail, reasons
+		}
+		return GateHold, []string{fmt.Sprintf("waiting for batches: %d/%d", m.Batches, p.MinBatches)}
+	}
+
+	if m.Batches > 0 {
+		if rate := float64(m.FailedBatches) / float64(m.Batches); rate > p.MaxFailureRate {
+			reasons = append(reasons, fmt.Sprintf("batch failure rate %.1f%% > %.1f%%", rate*100, p.MaxFailureRate*100))
+		}
+	}
+	// 对照组没有记录时无法比较，只依赖其他阈值；对照组有记录但一次异常都没有时按 1 次计（检测下限），
+	// 否则新版本引入的异常码永远不会被发现
+	if m.Records > 0 && m.ControlRecords > 0 {
+		control := math.Max(m.ControlErrorRate(), 1000/float64(m.ControlRecords))
+		if increase := m.ErrorRate()/control - 1; increase > p.MaxErrorRateIncrease {
+			reasons = append(reasons, fmt.Sprintf("error rate %.2f/1k records is %+.0f%% vs control %.2f/1k",
+				m.ErrorRate(), increase*100, m.ControlErrorRate()))
+		}
+	}
+	if len(reasons) > 0 {
+		return GateFail, reasons
+	}
+
+	if soaked := now.Sub(waveStartedAt); soaked < p.SoakDuration {
+		return GateHold, []string{fmt.Sprintf("soaking: %s/%s", soaked.Truncate(time.Minute), p.SoakDuration)}
+	}
+	return GatePass, nil
+}
+
+// Campaign OTA 升级活动
+type Campaign struct {
+	ID             uuid.UUID
+	TenantID       string // 所属租户，只能选择本租户注册的车辆
+	Name           string
+	Platform       string
+	TargetFirmware string
+	Cohort         CohortSelector
+	Waves          []RolloutWave
+	Gate           GatePolicy
+	CurrentWave    int // 已放量的最后一个波次（-1 表示尚未开始）
+	Status         CampaignStatus
+	StatusReason   string
+	LastGate       *GateResult
+	WaveStartedAt  *time.Time
+	CreatedBy      string
+	Version        int // 乐观锁：API 操作与 Leader 门禁任务可能同时修改
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	StartedAt      *time.Time
+	CompletedAt    *time.Time
+}
+
+func NewCampaign(name, platform, targetFirmware string, cohort CohortSelector, waves []RolloutWave, gate GatePolicy, createdBy string) (*Campaign, error) {
+	if name == "" || platform == "" || targetFirmware == "" {
+		return nil, fmt.Errorf("%w: name, platform and target firmware are required", ErrInvalidCampaign)
+	}
+	if len(waves) == 0 {
+		waves = []RolloutWave{{Percent: 1}, {Percent: 10}, {Percent: 50}, {Percent: 100}}
+	}
+	prev := 0
+	for i, w := range waves {
+		if w.Percent <= prev || w.Percent > 100 {
+			return nil, fmt.Errorf("%w: wave %d percent must increase within (0, 100]", ErrInvalidCampaign, i)
+		}
+		prev = w.Percent
+	}
+	if prev != 100 {
+		return nil, fmt.Errorf("%w: last wave must reach 100%%", ErrInvalidCampaign)
+	}
+
+	now := time.Now()
+	return &Campaign{
+		ID:             uuid.New(),
+		TenantID:       DefaultTenantID,
+		Name:           name,
+		Platform:       platform,
+		TargetFirmware: targetFirmware,
+		Cohort:         cohort,
+		Waves:          waves,
+		Gate:           gate,
+		CurrentWave:    -1,
+		Status:         CampaignStatusDraft,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, nil
+}
+
+// WaveFor 车辆所属波次：按 (活动, VIN) 哈希到 [0, 100) 的桶，落在第一个累计比例覆盖它的波次
+//
+// 哈希分桶是确定性的，重启、重算都得到同样的分配；混入活动 ID，避免同一批车在每个活动里都排在第一波
+func (c *Campaign) WaveFor(vin string) int {
+	h := fnv.New32a()
+	h.Write([]byte(c.ID.String()))
+	h.Write([]byte(vin))
+	bucket := int(h.Sum32() % 100)
+	for i, w := range c.Waves {
+		if bucket < w.Percent {
+			return i
+		}
+	}
+	return len(c.Waves) - 1
+}
+
+// Start 开始第一个波次
+func (c *Campaign) Start() error {
+	if c.Status != CampaignStatusDraft {
+		return fmt.Errorf("%w: cannot start %s campaign", ErrInvalidTransition, c.Status)
+	}
+	now := time.Now()
+	c.Status = CampaignStatusRunning
+	c.CurrentWave = 0
+	c.StartedAt = &now
+	c.WaveStartedAt = &now
+	c.UpdatedAt = now
+	return nil
+}
+
+// Pause 人工暂停，或门禁失败时自动暂停（reason 说明原因）
+func (c *Campaign) Pause(reason string) error {
+	if c.Status != CampaignStatusRunning {
+		return fmt.Errorf("%w: cannot pause %s campaign", ErrInvalidTransition, c.Status)
+	}
+	c.Status = CampaignStatusPaused
+	c.StatusReason = reason
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// Resume 恢复后重新计算观察期（暂停期间的数据可能已经被人为处理过）
+func (c *Campaign) Resume() error {
+	if c.Status != CampaignStatusPaused {
+		return fmt.Errorf("%w: cannot resume %s campaign", ErrInvalidTransition, c.Status)
+	}
+	now := time.Now()
+	c.Status = CampaignStatusRunning
+	c.StatusReason = ""
+	c.WaveStartedAt = &now
+	c.UpdatedAt = now
+	return nil
+}
+
+func (c *Campaign) Abort(reason string) error {
+	if c.Status.IsTerminal() {
+		return fmt.Errorf("%w: cannot abort %s campaign", ErrInvalidTransition, c.Status)
+	}
+	now := time.Now()
+	c.Status = CampaignStatusAborted
+	c.StatusReason = reason
+	c.CompletedAt = &now
+	c.UpdatedAt = now
+	return nil
+}
+
+// AdvanceWave 门禁通过：放量下一个波次，最后一个波次通过则活动完成；返回新放量的波次（完成时为 -1）
+func (c *Campaign) AdvanceWave() (int, error) {
+	if c.Status != CampaignStatusRunning {
+		return -1, fmt.Errorf("%w: cannot advance %s campaign", ErrInvalidTransition, c.Status)
+	}
+	now := time.Now()
+	c.UpdatedAt = now
+	if c.CurrentWave >= len(c.Waves)-1 {
+		c.Status = CampaignStatusCompleted
+		c.CompletedAt = &now
+		return -1, nil
+	}
+	c.CurrentWave++
+	c.WaveStartedAt = &now
+	return c.CurrentWave, nil
+}
+
+// RecordGate 保存门禁评估结果
+func (c *Campaign) RecordGate(result GateResult) {
+	c.LastGate = &result
+	c.UpdatedAt = time.Now()
+}
+
+// DeploymentStatus 单车升级状态
+//
+// pending（等待所在波次放量）→ scheduled（已下发）→ installed / failed；活动中止时未完成的变为 cancelled
+type DeploymentStatus string
+
+const (
+	DeploymentStatusPending   DeploymentStatus = "pending"
+	DeploymentStatusScheduled DeploymentStatus = "scheduled"
+	DeploymentStatusInstalled DeploymentStatus = "installed"
+	DeploymentStatusFailed    DeploymentStatus = "failed"
+	DeploymentStatusCancelled DeploymentStatus = "cancelled"
+)
+
+// Deployment 一辆车在某个活动中的升级记录
+type Deployment struct {
+	ID            uuid.UUID
+	TenantID      string // 与所属活动一致
+	CampaignID    uuid.UUID
+	VIN           string
+	Wave          int
+	FromVersion   string
+	TargetVersion string
+	Status        DeploymentStatus
+	ErrorMessage  string
+	ReleasedAt    *time.Time
+	InstalledAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func NewDeployment(campaign *Campaign, vehicle *Vehicle) *Deployment {
+	now := time.Now()
+	return &Deployment{
+		ID:            uuid.New(),
+		TenantID:      campaign.TenantID,
+		CampaignID:    campaign.ID,
+		VIN:           vehicle.VIN,
+		Wave:          campaign.WaveFor(vehicle.VIN),
+		FromVersion:   vehicle.FirmwareVersion,
+		TargetVersion: campaign.TargetFirmware,
+		Status:        DeploymentStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// ReportResult 车端上报安装结果
+func (d *Deployment) ReportResult(success bool, message string) error {
+	if d.Status != DeploymentStatusScheduled {
+		return fmt.Errorf("%w: deployment is %s", ErrInvalidTransition, d.Status)
+	}
+	now := time.Now()
+	d.UpdatedAt = now
+	if success {
+		d.Status = DeploymentStatusInstalled
+		d.InstalledAt = &now
+		d.ErrorMessage = ""
+		return nil
+	}
+	d.Status = DeploymentStatusFailed
+	d.ErrorMessage = message
+	return nil
+}
+
+type CampaignRepository interface {
+	// Save 新建或按 Version 乐观更新，版本不一致时返回 ErrCampaignConflict
+	Save(ctx context.Context, campaign *Campaign) error
+	// FindByID 不存在时返回 nil, nil
+	FindByID(ctx context.Context, id uuid.UUID) (*Campaign, error)
+	List(ctx context.Context, status CampaignStatus, limit int) ([]*Campaign, error)
+}
+
+type DeploymentRepository interface {
+	// CreateAll 批量创建；车辆已在其他进行中的活动里时跳过，返回实际创建数
+	CreateAll(ctx context.Context, deployments []*Deployment) (int, error)
+	// FindActiveByVIN 车辆当前已下发、未完成的升级（不存在时返回 nil, nil）
+	FindActiveByVIN(ctx context.Context, vin string) (*Deployment, error)
+	Save(ctx context.Context, deployment *Deployment) error
+	ListByCampaign(ctx context.Context, campaignID uuid.UUID, status DeploymentStatus, limit, offset int) ([]*Deployment, error)
+	CountByStatus(ctx context.Context, campaignID uuid.UUID) (map[DeploymentStatus]int, error)
+	// ReleaseWave 把波次内 pending 的升级标记为 scheduled
+	ReleaseWave(ctx context.Context, campaignID uuid.UUID, wave int) (int, error)
+	// SyncInstalled 车辆注册表的软件版本已是目标版本的 scheduled 升级标记为 installed
+	SyncInstalled(ctx context.Context, campaignID uuid.UUID) (int, error)
+	// CancelOpen 取消活动中所有未完成的升级
+	CancelOpen(ctx context.Context, campaignID uuid.UUID) (int, error)
+	// GateMetrics 统计已升级车辆在升级后、以及对照组在活动开始后上传的 Batch
+	GateMetrics(ctx context.Context, campaign *Campaign) (GateMetrics, error)
+}
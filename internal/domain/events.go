@@ -12,22 +12,49 @@ type DomainEvent interface {
 
 type BatchCreated struct {
 	BatchID     uuid.UUID
+	TenantID    string
 	VehicleID   string
 	VIN         string
+	VehiclePlatform string    // 来自车辆注册表，下游诊断按平台过滤 RAG 知识库
+	Priority    BatchPriority // 决定投递到哪个 Topic（高优先级车道）
+	StatusDuration time.Duration // 在 pending 停留的时间（仅进程内用于指标，不发布）
 	OccurredAt  time.Time
 }
 
 type BatchStatusChanged struct {
 	BatchID     uuid.UUID
+	TenantID    string
 	OldStatus   BatchStatus
 	NewStatus   BatchStatus
+	Priority    BatchPriority
+	StatusDuration time.Duration // 在 OldStatus 停留的时间（仅进程内用于指标，不发布）
 	OccurredAt  time.Time
 }
 
 // FileParsed - 文件解析完成事件（C++ Worker 发布）
 type FileParsed struct {
 	BatchID     uuid.UUID
+	TenantID    string
 	FileID      uuid.UUID
+	OutputPath  string // 解析产物在 MinIO 中的路径（可选）
+	Priority    BatchPriority // 后续阶段沿用 Batch 的优先级车道
+	OccurredAt  time.Time
+}
+
+// ParsedFileOutput - 单个文件的解析产物
+type ParsedFileOutput struct {
+	FileID     uuid.UUID `json:"file_id"`
+	OutputPath string    `json:"output_path"`
+}
+
+// GatherRequested - 聚合命令（Orchestrator 在 Barrier 完成后发布，Python Worker 消费）
+type GatherRequested struct {
+	Version     string             // 事件版本 "v1.0"
+	BatchID     uuid.UUID
+	TenantID    string
+	Priority    BatchPriority
+	TotalFiles  int
+	ParsedFiles []ParsedFileOutput // 所有已解析文件的产物列表
 	OccurredAt  time.Time
 }
 
@@ -46,14 +73,21 @@ type TokenUsageInfo struct {
 	EstimatedCost    float64 `json:"estimated_cost"` // USD
 }
 
-// GatheringCompleted - 数据聚合完成事件（Python Worker 发布）
+// GatheringCompleted - 数据聚合完成事件（Gather Worker 发布）
 // 注意：假设每个 Batch 平均 < 50 个文件，ChartFiles 总大小 < 1MB
 // 如果文件数过多，考虑分批发送事件
 type GatheringCompleted struct {
 	Version     string    // 事件版本 "v1.0"
 	BatchID     uuid.UUID
+	TenantID    string
+	VIN         string   // 仅进程内使用，Producer 发布为 vin_token（诊断 Prompt 与分析只见令牌）
+	Priority    BatchPriority
 	TotalFiles  int
 	ChartFiles  []string // MinIO object paths (PNG/JPG)
+	RecordCount   int
+	CPUStats      *CPUStats          // 统计摘要（诊断阶段直接使用，无需再读报告）
+	RAMStats      *RAMStats
+	TopErrorCodes []ErrorCodeSummary
 	OccurredAt  time.Time
 }
 
@@ -61,7 +95,9 @@ type GatheringCompleted struct {
 type DiagnosisCompleted struct {
 	Version           string             // 事件版本 "v1.0"
 	BatchID           uuid.UUID
+	TenantID          string
 	DiagnosisID       uuid.UUID
+	Priority          BatchPriority
 	DiagnosisSummary  string             // 诊断摘要（可能很长）
 	TopErrorCodes     []ErrorCodeSummary // ✅ Top-K 异常码
 	TokenUsage        TokenUsageInfo     // ✅ Token 使用统计
@@ -85,6 +121,19 @@ func (e FileParsed) EventType() string {
 	return "FileParsed"
 }
 
+// GatherRequested implements DomainEvent interface
+func (e GatherRequested) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func (e GatherRequested) AggregateID() uuid.UUID {
+	return e.BatchID
+}
+
+func (e GatherRequested) EventType() string {
+	return "GatherRequested"
+}
+
 // GatheringCompleted implements DomainEvent interface
 func (e GatheringCompleted) OccurredOn() time.Time {
 	return e.OccurredAt
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStaleFencingToken 写入方持有的 fencing token 已过期（有更新的持有者写入过）
+var ErrStaleFencingToken = errors.New("stale fencing token")
+
+// FencingToken 单调递增的令牌，用于防止失去 Leader/锁的进程继续写入
+//
+// 每次获得租约 / 锁都分配一个更大的 token，存储层拒绝 token 小于已见最大值的写入，
+// 失去租约的旧持有者（如 GC 停顿后醒来的旧 Leader）因此无法再写入
+type FencingToken struct {
+	Resource string // 受保护的资源名，如 "leader:orchestrator"
+	Token    int64
+}
+
+type fencingTokenKey struct{}
+
+// WithFencingToken 将 fencing token 附加到 context，Repository 写入时校验
+// 可以叠加多个资源（如 Leader token + Batch 锁 token），写入时全部校验
+func WithFencingToken(ctx context.Context, resource string, token int64) context.Context {
+	existing := FencingTokensFromContext(ctx)
+	tokens := make([]FencingToken, 0, len(existing)+1)
+	for _, t := range existing {
+		if t.Resource != resource {
+			tokens = append(tokens, t)
+		}
+	}
+	tokens = append(tokens, FencingToken{Resource: resource, Token: token})
+	return context.WithValue(ctx, fencingTokenKey{}, tokens)
+}
+
+// FencingTokenIssuer 为资源分配下一个 fencing token
+//
+// token 必须与存储层记录的最大值来自同一处，否则计数器丢失（如 Redis 被清空）后新 token 会小于已记录值，所有写入都被拒绝
+type FencingTokenIssuer interface {
+	NextFencingToken(ctx context.Context, resource string) (int64, error)
+	// CurrentFencingToken 资源最近分配或写入过的 token，从未分配时为 0
+	CurrentFencingToken(ctx context.Context, resource string) (int64, error)
+}
+
+// FencingTokensFromContext 读取 context 中的全部 fencing token
+func FencingTokensFromContext(ctx context.Context) []FencingToken {
+	tokens, _ := ctx.Value(fencingTokenKey{}).([]FencingToken)
+	return tokens
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"errors"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,6 +11,7 @@ import (
 type File struct {
 	ID               uuid.UUID
 	BatchID          uuid.UUID
+	TenantID         string // 与所属 Batch 一致
 	Filename         string
 	OriginalFilename string
 	FileSize         int64
@@ -23,6 +27,25 @@ type File struct {
 	UpdatedAt        time.Time
 }
 
+// FileTypeFromFilename 从原始文件名推断文件类型（小写扩展名，不含点），用于选择解码器
+func FileTypeFromFilename(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ParsedOutputPath 解析产物在对象存储中的路径（确定性路径，重复解析会覆盖而不是产生新文件）
+func ParsedOutputPath(tenantID string, batchID, fileID uuid.UUID) string {
+	return BatchObjectPrefix(tenantID, batchID) + "parsed/" + fileID.String() + ".csv"
+}
+
+func (f *File) TransitionTo(status ProcessingStatus) error {
+	if !f.ProcessingStatus.CanTransitionTo(status) {
+		return errors.New("invalid file status transition from " + f.ProcessingStatus.String() + " to " + status.String())
+	}
+	f.ProcessingStatus = status
+	f.UpdatedAt = time.Now()
+	return nil
+}
+
 type ProcessingStatus string 
 const (
 	FileStatusPending 		ProcessingStatus = "pending"
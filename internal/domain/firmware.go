@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVersionSelector = errors.New("invalid version selector")
+	ErrRegressionNotFound     = errors.New("firmware regression not found")
+)
+
+// VersionSelector 选定一组 Batch：某平台上某个软件版本
+//
+// ECU 为空表示按整车软件版本（batches.firmware_version）分组，
+// 否则按该 ECU 的版本（batches.ecu_versions->>ECU）分组
+type VersionSelector struct {
+	TenantID string // 只取该租户的 Batch
+	Platform string
+	ECU      string
+	Version  string
+}
+
+func (s VersionSelector) Validate() error {
+	if s.Platform == "" || s.Version == "" {
+		return fmt.Errorf("%w: platform and version are required", ErrInvalidVersionSelector)
+	}
+	return nil
+}
+
+// ReleaseSample 一个终态 Batch 在版本对比中的样本
+type ReleaseSample struct {
+	BatchID     uuid.UUID
+	VIN         string
+	Failed      bool
+	RecordCount int
+	ErrorCodes  []ErrorCodeSummary // 报告中的 Top-K 异常码
+	CPU         *CPUStats
+	RAM         *RAMStats
+}
+
+// ErrorOccurrences 样本内异常码出现总次数
+func (s ReleaseSample) ErrorOccurrences() int {
+	total := 0
+	for _, code := range s.ErrorCodes {
+		total += code.Count
+	}
+	return total
+}
+
+// TenantPlatform 回归检测的单位：不同租户的同名平台各自比较
+type TenantPlatform struct {
+	TenantID string
+	Platform string
+}
+
+// FirmwareVersionSummary 某平台上一个软件版本的概况
+type FirmwareVersionSummary struct {
+	Platform  string
+	ECU       string
+	Version   string
+	Batches   int
+	Vehicles  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// RegressionMetric 版本对比的指标
+type RegressionMetric string
+
+const (
+	MetricFailureRate RegressionMetric = "failure_rate" // Batch 失败率（两比例 z 检验）
+	MetricErrorRate   RegressionMetric = "error_rate"   // 每千条记录的异常码次数（泊松率检验）
+	MetricErrorCode   RegressionMetric = "error_code"   // 单个异常码的出现率（泊松率检验）
+	MetricCPUP95      RegressionMetric = "cpu_p95"      // Batch 级 CPU P95 分布（Mann-Whitney U）
+	MetricRAMP95      RegressionMetric = "ram_p95"      // Batch 级内存 P95 分布（Mann-Whitney U）
+)
+
+// RegressionThresholds 判定回归的阈值
+//
+// 样本量大时极小的差异也会显著，所以同时要求相对变化超过 MinRelativeChange，
+// 且两组样本都不少于 MinSamples（样本太少时正态近似不可靠）
+type RegressionThresholds struct {
+	Alpha             float64 // 多重检验校正后的显著性水平
+	MinSamples        int     // 每组最少 Batch 数
+	MinRelativeChange float64 // 最小相对变化（0.1 = 恶化 10%）
+}
+
+func DefaultRegressionThresholds() RegressionThresholds {
+	return RegressionThresholds{
+		Alpha:             0.05,
+		MinSamples:        30,
+		MinRelativeChange: 0.1,
+	}
+}
+
+// MetricComparison 单个指标在两个版本间的对比结果
+type MetricComparison struct {
+	Metric         RegressionMetric
+	ErrorCode      string // 仅 MetricErrorCode
+	Test           string // 使用的检验方法
+	Baseline       float64
+	Candidate      float64
+	RelativeChange float64 // (Candidate - Baseline) / Baseline；Baseline 为 0 时取 Candidate 是否大于 0
+	PValue         float64 // 双侧 p 值
+	AdjustedPValue float64 // Benjamini-Hochberg 校正后的 p 值
+	Significant    bool
+	Regression     bool // 显著、且向变差的方向超过阈值
+}
+
+// VersionComparison 两个版本的对比报告
+type VersionComparison struct {
+	TenantID         string
+	Platform         string
+	ECU              string
+	Baseline         string
+	Candidate        string
+	BaselineSamples  int
+	CandidateSamples int
+	Insufficient     bool // 样本不足，未做显著性判定
+	Thresholds       RegressionThresholds
+	Metrics          []MetricComparison
+	ComparedAt       time.Time
+}
+
+// Regressions 被判定为回归的指标
+func (c *VersionComparison) Regressions() []MetricComparison {
+	var regressions []MetricComparison
+	for _, m := range c.Metrics {
+		if m.Regression {
+			regressions = append(regressions, m)
+		}
+	}
+	return regressions
+}
+
+// RegressionStatus 回归的处理状态
+type RegressionStatus string
+
+const (
+	RegressionStatusOpen         RegressionStatus = "open"
+	RegressionStatusAcknowledged RegressionStatus = "acknowledged"
+)
+
+// FirmwareRegression 检测到的版本回归（发布经理确认后关闭）
+type FirmwareRegression struct {
+	ID             uuid.UUID
+	TenantID       string
+	Platform       string
+	ECU            string
+	Baseline       string
+	Candidate      string
+	Metric         RegressionMetric
+	ErrorCode      string
+	BaselineValue  float64
+	CandidateValue float64
+	RelativeChange float64
+	PValue         float64 // 校正后的 p 值
+	Status         RegressionStatus
+	AcknowledgedBy string
+	Note           string
+	AcknowledgedAt *time.Time
+	DetectedAt     time.Time
+	UpdatedAt      time.Time
+}
+
+func NewFirmwareRegression(c *VersionComparison, m MetricComparison) *FirmwareRegression {
+	now := time.Now()
+	return &FirmwareRegression{
+		ID:             uuid.New(),
+		TenantID:       c.TenantID,
+		Platform:       c.Platform,
+		ECU:            c.ECU,
+		Baseline:       c.Baseline,
+		Candidate:      c.Candidate,
+		Metric:         m.Metric,
+		ErrorCode:      m.ErrorCode,
+		BaselineValue:  m.Baseline,
+		CandidateValue: m.Candidate,
+		RelativeChange: m.RelativeChange,
+		PValue:         m.AdjustedPValue,
+		Status:         RegressionStatusOpen,
+		DetectedAt:     now,
+		UpdatedAt:      now,
+	}
+}
+
+// Acknowledge 发布经理确认回归（已知问题、已排期修复或判定为可接受）
+func (r *FirmwareRegression) Acknowledge(by, note string) error {
+	if by == "" {
+		return errors.New("acknowledged_by is required")
+	}
+	now := time.Now()
+	r.Status = RegressionStatusAcknowledged
+	r.AcknowledgedBy = by
+	r.Note = note
+	r.AcknowledgedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// RegressionListOptions 回归列表的过滤条件
+type RegressionListOptions struct {
+	Platform string
+	Status   RegressionStatus // 为空不过滤
+	Limit    int
+	Scope    DataScope // 调用方的数据范围，只返回可完整访问的平台（nil 不限）
+}
+
+// FirmwareRepository 版本维度的样本与回归记录
+type FirmwareRepository interface {
+	// ListPlatforms 有版本快照的（租户, 平台）
+	ListPlatforms(ctx context.Context) ([]TenantPlatform, error)
+	// ListVersions 租户在平台上出现过的软件版本（按首次出现时间倒序，第一个即最新发布的版本）
+	ListVersions(ctx context.Context, tenantID, platform, ecu string) ([]*FirmwareVersionSummary, error)
+	// FindSamples 选定版本最近的终态 Batch 样本（最多 limit 个）
+	FindSamples(ctx context.Context, sel VersionSelector, limit int) ([]ReleaseSample, error)
+	// SaveRegression 按 (平台, ECU, 基线, 候选, 指标, 异常码) 去重；重复检测只刷新数值，保留确认状态
+	SaveRegression(ctx context.Context, regression *FirmwareRegression) (created bool, err error)
+	FindRegression(ctx context.Context, id uuid.UUID) (*FirmwareRegression, error)
+	UpdateRegression(ctx context.Context, regression *FirmwareRegression) error
+	ListRegressions(ctx context.Context, opts RegressionListOptions) ([]*FirmwareRegression, error)
+}
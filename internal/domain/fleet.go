@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UnknownPlatform 没有平台信息的 Batch 在汇总表中的平台值
+const UnknownPlatform = "unknown"
+
+// BatchRollup 一个终态 Batch 对车队汇总表的贡献
+//
+// Batch 进入终态时把它的贡献累加到按小时 / 天预聚合的汇总表，车队查询不再解析每个报告的 JSONB；
+// 累加都是可交换的 SUM/MAX，事件乱序不影响结果
+type BatchRollup struct {
+	BatchID         uuid.UUID
+	TenantID        string // 汇总表按租户分开累加
+	VIN             string
+	Platform        string
+	FirmwareVersion string // 整车软件版本（未知为空）
+	Failed          bool
+	FinishedAt      time.Time
+	TotalFiles      int
+	Duration        time.Duration // 创建到终态
+	RecordCount     int
+	ErrorCodes      []ErrorCodeSummary
+	CPU             *CPUStats // 失败或未聚合的 Batch 为 nil
+	RAM             *RAMStats
+}
+
+// NewBatchRollup 只有终态 Batch 才计入汇总；report 可以为 nil（聚合前失败）
+func NewBatchRollup(batch *Batch, report *Report) (*BatchRollup, error) {
+	if !batch.Status.IsTerminal() {
+		return nil, fmt.Errorf("batch %s is not terminal: %s", batch.ID, batch.Status)
+	}
+	finishedAt := batch.UpdatedAt
+	if batch.CompletedAt != nil {
+		finishedAt = *batch.CompletedAt
+	}
+	platform := batch.VehiclePlatform
+	if platform == "" {
+		platform = UnknownPlatform
+	}
+	duration := finishedAt.Sub(batch.CreatedAt)
+	if duration < 0 {
+		duration = 0
+	}
+
+	rollup := &BatchRollup{
+		BatchID:         batch.ID,
+		TenantID:        batch.TenantID,
+		VIN:             batch.VIN,
+		Platform:        platform,
+		FirmwareVersion: batch.FirmwareVersion,
+		Failed:          batch.Status == BatchStatusFailed,
+		FinishedAt:      finishedAt.UTC(),
+		TotalFiles:      batch.TotalFiles,
+		Duration:        duration,
+	}
+	if report != nil {
+		rollup.RecordCount = report.RecordCount
+		rollup.ErrorCodes = report.TopErrorCodes
+		rollup.CPU = report.CPUStats
+		rollup.RAM = report.RAMStats
+	}
+	return rollup, nil
+}
+
+// Hour 吞吐量汇总的时间桶
+func (r *BatchRollup) Hour() time.Time {
+	return r.FinishedAt.Truncate(time.Hour)
+}
+
+// Day 错误码 / 车辆汇总的时间桶（UTC 日期）
+func (r *BatchRollup) Day() time.Time {
+	y, m, d := r.FinishedAt.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ErrorOccurrences 该 Batch 的异常码出现总次数（Top-K 之和）
+func (r *BatchRollup) ErrorOccurrences() int {
+	total := 0
+	for _, code := range r.ErrorCodes {
+		total += code.Count
+	}
+	return total
+}
+
+// FleetInterval 吞吐量查询的时间粒度
+type FleetInterval string
+
+const (
+	FleetIntervalHour FleetInterval = "hour"
+	FleetIntervalDay  FleetInterval = "day"
+)
+
+// AnomalyMetric 异常排行榜的排序指标
+type AnomalyMetric string
+
+const (
+	AnomalyMetricErrors            AnomalyMetric = "errors"             // 异常码出现次数
+	AnomalyMetricFailures          AnomalyMetric = "failures"           // 处理失败的 Batch 数
+	AnomalyMetricCPUP99            AnomalyMetric = "cpu_p99"            // 窗口内 CPU P99 最大值
+	AnomalyMetricRAMP99            AnomalyMetric = "ram_p99"            // 窗口内 RAM P99 最大值
+	AnomalyMetricCriticalDiagnoses AnomalyMetric = "critical_diagnoses" // AI 诊断为 critical 的次数
+)
+
+// ErrInvalidFleetQuery 车队查询参数错误
+var ErrInvalidFleetQuery = errors.New("invalid fleet query")
+
+// ParseAnomalyMetric 空字符串默认按异常码次数排序
+func ParseAnomalyMetric(s string) (AnomalyMetric, error) {
+	switch m := AnomalyMetric(s); m {
+	case "":
+		return AnomalyMetricErrors, nil
+	case AnomalyMetricErrors, AnomalyMetricFailures, AnomalyMetricCPUP99, AnomalyMetricRAMP99, AnomalyMetricCriticalDiagnoses:
+		return m, nil
+	}
+	return "", fmt.Errorf("%w: unknown anomaly metric %q", ErrInvalidFleetQuery, s)
+}
+
+// FleetQuery 车队查询条件（时间范围左闭右开）
+type FleetQuery struct {
+	From            time.Time
+	To              time.Time
+	Platform        string
+	FirmwareVersion string
+	Interval        FleetInterval
+	Limit           int
+	Scope           DataScope // 调用方的数据范围（nil 不限）
+}
+
+// maxFleetWindow 单次查询的最大时间跨度
+const maxFleetWindow = 366 * 24 * time.Hour
+
+// Normalize 填充默认值并校验：默认最近 7 天、按小时
+func (q *FleetQuery) Normalize(now time.Time) error {
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-7 * 24 * time.Hour)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFleetQuery)
+	}
+	if q.To.Sub(q.From) > maxFleetWindow {
+		return fmt.Errorf("%w: window exceeds %s", ErrInvalidFleetQuery, maxFleetWindow)
+	}
+	switch q.Interval {
+	case "":
+		q.Interval = FleetIntervalHour
+	case FleetIntervalHour, FleetIntervalDay:
+	default:
+		return fmt.Errorf("%w: interval must be hour or day", ErrInvalidFleetQuery)
+	}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Limit > 200 {
+		q.Limit = 200
+	}
+	return nil
+}
+
+// ThroughputPoint 一个时间桶内进入终态的 Batch
+type ThroughputPoint struct {
+	Bucket             time.Time
+	Completed          int
+	Failed             int
+	FailureRate        float64
+	TotalFiles         int64
+	AvgDurationSeconds float64
+}
+
+// ErrorCodeRank 按平台 / 软件版本汇总的异常码
+type ErrorCodeRank struct {
+	Platform        string
+	FirmwareVersion string
+	Code            string
+	Occurrences     int64
+	Batches         int
+}
+
+// VehicleResourcePoint 单车每日资源使用趋势（P95 为当日各 Batch 的平均值，P99/Max 为当日最大值）
+type VehicleResourcePoint struct {
+	Day       time.Time
+	Batches   int
+	CPUAvg    float64
+	CPUP95    float64
+	CPUP99Max float64
+	CPUMax    float64
+	RAMAvgMB  float64
+	RAMP95MB  float64
+	RAMP99Max float64
+	RAMMaxMB  float64
+}
+
+// VehicleAnomaly 异常排行榜条目
+type VehicleAnomaly struct {
+	VIN      string
+	Platform string
+	Value    float64
+	Batches  int
+}
+
+// FleetRollupRepository 车队汇总表
+type FleetRollupRepository interface {
+	// Apply 累加一个 Batch 的贡献；已累加过返回 false（幂等，Kafka 至少一次投递）
+	Apply(ctx context.Context, rollup *BatchRollup) (bool, error)
+	// FindUnapplied 尚未累加的终态 Batch（补漏：事件丢失、汇总表上线前的存量数据）
+	FindUnapplied(ctx context.Context, limit int) ([]uuid.UUID, error)
+
+	Throughput(ctx context.Context, q FleetQuery) ([]ThroughputPoint, error)
+	TopErrorCodes(ctx context.Context, q FleetQuery) ([]ErrorCodeRank, error)
+	VehicleTrend(ctx context.Context, vin string, q FleetQuery) ([]VehicleResourcePoint, error)
+	Anomalies(ctx context.Context, metric AnomalyMetric, q FleetQuery) ([]VehicleAnomaly, error)
+}
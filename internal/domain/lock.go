@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrLockTimeout 在等待时间内没有拿到锁（锁被其他实例持有）
+	ErrLockTimeout = errors.New("batch lock wait timeout")
+	// ErrLockUnavailable 锁后端不可用（如 Redis 连接失败），调用方可以降级
+	ErrLockUnavailable = errors.New("batch lock backend unavailable")
+)
+
+// BatchLock 已获取的 Batch 锁
+type BatchLock interface {
+	// FencingToken 本次加锁分配的单调递增 token
+	FencingToken() int64
+	// Release 释放锁；opErr 为锁内操作的结果（数据库锁据此提交或回滚）
+	Release(ctx context.Context, opErr error) error
+}
+
+// BatchLocker 按 Batch 加互斥锁，防止补偿任务、HTTP 请求、不同 Consumer Group 并发修改同一个 Batch
+//
+// LockBatch 返回的 context 必须传给锁内的 Repository 调用：
+// 其中携带 fencing token（或数据库事务），保证过期的持有者无法覆盖新数据
+type BatchLocker interface {
+	LockBatch(ctx context.Context, batchID uuid.UUID) (context.Context, BatchLock, error)
+}
+
+// BatchLockStats 锁竞争统计
+type BatchLockStats struct {
+	Acquired  int64         `json:"acquired"`
+	Contended int64         `json:"contended"` // 需要等待才拿到（或超时）的次数
+	Timeouts  int64         `json:"timeouts"`
+	Fallbacks int64         `json:"fallbacks"` // 降级到数据库行锁的次数
+	TotalWait time.Duration `json:"total_wait_ns"`
+}
+
+// BatchLockResource Batch 锁对应的 fencing 资源名
+func BatchLockResource(batchID uuid.UUID) string {
+	return "batch:" + batchID.String()
+}
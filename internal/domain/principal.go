@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+// PrincipalKind 调用方类型
+type PrincipalKind string
+
+const (
+	PrincipalVehicle  PrincipalKind = "vehicle"  // 车端设备（mTLS 客户端证书或车辆 JWT）
+	PrincipalOperator PrincipalKind = "operator" // 运维 / 分析人员（OIDC JWT）
+)
+
+// Principal 已认证的调用方
+type Principal struct {
+	Kind     PrincipalKind
+	Subject  string   // 证书 / JWT 的主体
+	VIN      string   // 仅车辆
+	Name     string   // 仅运维人员（email 或用户名）
+	Roles    []string // 仅运维人员（JWT 的 roles / groups 声明）
+	TenantID string   // 所属租户（JWT 的 tenant 声明 / 证书 SAN urn:tenant:），未携带时为空，按 default 处理
+}
+
+type principalKey struct{}
+
+// WithPrincipal 将已认证的调用方附加到 context（由 HTTP 中间件设置，应用层据此做归属校验）
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext 未启用认证（本地开发、内部 Worker）时返回 nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// AuthorizeVIN 车辆只能操作自己 VIN 的数据；运维人员与未认证的内部调用不受此限制
+//
+// 证书或令牌泄露的影响局限在这一辆车，不能用来伪造其他车辆的日志
+func AuthorizeVIN(ctx context.Context, vin string) error {
+	p := PrincipalFromContext(ctx)
+	if p == nil || p.Kind != PrincipalVehicle {
+		return nil
+	}
+	if p.VIN != NormalizeVIN(vin) {
+		return fmt.Errorf("%w: vehicle %s cannot access VIN %s", ErrForbidden, p.VIN, vin)
+	}
+	return nil
+}
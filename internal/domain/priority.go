@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// BatchPriority Batch 处理优先级
+//
+// Kafka 没有消息级优先级，只能按 Topic 分车道：紧急 Batch 走独立 Topic，
+// 消费者在高优先级车道有积压时暂缓消费普通车道
+type BatchPriority string
+
+const (
+	BatchPriorityRoutine        BatchPriority = "routine"         // 夜间批量上传等常规诊断
+	BatchPriorityElevated       BatchPriority = "elevated"        // 用户报修、售后工单
+	BatchPrioritySafetyCritical BatchPriority = "safety_critical" // 安全事件（碰撞、制动/转向故障）
+)
+
+// ParseBatchPriority 解析优先级，空字符串视为 routine
+func ParseBatchPriority(s string) (BatchPriority, error) {
+	if s == "" {
+		return BatchPriorityRoutine, nil
+	}
+	p := BatchPriority(s)
+	if !p.IsValid() {
+		return "", fmt.Errorf("invalid batch priority: %s", s)
+	}
+	return p, nil
+}
+
+func (p BatchPriority) IsValid() bool {
+	switch p {
+	case BatchPriorityRoutine, BatchPriorityElevated, BatchPrioritySafetyCritical:
+		return true
+	}
+	return false
+}
+
+// IsUrgent 是否走高优先级车道
+func (p BatchPriority) IsUrgent() bool {
+	return p == BatchPriorityElevated || p == BatchPrioritySafetyCritical
+}
+
+// Rank 排序权重（越大越优先），未知值按 routine 处理
+func (p BatchPriority) Rank() int {
+	switch p {
+	case BatchPrioritySafetyCritical:
+		return 2
+	case BatchPriorityElevated:
+		return 1
+	}
+	return 0
+}
+
+func (p BatchPriority) String() string {
+	return string(p)
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrErasureBlocked VIN 的数据暂时不能删除（法务保留、Batch 仍在处理中）
+var ErrErasureBlocked = errors.New("erasure blocked")
+
+// minPseudonymKeySize 假名化密钥至少 256 位
+const minPseudonymKeySize = 32
+
+// vinTokenPrefix 令牌前缀，日志和数据里一眼能区分令牌与真实 VIN
+const vinTokenPrefix = "vt_"
+
+// Pseudonymizer 用带密钥的哈希（HMAC-SHA256）把 VIN 替换为稳定的令牌
+//
+// VIN 的取值空间很小，不加密钥的哈希可以穷举还原；密钥只在平台内部，下游（分析、LLM 供应商）无法反推 VIN，
+// 同一个 VIN 的令牌始终相同，跨 Batch 的统计和关联不受影响
+type Pseudonymizer struct {
+	key []byte
+}
+
+func NewPseudonymizer(key []byte) (*Pseudonymizer, error) {
+	if len(key) < minPseudonymKeySize {
+		return nil, fmt.Errorf("pseudonymization key must be at least %d bytes, got %d", minPseudonymKeySize, len(key))
+	}
+	return &Pseudonymizer{key: append([]byte(nil), key...)}, nil
+}
+
+// VINToken vt_ + HMAC 前 96 位（24 个十六进制字符）；VIN 先规范化，大小写与空白不影响结果
+func (p *Pseudonymizer) VINToken(vin string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(NormalizeVIN(vin)))
+	return vinTokenPrefix + hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// MaskVIN 日志中的 VIN 只保留 WMI（制造商代码）和末 4 位，便于人工排查又不暴露完整 VIN
+func MaskVIN(vin string) string {
+	vin = NormalizeVIN(vin)
+	if len(vin) <= 7 {
+		return strings.Repeat("*", len(vin))
+	}
+	return vin[:3] + strings.Repeat("*", len(vin)-7) + vin[len(vin)-4:]
+}
+
+// VINErasure 一次数据删除请求（GDPR 第 17 条）的执行记录
+//
+// 记录只保存 VIN 令牌：证明"删除已执行"，但记录本身不再包含 VIN
+type VINErasure struct {
+	ID             uuid.UUID
+	VINToken       string
+	RequestedBy    string
+	Reason         string
+	BatchesDeleted int
+	ObjectsDeleted int
+	ErasedAt       time.Time
+}
+
+func NewVINErasure(vinToken, requestedBy, reason string) *VINErasure {
+	return &VINErasure{
+		ID:          uuid.New(),
+		VINToken:    vinToken,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+	}
+}
+
+// ErasureRepository Batch 之外与 VIN 相关的数据（注册表、升级记录、车队汇总、审计、墓碑）
+type ErasureRepository interface {
+	// EraseVehicleRecords 在一个事务内删除或匿名化 VIN 的记录，并保存执行记录
+	EraseVehicleRecords(ctx context.Context, vin string, erasure *VINErasure) error
+	FindByToken(ctx context.Context, vinToken string) ([]*VINErasure, error)
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRateLimited 请求过于频繁（令牌桶耗尽）
+var ErrRateLimited = errors.New("rate limited")
+
+// RetryAfterError 可以稍后重试的拒绝（限流、每日配额），HTTP 层据此返回 Retry-After
+type RetryAfterError struct {
+	Err        error // ErrRateLimited / ErrQuotaExceeded 包装后的原因
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return e.Err.Error() }
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// TokenBucket 令牌桶：每分钟补充 PerMinute 个令牌，最多积攒 Burst 个（PerMinute 为 0 表示不限）
+type TokenBucket struct {
+	PerMinute float64 `json:"per_minute"`
+	Burst     int     `json:"burst"`
+}
+
+func (b TokenBucket) Unlimited() bool { return b.PerMinute <= 0 }
+
+// Capacity 桶容量（未配置 Burst 时为 1，即不允许突发）
+func (b TokenBucket) Capacity() int {
+	if b.Burst < 1 {
+		return 1
+	}
+	return b.Burst
+}
+
+// RateLimitPair 同一个接口的单车限流与租户整体限流
+type RateLimitPair struct {
+	Vehicle TokenBucket `json:"vehicle"`
+	Tenant  TokenBucket `json:"tenant"`
+}
+
+// RateLimits 按接口配置的限流
+type RateLimits struct {
+	CreateBatch RateLimitPair `json:"create_batch"`
+	UploadFile  RateLimitPair `json:"upload_file"`
+}
+
+// VehicleQuotas 租户内每辆车的每日配额（0 表示不限）
+type VehicleQuotas struct {
+	MaxBatchesPerDay     int64 `json:"max_batches_per_day"`
+	MaxUploadBytesPerDay int64 `json:"max_upload_bytes_per_day"`
+}
+
+// RateLimitRule 一个令牌桶（Key 区分车辆 / 租户与接口）
+type RateLimitRule struct {
+	Key    string
+	Bucket TokenBucket
+}
+
+// RateLimiter 分布式令牌桶
+//
+// Ingestor 多副本部署，同一辆车的请求会落到不同实例，计数必须集中在 Redis，且"读令牌、补充、扣减"原子执行
+type RateLimiter interface {
+	// Take 从所有桶各取 cost 个令牌：全部足够才扣减，否则都不扣减并返回需要等待的时间（> 0）
+	Take(ctx context.Context, rules []RateLimitRule, cost int) (time.Duration, error)
+}
+
+// DailyLimits 一个计数对象（车辆 / 租户）的每日上限（0 表示不限）
+type DailyLimits struct {
+	MaxBatches int64
+	MaxBytes   int64
+}
+
+// UsageCharge 一次记账涉及的计数对象
+type UsageCharge struct {
+	Key    string
+	Limits DailyLimits
+}
+
+// DailyUsage 当天已用量
+type DailyUsage struct {
+	Batches int64
+	Bytes   int64
+}
+
+// UsageLedger 每日用量计数
+type UsageLedger interface {
+	// Consume 原子地为所有计数对象记账：任一超限则都不记账，返回超限对象的 Key（未超限返回空串）
+	Consume(ctx context.Context, charges []UsageCharge, batches, bytes int64, ttl time.Duration) (string, error)
+	// Refund 退回已记账的用量（计数不会低于 0）
+	Refund(ctx context.Context, keys []string, batches, bytes int64) error
+	Get(ctx context.Context, key string) (DailyUsage, error)
+}
+
+// QuotaDay 每日配额按 UTC 自然日计算
+func QuotaDay(now time.Time) string {
+	return now.UTC().Format("2006-01-02")
+}
+
+// UntilQuotaReset 距离下一个 UTC 零点（每日配额重置）的时间
+func UntilQuotaReset(now time.Time) time.Duration {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return next.Sub(now)
+}
@@ -9,8 +9,10 @@ import (
 type Report struct {
 	ID 				uuid.UUID
 	BatchID 		uuid.UUID
+	TenantID		string
 	VehicleID		string
 	VIN				string
+	VehiclePlatform	string // 车型平台（诊断时按平台过滤 RAG 知识库）
 	Status      	BatchStatus
 	TotalFiles		int
 	ProcessedFiles	int
@@ -19,6 +21,9 @@ type Report struct {
 	
 	CPUStats        *CPUStats
 	RAMStats        *RAMStats
+	RecordCount     int                // 参与统计的记录总数
+	TopErrorCodes   []ErrorCodeSummary // 出现次数最多的异常码
+	ChartFiles      []string           // 图表对象路径（<batch>/charts/<name>.<png|svg>）
 
 
 	DiagnosisResult	string
@@ -29,17 +34,17 @@ type Report struct {
 	
 }
 type CPUStats struct {
-	AvgUtilization float64
-	P95Utilization float64
-	P99Utilization float64
-	MaxUtilization float64
+	AvgUtilization float64 `json:"avg_utilization"`
+	P95Utilization float64 `json:"p95_utilization"`
+	P99Utilization float64 `json:"p99_utilization"`
+	MaxUtilization float64 `json:"max_utilization"`
 }
 
 type RAMStats struct {
-	AvgUsageMB     float64
-	P95UsageMB     float64
-	P99UsageMB     float64
-	MaxUsageMB     float64
+	AvgUsageMB     float64 `json:"avg_usage_mb"`
+	P95UsageMB     float64 `json:"p95_usage_mb"`
+	P99UsageMB     float64 `json:"p99_usage_mb"`
+	MaxUsageMB     float64 `json:"max_usage_mb"`
 }
 
 
@@ -48,8 +53,10 @@ func NewReport(batch *Batch) *Report {
 	return &Report{
 		ID:             uuid.New(),
 		BatchID:        batch.ID,
+		TenantID:       batch.TenantID,
 		VehicleID:      batch.VehicleID,
 		VIN:            batch.VIN,
+		VehiclePlatform: batch.VehiclePlatform,
 		Status:         batch.Status,
 		TotalFiles:     batch.TotalFiles,
 		ProcessedFiles: batch.ProcessedFiles,
@@ -59,6 +66,26 @@ func NewReport(batch *Batch) *Report {
 		UpdatedAt:      now,
 	}
 }
+// ApplyStatistics 写入聚合阶段的统计结果
+func (r *Report) ApplyStatistics(cpu *CPUStats, ram *RAMStats, recordCount int, topErrorCodes []ErrorCodeSummary) {
+	r.CPUStats = cpu
+	r.RAMStats = ram
+	r.RecordCount = recordCount
+	r.TopErrorCodes = topErrorCodes
+	r.UpdatedAt = time.Now()
+}
+
+// ChartObjectPath 图表在对象存储中的路径（确定性路径，重新聚合时覆盖旧图）
+func ChartObjectPath(tenantID string, batchID uuid.UUID, filename string) string {
+	return BatchObjectPrefix(tenantID, batchID) + "charts/" + filename
+}
+
+// SetChartFiles 记录本次聚合生成的图表
+func (r *Report) SetChartFiles(paths []string) {
+	r.ChartFiles = paths
+	r.UpdatedAt = time.Now()
+}
+
 type ReportRepository interface {
 	Save(ctx context.Context, report *Report) error
 	FindByID(ctx context.Context, id uuid.UUID) (*Report, error)
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -13,9 +14,14 @@ type ListOptions struct {
 	SortBy		string
 	SortOrder	string
 
-	vehicleID	*string
+	VehicleID	*string
 	VIN			*string
 	Status 		*string
+	Priority	*string
+	Platform	*string
+
+	TenantID	*string   // 调用方所属租户（内部调用为 nil，不过滤）
+	Scope		DataScope // 调用方的数据范围（nil 不限）
 }
 type BatchRepository interface {
 	Save(ctx context.Context, batch *Batch) error
@@ -24,10 +30,9 @@ type BatchRepository interface {
 	FindByStatus(ctx context.Context, status BatchStatus) ([]*Batch, error)
 	List(ctx context.Context, opts ListOptions) ([]*Batch, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	// FindStuckBatches 查询状态卡住的批次（用于补偿任务）
-	// - scattering 状态超过 5 分钟未更新
-	// - diagnosing 状态超过 10 分钟未更新
-	FindStuckBatches(ctx context.Context) ([]*Batch, error)
+	// FindStuckBatches 查询可能卡住的批次（用于补偿任务）
+	// 返回所有非终态且超过 olderThan 未更新的批次，精确的分阶段 SLA 判断由 SLAPolicy 完成
+	FindStuckBatches(ctx context.Context, olderThan time.Duration) ([]*Batch, error)
 }
 
 type FileRepository interface {
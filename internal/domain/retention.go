@@ -0,0 +1,260 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionStage 数据生命周期的清理阶段（按顺序推进）
+type RetentionStage string
+
+const (
+	RetentionStageRawLogs RetentionStage = "raw_logs" // 原始 rec 文件（<batch>/<file_id>）
+	RetentionStageDerived RetentionStage = "derived"  // 解析产物与图表（<batch>/parsed/、<batch>/charts/）
+	RetentionStageBatch   RetentionStage = "batch"    // 整个 Batch：剩余对象 + 数据库记录（报告、诊断随外键级联删除）
+)
+
+// RetentionRule 各类数据的保留时长，0 表示永久保留
+type RetentionRule struct {
+	RawLogs time.Duration
+	Derived time.Duration
+	Batch   time.Duration
+}
+
+// RetentionPolicy 按终态 + 车型平台配置保留时长（结构与 SLAPolicy 一致：平台覆盖优先）
+//
+// 保留期从进入终态的时间开始计算；处理中的 Batch 由补偿任务负责推进或标记失败，不会被删除
+type RetentionPolicy struct {
+	Default   map[BatchStatus]RetentionRule
+	Platforms map[string]map[BatchStatus]RetentionRule
+}
+
+// DefaultRetentionPolicy 默认策略：原始日志 30 天，解析产物 90 天，报告 2 年；失败的 Batch 7 天后整体删除
+func DefaultRetentionPolicy() *RetentionPolicy {
+	return &RetentionPolicy{
+		Default: map[BatchStatus]RetentionRule{
+			BatchStatusCompleted: {RawLogs: 30 * 24 * time.Hour, Derived: 90 * 24 * time.Hour, Batch: 2 * 365 * 24 * time.Hour},
+			BatchStatusFailed:    {RawLogs: 7 * 24 * time.Hour, Derived: 7 * 24 * time.Hour, Batch: 7 * 24 * time.Hour},
+		},
+		Platforms: map[string]map[BatchStatus]RetentionRule{},
+	}
+}
+
+// RuleFor 解析某个平台在某个终态下的保留规则（平台覆盖优先）
+func (p *RetentionPolicy) RuleFor(platform string, status BatchStatus) (RetentionRule, bool) {
+	if rules, ok := p.Platforms[platform]; ok && platform != "" {
+		if rule, ok := rules[status]; ok {
+			return rule, true
+		}
+	}
+	rule, ok := p.Default[status]
+	return rule, ok
+}
+
+// RetentionState 一个 Batch 的清理进度
+//
+// NextActionAt 为下一个阶段的到期时间：调度只查询到期的 Batch，每个 Batch 只在到期时被处理，
+// 而不是每轮都扫描所有历史 Batch。修改策略后已计算的 NextActionAt 不会自动重算
+// （可以将其置空触发重新评估）
+type RetentionState struct {
+	BatchID         uuid.UUID
+	RawPurgedAt     *time.Time
+	DerivedPurgedAt *time.Time
+	NextActionAt    *time.Time
+}
+
+// Done 某个阶段是否已执行
+func (s *RetentionState) Done(stage RetentionStage) bool {
+	switch stage {
+	case RetentionStageRawLogs:
+		return s.RawPurgedAt != nil
+	case RetentionStageDerived:
+		return s.DerivedPurgedAt != nil
+	}
+	return false
+}
+
+// MarkDone 记录阶段完成时间
+func (s *RetentionState) MarkDone(stage RetentionStage, at time.Time) {
+	switch stage {
+	case RetentionStageRawLogs:
+		s.RawPurgedAt = &at
+	case RetentionStageDerived:
+		s.DerivedPurgedAt = &at
+	}
+}
+
+// RetentionCandidate 到期（或从未评估过）的终态 Batch
+type RetentionCandidate struct {
+	Batch *Batch
+	State RetentionState
+}
+
+// retentionBase 保留期起点：完成时间，缺失时用最后一次状态变更时间
+func retentionBase(batch *Batch) time.Time {
+	if batch.CompletedAt != nil {
+		return *batch.CompletedAt
+	}
+	return batch.UpdatedAt
+}
+
+// Evaluate 返回当前已到期且尚未执行的阶段，以及下一个阶段的到期时间（没有后续阶段时为 nil）
+//
+// Batch 阶段到期时只返回 Batch（整体删除已经包含前两个阶段）
+func (p *RetentionPolicy) Evaluate(batch *Batch, state RetentionState, now time.Time) ([]RetentionStage, *time.Time) {
+	if !batch.Status.IsTerminal() {
+		return nil, nil
+	}
+	rule, ok := p.RuleFor(batch.VehiclePlatform, batch.Status)
+	if !ok {
+		return nil, nil
+	}
+	base := retentionBase(batch)
+
+	if rule.Batch > 0 && !now.Before(base.Add(rule.Batch)) {
+		return []RetentionStage{RetentionStageBatch}, nil
+	}
+
+	var due []RetentionStage
+	var next *time.Time
+	consider := func(stage RetentionStage, keep time.Duration) {
+		if keep <= 0 || state.Done(stage) {
+			return
+		}
+		at := base.Add(keep)
+		if !now.Before(at) {
+			due = append(due, stage)
+			return
+		}
+		if next == nil || at.Before(*next) {
+			next = &at
+		}
+	}
+	consider(RetentionStageRawLogs, rule.RawLogs)
+	consider(RetentionStageDerived, rule.Derived)
+	if rule.Batch > 0 {
+		at := base.Add(rule.Batch)
+		if next == nil || at.Before(*next) {
+			next = &at
+		}
+	}
+	return due, next
+}
+
+// ErrLegalHoldReleased 重复解除法务保留
+var ErrLegalHoldReleased = errors.New("legal hold already released")
+
+// LegalHoldScope 法务保留的作用范围
+type LegalHoldScope string
+
+const (
+	LegalHoldScopeVIN   LegalHoldScope = "vin"   // 该车辆的所有 Batch
+	LegalHoldScopeBatch LegalHoldScope = "batch" // 单个 Batch
+)
+
+// LegalHold 法务保留：生效期间对应数据不会被生命周期任务清理
+type LegalHold struct {
+	ID         uuid.UUID
+	Scope      LegalHoldScope
+	Value      string // VIN 或 Batch ID
+	Reason     string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ReleasedAt *time.Time
+}
+
+func NewLegalHold(scope LegalHoldScope, value, reason, createdBy string) (*LegalHold, error) {
+	value = strings.TrimSpace(value)
+	switch scope {
+	case LegalHoldScopeVIN:
+		if value == "" {
+			return nil, errors.New("legal hold vin is empty")
+		}
+		value = strings.ToUpper(value)
+	case LegalHoldScopeBatch:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errors.New("legal hold batch id is invalid")
+		}
+		value = id.String()
+	default:
+		return nil, errors.New("legal hold scope must be vin or batch")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("legal hold reason is required")
+	}
+	return &LegalHold{
+		ID:        uuid.New(),
+		Scope:     scope,
+		Value:     value,
+		Reason:    reason,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Active 是否仍在生效
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == nil
+}
+
+// Release 解除保留（保留记录用于审计，不删除）
+func (h *LegalHold) Release() error {
+	if !h.Active() {
+		return ErrLegalHoldReleased
+	}
+	now := time.Now()
+	h.ReleasedAt = &now
+	return nil
+}
+
+// BatchTombstone Batch 被生命周期任务删除后留下的墓碑记录（审计、回答“这份数据去哪了”）
+type BatchTombstone struct {
+	BatchID         uuid.UUID
+	VehicleID       string
+	VIN             string
+	VehiclePlatform string
+	Status          BatchStatus
+	TotalFiles      int
+	ObjectsDeleted  int
+	Reason          string
+	BatchCreatedAt  time.Time
+	DeletedAt       time.Time
+}
+
+func NewBatchTombstone(batch *Batch, objectsDeleted int, reason string) *BatchTombstone {
+	return &BatchTombstone{
+		BatchID:         batch.ID,
+		VehicleID:       batch.VehicleID,
+		VIN:             batch.VIN,
+		VehiclePlatform: batch.VehiclePlatform,
+		Status:          batch.Status,
+		TotalFiles:      batch.TotalFiles,
+		ObjectsDeleted:  objectsDeleted,
+		Reason:          reason,
+		BatchCreatedAt:  batch.CreatedAt,
+		DeletedAt:       time.Now(),
+	}
+}
+
+// RetentionRepository 生命周期任务的持久化
+type RetentionRepository interface {
+	// FindDue 到期（NextActionAt <= now 或从未评估）且不在法务保留中的终态 Batch，按完成时间升序
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*RetentionCandidate, error)
+	SaveState(ctx context.Context, state *RetentionState) error
+	SaveTombstone(ctx context.Context, tombstone *BatchTombstone) error
+	FindTombstone(ctx context.Context, batchID uuid.UUID) (*BatchTombstone, error)
+}
+
+// LegalHoldRepository 法务保留记录
+type LegalHoldRepository interface {
+	Save(ctx context.Context, hold *LegalHold) error
+	FindByID(ctx context.Context, id uuid.UUID) (*LegalHold, error)
+	ListActive(ctx context.Context) ([]*LegalHold, error)
+	// IsHeld Batch 本身或其 VIN 是否有生效中的保留
+	IsHeld(ctx context.Context, batchID uuid.UUID, vin string) (bool, error)
+}
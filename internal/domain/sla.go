@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"time"
+)
+
+// StageSLA 单个阶段的超时与升级策略
+//
+// 超时 = Timeout + PerFile * TotalFiles（大 Batch 需要更长的处理时间）
+// 超时后先重试 MaxRetries 次，仍未推进则标记为 failed
+type StageSLA struct {
+	Timeout    time.Duration
+	PerFile    time.Duration
+	MaxRetries int
+}
+
+// TimeoutFor 按 Batch 大小缩放后的超时时间
+func (s StageSLA) TimeoutFor(totalFiles int) time.Duration {
+	if totalFiles < 0 {
+		totalFiles = 0
+	}
+	return s.Timeout + s.PerFile*time.Duration(totalFiles)
+}
+
+// SLAPolicy 补偿任务的分阶段 SLA 配置
+// - Default: 全局默认，覆盖所有非终态
+// - Platforms: 按车型平台覆盖（未配置的阶段回退到 Default）
+// - PriorityScale: 按优先级缩放超时（安全事件卡住要更早发现并重试）
+type SLAPolicy struct {
+	Default       map[BatchStatus]StageSLA
+	Platforms     map[string]map[BatchStatus]StageSLA
+	PriorityScale map[BatchPriority]float64
+}
+
+// DefaultSLAPolicy 默认 SLA（scattering 5 分钟、diagnosing 10 分钟与旧版硬编码保持一致）
+func DefaultSLAPolicy() *SLAPolicy {
+	return &SLAPolicy{
+		Default: map[BatchStatus]StageSLA{
+			BatchStatusPending:    {Timeout: 24 * time.Hour, MaxRetries: 0}, // 客户端放弃上传
+			BatchStatusUploaded:   {Timeout: 2 * time.Minute, MaxRetries: 3},
+			BatchStatusScattering: {Timeout: 5 * time.Minute, PerFile: 10 * time.Second, MaxRetries: 2},
+			BatchStatusScattered:  {Timeout: 2 * time.Minute, MaxRetries: 3},
+			BatchStatusGathering:  {Timeout: 5 * time.Minute, PerFile: 5 * time.Second, MaxRetries: 2},
+			BatchStatusGathered:   {Timeout: 2 * time.Minute, MaxRetries: 3},
+			BatchStatusDiagnosing: {Timeout: 10 * time.Minute, MaxRetries: 0},
+		},
+		Platforms: map[string]map[BatchStatus]StageSLA{},
+		PriorityScale: map[BatchPriority]float64{
+			BatchPriorityRoutine:        1,
+			BatchPriorityElevated:       0.5,
+			BatchPrioritySafetyCritical: 0.25,
+		},
+	}
+}
+
+// StageFor 解析某个平台在某个状态下的 SLA（平台覆盖优先）
+func (p *SLAPolicy) StageFor(platform string, status BatchStatus) (StageSLA, bool) {
+	if stages, ok := p.Platforms[platform]; ok && platform != "" {
+		if stage, ok := stages[status]; ok {
+			return stage, true
+		}
+	}
+	stage, ok := p.Default[status]
+	return stage, ok
+}
+
+// Deadline Batch 在当前状态下的截止时间；终态或未配置的状态返回 false
+func (p *SLAPolicy) Deadline(batch *Batch) (time.Time, bool) {
+	if batch.Status.IsTerminal() {
+		return time.Time{}, false
+	}
+	stage, ok := p.StageFor(batch.VehiclePlatform, batch.Status)
+	if !ok || stage.Timeout <= 0 {
+		return time.Time{}, false
+	}
+	return batch.UpdatedAt.Add(p.scale(batch.Priority, stage.TimeoutFor(batch.TotalFiles))), true
+}
+
+// scale 按优先级缩放超时；未配置的优先级不缩放
+func (p *SLAPolicy) scale(priority BatchPriority, timeout time.Duration) time.Duration {
+	factor, ok := p.PriorityScale[priority]
+	if !ok || factor <= 0 {
+		return timeout
+	}
+	return time.Duration(float64(timeout) * factor)
+}
+
+// IsStuck Batch 是否已超过当前阶段的 SLA
+func (p *SLAPolicy) IsStuck(batch *Batch, now time.Time) bool {
+	deadline, ok := p.Deadline(batch)
+	return ok && now.After(deadline)
+}
+
+// MinTimeout 所有阶段中最短的超时（已按最小优先级系数缩放），用作数据库预筛选条件
+func (p *SLAPolicy) MinTimeout() time.Duration {
+	var min time.Duration
+	visit := func(stage StageSLA) {
+		if stage.Timeout <= 0 {
+			return
+		}
+		if min == 0 || stage.Timeout < min {
+			min = stage.Timeout
+		}
+	}
+	for _, stage := range p.Default {
+		visit(stage)
+	}
+	for _, stages := range p.Platforms {
+		for _, stage := range stages {
+			visit(stage)
+		}
+	}
+	minFactor := 1.0
+	for _, factor := range p.PriorityScale {
+		if factor > 0 && factor < minFactor {
+			minFactor = factor
+		}
+	}
+	return time.Duration(float64(min) * minFactor)
+}
@@ -37,13 +37,33 @@ func (s BatchStatus) IsValid() bool {
 		return false
 	}
 }
+// IsTerminal 是否为终态（completed / failed），终态不参与补偿
+func (s BatchStatus) IsTerminal() bool {
+	return s == BatchStatusCompleted || s == BatchStatusFailed
+}
+
+// NonTerminalBatchStatuses 所有非终态（补偿任务需要覆盖的状态）
+func NonTerminalBatchStatuses() []BatchStatus {
+	return []BatchStatus{
+		BatchStatusPending,
+		BatchStatusUploaded,
+		BatchStatusScattering,
+		BatchStatusScattered,
+		BatchStatusGathering,
+		BatchStatusGathered,
+		BatchStatusDiagnosing,
+	}
+}
+
 func (s BatchStatus) CanTransitionTo(newStatus BatchStatus) bool {
 	var batchStatusTransitions = map[BatchStatus][]BatchStatus{
 		BatchStatusPending: {
 			BatchStatusUploaded,
+			BatchStatusFailed, // 上传超时未完成
 		},
 		BatchStatusUploaded: {
 			BatchStatusScattering,
+			BatchStatusFailed,
 		},
 		BatchStatusScattering: {
 			BatchStatusScattered,
@@ -51,6 +71,7 @@ func (s BatchStatus) CanTransitionTo(newStatus BatchStatus) bool {
 		},
 		BatchStatusScattered: {
 			BatchStatusGathering,
+			BatchStatusFailed,
 		},
 		BatchStatusGathering: {
 			BatchStatusGathered,
@@ -58,6 +79,7 @@ func (s BatchStatus) CanTransitionTo(newStatus BatchStatus) bool {
 		},
 		BatchStatusGathered: {
 			BatchStatusDiagnosing,
+			BatchStatusFailed,
 		},
 		BatchStatusDiagnosing: {
 			BatchStatusCompleted,
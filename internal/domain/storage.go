@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrObjectNotFound 对象不存在（调用方用 errors.Is 判断，与存储后端无关）
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo 对象元数据
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+}
+
+// ObjectStore 对象存储抽象（MinIO / 本地文件系统）
+//
+// 开发和 CI 使用本地文件系统实现即可跑通上传 → 解析 → 聚合，生产换回 MinIO 只改配置
+type ObjectStore interface {
+	// PutObject size = -1 表示长度未知（流式上传）
+	PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+	// GetObject 对象不存在时返回 ErrObjectNotFound（调用方负责 Close）
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	// GetObjectRange 读取 [offset, offset+length)，length <= 0 表示读到末尾
+	GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *ObjectInfo, error)
+	StatObject(ctx context.Context, key string) (*ObjectInfo, error)
+	// ListObjects recursive = false 时只列一层，子目录以 "/" 结尾返回
+	ListObjects(ctx context.Context, prefix string, recursive bool) ([]ObjectInfo, error)
+	// RemoveObjects 不存在的对象视为删除成功
+	RemoveObjects(ctx context.Context, keys []string) error
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+	// PresignedGetObject 限时下载链接；downloadName 非空时按附件下载
+	PresignedGetObject(ctx context.Context, key string, expiry time.Duration, downloadName string) (string, error)
+}
+
+// ObjectStorePinger 可选接口：检查存储后端可用（就绪检查使用；加密、链路追踪等装饰器转发给内层）
+type ObjectStorePinger interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTenantID 单租户部署与多租户改造之前的存量数据都属于 default 租户
+const DefaultTenantID = "default"
+
+var (
+	ErrInvalidTenant   = errors.New("invalid tenant")
+	ErrTenantNotFound  = errors.New("tenant not found")
+	ErrTenantSuspended = errors.New("tenant suspended")
+	ErrQuotaExceeded   = errors.New("tenant quota exceeded")
+	ErrFileTooLarge    = errors.New("file exceeds tenant size limit")
+)
+
+// tenantIDPattern 租户 ID 会出现在 Redis Key、对象路径和 Kafka 消息里，只允许小写字母、数字和连字符
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func ValidateTenantID(id string) error {
+	if !tenantIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+	}
+	return nil
+}
+
+// TenantQuotas 租户配额（0 表示不限）
+type TenantQuotas struct {
+	MaxBatchesPerDay     int64         `json:"max_batches_per_day"`      // 每天（UTC）创建的 Batch 数
+	MaxUploadBytesPerDay int64         `json:"max_upload_bytes_per_day"` // 每天（UTC）上传的字节数
+	MaxFileSizeBytes     int64         `json:"max_file_size_bytes"`      // 单个上传文件大小
+	MaxStorageBytes      int64         `json:"max_storage_bytes"`        // 原始文件总大小（已被保留策略删除的不计）
+	Vehicle              VehicleQuotas `json:"vehicle"`                  // 租户内每辆车的每日配额
+	RateLimits           RateLimits    `json:"rate_limits"`
+}
+
+// DailyLimits 租户整体的每日上限
+func (q TenantQuotas) DailyLimits() DailyLimits {
+	return DailyLimits{MaxBatches: q.MaxBatchesPerDay, MaxBytes: q.MaxUploadBytesPerDay}
+}
+
+// VehicleDailyLimits 单车的每日上限
+func (q TenantQuotas) VehicleDailyLimits() DailyLimits {
+	return DailyLimits{MaxBatches: q.Vehicle.MaxBatchesPerDay, MaxBytes: q.Vehicle.MaxUploadBytesPerDay}
+}
+
+// Tenant 一个 OEM 客户
+type Tenant struct {
+	ID        string
+	Name      string
+	Suspended bool // 暂停后拒绝该租户的所有 API 请求（欠费、合同到期），已有数据保留
+	Quotas    TenantQuotas
+}
+
+// CheckUpload 上传前校验单文件大小与存储总量
+func (t *Tenant) CheckUpload(fileSize, storedBytes int64) error {
+	if t.Quotas.MaxFileSizeBytes > 0 && fileSize > t.Quotas.MaxFileSizeBytes {
+		return fmt.Errorf("%w: %d bytes (limit %d)", ErrFileTooLarge, fileSize, t.Quotas.MaxFileSizeBytes)
+	}
+	if t.Quotas.MaxStorageBytes > 0 && storedBytes+fileSize > t.Quotas.MaxStorageBytes {
+		return fmt.Errorf("%w: tenant %s storage would reach %d bytes (limit %d)",
+			ErrQuotaExceeded, t.ID, storedBytes+fileSize, t.Quotas.MaxStorageBytes)
+	}
+	return nil
+}
+
+// TenantRegistry 租户配置（由配置文件加载，进程内只读）
+type TenantRegistry struct {
+	tenants map[string]*Tenant
+}
+
+// NewTenantRegistry 总是包含 default 租户（配置中没有时不限配额）
+func NewTenantRegistry(tenants []*Tenant) (*TenantRegistry, error) {
+	r := &TenantRegistry{tenants: make(map[string]*Tenant, len(tenants)+1)}
+	for _, t := range tenants {
+		if err := ValidateTenantID(t.ID); err != nil {
+			return nil, err
+		}
+		if _, dup := r.tenants[t.ID]; dup {
+			return nil, fmt.Errorf("%w: duplicate tenant %q", ErrInvalidTenant, t.ID)
+		}
+		r.tenants[t.ID] = t
+	}
+	if _, ok := r.tenants[DefaultTenantID]; !ok {
+		r.tenants[DefaultTenantID] = &Tenant{ID: DefaultTenantID, Name: "Default"}
+	}
+	return r, nil
+}
+
+// Get 未配置的租户返回 ErrTenantNotFound，已暂停的返回 ErrTenantSuspended
+func (r *TenantRegistry) Get(id string) (*Tenant, error) {
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, id)
+	}
+	if t.Suspended {
+		return nil, fmt.Errorf("%w: %s", ErrTenantSuspended, id)
+	}
+	return t, nil
+}
+
+type tenantKey struct{}
+
+// WithTenant 将调用方所属租户附加到 context（由 HTTP 中间件设置；Worker 按消息中的 tenant_id 设置）
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext ok = false 表示内部调用（Worker、补偿任务），不做租户隔离校验
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// AuthorizeTenant 调用方只能访问本租户的数据
+//
+// RLS 只保护数据库，Redis 缓存和对象存储按 Key 直接访问，必须在应用层校验；两层互为兜底
+func AuthorizeTenant(ctx context.Context, tenantID string) error {
+	caller, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if normalizeTenant(tenantID) != caller {
+		return fmt.Errorf("%w: resource belongs to another tenant", ErrForbidden)
+	}
+	return nil
+}
+
+// normalizeTenant 多租户改造之前的事件、缓存中没有租户，视为 default
+func normalizeTenant(tenantID string) string {
+	if tenantID == "" {
+		return DefaultTenantID
+	}
+	return tenantID
+}
+
+// TenantCacheKey Redis Key 加租户前缀：tenant:<id>:<key>
+//
+// default 租户沿用不带前缀的旧 Key，升级时进行中的 Barrier 和已有缓存不会失效
+func TenantCacheKey(tenantID, key string) string {
+	tenantID = normalizeTenant(tenantID)
+	if tenantID == DefaultTenantID {
+		return key
+	}
+	return "tenant:" + tenantID + ":" + key
+}
+
+// BatchObjectPrefix Batch 在对象存储中的前缀：tenants/<id>/<batch>/（default 租户沿用 <batch>/）
+//
+// 原始文件直接位于该前缀下，parsed/ 与 charts/ 子目录是派生数据
+func BatchObjectPrefix(tenantID string, batchID uuid.UUID) string {
+	tenantID = normalizeTenant(tenantID)
+	if tenantID == DefaultTenantID {
+		return batchID.String() + "/"
+	}
+	return "tenants/" + tenantID + "/" + batchID.String() + "/"
+}
+
+// RawObjectPath 原始上传文件在对象存储中的路径
+func RawObjectPath(tenantID string, batchID, fileID uuid.UUID) string {
+	return BatchObjectPrefix(tenantID, batchID) + fileID.String()
+}
+
+// TenantFromObjectKey 由对象路径反推所属租户（BatchObjectPrefix 的逆运算，不带 tenants/ 前缀的属于 default 租户）
+func TenantFromObjectKey(key string) string {
+	rest, ok := strings.CutPrefix(key, "tenants/")
+	if !ok {
+		return DefaultTenantID
+	}
+	tenantID, _, _ := strings.Cut(rest, "/")
+	return normalizeTenant(tenantID)
+}
+
+// TenantUsageRepository 存储总量统计（每日用量见 UsageLedger）
+type TenantUsageRepository interface {
+	// StorageBytes 租户现存原始文件的总大小
+	StorageBytes(ctx context.Context, tenantID string) (int64, error)
+}
+
+// TenantScoper 以租户身份执行数据库操作（Postgres 实现为切换到受 RLS 约束的角色）
+type TenantScoper interface {
+	RunAsTenant(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error
+}
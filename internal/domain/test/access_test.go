@@ -0,0 +1,75 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestRole_Permissions - 高角色包含低角色的全部权限
+func TestRole_Permissions(t *testing.T) {
+	viewer := domain.RoleViewer.Permissions()
+	assert.True(t, viewer[domain.PermBatchesRead])
+	assert.False(t, viewer[domain.PermReportsRead], "viewers cannot read diagnoses")
+
+	analyst := domain.RoleAnalyst.Permissions()
+	assert.True(t, analyst[domain.PermBatchesRead])
+	assert.True(t, analyst[domain.PermReportsRead])
+	assert.False(t, analyst[domain.PermCampaignsWrite])
+
+	admin := domain.RoleAdmin.Permissions()
+	assert.True(t, admin[domain.PermCampaignsWrite])
+	assert.True(t, admin[domain.PermAuditRead])
+
+	_, err := domain.ParseRole("root")
+	assert.ErrorIs(t, err, domain.ErrInvalidAccessPolicy)
+}
+
+// TestAccessPolicy_Authorize - 多条授权按规则整体取"或"，任一不限范围则整体不限
+func TestAccessPolicy_Authorize(t *testing.T) {
+	policy := &domain.AccessPolicy{Grants: []domain.AccessGrant{
+		{Claim: "north-ops", Role: domain.RoleAnalyst, Scope: domain.AccessScope{Fleets: []string{"north"}, Platforms: []string{"J7"}}},
+		{Claim: "south-ops", Role: domain.RoleViewer, Scope: domain.AccessScope{Fleets: []string{"south"}, Platforms: []string{"J6"}}},
+		{Claim: "j6-quality", Role: domain.RoleAnalyst, Scope: domain.AccessScope{Platforms: []string{"J6"}}},
+		{Claim: "admins", Role: domain.RoleAdmin},
+	}}
+	operator := func(roles ...string) *domain.Principal {
+		return &domain.Principal{Kind: domain.PrincipalOperator, Subject: "u-1", Roles: roles}
+	}
+
+	access := policy.Authorize(operator("north-ops", "south-ops"), domain.PermBatchesRead)
+	assert.True(t, access.Allowed)
+	assert.True(t, access.Scope.Allows("J7", "north"))
+	assert.True(t, access.Scope.Allows("J6", "south"))
+	assert.False(t, access.Scope.Allows("J6", "north"), "scopes must not be cross-multiplied")
+	assert.False(t, access.Scope.AllowsPlatform("J7"), "fleet-scoped grants do not cover platform aggregates")
+	assert.Empty(t, access.Scope.Platforms())
+
+	// south-ops 只是 viewer：读报告时只剩 north 的授权
+	access = policy.Authorize(operator("north-ops", "south-ops"), domain.PermReportsRead)
+	assert.True(t, access.Allowed)
+	assert.False(t, access.Scope.Allows("J6", "south"))
+
+	access = policy.Authorize(operator("j6-quality"), domain.PermFirmwareRead)
+	assert.True(t, access.Scope.AllowsPlatform("J6"))
+	assert.False(t, access.Scope.AllowsPlatform("J7"))
+	assert.Equal(t, []string{"J6"}, access.Scope.Platforms())
+
+	access = policy.Authorize(operator("north-ops", "admins"), domain.PermBatchesRead)
+	assert.True(t, access.Allowed)
+	assert.False(t, access.Scope.Restricted(), "an unrestricted grant lifts all scopes")
+
+	assert.False(t, policy.Authorize(operator("north-ops"), domain.PermCampaignsWrite).Allowed)
+	assert.False(t, policy.Authorize(operator("unknown"), domain.PermBatchesRead).Allowed)
+}
+
+// TestDefaultAccessPolicy - 默认策略：JWT 角色名直接对应平台角色
+func TestDefaultAccessPolicy(t *testing.T) {
+	policy := domain.DefaultAccessPolicy()
+	p := &domain.Principal{Kind: domain.PrincipalOperator, Roles: []string{"analyst"}}
+	assert.True(t, policy.Authorize(p, domain.PermReportsRead).Allowed)
+	assert.False(t, policy.Authorize(p, domain.PermVehiclesWrite).Allowed)
+	assert.Nil(t, policy.Authorize(p, domain.PermReportsRead).Scope)
+}
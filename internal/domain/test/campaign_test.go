@@ -0,0 +1,126 @@
+package domain_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+func newTestCampaign(t *testing.T, percents ...int) *domain.Campaign {
+	waves := make([]domain.RolloutWave, 0, len(percents))
+	for _, p := range percents {
+		waves = append(waves, domain.RolloutWave{Percent: p})
+	}
+	c, err := domain.NewCampaign("J7 2025.07", "J7", "J7-2025.07", domain.CohortSelector{}, waves, domain.DefaultGatePolicy(), "release-manager")
+	require.NoError(t, err)
+	return c
+}
+
+// TestCampaign_WavesAndLifecycle - 波次校验、确定性分桶与状态流转
+func TestCampaign_WavesAndLifecycle(t *testing.T) {
+	for _, waves := range [][]domain.RolloutWave{
+		{{Percent: 10}, {Percent: 10}, {Percent: 100}}, // 不递增
+		{{Percent: 10}, {Percent: 50}},                 // 没有覆盖 100%
+	} {
+		_, err := domain.NewCampaign("c", "J7", "v2", domain.CohortSelector{}, waves, domain.DefaultGatePolicy(), "")
+		assert.ErrorIs(t, err, domain.ErrInvalidCampaign)
+	}
+
+	c := newTestCampaign(t, 10, 50, 100)
+	perWave := make([]int, len(c.Waves))
+	for i := 0; i < 10000; i++ {
+		vin := fmt.Sprintf("VIN%014d", i)
+		wave := c.WaveFor(vin)
+		assert.Equal(t, wave, c.WaveFor(vin), "assignment must be deterministic")
+		perWave[wave]++
+	}
+	assert.InDelta(t, 1000, perWave[0], 150)
+	assert.InDelta(t, 4000, perWave[1], 300)
+	assert.InDelta(t, 5000, perWave[2], 300)
+
+	assert.ErrorIs(t, c.Pause("too early"), domain.ErrInvalidTransition)
+	require.NoError(t, c.Start())
+	assert.Equal(t, 0, c.CurrentWave)
+	require.NoError(t, c.Pause("gate failed"))
+	require.NoError(t, c.Resume())
+
+	next, err := c.AdvanceWave()
+	require.NoError(t, err)
+	assert.Equal(t, 1, next)
+	next, err = c.AdvanceWave()
+	require.NoError(t, err)
+	assert.Equal(t, 2, next)
+	next, err = c.AdvanceWave()
+	require.NoError(t, err)
+	assert.Equal(t, -1, next)
+	assert.Equal(t, domain.CampaignStatusCompleted, c.Status)
+	assert.ErrorIs(t, c.Abort("late"), domain.ErrInvalidTransition)
+}
+
+// TestGatePolicy_Evaluate - critical 诊断立即失败，比率阈值在样本量足够后才判断
+func TestGatePolicy_Evaluate(t *testing.T) {
+	policy := domain.DefaultGatePolicy()
+	now := time.Now()
+	soaked := now.Add(-2 * policy.SoakDuration)
+
+	healthy := domain.GateMetrics{
+		Installed: 100, Batches: 50, FailedBatches: 1,
+		Records: 100000, ErrorOccurrences: 110,
+		ControlRecords: 100000, ControlErrors: 100,
+	}
+	decision, _ := policy.Evaluate(healthy, soaked, now)
+	assert.Equal(t, domain.GatePass, decision)
+
+	decision, _ = policy.Evaluate(healthy, now.Add(-time.Hour), now)
+	assert.Equal(t, domain.GateHold, decision, "soak period not over")
+
+	few := healthy
+	few.Batches, few.FailedBatches = 5, 0
+	decision, _ = policy.Evaluate(few, soaked, now)
+	assert.Equal(t, domain.GateHold, decision, "not enough batches")
+
+	critical := few
+	critical.CriticalDiagnoses = 1
+	decision, reasons := policy.Evaluate(critical, now, now)
+	assert.Equal(t, domain.GateFail, decision, "critical diagnoses fail immediately")
+	assert.Len(t, reasons, 1)
+
+	noisy := healthy
+	noisy.ErrorOccurrences = 150 // +50% vs control
+	decision, _ = policy.Evaluate(noisy, soaked, now)
+	assert.Equal(t, domain.GateFail, decision)
+
+	flaky := healthy
+	flaky.InstallFailed = 10
+	decision, _ = policy.Evaluate(flaky, soaked, now)
+	assert.Equal(t, domain.GateFail, decision)
+
+	early := few
+	early.FailedBatches = 1 // 5 个 Batch 中 1 个失败（20%），样本量不足时不判断
+	decision, _ = policy.Evaluate(early, soaked, now)
+	assert.Equal(t, domain.GateHold, decision, "rate thresholds wait for min batches")
+
+	// 对照组没有异常码时仍然比较（按检测下限 1 次计）
+	regression := healthy
+	regression.ControlErrors = 0
+	decision, _ = policy.Evaluate(regression, soaked, now)
+	assert.Equal(t, domain.GateFail, decision, "new error codes with a clean control group")
+
+	clean := regression
+	clean.ErrorOccurrences = 1
+	decision, _ = policy.Evaluate(clean, soaked, now)
+	assert.Equal(t, domain.GatePass, decision)
+}
+
+// TestCohortSelector_Matches - 空条件不限，多个条件同时满足
+func TestCohortSelector_Matches(t *testing.T) {
+	v := &domain.Vehicle{VIN: "LFWSRXSJ5M1A00001", Model: "J7-6x4", OwnerFleet: "north", FirmwareVersion: "J7-2025.05"}
+	assert.True(t, domain.CohortSelector{}.Matches(v))
+	assert.True(t, domain.CohortSelector{OwnerFleets: []string{"north", "south"}, FirmwareVersions: []string{"J7-2025.05"}}.Matches(v))
+	assert.False(t, domain.CohortSelector{OwnerFleets: []string{"north"}, Models: []string{"J6"}}.Matches(v))
+}
@@ -0,0 +1,64 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestNewBatchRollup - 只有终态 Batch 计入汇总，时间桶按完成时间（UTC）
+func TestNewBatchRollup(t *testing.T) {
+	batch, err := domain.NewBatch("vehicle-001", "VIN123", 1)
+	require.NoError(t, err)
+
+	_, err = domain.NewBatchRollup(batch, nil)
+	assert.Error(t, err)
+
+	completedAt := time.Date(2025, 6, 1, 23, 45, 0, 0, time.FixedZone("CST", 8*3600))
+	batch.Status = domain.BatchStatusCompleted
+	batch.CreatedAt = completedAt.Add(-90 * time.Second)
+	batch.CompletedAt = &completedAt
+	batch.TotalFiles = 3
+	batch.TenantID = "oem-a"
+
+	report := domain.NewReport(batch)
+	report.ApplyStatistics(&domain.CPUStats{P99Utilization: 91}, &domain.RAMStats{P99UsageMB: 2048}, 100,
+		[]domain.ErrorCodeSummary{{Code: "E001", Count: 5}, {Code: "E002", Count: 2}})
+
+	rollup, err := domain.NewBatchRollup(batch, report)
+	require.NoError(t, err)
+	assert.Equal(t, domain.UnknownPlatform, rollup.Platform)
+	assert.Equal(t, "oem-a", rollup.TenantID)
+	assert.False(t, rollup.Failed)
+	assert.Equal(t, 90*time.Second, rollup.Duration)
+	assert.Equal(t, 7, rollup.ErrorOccurrences())
+	assert.Equal(t, time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC), rollup.Hour())
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), rollup.Day())
+}
+
+// TestFleetQuery_Normalize - 默认最近 7 天按小时，非法参数返回 ErrInvalidFleetQuery
+func TestFleetQuery_Normalize(t *testing.T) {
+	now := time.Date(2025, 6, 8, 12, 0, 0, 0, time.UTC)
+
+	q := domain.FleetQuery{Limit: 1000}
+	require.NoError(t, q.Normalize(now))
+	assert.Equal(t, now.Add(-7*24*time.Hour), q.From)
+	assert.Equal(t, domain.FleetIntervalHour, q.Interval)
+	assert.Equal(t, 200, q.Limit)
+
+	bad := []domain.FleetQuery{
+		{From: now, To: now.Add(-time.Hour)},
+		{From: now.AddDate(-2, 0, 0), To: now},
+		{Interval: "week"},
+	}
+	for _, q := range bad {
+		assert.ErrorIs(t, q.Normalize(now), domain.ErrInvalidFleetQuery)
+	}
+
+	_, err := domain.ParseAnomalyMetric("temperature")
+	assert.ErrorIs(t, err, domain.ErrInvalidFleetQuery)
+}
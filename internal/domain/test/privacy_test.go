@@ -0,0 +1,37 @@
+package domain_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestPseudonymizer_VINToken - 同一密钥下令牌稳定且不受大小写影响，换密钥后令牌不同
+func TestPseudonymizer_VINToken(t *testing.T) {
+	_, err := domain.NewPseudonymizer([]byte("too-short"))
+	assert.Error(t, err)
+
+	p, err := domain.NewPseudonymizer(bytes.Repeat([]byte("k"), 32))
+	require.NoError(t, err)
+	token := p.VINToken("LSVAU218XN2183294")
+	assert.True(t, strings.HasPrefix(token, "vt_"))
+	assert.Len(t, token, 27)
+	assert.NotContains(t, token, "2183294")
+	assert.Equal(t, token, p.VINToken(" lsvau218xn2183294 "))
+	assert.NotEqual(t, token, p.VINToken("LSVAU218XN2183295"))
+
+	other, err := domain.NewPseudonymizer(bytes.Repeat([]byte("x"), 32))
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other.VINToken("LSVAU218XN2183294"))
+}
+
+// TestMaskVIN - 日志中只保留 WMI 与末 4 位
+func TestMaskVIN(t *testing.T) {
+	assert.Equal(t, "LSV**********3294", domain.MaskVIN("lsvau218xn2183294"))
+	assert.Equal(t, "*****", domain.MaskVIN("ABC12"))
+}
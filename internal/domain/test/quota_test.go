@@ -0,0 +1,46 @@
+package domain_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestQuotaDay - 每日配额按 UTC 自然日计算，与服务器时区无关
+func TestQuotaDay(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	now := time.Date(2025, 6, 2, 7, 30, 0, 0, shanghai) // UTC 2025-06-01 23:30
+
+	assert.Equal(t, "2025-06-01", domain.QuotaDay(now))
+	assert.Equal(t, 30*time.Minute, domain.UntilQuotaReset(now))
+	assert.Equal(t, 24*time.Hour, domain.UntilQuotaReset(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+// TestTokenBucket - 未配置速率表示不限流；未配置 Burst 时容量为 1
+func TestTokenBucket(t *testing.T) {
+	assert.True(t, domain.TokenBucket{}.Unlimited())
+	assert.False(t, domain.TokenBucket{PerMinute: 2}.Unlimited())
+	assert.Equal(t, 1, domain.TokenBucket{PerMinute: 2}.Capacity())
+	assert.Equal(t, 5, domain.TokenBucket{PerMinute: 2, Burst: 5}.Capacity())
+}
+
+// TestRetryAfterError - 包装后仍能用 errors.Is 区分限流与配额
+func TestRetryAfterError(t *testing.T) {
+	var err error = &domain.RetryAfterError{
+		Err:        fmt.Errorf("%w: create_batch", domain.ErrRateLimited),
+		RetryAfter: 3 * time.Second,
+	}
+	err = fmt.Errorf("create batch: %w", err)
+
+	assert.ErrorIs(t, err, domain.ErrRateLimited)
+	assert.NotErrorIs(t, err, domain.ErrQuotaExceeded)
+	var retry *domain.RetryAfterError
+	if assert.True(t, errors.As(err, &retry)) {
+		assert.Equal(t, 3*time.Second, retry.RetryAfter)
+	}
+}
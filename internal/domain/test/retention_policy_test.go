@@ -0,0 +1,45 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestRetentionPolicy_StagesInOrder - 原始日志先到期，已执行的阶段不再返回，最后整体删除
+func TestRetentionPolicy_StagesInOrder(t *testing.T) {
+	day := 24 * time.Hour
+	policy := domain.DefaultRetentionPolicy()
+	policy.Platforms["model-y"] = map[domain.BatchStatus]domain.RetentionRule{
+		domain.BatchStatusCompleted: {RawLogs: 60 * day},
+	}
+	now := time.Now()
+
+	batch := newBatchInStatus(t, domain.BatchStatusCompleted, now.Add(-31*day))
+	due, next := policy.Evaluate(batch, domain.RetentionState{}, now)
+	assert.Equal(t, []domain.RetentionStage{domain.RetentionStageRawLogs}, due)
+	assert.WithinDuration(t, now.Add(59*day), *next, time.Second) // derived 90 天
+
+	state := domain.RetentionState{}
+	state.MarkDone(domain.RetentionStageRawLogs, now)
+	due, _ = policy.Evaluate(batch, state, now)
+	assert.Empty(t, due)
+
+	// 平台覆盖：model-y 原始日志保留 60 天，其余字段为 0（永久保留）
+	batch.VehiclePlatform = "model-y"
+	due, next = policy.Evaluate(batch, domain.RetentionState{}, now)
+	assert.Empty(t, due)
+	assert.WithinDuration(t, now.Add(29*day), *next, time.Second)
+
+	failed := newBatchInStatus(t, domain.BatchStatusFailed, now.Add(-8*day))
+	due, next = policy.Evaluate(failed, domain.RetentionState{}, now)
+	assert.Equal(t, []domain.RetentionStage{domain.RetentionStageBatch}, due)
+	assert.Nil(t, next)
+
+	// 非终态不参与生命周期
+	running := newBatchInStatus(t, domain.BatchStatusDiagnosing, now.Add(-365*day))
+	due, _ = policy.Evaluate(running, domain.RetentionState{}, now)
+	assert.Empty(t, due)
+}
@@ -0,0 +1,96 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+func newBatchInStatus(t *testing.T, status domain.BatchStatus, updatedAt time.Time) *domain.Batch {
+	batch, err := domain.NewBatch("vehicle-001", "VIN123", 1)
+	assert.NoError(t, err)
+	batch.Status = status
+	batch.UpdatedAt = updatedAt
+	return batch
+}
+
+// TestSLAPolicy_ScalesWithBatchSize - 超时按文件数缩放
+func TestSLAPolicy_ScalesWithBatchSize(t *testing.T) {
+	policy := &domain.SLAPolicy{
+		Default: map[domain.BatchStatus]domain.StageSLA{
+			domain.BatchStatusScattering: {Timeout: 5 * time.Minute, PerFile: 10 * time.Second},
+		},
+	}
+	now := time.Now()
+
+	small := newBatchInStatus(t, domain.BatchStatusScattering, now.Add(-6*time.Minute))
+	small.TotalFiles = 1
+	assert.True(t, policy.IsStuck(small, now))
+
+	// 60 个文件：5m + 60*10s = 15m，6 分钟还没超时
+	large := newBatchInStatus(t, domain.BatchStatusScattering, now.Add(-6*time.Minute))
+	large.TotalFiles = 60
+	assert.False(t, policy.IsStuck(large, now))
+}
+
+// TestSLAPolicy_PlatformOverride - 平台覆盖优先，未配置的阶段回退到默认
+func TestSLAPolicy_PlatformOverride(t *testing.T) {
+	policy := domain.DefaultSLAPolicy()
+	policy.Platforms["model-y"] = map[domain.BatchStatus]domain.StageSLA{
+		domain.BatchStatusDiagnosing: {Timeout: 30 * time.Minute, MaxRetries: 1},
+	}
+
+	stage, ok := policy.StageFor("model-y", domain.BatchStatusDiagnosing)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Minute, stage.Timeout)
+	assert.Equal(t, 1, stage.MaxRetries)
+
+	stage, ok = policy.StageFor("model-y", domain.BatchStatusScattering)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, stage.Timeout)
+
+	now := time.Now()
+	batch := newBatchInStatus(t, domain.BatchStatusDiagnosing, now.Add(-15*time.Minute))
+	assert.True(t, policy.IsStuck(batch, now))
+	batch.VehiclePlatform = "model-y"
+	assert.False(t, policy.IsStuck(batch, now))
+}
+
+// TestSLAPolicy_TerminalStatusesNeverStuck - 终态不参与补偿
+func TestSLAPolicy_TerminalStatusesNeverStuck(t *testing.T) {
+	policy := domain.DefaultSLAPolicy()
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+
+	for _, status := range []domain.BatchStatus{domain.BatchStatusCompleted, domain.BatchStatusFailed} {
+		assert.False(t, policy.IsStuck(newBatchInStatus(t, status, longAgo), time.Now()))
+	}
+	for _, status := range domain.NonTerminalBatchStatuses() {
+		assert.True(t, policy.IsStuck(newBatchInStatus(t, status, longAgo), time.Now()), status)
+	}
+}
+
+// TestBatch_TransitionResetsCompensationAttempts - 状态推进后重试次数清零
+func TestBatch_TransitionResetsCompensationAttempts(t *testing.T) {
+	batch, _ := domain.NewBatch("vehicle-001", "VIN123", 1)
+	batch.CompensationAttempts = 2
+
+	assert.NoError(t, batch.TransitionTo(domain.BatchStatusUploaded))
+	assert.Equal(t, 0, batch.CompensationAttempts)
+}
+
+// TestSLAPolicy_PriorityScale - 高优先级 Batch 更早被判定为卡住
+func TestSLAPolicy_PriorityScale(t *testing.T) {
+	policy := domain.DefaultSLAPolicy()
+	now := time.Now()
+
+	// diagnosing 默认 10 分钟；safety_critical 缩放为 2.5 分钟
+	batch := newBatchInStatus(t, domain.BatchStatusDiagnosing, now.Add(-5*time.Minute))
+	assert.False(t, policy.IsStuck(batch, now))
+	batch.Priority = domain.BatchPrioritySafetyCritical
+	assert.True(t, policy.IsStuck(batch, now))
+
+	// 数据库粗筛条件要覆盖缩放后的最短超时（2 分钟 * 0.25）
+	assert.Equal(t, 30*time.Second, policy.MinTimeout())
+}
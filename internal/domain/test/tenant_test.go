@@ -0,0 +1,69 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestTenantRegistry - 总是包含 default 租户；未知与已暂停的租户被拒绝
+func TestTenantRegistry(t *testing.T) {
+	registry, err := domain.NewTenantRegistry([]*domain.Tenant{
+		{ID: "oem-a", Quotas: domain.TenantQuotas{MaxBatchesPerDay: 2, MaxFileSizeBytes: 100, MaxStorageBytes: 1000}},
+		{ID: "oem-b", Suspended: true},
+	})
+	require.NoError(t, err)
+
+	def, err := registry.Get(domain.DefaultTenantID)
+	require.NoError(t, err)
+	assert.NoError(t, def.CheckUpload(1<<40, 1<<50), "default tenant is unlimited")
+
+	_, err = registry.Get("oem-b")
+	assert.ErrorIs(t, err, domain.ErrTenantSuspended)
+	_, err = registry.Get("oem-c")
+	assert.ErrorIs(t, err, domain.ErrTenantNotFound)
+
+	oemA, err := registry.Get("oem-a")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DailyLimits{MaxBatches: 2}, oemA.Quotas.DailyLimits())
+	assert.ErrorIs(t, oemA.CheckUpload(101, 0), domain.ErrFileTooLarge)
+	assert.ErrorIs(t, oemA.CheckUpload(100, 950), domain.ErrQuotaExceeded)
+	assert.NoError(t, oemA.CheckUpload(100, 900))
+
+	_, err = domain.NewTenantRegistry([]*domain.Tenant{{ID: "OEM A"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidTenant)
+}
+
+// TestTenantKeysAndPaths - default 租户沿用旧的 Key 与对象路径，其他租户加前缀
+func TestTenantKeysAndPaths(t *testing.T) {
+	batchID, fileID := uuid.New(), uuid.New()
+
+	assert.Equal(t, "report:"+batchID.String(), domain.TenantCacheKey(domain.DefaultTenantID, "report:"+batchID.String()))
+	assert.Equal(t, "report:x", domain.TenantCacheKey("", "report:x"), "legacy events without tenant")
+	assert.Equal(t, "tenant:oem-a:report:x", domain.TenantCacheKey("oem-a", "report:x"))
+
+	assert.Equal(t, batchID.String()+"/"+fileID.String(), domain.RawObjectPath(domain.DefaultTenantID, batchID, fileID))
+	assert.Equal(t, "tenants/oem-a/"+batchID.String()+"/parsed/"+fileID.String()+".csv",
+		domain.ParsedOutputPath("oem-a", batchID, fileID))
+	assert.Equal(t, "tenants/oem-a/"+batchID.String()+"/charts/cpu.png", domain.ChartObjectPath("oem-a", batchID, "cpu.png"))
+
+	assert.Equal(t, "oem-a", domain.TenantFromObjectKey(domain.RawObjectPath("oem-a", batchID, fileID)))
+	assert.Equal(t, domain.DefaultTenantID, domain.TenantFromObjectKey(domain.RawObjectPath(domain.DefaultTenantID, batchID, fileID)))
+}
+
+// TestAuthorizeTenant - 内部调用不受限；外部调用只能访问本租户（旧数据视为 default）
+func TestAuthorizeTenant(t *testing.T) {
+	assert.NoError(t, domain.AuthorizeTenant(context.Background(), "oem-a"))
+
+	ctx := domain.WithTenant(context.Background(), "oem-a")
+	assert.NoError(t, domain.AuthorizeTenant(ctx, "oem-a"))
+	assert.ErrorIs(t, domain.AuthorizeTenant(ctx, "oem-b"), domain.ErrForbidden)
+
+	ctx = domain.WithTenant(context.Background(), domain.DefaultTenantID)
+	assert.NoError(t, domain.AuthorizeTenant(ctx, ""))
+}
@@ -0,0 +1,51 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// TestValidateVIN - 校验位、非法字符与长度
+func TestValidateVIN(t *testing.T) {
+	assert.NoError(t, domain.ValidateVIN("1M8GDM9AXKP042788")) // 校验位为 X 的经典样例
+	assert.NoError(t, domain.ValidateVIN("LFWSRXSJ5M1A00001"))
+
+	for _, vin := range []string{
+		"LFWSRXSJ0M1A00001",  // 校验位错误
+		"LFWSRXSJ5M1A00010",  // 相邻字符对调
+		"LFWSRXSJ5M1AO0001",  // 字母 O
+		"LFWSRXSJ5M1A0001",   // 16 位
+		"LFWSRXSJ5M1A000011", // 18 位
+	} {
+		assert.ErrorIs(t, domain.ValidateVIN(vin), domain.ErrInvalidVIN, vin)
+	}
+
+	info := domain.DecodeWMI("LFWSRXSJ5M1A00001")
+	assert.Equal(t, "FAW Jiefang", info.Manufacturer)
+	assert.Equal(t, "China", info.Country)
+	assert.Equal(t, "Asia", info.Region)
+}
+
+// TestVehicle_AcceptBatch - 车型年与年份代码、车辆编号一致性、退役后拒绝新 Batch
+func TestVehicle_AcceptBatch(t *testing.T) {
+	_, err := domain.NewVehicle("LFWSRXSJ5M1A00001", "truck-001", "J7", "J7 6x4", 2015)
+	assert.ErrorIs(t, err, domain.ErrInvalidVIN)
+
+	vehicle, err := domain.NewVehicle(" lfwsrxsj5m1a00001 ", "truck-001", "J7", "J7 6x4", 2021)
+	require.NoError(t, err)
+	assert.Equal(t, "LFWSRXSJ5M1A00001", vehicle.VIN)
+	assert.Equal(t, "FAW Jiefang", vehicle.Manufacturer)
+
+	assert.NoError(t, vehicle.AcceptBatch("truck-001"))
+	assert.ErrorIs(t, vehicle.AcceptBatch("truck-002"), domain.ErrVehicleMismatch)
+
+	assert.ErrorIs(t, vehicle.UpdateInventory("J7-2025.06", []domain.ECU{{Name: "ADAS"}, {Name: "ADAS"}}), domain.ErrInvalidInventory)
+
+	require.NoError(t, vehicle.Decommission())
+	assert.ErrorIs(t, vehicle.AcceptBatch("truck-001"), domain.ErrVehicleDecommissioned)
+	assert.ErrorIs(t, vehicle.Decommission(), domain.ErrVehicleDecommissioned)
+}
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrVehicleNotRegistered 车辆未在注册表中登记
+	ErrVehicleNotRegistered = errors.New("vehicle not registered")
+	// ErrVehicleDecommissioned 车辆已退役（报废、回收），不再接收日志
+	ErrVehicleDecommissioned = errors.New("vehicle decommissioned")
+	// ErrVehicleExists VIN 已注册
+	ErrVehicleExists = errors.New("vehicle already registered")
+	// ErrInvalidInventory ECU 清单不合法
+	ErrInvalidInventory = errors.New("invalid ecu inventory")
+	// ErrVehicleMismatch 上传的 vehicle_id 与注册表不一致
+	ErrVehicleMismatch = errors.New("vehicle id does not match registry")
+)
+
+// VehicleStatus 车辆生命周期状态
+type VehicleStatus string
+
+const (
+	VehicleStatusActive         VehicleStatus = "active"
+	VehicleStatusDecommissioned VehicleStatus = "decommissioned"
+)
+
+// ECU 车上的一个电子控制单元及其当前软件版本
+type ECU struct {
+	Name            string `json:"name"` // 例如 ADAS、BMS、TBOX
+	PartNumber      string `json:"part_number"`
+	HardwareVersion string `json:"hardware_version"`
+	SoftwareVersion string `json:"software_version"`
+}
+
+// Vehicle 车辆聚合根（以 VIN 为标识）
+//
+// 注册表是车辆信息的唯一来源：创建 Batch 时校验车辆存在且在役，并用注册表的平台覆盖上传方填写的值
+type Vehicle struct {
+	VIN              string
+	TenantID         string // 所属租户（VIN 全局唯一，一辆车只属于一个租户）
+	VehicleID        string // 业务侧车辆编号（与 Batch.VehicleID 对应）
+	Platform         string // 车型平台（J6、J7 等），用于分平台 SLA 和 RAG 过滤
+	Model            string
+	ModelYear        int
+	Manufacturer     string // 由 WMI 解码
+	FirmwareVersion  string // 整车软件基线版本（OTA 包版本）
+	ECUs             []ECU
+	OwnerFleet       string // 所属车队
+	Status           VehicleStatus
+	DecommissionedAt *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// NewVehicle 注册车辆：校验 VIN（校验位、年份代码）并解码制造商
+func NewVehicle(vin, vehicleID, platform, model string, modelYear int) (*Vehicle, error) {
+	vin = NormalizeVIN(vin)
+	if err := ValidateVIN(vin); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(vehicleID) == "" {
+		return nil, errors.New("vehicle id is empty")
+	}
+	if strings.TrimSpace(platform) == "" {
+		return nil, errors.New("vehicle platform is empty")
+	}
+	if modelYear != 0 && !VINModelYearMatches(vin, modelYear) {
+		return nil, fmt.Errorf("%w: model year %d does not match year code %c", ErrInvalidVIN, modelYear, vin[9])
+	}
+
+	now := time.Now()
+	return &Vehicle{
+		VIN:          vin,
+		TenantID:     DefaultTenantID,
+		VehicleID:    vehicleID,
+		Platform:     platform,
+		Model:        model,
+		ModelYear:    modelYear,
+		Manufacturer: DecodeWMI(vin).Manufacturer,
+		Status:       VehicleStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// Active 是否在役
+func (v *Vehicle) Active() bool {
+	return v.Status == VehicleStatusActive
+}
+
+// UpdateInventory 上报 ECU 清单和整车软件版本（OTA 升级后由车端或产线同步）
+func (v *Vehicle) UpdateInventory(firmwareVersion string, ecus []ECU) error {
+	seen := make(map[string]bool, len(ecus))
+	for _, ecu := range ecus {
+		if ecu.Name == "" {
+			return fmt.Errorf("%w: ecu name is empty", ErrInvalidInventory)
+		}
+		if seen[ecu.Name] {
+			return fmt.Errorf("%w: duplicate ecu %s", ErrInvalidInventory, ecu.Name)
+		}
+		seen[ecu.Name] = true
+	}
+	v.FirmwareVersion = firmwareVersion
+	v.ECUs = ecus
+	v.UpdatedAt = time.Now()
+	return nil
+}
+
+// AssignFleet 变更所属车队
+func (v *Vehicle) AssignFleet(fleet string) {
+	v.OwnerFleet = fleet
+	v.UpdatedAt = time.Now()
+}
+
+// Decommission 退役（不可逆；历史 Batch 保留，按保留策略清理）
+func (v *Vehicle) Decommission() error {
+	if !v.Active() {
+		return ErrVehicleDecommissioned
+	}
+	now := time.Now()
+	v.Status = VehicleStatusDecommissioned
+	v.DecommissionedAt = &now
+	v.UpdatedAt = now
+	return nil
+}
+
+// AcceptBatch 校验车辆能否上传新的 Batch
+func (v *Vehicle) AcceptBatch(vehicleID string) error {
+	if !v.Active() {
+		return fmt.Errorf("%w: %s", ErrVehicleDecommissioned, v.VIN)
+	}
+	if vehicleID != v.VehicleID {
+		return fmt.Errorf("%w: vin %s is registered as %s, got %s", ErrVehicleMismatch, v.VIN, v.VehicleID, vehicleID)
+	}
+	return nil
+}
+
+// ECUVersions 当前 ECU 清单的软件版本（ECU 名 → 版本）
+func (v *Vehicle) ECUVersions() map[string]string {
+	versions := make(map[string]string, len(v.ECUs))
+	for _, ecu := range v.ECUs {
+		versions[ecu.Name] = ecu.SoftwareVersion
+	}
+	return versions
+}
+
+// VehicleListOptions 注册表查询条件
+type VehicleListOptions struct {
+	Limit  int
+	Offset int
+
+	Platform   *string
+	OwnerFleet *string
+	Status     *string
+}
+
+type VehicleRepository interface {
+	Save(ctx context.Context, vehicle *Vehicle) error
+	// FindByVIN 不存在时返回 nil, nil
+	FindByVIN(ctx context.Context, vin string) (*Vehicle, error)
+	List(ctx context.Context, opts VehicleListOptions) ([]*Vehicle, error)
+}
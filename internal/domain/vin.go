@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidVIN VIN 格式或校验位错误
+var ErrInvalidVIN = errors.New("invalid vin")
+
+// vinLength ISO 3779 VIN 固定 17 位
+const vinLength = 17
+
+// vinWeights 各位的加权系数（第 9 位是校验位本身，权重为 0）
+var vinWeights = [vinLength]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// vinTransliteration 字母对应的数值（I、O、Q 与 1、0 易混淆，不允许出现）
+var vinTransliteration = map[byte]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+// NormalizeVIN 去空白并转大写
+func NormalizeVIN(vin string) string {
+	return strings.ToUpper(strings.TrimSpace(vin))
+}
+
+// VINCheckDigit 计算第 9 位校验位：各位数值 × 权重求和 mod 11，余 10 记为 X
+func VINCheckDigit(vin string) (byte, error) {
+	if len(vin) != vinLength {
+		return 0, fmt.Errorf("%w: must be %d characters, got %d", ErrInvalidVIN, vinLength, len(vin))
+	}
+	sum := 0
+	for i := 0; i < vinLength; i++ {
+		c := vin[i]
+		var value int
+		switch {
+		case c >= '0' && c <= '9':
+			value = int(c - '0')
+		default:
+			v, ok := vinTransliteration[c]
+			if !ok {
+				return 0, fmt.Errorf("%w: illegal character %q at position %d", ErrInvalidVIN, c, i+1)
+			}
+			value = v
+		}
+		sum += value * vinWeights[i]
+	}
+	if r := sum % 11; r != 10 {
+		return byte('0' + r), nil
+	}
+	return 'X', nil
+}
+
+// ValidateVIN 校验长度、字符集和校验位（调用方先 NormalizeVIN）
+//
+// ISO 3779 不强制校验位，但北美（49 CFR 565）和中国（GB 16735）都强制第 9 位为校验位；
+// 校验位能拦住手工录入、日志截断造成的绝大多数单字符错误和相邻字符对调
+func ValidateVIN(vin string) error {
+	check, err := VINCheckDigit(vin)
+	if err != nil {
+		return err
+	}
+	if vin[8] != check {
+		return fmt.Errorf("%w: check digit is %c, expected %c", ErrInvalidVIN, vin[8], check)
+	}
+	return nil
+}
+
+// WMIInfo 由 VIN 前 3 位（World Manufacturer Identifier）解码的制造商信息
+type WMIInfo struct {
+	WMI          string
+	Region       string
+	Country      string
+	Manufacturer string // 未收录的 WMI 为空
+}
+
+// wmiManufacturers 常见 WMI（以接入平台的国内主机厂为主，未收录不影响注册）
+var wmiManufacturers = map[string]string{
+	"LFN": "FAW Jiefang",
+	"LFW": "FAW Jiefang",
+	"LFV": "FAW-Volkswagen",
+	"LFM": "FAW Toyota",
+	"LSV": "SAIC Volkswagen",
+	"LSG": "SAIC General Motors",
+	"LSJ": "SAIC MG",
+	"LZW": "SAIC-GM-Wuling",
+	"LVS": "Changan Ford",
+	"LS5": "Changan",
+	"LVG": "GAC Toyota",
+	"LHG": "GAC Honda",
+	"LMG": "GAC Motor",
+	"LGB": "Dongfeng Nissan",
+	"LGA": "Dongfeng Commercial Vehicle",
+	"LDC": "Dongfeng Peugeot Citroen",
+	"LRW": "Tesla Shanghai",
+	"LGX": "BYD",
+	"LC0": "BYD",
+	"L6T": "Geely",
+	"LB3": "Geely",
+	"LVV": "Chery",
+	"LGW": "Great Wall",
+	"LNB": "BAIC",
+	"LJ1": "JAC",
+	"LE4": "Beijing Benz",
+	"LBV": "BMW Brilliance",
+	"LZZ": "Sinotruk",
+	"LZG": "Shaanxi Automobile",
+	"LRB": "SAIC-GM Buick",
+	"1FA": "Ford",
+	"1G1": "Chevrolet",
+	"1HG": "Honda",
+	"1M8": "Motor Coach Industries",
+	"5YJ": "Tesla",
+	"JTD": "Toyota",
+	"KMH": "Hyundai",
+	"WBA": "BMW",
+	"WDD": "Mercedes-Benz",
+	"WVW": "Volkswagen",
+	"WAU": "Audi",
+	"YV1": "Volvo Cars",
+	"YV2": "Volvo Trucks",
+	"SAL": "Land Rover",
+	"VF1": "Renault",
+	"ZFA": "Fiat",
+}
+
+// wmiCountries 前两位对应的国家（只收录主要汽车生产国，按前缀匹配）
+var wmiCountries = []struct {
+	prefix  string
+	country string
+}{
+	{"L", "China"},
+	{"J", "Japan"},
+	{"KL", "South Korea"}, {"KM", "South Korea"}, {"KN", "South Korea"}, {"KP", "South Korea"}, {"KR", "South Korea"},
+	{"MA", "India"}, {"MB", "India"}, {"MC", "India"}, {"MD", "India"}, {"ME", "India"},
+	{"W", "Germany"},
+	{"VF", "France"}, {"VG", "France"}, {"VR", "France"},
+	{"Z", "Italy"},
+	{"SA", "United Kingdom"}, {"SB", "United Kingdom"}, {"SC", "United Kingdom"},
+	{"YS", "Sweden"}, {"YT", "Sweden"}, {"YU", "Sweden"}, {"YV", "Sweden"},
+	{"1", "United States"}, {"4", "United States"}, {"5", "United States"},
+	{"2", "Canada"},
+	{"3", "Mexico"},
+	{"9", "Brazil"},
+}
+
+// vinRegion 第 1 位对应的大区
+func vinRegion(c byte) string {
+	switch {
+	case c >= 'A' && c <= 'H':
+		return "Africa"
+	case c >= 'J' && c <= 'R':
+		return "Asia"
+	case c >= 'S' && c <= 'Z':
+		return "Europe"
+	case c >= '1' && c <= '5':
+		return "North America"
+	case c == '6' || c == '7':
+		return "Oceania"
+	case c == '8' || c == '9':
+		return "South America"
+	}
+	return ""
+}
+
+// DecodeWMI 解码制造商信息（调用方先 ValidateVIN）
+func DecodeWMI(vin string) WMIInfo {
+	if len(vin) < 3 {
+		return WMIInfo{}
+	}
+	info := WMIInfo{
+		WMI:          vin[:3],
+		Region:       vinRegion(vin[0]),
+		Manufacturer: wmiManufacturers[vin[:3]],
+	}
+	for _, c := range wmiCountries {
+		if strings.HasPrefix(vin, c.prefix) {
+			info.Country = c.country
+			break
+		}
+	}
+	return info
+}
+
+// vinYearCodes 第 10 位年份代码，每 30 年一个循环（A = 1980/2010，9 = 2009/2039）
+const vinYearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// VINModelYearMatches 第 10 位年份代码与车型年是否一致（年份代码无法识别时不判断）
+func VINModelYearMatches(vin string, modelYear int) bool {
+	if len(vin) != vinLength || modelYear <= 0 {
+		return true
+	}
+	idx := strings.IndexByte(vinYearCodes, vin[9])
+	if idx < 0 {
+		return true
+	}
+	return (modelYear-1980-idx)%30 == 0
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// vinURIPrefix 车辆证书 SAN 中的 VIN（urn:vin:LFWSRXSJ5M1A00001）
+const vinURIPrefix = "urn:vin:"
+
+// tenantURIPrefix 车辆证书 SAN 中的租户（urn:tenant:oem-a）；由各 OEM 的签发 CA 写入，必须与签发 CA 所属租户一致
+const tenantURIPrefix = "urn:tenant:"
+
+// tenantClaim JWT 中的租户声明
+const tenantClaim = "tenant"
+
+// Authenticator 从 HTTP 请求中识别调用方
+//
+// 车辆：mTLS 客户端证书（优先）或车辆签发方的 JWT；运维人员：OIDC 签发方的 JWT。
+// 两类 JWT 按 iss 区分，使用各自的 Verifier（可以共用一个 JWKS 文件）
+type Authenticator struct {
+	vehicles  *Verifier  // 为 nil 表示不接受车辆 JWT
+	operators *Verifier  // 为 nil 表示不接受运维 JWT
+	clientCAs *ClientCAs // 车辆证书签发 CA 的租户映射；为 nil 时所有证书属于 default 租户
+}
+
+// AuthenticatorOption 可选配置
+type AuthenticatorOption func(*Authenticator)
+
+// WithClientCAs 按签发 CA 确定车辆证书的租户
+func WithClientCAs(cas *ClientCAs) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.clientCAs = cas
+	}
+}
+
+func NewAuthenticator(vehicles, operators *Verifier, opts ...AuthenticatorOption) *Authenticator {
+	a := &Authenticator{
+		vehicles:  vehicles,
+		operators: operators,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authenticate 认证失败返回 domain.ErrUnauthenticated
+func (a *Authenticator) Authenticate(r *http.Request) (*domain.Principal, error) {
+	// TLS 层已经用 ClientCAs 校验过证书链，这里从叶子证书取 VIN，从签发 CA 确定租户
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		cert := r.TLS.VerifiedChains[0][0]
+		vin, err := VINFromCertificate(cert)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+		}
+		tenantID, err := a.clientCAs.CertificateTenant(r.TLS.VerifiedChains)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+		}
+		return &domain.Principal{Kind: domain.PrincipalVehicle, Subject: vin, VIN: vin, TenantID: tenantID}, nil
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing credentials", domain.ErrUnauthenticated)
+	}
+	issuer, err := UnverifiedIssuer(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+	}
+	switch {
+	case a.vehicles != nil && issuer == a.vehicles.Issuer():
+		return a.vehiclePrincipal(token)
+	case a.operators != nil && issuer == a.operators.Issuer():
+		return a.operatorPrincipal(token)
+	default:
+		return nil, fmt.Errorf("%w: untrusted issuer %q", domain.ErrUnauthenticated, issuer)
+	}
+}
+
+// vehiclePrincipal 车辆 JWT：VIN 取 vin 声明，没有时取 sub
+func (a *Authenticator) vehiclePrincipal(token string) (*domain.Principal, error) {
+	claims, err := a.vehicles.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+	}
+	vin := claims.String("vin")
+	if vin == "" {
+		vin = claims.Subject
+	}
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+	}
+	return &domain.Principal{Kind: domain.PrincipalVehicle, Subject: claims.Subject, VIN: vin, TenantID: claims.String(tenantClaim)}, nil
+}
+
+// operatorPrincipal OIDC JWT：角色取 roles 声明，没有时取 groups
+func (a *Authenticator) operatorPrincipal(token string) (*domain.Principal, error) {
+	claims, err := a.operators.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", domain.ErrUnauthenticated)
+	}
+	name := claims.String("email")
+	if name == "" {
+		name = claims.String("preferred_username")
+	}
+	roles := claims.Strings("roles")
+	if len(roles) == 0 {
+		roles = claims.Strings("groups")
+	}
+	return &domain.Principal{
+		Kind:     domain.PrincipalOperator,
+		Subject:  claims.Subject,
+		Name:     name,
+		Roles:    roles,
+		TenantID: claims.String(tenantClaim),
+	}, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(h[7:])
+	return token, token != ""
+}
+
+// VINFromCertificate 车辆证书的 VIN：优先取 SAN URI（urn:vin:...），否则取 Subject CN
+func VINFromCertificate(cert *x509.Certificate) (string, error) {
+	vin := ""
+	for _, uri := range cert.URIs {
+		if s := uri.String(); strings.HasPrefix(strings.ToLower(s), vinURIPrefix) {
+			vin = s[len(vinURIPrefix):]
+			break
+		}
+	}
+	if vin == "" {
+		vin = cert.Subject.CommonName
+	}
+	vin = domain.NormalizeVIN(vin)
+	if err := domain.ValidateVIN(vin); err != nil {
+		return "", fmt.Errorf("client certificate does not carry a valid VIN: %w", err)
+	}
+	return vin, nil
+}
+
+// TenantFromCertificate 车辆证书声明的租户（SAN URI urn:tenant:...），没有时返回空
+//
+// 只是证书自己的声明，认证时以签发 CA 为准（见 ClientCAs.CertificateTenant）
+func TenantFromCertificate(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if s := uri.String(); strings.HasPrefix(strings.ToLower(s), tenantURIPrefix) {
+			return strings.ToLower(s[len(tenantURIPrefix):])
+		}
+	}
+	return ""
+}
+
+// ServerTLSConfig Ingestor 的 TLS 配置；clientCAs 非 nil 时校验车辆客户端证书
+//
+// 使用 VerifyClientCertIfGiven 而不是 RequireAndVerifyClientCert：同一个端口还要服务
+// 只带 Bearer Token 的客户端（运维人员、使用 JWT 的车辆），出示了证书就必须能验证通过
+func ServerTLSConfig(certFile, keyFile string, clientCAs *ClientCAs) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if clientCAs != nil {
+		cfg.ClientCAs = clientCAs.Pool()
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// ClientCAs 车辆客户端证书的签发 CA：TLS 握手使用的证书池，以及 CA → 租户的映射
+//
+// 所有 OEM 的 CA 都在同一个证书池里，任何一个 CA 都能签出携带任意 urn:tenant: 的证书；
+// 所以证书的租户以签发它的 CA 为准，SAN 中的租户只能与之一致
+type ClientCAs struct {
+	pool    *x509.CertPool
+	tenants map[[sha256.Size]byte]string // CA 证书 SHA-256 指纹 → 租户
+}
+
+// LoadClientCAs sharedFile 中的 CA 不属于任何租户，签发的证书归 default 租户；
+// tenantFiles 为 租户 → 该 OEM 的 CA 文件（根 CA、中间 CA 均可），签发的证书只能属于该租户
+func LoadClientCAs(sharedFile string, tenantFiles map[string]string) (*ClientCAs, error) {
+	cas := &ClientCAs{pool: x509.NewCertPool(), tenants: make(map[[sha256.Size]byte]string)}
+	if sharedFile != "" {
+		if _, err := cas.add(sharedFile); err != nil {
+			return nil, err
+		}
+	}
+	for tenantID, file := range tenantFiles {
+		if err := domain.ValidateTenantID(tenantID); err != nil {
+			return nil, err
+		}
+		certs, err := cas.add(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			fingerprint := sha256.Sum256(cert.Raw)
+			if owner, ok := cas.tenants[fingerprint]; ok && owner != tenantID {
+				return nil, fmt.Errorf("client CA %q is configured for both tenant %s and %s", cert.Subject, owner, tenantID)
+			}
+			cas.tenants[fingerprint] = tenantID
+		}
+	}
+	return cas, nil
+}
+
+func (c *ClientCAs) add(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA: %w", err)
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse client CA %s: %w", file, err)
+		}
+		c.pool.AddCert(cert)
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("client CA file %s contains no certificates", file)
+	}
+	return certs, nil
+}
+
+// Pool TLS 握手校验客户端证书使用的证书池
+func (c *ClientCAs) Pool() *x509.CertPool {
+	return c.pool
+}
+
+// chainTenant 证书链中离叶子证书最近的、配置了租户的 CA 决定租户；都没有配置时为 default
+func (c *ClientCAs) chainTenant(chain []*x509.Certificate) string {
+	if c != nil {
+		for _, ca := range chain[1:] {
+			if tenantID, ok := c.tenants[sha256.Sum256(ca.Raw)]; ok {
+				return tenantID
+			}
+		}
+	}
+	return domain.DefaultTenantID
+}
+
+// CertificateTenant 已验证证书链对应的租户；多条链的结论不一致、或 SAN 中的租户与签发 CA 不一致时返回错误
+func (c *ClientCAs) CertificateTenant(chains [][]*x509.Certificate) (string, error) {
+	tenantID := ""
+	for _, chain := range chains {
+		t := c.chainTenant(chain)
+		if tenantID != "" && t != tenantID {
+			return "", fmt.Errorf("certificate chains to CAs of different tenants (%s, %s)", tenantID, t)
+		}
+		tenantID = t
+	}
+	if claimed := TenantFromCertificate(chains[0][0]); claimed != "" && claimed != tenantID {
+		return "", fmt.Errorf("certificate claims tenant %s but is issued by a CA of tenant %s", claimed, tenantID)
+	}
+	return tenantID, nil
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey JWT 头中的 kid 不在 JWKS 中
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// jwk RFC 7517 JSON Web Key（只解析验签需要的公钥字段）
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC / OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// PublicKey JWKS 中的一个验签公钥
+type PublicKey struct {
+	ID  string
+	Alg string // JWK 声明的算法（可为空，为空时按密钥类型允许的算法校验）
+	Key crypto.PublicKey
+}
+
+// FileJWKS 从本地 JWKS 文件读取验签公钥（与 IdP 的 /.well-known/jwks.json 格式相同）
+//
+// Ingestor 部署在网络边缘，不一定能访问企业 IdP，所以不在线拉取；文件由配置管理下发，按 mtime 自动重新加载。
+// 轮换时先追加新公钥再切换签名密钥
+type FileJWKS struct {
+	path string
+
+	mu       sync.RWMutex
+	keys     map[string]PublicKey
+	modTime  time.Time
+	lastStat time.Time
+}
+
+// jwksCheckInterval 检查 JWKS 文件是否变更的最小间隔
+const jwksCheckInterval = 30 * time.Second
+
+func NewFileJWKS(path string) (*FileJWKS, error) {
+	j := &FileJWKS{path: path}
+	if err := j.Reload(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Reload 重新读取 JWKS 文件
+func (j *FileJWKS) Reload() error {
+	info, err := os.Stat(j.path)
+	if err != nil {
+		return fmt.Errorf("stat jwks file: %w", err)
+	}
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		return fmt.Errorf("read jwks file: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse jwks file %s: %w", j.path, err)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.modTime, j.lastStat = info.ModTime(), time.Now()
+	j.mu.Unlock()
+	log.Printf("[Auth] Loaded %d signing keys from %s", len(keys), j.path)
+	return nil
+}
+
+// maybeReload 文件变更时重新加载；加载失败保留旧公钥继续工作
+func (j *FileJWKS) maybeReload() {
+	j.mu.RLock()
+	due := time.Since(j.lastStat) >= jwksCheckInterval
+	modTime := j.modTime
+	j.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(j.path)
+	j.mu.Lock()
+	j.lastStat = time.Now()
+	j.mu.Unlock()
+	if err != nil || !info.ModTime().After(modTime) {
+		return
+	}
+	if err := j.Reload(); err != nil {
+		log.Printf("[Auth] Failed to reload jwks file, keeping previous keys: %v", err)
+	}
+}
+
+// Key 按 kid 查找公钥
+func (j *FileJWKS) Key(kid string) (PublicKey, error) {
+	j.maybeReload()
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	if !ok {
+		return PublicKey{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// ParseJWKS 解析 JWKS 文档；不支持的密钥类型与非签名用途的密钥直接跳过
+func ParseJWKS(data []byte) (map[string]PublicKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]PublicKey, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Kid == "" {
+			return nil, fmt.Errorf("key %d: missing kid", i)
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		if pub == nil {
+			log.Printf("[Auth] Skipping key %s with unsupported type %s", k.Kid, k.Kty)
+			continue
+		}
+		keys[k.Kid] = PublicKey{ID: k.Kid, Alg: k.Alg, Key: pub}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid e")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key too short: %d bits", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken 令牌格式、签名或声明校验失败
+var ErrInvalidToken = errors.New("invalid token")
+
+// KeySource 按 kid 查找验签公钥（FileJWKS 实现）
+type KeySource interface {
+	Key(kid string) (PublicKey, error)
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Claims 已验证的 JWT 声明
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+
+	raw map[string]json.RawMessage
+}
+
+// String 读取字符串声明，不存在或类型不符时返回空串
+func (c *Claims) String(name string) string {
+	var s string
+	if v, ok := c.raw[name]; ok {
+		_ = json.Unmarshal(v, &s)
+	}
+	return s
+}
+
+// Strings 读取字符串或字符串数组声明（aud、roles、groups 两种写法都合法）
+func (c *Claims) Strings(name string) []string {
+	v, ok := c.raw[name]
+	if !ok {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(v, &list); err == nil {
+		return list
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// Verifier 校验某个签发方的 JWT
+type Verifier struct {
+	keys     KeySource
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// defaultLeeway 允许的时钟偏差（车端 RTC 不一定准）
+const defaultLeeway = 60 * time.Second
+
+func NewVerifier(keys KeySource, issuer, audience string) *Verifier {
+	return &Verifier{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		leeway:   defaultLeeway,
+		now:      time.Now,
+	}
+}
+
+// Issuer 该 Verifier 接受的签发方
+func (v *Verifier) Issuer() string { return v.issuer }
+
+// Verify 校验签名、签发方、受众和有效期
+//
+// 不信任令牌头里的 alg（alg=none、RS256 改 HS256 用公钥当 HMAC 密钥伪造签名）：
+// alg 必须与 kid 对应公钥的类型（以及 JWK 声明的 alg）一致，且不支持任何对称算法
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	key, err := v.keys.Key(h.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if key.Alg != "" && key.Alg != h.Alg {
+		return nil, fmt.Errorf("%w: alg %s does not match key %s (%s)", ErrInvalidToken, h.Alg, key.ID, key.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := verifySignature(h.Alg, key.Key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, err := parseClaims(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+func (v *Verifier) validate(c *Claims) error {
+	now := v.now()
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.audience != "" && !contains(c.Audience, v.audience) {
+		return fmt.Errorf("audience %v does not include %q", c.Audience, v.audience)
+	}
+	if c.ExpiresAt.IsZero() {
+		return errors.New("missing exp")
+	}
+	if now.After(c.ExpiresAt.Add(v.leeway)) {
+		return errors.New("token expired")
+	}
+	if !c.NotBefore.IsZero() && now.Add(v.leeway).Before(c.NotBefore) {
+		return errors.New("token not yet valid")
+	}
+	return nil
+}
+
+// UnverifiedIssuer 读取未验证的 iss，仅用于选择对应的 Verifier（之后必须完整校验）
+func UnverifiedIssuer(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	claims, err := parseClaims(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return claims.Issuer, nil
+}
+
+func parseClaims(segment string) (*Claims, error) {
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(segment, &raw); err != nil {
+		return nil, err
+	}
+	c := &Claims{raw: raw}
+	c.Issuer = c.String("iss")
+	c.Subject = c.String("sub")
+	c.Audience = c.Strings("aud")
+	var err error
+	if c.ExpiresAt, err = numericDate(raw, "exp"); err != nil {
+		return nil, err
+	}
+	if c.NotBefore, err = numericDate(raw, "nbf"); err != nil {
+		return nil, err
+	}
+	if c.IssuedAt, err = numericDate(raw, "iat"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// numericDate RFC 7519 NumericDate：Unix 秒，允许小数
+func numericDate(raw map[string]json.RawMessage, name string) (time.Time, error) {
+	v, ok := raw[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(v, &seconds); err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s", name)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature 只支持非对称算法；alg 与公钥类型不匹配时拒绝
+func verifySignature(alg string, key interface{}, signed, signature []byte) error {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg %s requires an RSA key", alg)
+		}
+		hash, digest := digestFor(alg[2:], signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	case "ES256", "ES384", "ES512":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg %s requires an EC key", alg)
+		}
+		curveBits := map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}[alg]
+		if pub.Curve.Params().BitSize != curveBits {
+			return fmt.Errorf("alg %s does not match curve %s", alg, pub.Curve.Params().Name)
+		}
+		// JWS 的 ECDSA 签名是定长的 r || s，而不是 ASN.1
+		size := (curveBits + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ECDSA signature length")
+		}
+		_, digest := digestFor(alg[2:], signed)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("alg EdDSA requires an Ed25519 key")
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported alg %q", alg)
+	}
+}
+
+func digestFor(bits string, data []byte) (crypto.Hash, []byte) {
+	switch bits {
+	case "384":
+		sum := sha512.Sum384(data)
+		return crypto.SHA384, sum[:]
+	case "512":
+		sum := sha512.Sum512(data)
+		return crypto.SHA512, sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return crypto.SHA256, sum[:]
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
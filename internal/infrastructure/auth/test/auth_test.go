@@ -0,0 +1,237 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/auth"
+)
+
+const (
+	vehicleIssuer  = "https://pki.argus.example/vehicles"
+	operatorIssuer = "https://idp.argus.example"
+	testVIN        = "LFWSRXSJ5M1A00001"
+)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+// writeJWKS 生成测试用 JWKS 文件：ES256 车辆密钥与 Ed25519 运维密钥
+func writeJWKS(t *testing.T) (string, *ecdsa.PrivateKey, ed25519.PrivateKey) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "EC", "kid": "vehicle-1", "alg": "ES256", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "idp-1", "crv": "Ed25519", "x": b64(edPub)},
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path, ecKey, edKey
+}
+
+func sign(t *testing.T, alg, kid string, claims map[string]interface{}, key crypto.Signer) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	return signed + "." + b64(sig)
+}
+
+func claims(issuer, audience, subject string, exp time.Time) map[string]interface{} {
+	return map[string]interface{}{"iss": issuer, "aud": audience, "sub": subject, "exp": exp.Unix()}
+}
+
+// TestAuthenticator_JWT - 按 iss 选择 Verifier，拒绝过期、错误受众、alg 不匹配与 alg=none
+func TestAuthenticator_JWT(t *testing.T) {
+	path, ecKey, edKey := writeJWKS(t)
+	keys, err := auth.NewFileJWKS(path)
+	require.NoError(t, err)
+	authenticator := auth.NewAuthenticator(
+		auth.NewVerifier(keys, vehicleIssuer, "argus-ingestor"),
+		auth.NewVerifier(keys, operatorIssuer, "argus"),
+	)
+	authenticate := func(token string) (*domain.Principal, error) {
+		req := httptest.NewRequest("POST", "/api/v1/batches", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return authenticator.Authenticate(req)
+	}
+	future := time.Now().Add(time.Hour)
+
+	vehicle, err := authenticate(sign(t, "ES256", "vehicle-1", claims(vehicleIssuer, "argus-ingestor", testVIN, future), ecKey))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PrincipalVehicle, vehicle.Kind)
+	assert.Equal(t, testVIN, vehicle.VIN)
+
+	operatorClaims := claims(operatorIssuer, "argus", "u-42", future)
+	operatorClaims["email"] = "ops@argus.example"
+	operatorClaims["groups"] = []string{"analyst"}
+	operator, err := authenticate(sign(t, "EdDSA", "idp-1", operatorClaims, edKey))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PrincipalOperator, operator.Kind)
+	assert.Equal(t, "ops@argus.example", operator.Name)
+	assert.Equal(t, []string{"analyst"}, operator.Roles)
+
+	rejected := map[string]string{
+		"expired":      sign(t, "ES256", "vehicle-1", claims(vehicleIssuer, "argus-ingestor", testVIN, time.Now().Add(-time.Hour)), ecKey),
+		"wrong aud":    sign(t, "ES256", "vehicle-1", claims(vehicleIssuer, "other", testVIN, future), ecKey),
+		"invalid vin":  sign(t, "ES256", "vehicle-1", claims(vehicleIssuer, "argus-ingestor", "LFWSRXSJ0M1A00001", future), ecKey),
+		"unknown iss":  sign(t, "ES256", "vehicle-1", claims("https://evil.example", "argus-ingestor", testVIN, future), ecKey),
+		"alg mismatch": sign(t, "EdDSA", "vehicle-1", claims(vehicleIssuer, "argus-ingestor", testVIN, future), edKey),
+		"wrong signer": sign(t, "EdDSA", "idp-1", claims(vehicleIssuer, "argus-ingestor", testVIN, future), ecKey),
+		"alg none":     b64([]byte(`{"alg":"none","kid":"vehicle-1"}`)) + "." + b64([]byte(`{"iss":"`+vehicleIssuer+`","sub":"`+testVIN+`"}`)) + ".",
+		"not a jwt":    "garbage",
+	}
+	for name, token := range rejected {
+		_, err := authenticate(token)
+		assert.ErrorIs(t, err, domain.ErrUnauthenticated, name)
+	}
+
+	_, err = authenticator.Authenticate(httptest.NewRequest("GET", "/", nil))
+	assert.ErrorIs(t, err, domain.ErrUnauthenticated, "missing credentials")
+}
+
+// TestAuthenticator_ClientCertificate - mTLS 证书的 VIN 优先取 SAN URI，其次取 CN
+func TestAuthenticator_ClientCertificate(t *testing.T) {
+	vinURI, _ := url.Parse("urn:vin:" + testVIN)
+	withSAN := &x509.Certificate{Subject: pkix.Name{CommonName: "telematics-unit"}, URIs: []*url.URL{vinURI}}
+	vin, err := auth.VINFromCertificate(withSAN)
+	require.NoError(t, err)
+	assert.Equal(t, testVIN, vin)
+
+	vin, err = auth.VINFromCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "lfwsrxsj5m1a00001"}})
+	require.NoError(t, err)
+	assert.Equal(t, testVIN, vin)
+
+	_, err = auth.VINFromCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "telematics-unit"}})
+	assert.Error(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/batches", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{withSAN}}}
+	principal, err := auth.NewAuthenticator(nil, nil).Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PrincipalVehicle, principal.Kind)
+	assert.Equal(t, testVIN, principal.VIN)
+
+	ctx := domain.WithPrincipal(req.Context(), principal)
+	assert.NoError(t, domain.AuthorizeVIN(ctx, testVIN))
+	assert.ErrorIs(t, domain.AuthorizeVIN(ctx, "1M8GDM9AXKP042788"), domain.ErrForbidden)
+}
+
+// issueCert 签发测试证书；parent 为 nil 时自签名（CA），uris 为 SAN URI
+func issueCert(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, uris ...string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeCA(t *testing.T, cert *x509.Certificate) string {
+	path := filepath.Join(t.TempDir(), cert.Subject.CommonName+".pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600))
+	return path
+}
+
+// TestAuthenticator_ClientCertificateTenant - 证书的租户由签发 CA 决定，SAN 中的租户与签发 CA 不一致时拒绝
+func TestAuthenticator_ClientCertificateTenant(t *testing.T) {
+	sharedCA, sharedKey := issueCert(t, "shared-ca", true, nil, nil)
+	rootA, rootAKey := issueCert(t, "oem-a-root", true, nil, nil)
+	interA, interAKey := issueCert(t, "oem-a-issuing", true, rootA, rootAKey)
+	rootB, rootBKey := issueCert(t, "oem-b-root", true, nil, nil)
+
+	cas, err := auth.LoadClientCAs(writeCA(t, sharedCA), map[string]string{
+		"oem-a": writeCA(t, interA),
+		"oem-b": writeCA(t, rootB),
+	})
+	require.NoError(t, err)
+	authenticator := auth.NewAuthenticator(nil, nil, auth.WithClientCAs(cas))
+
+	authenticate := func(chain ...*x509.Certificate) (*domain.Principal, error) {
+		req := httptest.NewRequest("POST", "/api/v1/batches", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{chain}}
+		return authenticator.Authenticate(req)
+	}
+
+	leafA, _ := issueCert(t, "leaf-a", false, interA, interAKey, "urn:vin:"+testVIN, "urn:tenant:oem-a")
+	principal, err := authenticate(leafA, interA, rootA)
+	require.NoError(t, err)
+	assert.Equal(t, "oem-a", principal.TenantID)
+
+	// 没有声明租户时同样取签发 CA 的租户
+	leafB, _ := issueCert(t, "leaf-b", false, rootB, rootBKey, "urn:vin:"+testVIN)
+	principal, err = authenticate(leafB, rootB)
+	require.NoError(t, err)
+	assert.Equal(t, "oem-b", principal.TenantID)
+
+	// oem-b 的 CA 签发声称属于 oem-a 的证书
+	forged, _ := issueCert(t, "forged", false, rootB, rootBKey, "urn:vin:"+testVIN, "urn:tenant:oem-a")
+	_, err = authenticate(forged, rootB)
+	assert.ErrorIs(t, err, domain.ErrUnauthenticated)
+
+	// 共享 CA 签发的证书只能属于 default 租户
+	shared, _ := issueCert(t, "shared", false, sharedCA, sharedKey, "urn:vin:"+testVIN, "urn:tenant:oem-a")
+	_, err = authenticate(shared, sharedCA)
+	assert.ErrorIs(t, err, domain.ErrUnauthenticated)
+	shared, _ = issueCert(t, "shared", false, sharedCA, sharedKey, "urn:vin:"+testVIN)
+	principal, err = authenticate(shared, sharedCA)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultTenantID, principal.TenantID)
+
+	// 同一个 CA 不能配置给两个租户
+	_, err = auth.LoadClientCAs("", map[string]string{"oem-a": writeCA(t, rootB), "oem-b": writeCA(t, rootB)})
+	assert.Error(t, err)
+}
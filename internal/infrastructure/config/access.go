@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// accessGrantFile 配置文件中的一条授权
+type accessGrantFile struct {
+	Claim     string   `json:"claim"`
+	Role      string   `json:"role"`
+	Fleets    []string `json:"fleets"`
+	Platforms []string `json:"platforms"`
+}
+
+// accessPolicyFile 权限策略配置文件格式（claim 为 JWT roles / groups 声明中的值）：
+//
+//	{
+//	  "grants": [
+//	    {"claim": "argus-admins",      "role": "admin"},
+//	    {"claim": "release-managers",  "role": "operator"},
+//	    {"claim": "north-fleet-ops",   "role": "analyst", "fleets": ["north"]},
+//	    {"claim": "j7-quality",        "role": "analyst", "platforms": ["J7"]},
+//	    {"claim": "dashboards",        "role": "viewer"}
+//	  ]
+//	}
+type accessPolicyFile struct {
+	Grants []accessGrantFile `json:"grants"`
+}
+
+// LoadAccessPolicy 加载权限策略；path 为空时使用默认策略（JWT 角色名直接对应平台角色，不限范围）
+//
+// 配置了文件时只使用文件中的授权，不再隐式包含默认策略
+func LoadAccessPolicy(path string) (*domain.AccessPolicy, error) {
+	if path == "" {
+		return domain.DefaultAccessPolicy(), nil
+	}
+
+	var file accessPolicyFile
+	if err := LoadJSON(path, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Grants) == 0 {
+		return nil, fmt.Errorf("%w: no grants in %s", domain.ErrInvalidAccessPolicy, path)
+	}
+
+	policy := &domain.AccessPolicy{}
+	for i, g := range file.Grants {
+		if g.Claim == "" {
+			return nil, fmt.Errorf("%w: grant %d: claim is required", domain.ErrInvalidAccessPolicy, i)
+		}
+		role, err := domain.ParseRole(g.Role)
+		if err != nil {
+			return nil, fmt.Errorf("grant %s: %w", g.Claim, err)
+		}
+		policy.Grants = append(policy.Grants, domain.AccessGrant{
+			Claim: g.Claim,
+			Role:  role,
+			Scope: domain.AccessScope{Fleets: g.Fleets, Platforms: g.Platforms},
+		})
+	}
+	log.Printf("[Config] Loaded %d access grants from %s", len(policy.Grants), path)
+	return policy, nil
+}
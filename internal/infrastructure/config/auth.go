@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/auth"
+)
+
+// AuthConfig 认证配置：车辆（mTLS / 车辆 JWT）与运维人员（OIDC JWT）
+type AuthConfig struct {
+	JWKSFile string // 验签公钥（车辆签发方与 IdP 的公钥可以放在同一个文件）
+
+	VehicleIssuer    string // 车辆 JWT 的 iss，为空表示不接受车辆 JWT
+	VehicleAudience  string
+	OperatorIssuer   string // IdP 的 iss，为空表示不接受运维 JWT
+	OperatorAudience string
+
+	TLSCertFile     string // 非空时 HTTP Server 使用 TLS
+	TLSKeyFile      string
+	TLSClientCAFile string // 非空时接受车辆 mTLS 客户端证书（签发的证书归 default 租户）
+
+	// TLSClientCATenants 各 OEM 的签发 CA，格式 oem-a=/etc/argus/ca/oem-a.pem,oem-b=...
+	// 这些 CA 同样用于校验客户端证书，签发的证书只能属于对应租户
+	TLSClientCATenants string
+
+	Disabled bool // AUTH_DISABLED=true：本地开发显式关闭认证（只在没有配置任何凭证来源时生效）
+}
+
+// AuthConfigFromEnv 从环境变量读取（getEnv 为各服务自己的带默认值读取函数）
+func AuthConfigFromEnv(getEnv func(key, defaultValue string) string) AuthConfig {
+	disabled, _ := strconv.ParseBool(getEnv("AUTH_DISABLED", "false"))
+	return AuthConfig{
+		JWKSFile:           getEnv("JWKS_FILE", ""),
+		VehicleIssuer:      getEnv("VEHICLE_JWT_ISSUER", ""),
+		VehicleAudience:    getEnv("VEHICLE_JWT_AUDIENCE", "argus-ingestor"),
+		OperatorIssuer:     getEnv("OPERATOR_JWT_ISSUER", ""),
+		OperatorAudience:   getEnv("OPERATOR_JWT_AUDIENCE", "argus"),
+		TLSCertFile:        getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:         getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:    getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientCATenants: getEnv("TLS_CLIENT_CA_TENANTS", ""),
+		Disabled:           disabled,
+	}
+}
+
+// Enabled 配置了任一凭证来源即启用认证
+func (c AuthConfig) Enabled() bool {
+	return c.JWKSFile != "" || c.mTLSEnabled()
+}
+
+func (c AuthConfig) mTLSEnabled() bool {
+	return c.TLSClientCAFile != "" || c.TLSClientCATenants != ""
+}
+
+// LoadClientCAs 加载车辆客户端证书的签发 CA；没有配置 mTLS 时返回 nil
+func LoadClientCAs(cfg AuthConfig) (*auth.ClientCAs, error) {
+	if !cfg.mTLSEnabled() {
+		return nil, nil
+	}
+	tenantFiles := make(map[string]string)
+	for _, entry := range strings.Split(cfg.TLSClientCATenants, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tenantID, file, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(file) == "" {
+			return nil, fmt.Errorf("invalid TLS_CLIENT_CA_TENANTS entry %q, want tenant=file", entry)
+		}
+		tenantFiles[strings.TrimSpace(tenantID)] = strings.TrimSpace(file)
+	}
+	cas, err := auth.LoadClientCAs(cfg.TLSClientCAFile, tenantFiles)
+	if err != nil {
+		return nil, fmt.Errorf("load client CAs: %w", err)
+	}
+	return cas, nil
+}
+
+// NewAuthenticator 按配置创建认证器
+//
+// 没有配置凭证来源时拒绝启动（默认关闭）；只有显式设置 AUTH_DISABLED=true 才返回 nil（中间件放行，仅用于本地开发）
+// 接受 mTLS 的服务通过 auth.WithClientCAs 传入 LoadClientCAs 的结果
+func NewAuthenticator(cfg AuthConfig, opts ...auth.AuthenticatorOption) (*auth.Authenticator, error) {
+	if !cfg.Enabled() {
+		if !cfg.Disabled {
+			return nil, fmt.Errorf("authentication is not configured: set JWKS_FILE and/or TLS_CLIENT_CA_FILE / TLS_CLIENT_CA_TENANTS, or AUTH_DISABLED=true for local development")
+		}
+		log.Printf("[Auth] Warning: authentication disabled by AUTH_DISABLED=true, do not run like this in production")
+		return nil, nil
+	}
+	if cfg.Disabled {
+		log.Printf("[Auth] AUTH_DISABLED is ignored because credentials are configured")
+	}
+
+	var vehicles, operators *auth.Verifier
+	if cfg.JWKSFile != "" {
+		if cfg.VehicleIssuer == "" && cfg.OperatorIssuer == "" {
+			return nil, fmt.Errorf("JWKS_FILE is set but neither VEHICLE_JWT_ISSUER nor OPERATOR_JWT_ISSUER is configured")
+		}
+		keys, err := auth.NewFileJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("init jwks: %w", err)
+		}
+		if cfg.VehicleIssuer != "" {
+			vehicles = auth.NewVerifier(keys, cfg.VehicleIssuer, cfg.VehicleAudience)
+		}
+		if cfg.OperatorIssuer != "" {
+			operators = auth.NewVerifier(keys, cfg.OperatorIssuer, cfg.OperatorAudience)
+		}
+	}
+	log.Printf("[Auth] Authentication enabled (vehicle_jwt=%t, operator_jwt=%t, mtls=%t)",
+		vehicles != nil, operators != nil, cfg.mTLSEnabled())
+	return auth.NewAuthenticator(vehicles, operators, opts...), nil
+}
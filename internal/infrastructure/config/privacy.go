@@ -0,0 +1,38 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// defaultPseudonymKey 仅用于本地开发；所有服务必须使用同一个密钥，否则同一 VIN 的令牌不一致
+const defaultPseudonymKey = "argus-local-dev-pseudonymization-key"
+
+// NewPseudonymizer VIN_PSEUDONYM_KEY 为 base64 编码的密钥（至少 32 字节）
+//
+// 密钥泄露后令牌可以被穷举还原，轮换密钥会让所有历史令牌失效（跨期分析需要重新关联）
+func NewPseudonymizer(getEnv func(key, defaultValue string) string) (*domain.Pseudonymizer, error) {
+	encoded := getEnv("VIN_PSEUDONYM_KEY", "")
+	if encoded == "" {
+		log.Printf("[Privacy] Warning: using default VIN_PSEUDONYM_KEY, do not use it in production")
+		return domain.NewPseudonymizer([]byte(defaultPseudonymKey))
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VIN_PSEUDONYM_KEY: %w", err)
+	}
+	return domain.NewPseudonymizer(key)
+}
+
+// GPSDecimalsFromEnv GPS_REDACTION_DECIMALS 解析产物中 GPS 坐标保留的小数位，-1 表示删除
+func GPSDecimalsFromEnv(getEnv func(key, defaultValue string) string, defaultValue int) (int, error) {
+	decimals, err := strconv.Atoi(getEnv("GPS_REDACTION_DECIMALS", strconv.Itoa(defaultValue)))
+	if err != nil || decimals < -1 || decimals > 6 {
+		return 0, fmt.Errorf("invalid GPS_REDACTION_DECIMALS: must be between -1 and 6")
+	}
+	return decimals, nil
+}
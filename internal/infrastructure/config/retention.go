@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// retentionRuleFile 配置文件中的保留规则（未填写的字段继承上一层，"0s" 表示永久保留）
+type retentionRuleFile struct {
+	RawLogs *Duration `json:"raw_logs"`
+	Derived *Duration `json:"derived"`
+	Batch   *Duration `json:"batch"`
+}
+
+// retentionFile 保留策略配置文件格式：
+//
+//	{
+//	  "default":   {"completed": {"raw_logs": "720h", "derived": "2160h", "batch": "17520h"},
+//	                "failed":    {"batch": "168h"}},
+//	  "platforms": {"model-y": {"completed": {"raw_logs": "2160h"}}}
+//	}
+type retentionFile struct {
+	Default   map[string]retentionRuleFile            `json:"default"`
+	Platforms map[string]map[string]retentionRuleFile `json:"platforms"`
+}
+
+// LoadRetentionPolicy 加载保留策略；path 为空时返回默认策略
+//
+// 覆盖顺序与 SLA 一致：内置默认 → 文件 default → 文件 platforms（按字段覆盖）
+func LoadRetentionPolicy(path string) (*domain.RetentionPolicy, error) {
+	policy := domain.DefaultRetentionPolicy()
+	if path == "" {
+		return policy, nil
+	}
+
+	var file retentionFile
+	if err := LoadJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	for statusStr, override := range file.Default {
+		status, err := parseRetentionStatus(statusStr)
+		if err != nil {
+			return nil, err
+		}
+		policy.Default[status] = override.apply(policy.Default[status])
+	}
+
+	for platform, rules := range file.Platforms {
+		resolved := make(map[domain.BatchStatus]domain.RetentionRule, len(rules))
+		for statusStr, override := range rules {
+			status, err := parseRetentionStatus(statusStr)
+			if err != nil {
+				return nil, fmt.Errorf("platform %s: %w", platform, err)
+			}
+			resolved[status] = override.apply(policy.Default[status])
+		}
+		policy.Platforms[platform] = resolved
+	}
+
+	return policy, nil
+}
+
+func (o retentionRuleFile) apply(base domain.RetentionRule) domain.RetentionRule {
+	if o.RawLogs != nil {
+		base.RawLogs = time.Duration(*o.RawLogs)
+	}
+	if o.Derived != nil {
+		base.Derived = time.Duration(*o.Derived)
+	}
+	if o.Batch != nil {
+		base.Batch = time.Duration(*o.Batch)
+	}
+	return base
+}
+
+func parseRetentionStatus(s string) (domain.BatchStatus, error) {
+	status := domain.BatchStatus(s)
+	if !status.IsTerminal() {
+		return "", fmt.Errorf("invalid retention status %q: must be completed or failed", s)
+	}
+	return status, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// stageSLAFile 配置文件中的单阶段 SLA（未填写的字段继承上一层）
+type stageSLAFile struct {
+	Timeout    *Duration `json:"timeout"`
+	PerFile    *Duration `json:"per_file"`
+	MaxRetries *int      `json:"max_retries"`
+}
+
+// slaFile SLA 配置文件格式：
+//
+//	{
+//	  "default":   {"scattering": {"timeout": "5m", "per_file": "10s", "max_retries": 2}},
+//	  "platforms": {"model-y": {"diagnosing": {"timeout": "20m"}}},
+//	  "priority_scale": {"safety_critical": 0.2}
+//	}
+type slaFile struct {
+	Default       map[string]stageSLAFile            `json:"default"`
+	Platforms     map[string]map[string]stageSLAFile `json:"platforms"`
+	PriorityScale map[string]float64                 `json:"priority_scale"`
+}
+
+// LoadSLAPolicy 加载 SLA 配置；path 为空时返回默认策略
+//
+// 覆盖顺序：内置默认 → 文件 default → 文件 platforms（按字段覆盖）
+func LoadSLAPolicy(path string) (*domain.SLAPolicy, error) {
+	policy := domain.DefaultSLAPolicy()
+	if path == "" {
+		return policy, nil
+	}
+
+	var file slaFile
+	if err := LoadJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	for statusStr, override := range file.Default {
+		status, err := parseStageStatus(statusStr)
+		if err != nil {
+			return nil, err
+		}
+		policy.Default[status] = override.apply(policy.Default[status])
+	}
+
+	for platform, stages := range file.Platforms {
+		resolved := make(map[domain.BatchStatus]domain.StageSLA, len(stages))
+		for statusStr, override := range stages {
+			status, err := parseStageStatus(statusStr)
+			if err != nil {
+				return nil, fmt.Errorf("platform %s: %w", platform, err)
+			}
+			resolved[status] = override.apply(policy.Default[status])
+		}
+		policy.Platforms[platform] = resolved
+	}
+
+	for priorityStr, factor := range file.PriorityScale {
+		priority := domain.BatchPriority(priorityStr)
+		if !priority.IsValid() {
+			return nil, fmt.Errorf("invalid priority %q in priority_scale", priorityStr)
+		}
+		if factor <= 0 {
+			return nil, fmt.Errorf("priority_scale for %s must be positive, got %v", priorityStr, factor)
+		}
+		policy.PriorityScale[priority] = factor
+	}
+
+	return policy, nil
+}
+
+func (o stageSLAFile) apply(base domain.StageSLA) domain.StageSLA {
+	if o.Timeout != nil {
+		base.Timeout = time.Duration(*o.Timeout)
+	}
+	if o.PerFile != nil {
+		base.PerFile = time.Duration(*o.PerFile)
+	}
+	if o.MaxRetries != nil {
+		base.MaxRetries = *o.MaxRetries
+	}
+	return base
+}
+
+func parseStageStatus(s string) (domain.BatchStatus, error) {
+	status := domain.BatchStatus(s)
+	if !status.IsValid() || status.IsTerminal() {
+		return "", fmt.Errorf("invalid SLA stage %q: must be a non-terminal batch status", s)
+	}
+	return status, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/localfs"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/minio"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+)
+
+const (
+	StorageBackendMinIO = "minio"
+	StorageBackendLocal = "local"
+
+	// defaultLocalSecret 仅用于本地开发；多个进程共享同一目录时必须使用同一个 secret
+	defaultLocalSecret = "argus-local-dev-secret"
+)
+
+// StorageConfig 对象存储配置（STORAGE_BACKEND 选择实现）
+type StorageConfig struct {
+	Backend string
+
+	MinIOEndpoint  string
+	MinIOBucket    string
+	MinIOAccessKey string
+	MinIOSecretKey string
+	MinIOUseSSL    bool
+
+	LocalRoot    string // 本地存储根目录
+	LocalBaseURL string // 预签名链接前缀（指向挂载了 PresignHandler 的服务）
+	LocalSecret  string
+
+	EncryptionKeyFile string // 主密钥文件，非空时启用信封加密
+	EncryptionScope   string // 默认使用的主密钥 scope
+	// EncryptionAllowLegacy 迁移期间允许读取启用加密前的明文对象与 v1 加密对象（rotate-keys 改写完成后关闭）
+	EncryptionAllowLegacy bool
+}
+
+// StorageConfigFromEnv 从环境变量读取（getEnv 为各服务自己的带默认值读取函数）
+func StorageConfigFromEnv(getEnv func(key, defaultValue string) string) StorageConfig {
+	useSSL, _ := strconv.ParseBool(getEnv("MINIO_USE_SSL", "false"))
+	allowLegacy, _ := strconv.ParseBool(getEnv("ENCRYPTION_ALLOW_LEGACY_OBJECTS", "false"))
+	return StorageConfig{
+		Backend:        getEnv("STORAGE_BACKEND", StorageBackendMinIO),
+		MinIOEndpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
+		MinIOBucket:    getEnv("MINIO_BUCKET", "argus-files"),
+		MinIOAccessKey: getEnv("MINIO_ACCESS_KEY", ""),
+		MinIOSecretKey: getEnv("MINIO_SECRET_KEY", ""),
+		MinIOUseSSL:    useSSL,
+		LocalRoot:      getEnv("LOCAL_STORAGE_ROOT", "./data/objects"),
+		LocalBaseURL:   getEnv("LOCAL_STORAGE_BASE_URL", "http://localhost:8080/storage"),
+		LocalSecret:    getEnv("LOCAL_STORAGE_SECRET", defaultLocalSecret),
+
+		EncryptionKeyFile: getEnv("ENCRYPTION_KEY_FILE", ""),
+		EncryptionScope:   getEnv("ENCRYPTION_SCOPE", "default"),
+
+		EncryptionAllowLegacy: allowLegacy,
+	}
+}
+
+// NewObjectStore 按配置创建对象存储；配置了 ENCRYPTION_KEY_FILE 时包一层信封加密
+//
+// 上传、解析、聚合、下载各服务都通过这里创建存储，必须使用同一份密钥文件
+func NewObjectStore(cfg StorageConfig) (domain.ObjectStore, error) {
+	store, err := newBackendStore(cfg)
+	if err != nil || cfg.EncryptionKeyFile == "" {
+		return store, err
+	}
+	keys, err := envelope.NewFileKeyProvider(cfg.EncryptionKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("init encryption keys: %w", err)
+	}
+	var opts []envelope.StoreOption
+	if cfg.EncryptionAllowLegacy {
+		log.Printf("[Storage] Warning: legacy plaintext and v1 encrypted objects are readable, run rotate-keys and unset ENCRYPTION_ALLOW_LEGACY_OBJECTS")
+		opts = append(opts, envelope.AllowLegacyObjects())
+	}
+	log.Printf("[Storage] Envelope encryption enabled (scope=%s)", cfg.EncryptionScope)
+	return envelope.NewStore(store, keys, cfg.EncryptionScope, opts...), nil
+}
+
+func newBackendStore(cfg StorageConfig) (domain.ObjectStore, error) {
+	switch cfg.Backend {
+	case StorageBackendMinIO, "":
+		client, err := minio.NewMinIOClient(cfg.MinIOEndpoint, cfg.MinIOBucket, cfg.MinIOAccessKey, cfg.MinIOSecretKey, cfg.MinIOUseSSL)
+		if err != nil {
+			return nil, err
+		}
+		return tracing.WrapObjectStore(client, StorageBackendMinIO), nil
+	case StorageBackendLocal:
+		if cfg.LocalSecret == defaultLocalSecret {
+			log.Printf("[Storage] Warning: using default LOCAL_STORAGE_SECRET, do not use the local backend in production")
+		}
+		log.Printf("[Storage] Using local filesystem backend at %s", cfg.LocalRoot)
+		store, err := localfs.NewStore(cfg.LocalRoot, cfg.LocalBaseURL, []byte(cfg.LocalSecret))
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %s or %s)", cfg.Backend, StorageBackendMinIO, StorageBackendLocal)
+	}
+}
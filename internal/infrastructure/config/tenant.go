@@ -0,0 +1,64 @@
+package config
+
+import (
+	"log"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// tenantFile 配置文件中的一个租户
+type tenantFile struct {
+	Name      string               `json:"name"`
+	Suspended bool                 `json:"suspended"`
+	Quotas    *domain.TenantQuotas `json:"quotas"` // 未配置时使用 default_quotas
+}
+
+// tenantsFile 租户配置文件格式（租户 ID 为小写字母、数字和连字符）：
+//
+//	{
+//	  "default_quotas": {
+//	    "max_batches_per_day": 5000, "max_file_size_bytes": 2147483648,
+//	    "vehicle": {"max_batches_per_day": 24, "max_upload_bytes_per_day": 5368709120},
+//	    "rate_limits": {
+//	      "create_batch": {"vehicle": {"per_minute": 2, "burst": 5}, "tenant": {"per_minute": 600, "burst": 1000}},
+//	      "upload_file":  {"vehicle": {"per_minute": 60, "burst": 120}}
+//	    }
+//	  },
+//	  "tenants": {
+//	    "default": {"name": "Argus"},
+//	    "oem-a":   {"name": "OEM A", "quotas": {"max_batches_per_day": 20000, "max_storage_bytes": 10995116277760}},
+//	    "oem-b":   {"name": "OEM B", "suspended": true}
+//	  }
+//	}
+//
+// 租户配置了 quotas 时整体替换 default_quotas（不逐项合并）
+type tenantsFile struct {
+	DefaultQuotas domain.TenantQuotas   `json:"default_quotas"`
+	Tenants       map[string]tenantFile `json:"tenants"`
+}
+
+// LoadTenants 加载租户配置；path 为空时只有 default 租户且不限配额（单租户部署）
+func LoadTenants(path string) (*domain.TenantRegistry, error) {
+	if path == "" {
+		return domain.NewTenantRegistry(nil)
+	}
+
+	var file tenantsFile
+	if err := LoadJSON(path, &file); err != nil {
+		return nil, err
+	}
+	tenants := make([]*domain.Tenant, 0, len(file.Tenants))
+	for id, t := range file.Tenants {
+		quotas := file.DefaultQuotas
+		if t.Quotas != nil {
+			quotas = *t.Quotas
+		}
+		tenants = append(tenants, &domain.Tenant{ID: id, Name: t.Name, Suspended: t.Suspended, Quotas: quotas})
+	}
+	registry, err := domain.NewTenantRegistry(tenants)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Config] Loaded %d tenants from %s", len(tenants), path)
+	return registry, nil
+}
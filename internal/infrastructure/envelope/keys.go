@@ -0,0 +1,236 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// dataKeySize 数据密钥长度（AES-256）
+const dataKeySize = 32
+
+// ErrUnknownKey 主密钥不存在（已被删除，或密钥文件未同步到该副本）
+var ErrUnknownKey = errors.New("unknown master key")
+
+// DataKey 一个新生成的数据密钥
+type DataKey struct {
+	KeyID     string // 包裹它的主密钥 ID
+	Plaintext []byte // 明文，只在内存中使用，用完即丢
+	Wrapped   []byte // 主密钥加密后的密文，随对象保存
+}
+
+// KeyProvider 主密钥管理（KMS）接口
+//
+// 主密钥不出 KMS：每个对象用独立的数据密钥加密，对象里只存被包裹的数据密钥，
+// 轮换主密钥时只需要重新包裹数据密钥（Rewrap），不用重新加密日志
+//
+// 云厂商 KMS（AWS KMS GenerateDataKey/Decrypt 等）实现该接口即可替换本地文件实现
+type KeyProvider interface {
+	// GenerateDataKey 用 scope（租户等）当前的主密钥生成数据密钥
+	GenerateDataKey(ctx context.Context, scope string) (*DataKey, error)
+	// UnwrapDataKey 解包数据密钥
+	UnwrapDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	// ActiveKeyID scope 当前使用的主密钥（用于判断对象是否需要 Rewrap）
+	ActiveKeyID(ctx context.Context, scope string) (string, error)
+	// RewrapDataKey 用 scope 当前的主密钥重新包裹数据密钥（对应 AWS KMS ReEncrypt，明文不出 KMS）
+	RewrapDataKey(ctx context.Context, scope, keyID string, wrapped []byte) (*DataKey, error)
+}
+
+// keyFile 本地主密钥文件格式：
+//
+//	{
+//	  "keys":   {"k-2025-01": "<base64 32 bytes>", "k-2025-07": "<base64 32 bytes>"},
+//	  "active": {"default": "k-2025-07", "tenant-a": "k-2025-01"}
+//	}
+//
+// 轮换：新增一个 key 并修改 active，旧 key 保留到所有对象 Rewrap 完成后再删除
+type keyFile struct {
+	Keys   map[string]string `json:"keys"`
+	Active map[string]string `json:"active"`
+}
+
+// FileKeyProvider 基于本地密钥文件的 KeyProvider（开发、测试和单机部署使用）
+//
+// 文件修改后自动重新加载（按 mtime 检查），轮换密钥不需要重启服务
+type FileKeyProvider struct {
+	path string
+
+	mu       sync.RWMutex
+	keys     map[string]cipher.AEAD
+	active   map[string]string
+	modTime  time.Time
+	lastStat time.Time
+}
+
+// reloadCheckInterval 检查密钥文件是否变更的最小间隔
+const reloadCheckInterval = 30 * time.Second
+
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload 重新读取密钥文件
+func (p *FileKeyProvider) Reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("stat key file: %w", err)
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("read key file: %w", err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse key file %s: %w", p.path, err)
+	}
+
+	keys := make(map[string]cipher.AEAD, len(file.Keys))
+	for id, encoded := range file.Keys {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("key %s: invalid base64: %w", id, err)
+		}
+		if len(raw) != dataKeySize {
+			return fmt.Errorf("key %s: must be %d bytes, got %d", id, dataKeySize, len(raw))
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", id, err)
+		}
+		keys[id] = aead
+	}
+	if _, ok := file.Active["default"]; !ok {
+		return errors.New("key file must set active key for scope \"default\"")
+	}
+	for scope, id := range file.Active {
+		if _, ok := keys[id]; !ok {
+			return fmt.Errorf("active key %s for scope %s: %w", id, scope, ErrUnknownKey)
+		}
+	}
+
+	p.mu.Lock()
+	p.keys, p.active = keys, file.Active
+	p.modTime, p.lastStat = info.ModTime(), time.Now()
+	p.mu.Unlock()
+	log.Printf("[Envelope] Loaded %d master keys, %d scopes from %s", len(keys), len(file.Active), p.path)
+	return nil
+}
+
+// maybeReload 文件变更时重新加载；加载失败保留旧密钥继续工作
+func (p *FileKeyProvider) maybeReload() {
+	p.mu.RLock()
+	due := time.Since(p.lastStat) >= reloadCheckInterval
+	modTime := p.modTime
+	p.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(p.path)
+	p.mu.Lock()
+	p.lastStat = time.Now()
+	p.mu.Unlock()
+	if err != nil || !info.ModTime().After(modTime) {
+		return
+	}
+	if err := p.Reload(); err != nil {
+		log.Printf("[Envelope] Failed to reload key file, keeping previous keys: %v", err)
+	}
+}
+
+func (p *FileKeyProvider) ActiveKeyID(ctx context.Context, scope string) (string, error) {
+	p.maybeReload()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if id, ok := p.active[scope]; ok {
+		return id, nil
+	}
+	return p.active["default"], nil
+}
+
+func (p *FileKeyProvider) GenerateDataKey(ctx context.Context, scope string) (*DataKey, error) {
+	keyID, err := p.ActiveKeyID(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	wrapped, err := p.wrap(keyID, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: keyID, Plaintext: plaintext, Wrapped: wrapped}, nil
+}
+
+func (p *FileKeyProvider) RewrapDataKey(ctx context.Context, scope, keyID string, wrapped []byte) (*DataKey, error) {
+	plaintext, err := p.UnwrapDataKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	activeID, err := p.ActiveKeyID(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped, err := p.wrap(activeID, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: activeID, Wrapped: rewrapped}, nil
+}
+
+// wrap AES-GCM(主密钥)，nonce 放在密文前，keyID 作为附加认证数据（防止换 keyID 头）
+func (p *FileKeyProvider) wrap(keyID string, plaintext []byte) ([]byte, error) {
+	p.mu.RLock()
+	aead, ok := p.keys[keyID]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(keyID)), nil
+}
+
+func (p *FileKeyProvider) UnwrapDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	p.maybeReload()
+	p.mu.RLock()
+	aead, ok := p.keys[keyID]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped data key too short")
+	}
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with %s: %w", keyID, err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// rewrapSuffix Rewrap 期间的临时副本
+const rewrapSuffix = ".rewrap-tmp"
+
+// RotationResult 一次密钥轮换的统计
+type RotationResult struct {
+	Scanned   int
+	Rewrapped int // 数据密钥改用新主密钥包裹（v1 对象整体重新加密为当前格式）
+	Encrypted int // 存量明文对象被加密
+	Failed    int
+}
+
+// Rewrap 让对象使用其 scope 当前的主密钥与当前格式：
+// 当前格式的加密对象只替换头里被包裹的数据密钥，密文段原样保留（数据密钥、nonce 前缀与关联数据都不变）；
+// v1 对象解密后整体重新加密；明文对象（启用加密前写入）用对象路径所属租户的主密钥整体加密。返回对象是否被改写
+//
+// 不能边读边写同一个 key：MinIO 的 PUT 要等同一对象的 GET 结束，而 GET 的数据又要等 PUT 消费，会死锁。
+// 所以先 CopyObject（服务端复制）到临时 key，从副本读、写回原 key，最后删除副本
+func (s *Store) Rewrap(ctx context.Context, key string) (bool, error) {
+	h, _, info, err := s.stat(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if !isLegacy(h) {
+		activeID, err := s.keys.ActiveKeyID(ctx, h.Scope)
+		if err != nil {
+			return false, err
+		}
+		if activeID == h.KeyID {
+			return false, nil
+		}
+	}
+
+	tmpKey := key + rewrapSuffix
+	if err := s.inner.CopyObject(ctx, key, tmpKey); err != nil {
+		return false, fmt.Errorf("copy to temp: %w", err)
+	}
+	defer func() {
+		if err := s.inner.RemoveObjects(context.Background(), []string{tmpKey}); err != nil {
+			log.Printf("[Envelope] Failed to remove temp object %s: %v", tmpKey, err)
+		}
+	}()
+
+	if isLegacy(h) {
+		// v1 密文不绑定对象 key，从副本解密即可；Rewrap 本身就是迁移，不受 AllowLegacyObjects 限制
+		src, plain, _, err := s.openWhole(ctx, tmpKey, true)
+		if err != nil {
+			return false, err
+		}
+		defer src.Close()
+		// 轮换任务的 ctx 不带租户，明文对象按对象路径确定 scope（与写入时 TenantMiddleware / Worker 使用的租户一致）
+		scope := domain.TenantFromObjectKey(key)
+		if h != nil {
+			scope = h.Scope
+		}
+		return true, s.PutObject(WithScope(ctx, scope), key, src, plain.Size, plain.ContentType)
+	}
+	return true, s.rewrapHeader(ctx, tmpKey, key, info.ContentType)
+}
+
+// rewrapHeader 从 src 读出密文，换上新头写到 dst
+func (s *Store) rewrapHeader(ctx context.Context, src, dst, contentType string) error {
+	body, raw, err := s.inner.GetObjectRange(ctx, src, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	br := bufio.NewReaderSize(body, maxHeaderSize)
+	h, headerLen, err := readHeader(br)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		return fmt.Errorf("%w: %s is no longer encrypted", ErrCorrupted, src)
+	}
+
+	dataKey, err := s.keys.RewrapDataKey(ctx, h.Scope, h.KeyID, h.WrappedKey)
+	if err != nil {
+		return err
+	}
+	h.KeyID, h.WrappedKey = dataKey.KeyID, dataKey.Wrapped
+	headerBytes, err := h.marshal()
+	if err != nil {
+		return err
+	}
+
+	size := int64(len(headerBytes)) + raw.Size - headerLen
+	r := io.MultiReader(bytes.NewReader(headerBytes), br)
+	return s.inner.PutObject(ctx, dst, r, size, contentType)
+}
+
+// RotateKeys 对 prefix 下的所有对象执行 Rewrap（主密钥轮换后运行，完成后才能从密钥文件删除旧密钥）
+func (s *Store) RotateKeys(ctx context.Context, prefix string) (*RotationResult, error) {
+	objects, err := s.inner.ListObjects(ctx, prefix, true)
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+
+	result := &RotationResult{}
+	for _, obj := range objects {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if strings.HasSuffix(obj.Key, rewrapSuffix) {
+			continue
+		}
+		result.Scanned++
+
+		wasEncrypted, err := s.isEncrypted(ctx, obj.Key)
+		if err == nil {
+			var changed bool
+			changed, err = s.Rewrap(ctx, obj.Key)
+			switch {
+			case err != nil:
+			case changed && wasEncrypted:
+				result.Rewrapped++
+			case changed:
+				result.Encrypted++
+			}
+		}
+		if err != nil {
+			result.Failed++
+			log.Printf("[Envelope] Failed to rewrap %s: %v", obj.Key, err)
+		}
+	}
+	log.Printf("[Envelope] Key rotation under %q: scanned=%d, rewrapped=%d, encrypted=%d, failed=%d",
+		prefix, result.Scanned, result.Rewrapped, result.Encrypted, result.Failed)
+	return result, nil
+}
+
+func (s *Store) isEncrypted(ctx context.Context, key string) (bool, error) {
+	h, _, _, err := s.stat(ctx, key)
+	return h != nil, err
+}
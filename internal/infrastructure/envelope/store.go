@@ -0,0 +1,416 @@
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// 对象格式：magic(8) | 头长度(uint32) | 头(JSON) | 分段密文
+//
+// 被包裹的数据密钥放在对象自身而不是存储元数据里：MinIO 和本地文件系统都能用，
+// CopyObject 复制的对象依然可以解密
+const (
+	magic = "ARGUSE1\n"
+
+	// maxHeaderSize 读取头时一次 Range 请求的大小（头实际只有两三百字节）
+	maxHeaderSize = 4096
+
+	// headerVersion 新对象的格式版本：v2 起各段密文绑定对象 key（v1 没有关联数据）
+	headerVersion = 2
+	aadContext    = "argus-envelope-v2"
+)
+
+// ErrPresignUnsupported 加密对象不能直接通过预签名链接下载（存储端只有密文），需经服务端解密流式返回
+var ErrPresignUnsupported = errors.New("presigned download is not supported for encrypted objects")
+
+// ErrLegacyObject 启用加密前写入的明文对象或 v1 加密对象，只有迁移期间（AllowLegacyObjects）可读；
+// rotate-keys 会把它们改写为当前格式
+var ErrLegacyObject = errors.New("legacy object is not readable, run rotate-keys or allow legacy objects during migration")
+
+type header struct {
+	Version     int    `json:"v"`
+	KeyID       string `json:"key_id"`
+	Scope       string `json:"scope"`
+	WrappedKey  []byte `json:"wrapped_key"`
+	NoncePrefix []byte `json:"nonce_prefix"`
+	SegmentSize int    `json:"segment_size"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+func (h *header) marshal() ([]byte, error) {
+	body, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(magic)+4+len(body))
+	out = append(out, magic...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...), nil
+}
+
+// readHeader 读取对象头；不是加密对象时返回 (nil, 0, nil)，调用方按明文处理
+func readHeader(r *bufio.Reader) (*header, int64, error) {
+	prefix, err := r.Peek(len(magic) + 4)
+	if err != nil || string(prefix[:len(magic)]) != magic {
+		return nil, 0, nil
+	}
+	bodyLen := int(binary.BigEndian.Uint32(prefix[len(magic):]))
+	if bodyLen > maxHeaderSize-len(prefix) {
+		return nil, 0, fmt.Errorf("%w: header too large", ErrCorrupted)
+	}
+	buf := make([]byte, len(prefix)+bodyLen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, 0, fmt.Errorf("%w: read header: %v", ErrCorrupted, err)
+	}
+	var h header
+	if err := json.Unmarshal(buf[len(prefix):], &h); err != nil {
+		return nil, 0, fmt.Errorf("%w: parse header: %v", ErrCorrupted, err)
+	}
+	if h.Version < 1 || h.Version > headerVersion || h.SegmentSize <= 0 || len(h.NoncePrefix) != noncePrefixSize {
+		return nil, 0, fmt.Errorf("%w: unsupported header", ErrCorrupted)
+	}
+	return &h, int64(len(buf)), nil
+}
+
+// isLegacy 明文对象（h == nil）与 v1 对象：密文不绑定对象 key
+func isLegacy(h *header) bool {
+	return h == nil || h.Version < headerVersion
+}
+
+// objectAAD v2 对象各段关联数据的公共部分：对象 key 与头中 Rewrap 不会改变的字段。
+// key_id / wrapped_key 会被 Rewrap 替换，不放进来；被篡改时解出的数据密钥不对，GCM 同样校验失败
+func objectAAD(key string, h *header) []byte {
+	if isLegacy(h) {
+		return nil
+	}
+	var aad []byte
+	for _, field := range []string{aadContext, key, h.Scope, h.ContentType} {
+		aad = binary.BigEndian.AppendUint32(aad, uint32(len(field)))
+		aad = append(aad, field...)
+	}
+	return binary.BigEndian.AppendUint32(aad, uint32(h.SegmentSize))
+}
+
+type scopeKey struct{}
+
+// WithScope 指定本次写入使用哪个 scope（租户）的主密钥；未指定时使用 Store 的默认 scope
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// Store 透明加解密的 ObjectStore 装饰器
+//
+// Worker、Query 下载路径只依赖 domain.ObjectStore，加密对它们透明；
+// MinIO 和本地文件系统两种后端都能复用
+//
+// 没有 magic 头的对象默认拒绝读取（ErrLegacyObject），否则任何人写入存储的明文都会被当作合法对象返回；
+// 启用加密之前的存量对象在迁移期间用 AllowLegacyObjects 读取，由 rotate-keys 改写为当前格式
+type Store struct {
+	inner        domain.ObjectStore
+	keys         KeyProvider
+	defaultScope string
+	segmentSize  int
+	allowLegacy  bool
+}
+
+var _ domain.ObjectStore = (*Store)(nil)
+
+// StoreOption 可选配置
+type StoreOption func(*Store)
+
+// AllowLegacyObjects 迁移期间允许读取明文对象与 v1 加密对象；rotate-keys 全部改写后应关闭
+func AllowLegacyObjects() StoreOption {
+	return func(s *Store) {
+		s.allowLegacy = true
+	}
+}
+
+func NewStore(inner domain.ObjectStore, keys KeyProvider, defaultScope string, opts ...StoreOption) *Store {
+	if defaultScope == "" {
+		defaultScope = "default"
+	}
+	s := &Store{inner: inner, keys: keys, defaultScope: defaultScope, segmentSize: DefaultSegmentSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Store) checkLegacy(key string, h *header, allowLegacy bool) error {
+	if isLegacy(h) && !allowLegacy {
+		return fmt.Errorf("%w: %s", ErrLegacyObject, key)
+	}
+	return nil
+}
+
+// Ping 转发给内层存储
+func (s *Store) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(domain.ObjectStorePinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (s *Store) scope(ctx context.Context) string {
+	if scope, ok := ctx.Value(scopeKey{}).(string); ok && scope != "" {
+		return scope
+	}
+	return s.defaultScope
+}
+
+// PutObject 每个对象生成一个新的数据密钥，边读边加密上传
+func (s *Store) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	scope := s.scope(ctx)
+	dataKey, err := s.keys.GenerateDataKey(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("generate data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey.Plaintext)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	h := &header{
+		Version:     headerVersion,
+		KeyID:       dataKey.KeyID,
+		Scope:       scope,
+		WrappedKey:  dataKey.Wrapped,
+		NoncePrefix: prefix,
+		SegmentSize: s.segmentSize,
+		ContentType: contentType,
+	}
+	headerBytes, err := h.marshal()
+	if err != nil {
+		return err
+	}
+
+	// 长度已知时算出密文总长度，MinIO 可以走单次 PUT 而不是 multipart
+	encSize := int64(-1)
+	if size >= 0 {
+		encSize = int64(len(headerBytes)) + ciphertextSize(size, s.segmentSize)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		if _, err := pw.Write(headerBytes); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		enc := newEncryptWriter(pw, aead, prefix, objectAAD(key, h), s.segmentSize)
+		_, err := io.Copy(enc, reader)
+		if err == nil {
+			err = enc.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = s.inner.PutObject(ctx, key, pr, encSize, contentType)
+	// 上传提前失败时让加密端的写入立即返回，而不是阻塞在管道上
+	pr.CloseWithError(err)
+	return err
+}
+
+func (s *Store) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, _, err := s.GetObjectRange(ctx, key, 0, 0)
+	return rc, err
+}
+
+// GetObjectRange offset/length 都是明文坐标，换算成涉及的密文段再解密
+func (s *Store) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *domain.ObjectInfo, error) {
+	if offset == 0 && length <= 0 {
+		rc, info, _, err := s.openWhole(ctx, key, s.allowLegacy)
+		return rc, info, err
+	}
+
+	h, headerLen, info, err := s.stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkLegacy(key, h, s.allowLegacy); err != nil {
+		return nil, nil, err
+	}
+	if h == nil {
+		return s.inner.GetObjectRange(ctx, key, offset, length)
+	}
+	if offset >= info.Size {
+		return io.NopCloser(bytes.NewReader(nil)), info, nil
+	}
+
+	seg := int64(h.SegmentSize)
+	lastSeg := segmentCount(info.Size, h.SegmentSize) - 1
+	first := offset / seg
+	end := lastSeg
+	if length > 0 && (offset+length-1)/seg < end {
+		end = (offset + length - 1) / seg
+	}
+
+	cipherOffset := headerLen + first*(seg+tagSize)
+	cipherLength := int64(0) // 读到末尾
+	if end < lastSeg {
+		cipherLength = (end - first + 1) * (seg + tagSize)
+	}
+	body, _, err := s.inner.GetObjectRange(ctx, key, cipherOffset, cipherLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := s.dataAEAD(ctx, h)
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+
+	var r io.Reader = newDecryptReader(body, aead, h.NoncePrefix, objectAAD(key, h), h.SegmentSize, first, end, lastSeg)
+	if skip := offset - first*seg; skip > 0 {
+		if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+			body.Close()
+			return nil, nil, err
+		}
+	}
+	if length > 0 {
+		r = io.LimitReader(r, length)
+	}
+	return readCloser{Reader: r, Closer: body}, info, nil
+}
+
+// openWhole 整个对象只发一次请求：先读头，剩余部分直接接着解密；明文对象返回 h == nil
+func (s *Store) openWhole(ctx context.Context, key string, allowLegacy bool) (io.ReadCloser, *domain.ObjectInfo, *header, error) {
+	body, info, err := s.inner.GetObjectRange(ctx, key, 0, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	br := bufio.NewReaderSize(body, maxHeaderSize)
+	h, headerLen, err := readHeader(br)
+	if err == nil {
+		err = s.checkLegacy(key, h, allowLegacy)
+	}
+	if err != nil {
+		body.Close()
+		return nil, nil, nil, err
+	}
+	if h == nil {
+		return readCloser{Reader: br, Closer: body}, info, nil, nil
+	}
+
+	plain, err := s.plainInfo(info, h, headerLen)
+	if err != nil {
+		body.Close()
+		return nil, nil, nil, err
+	}
+	aead, err := s.dataAEAD(ctx, h)
+	if err != nil {
+		body.Close()
+		return nil, nil, nil, err
+	}
+	lastSeg := segmentCount(plain.Size, h.SegmentSize) - 1
+	r := newDecryptReader(br, aead, h.NoncePrefix, objectAAD(key, h), h.SegmentSize, 0, lastSeg, lastSeg)
+	return readCloser{Reader: r, Closer: body}, plain, h, nil
+}
+
+// StatObject Size 为明文大小
+func (s *Store) StatObject(ctx context.Context, key string) (*domain.ObjectInfo, error) {
+	h, _, info, err := s.stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLegacy(key, h, s.allowLegacy); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// stat 读取对象头并换算明文元数据；明文对象返回 h == nil
+func (s *Store) stat(ctx context.Context, key string) (*header, int64, *domain.ObjectInfo, error) {
+	body, info, err := s.inner.GetObjectRange(ctx, key, 0, maxHeaderSize)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer body.Close()
+
+	h, headerLen, err := readHeader(bufio.NewReaderSize(body, maxHeaderSize))
+	if err != nil || h == nil {
+		return nil, 0, info, err
+	}
+	plain, err := s.plainInfo(info, h, headerLen)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return h, headerLen, plain, nil
+}
+
+func (s *Store) plainInfo(info *domain.ObjectInfo, h *header, headerLen int64) (*domain.ObjectInfo, error) {
+	size, err := plaintextSize(info.Size-headerLen, h.SegmentSize)
+	if err != nil {
+		return nil, err
+	}
+	plain := *info
+	plain.Size = size
+	if h.ContentType != "" {
+		plain.ContentType = h.ContentType
+	}
+	return &plain, nil
+}
+
+func (s *Store) dataAEAD(ctx context.Context, h *header) (cipher.AEAD, error) {
+	dataKey, err := s.keys.UnwrapDataKey(ctx, h.KeyID, h.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(dataKey)
+}
+
+// ListObjects 返回的 Size 为存储端（密文）大小，需要明文大小时用 StatObject
+func (s *Store) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	return s.inner.ListObjects(ctx, prefix, recursive)
+}
+
+func (s *Store) RemoveObjects(ctx context.Context, keys []string) error {
+	return s.inner.RemoveObjects(ctx, keys)
+}
+
+// CopyObject 密文绑定了对象 key，不能原样复制：解密后用原对象的 scope 重新加密写到 dstKey
+func (s *Store) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	src, info, h, err := s.openWhole(ctx, srcKey, s.allowLegacy)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if h == nil {
+		// 迁移期间的存量明文对象保持明文，由 rotate-keys 统一加密
+		return s.inner.CopyObject(ctx, srcKey, dstKey)
+	}
+	return s.PutObject(WithScope(ctx, h.Scope), dstKey, src, info.Size, info.ContentType)
+}
+
+// PresignedGetObject 迁移期间存量明文对象仍可预签名；加密对象返回 ErrPresignUnsupported
+func (s *Store) PresignedGetObject(ctx context.Context, key string, expiry time.Duration, downloadName string) (string, error) {
+	h, _, _, err := s.stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if h != nil {
+		return "", ErrPresignUnsupported
+	}
+	if err := s.checkLegacy(key, h, s.allowLegacy); err != nil {
+		return "", err
+	}
+	return s.inner.PresignedGetObject(ctx, key, expiry, downloadName)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
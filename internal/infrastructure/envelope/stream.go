@@ -0,0 +1,182 @@
+package envelope
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 流式分段 AES-GCM（参考 Tink Streaming AEAD / age 的 STREAM 构造）
+//
+// GCM 要拿到全部密文才能校验 tag，整对象加密无法边下边解，也无法只解密 Range 读取涉及的部分。
+// 每段独立加密，nonce = 前缀(7) || 段序号(4) || 末段标记(1)：
+// 段被调换顺序 → 序号不匹配；对象被截断 → 最后一段没有末段标记，都会校验失败。
+// v2 对象的关联数据（AAD）= 对象 AAD（见 objectAAD）|| 段序号 || 末段标记，
+// 段或整个对象被搬到别的 key 下（包括共用数据密钥的其他租户）同样校验失败
+const (
+	tagSize         = 16
+	noncePrefixSize = 7
+
+	// DefaultSegmentSize 每段明文大小
+	DefaultSegmentSize = 64 * 1024
+)
+
+// ErrCorrupted 密文校验失败（被篡改、截断，或数据密钥不匹配）
+var ErrCorrupted = errors.New("encrypted object corrupted")
+
+func segmentNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// segmentAAD 段的关联数据；objectAAD 为 nil（v1 对象）时不带关联数据
+func segmentAAD(objectAAD []byte, counter uint32, last bool) []byte {
+	if objectAAD == nil {
+		return nil
+	}
+	aad := make([]byte, 0, len(objectAAD)+5)
+	aad = append(aad, objectAAD...)
+	aad = binary.BigEndian.AppendUint32(aad, counter)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
+
+// segmentCount 明文长度对应的段数（空对象也有一个空的末段）
+func segmentCount(plainSize int64, segSize int) int64 {
+	n := (plainSize + int64(segSize) - 1) / int64(segSize)
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// ciphertextSize 加密后的数据部分大小（不含头）
+func ciphertextSize(plainSize int64, segSize int) int64 {
+	return plainSize + segmentCount(plainSize, segSize)*tagSize
+}
+
+// plaintextSize 由数据部分大小反推明文大小
+func plaintextSize(cipherSize int64, segSize int) (int64, error) {
+	full := int64(segSize + tagSize)
+	n := (cipherSize + full - 1) / full
+	if cipherSize < tagSize || cipherSize-n*tagSize < 0 {
+		return 0, fmt.Errorf("%w: invalid ciphertext size %d", ErrCorrupted, cipherSize)
+	}
+	return cipherSize - n*tagSize, nil
+}
+
+// encryptWriter 按段加密写入；Close 写出末段（调用方必须 Close）
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	aad     []byte
+	segSize int
+	buf     []byte
+	counter uint32
+}
+
+func newEncryptWriter(w io.Writer, aead cipher.AEAD, prefix, aad []byte, segSize int) *encryptWriter {
+	return &encryptWriter{w: w, aead: aead, prefix: prefix, aad: aad, segSize: segSize, buf: make([]byte, 0, segSize)}
+}
+
+// Write 缓冲满一段且还有后续数据时才写出（此时才能确定它不是末段）
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if len(e.buf) == e.segSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):e.segSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	if e.counter == ^uint32(0) {
+		return errors.New("encrypted object too large")
+	}
+	out := e.aead.Seal(nil, segmentNonce(e.prefix, e.counter, last), e.buf, segmentAAD(e.aad, e.counter, last))
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(out)
+	return err
+}
+
+// decryptReader 按段解密 [first, end] 范围内的段；last 为对象末段序号
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	aad     []byte
+	segSize int
+	counter uint32
+	end     uint32
+	last    uint32
+
+	chunk []byte
+	plain []byte
+	pos   int
+	done  bool
+}
+
+func newDecryptReader(r io.Reader, aead cipher.AEAD, prefix, aad []byte, segSize int, first, end, last int64) *decryptReader {
+	return &decryptReader{
+		r: r, aead: aead, prefix: prefix, aad: aad, segSize: segSize,
+		counter: uint32(first), end: uint32(end), last: uint32(last),
+		chunk: make([]byte, segSize+tagSize),
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for d.pos >= len(d.plain) {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+func (d *decryptReader) next() error {
+	isLast := d.counter == d.last
+	n, err := io.ReadFull(d.r, d.chunk)
+	switch {
+	case err == io.ErrUnexpectedEOF && isLast:
+	case err == io.ErrUnexpectedEOF, err == io.EOF:
+		return fmt.Errorf("%w: segment %d truncated", ErrCorrupted, d.counter)
+	case err != nil:
+		return err
+	}
+
+	plain, err := d.aead.Open(d.plain[:0], segmentNonce(d.prefix, d.counter, isLast), d.chunk[:n], segmentAAD(d.aad, d.counter, isLast))
+	if err != nil {
+		return fmt.Errorf("%w: segment %d", ErrCorrupted, d.counter)
+	}
+	d.plain, d.pos = plain, 0
+	d.done = d.counter == d.end
+	d.counter++
+	return nil
+}
@@ -0,0 +1,177 @@
+package envelope_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/localfs"
+)
+
+func writeKeyFile(t *testing.T, path string, keys map[string][]byte, active map[string]string) {
+	encoded := make(map[string]string, len(keys))
+	for id, key := range keys {
+		encoded[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": encoded, "active": active})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+func readAll(t *testing.T, rc io.ReadCloser) []byte {
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return data
+}
+
+// TestStore_EncryptDecryptRotate - 加解密往返、区间读取、篡改与搬移检测、存量明文与密钥轮换
+func TestStore_EncryptDecryptRotate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	inner, err := localfs.NewStore(filepath.Join(dir, "objects"), "http://localhost/storage", []byte("secret"))
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(dir, "keys.json")
+	oldKey, newKey := randomBytes(t, 32), randomBytes(t, 32)
+	writeKeyFile(t, keyPath, map[string][]byte{"k1": oldKey}, map[string]string{"default": "k1"})
+	keys, err := envelope.NewFileKeyProvider(keyPath)
+	require.NoError(t, err)
+	store := envelope.NewStore(inner, keys, "default")
+
+	// 跨越多个 64KiB 段，分别测试已知长度和流式上传
+	plain := randomBytes(t, 3*envelope.DefaultSegmentSize+123)
+	require.NoError(t, store.PutObject(ctx, "b1/raw.log", bytes.NewReader(plain), int64(len(plain)), "text/plain"))
+	require.NoError(t, store.PutObject(ctx, "b1/stream.log", io.MultiReader(bytes.NewReader(plain)), -1, "text/plain"))
+	require.NoError(t, store.PutObject(ctx, "b1/empty", bytes.NewReader(nil), 0, ""))
+
+	for _, key := range []string{"b1/raw.log", "b1/stream.log"} {
+		rc, err := store.GetObject(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, plain, readAll(t, rc), key)
+
+		info, err := store.StatObject(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(plain)), info.Size)
+		assert.Equal(t, "text/plain", info.ContentType)
+	}
+	rc, err := store.GetObject(ctx, "b1/empty")
+	require.NoError(t, err)
+	assert.Empty(t, readAll(t, rc))
+
+	// 存储端是密文
+	raw, err := inner.GetObject(ctx, "b1/raw.log")
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(readAll(t, raw), plain[:64]))
+
+	// 区间读取：段内、跨段边界、读到末尾、越界
+	seg := int64(envelope.DefaultSegmentSize)
+	for _, r := range [][2]int64{{10, 20}, {seg - 5, 10}, {2*seg + 7, seg}, {3 * seg, 0}, {int64(len(plain)) + 10, 5}} {
+		rc, _, err := store.GetObjectRange(ctx, "b1/raw.log", r[0], r[1])
+		require.NoError(t, err)
+		start, end := r[0], int64(len(plain))
+		if r[1] > 0 && start+r[1] < end {
+			end = start + r[1]
+		}
+		if start > end {
+			start = end
+		}
+		assert.Equal(t, plain[start:end], readAll(t, rc), "range %v", r)
+	}
+
+	// 篡改任意一个字节 → 解密失败
+	raw, err = inner.GetObject(ctx, "b1/raw.log")
+	require.NoError(t, err)
+	tampered := readAll(t, raw)
+	tampered[len(tampered)-100] ^= 0xff
+	require.NoError(t, inner.PutObject(ctx, "b1/tampered", bytes.NewReader(tampered), int64(len(tampered)), ""))
+	rc, err = store.GetObject(ctx, "b1/tampered")
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	rc.Close()
+	assert.ErrorIs(t, err, envelope.ErrCorrupted)
+	require.NoError(t, inner.RemoveObjects(ctx, []string{"b1/tampered"}))
+
+	// 密文绑定对象 key：原样搬到别的 key（如另一个租户的路径）下无法解密；经 Store 复制则重新加密
+	raw, err = inner.GetObject(ctx, "b1/raw.log")
+	require.NoError(t, err)
+	moved := readAll(t, raw)
+	require.NoError(t, inner.PutObject(ctx, "tenants/oem-b/raw.log", bytes.NewReader(moved), int64(len(moved)), ""))
+	rc, err = store.GetObject(ctx, "tenants/oem-b/raw.log")
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	rc.Close()
+	assert.ErrorIs(t, err, envelope.ErrCorrupted)
+	require.NoError(t, inner.RemoveObjects(ctx, []string{"tenants/oem-b/raw.log"}))
+
+	require.NoError(t, store.CopyObject(ctx, "b1/raw.log", "b2/copy.log"))
+	rc, err = store.GetObject(ctx, "b2/copy.log")
+	require.NoError(t, err)
+	assert.Equal(t, plain, readAll(t, rc))
+	require.NoError(t, inner.RemoveObjects(ctx, []string{"b2/copy.log"}))
+
+	// 启用加密前写入的明文对象默认拒绝读取，迁移期间显式允许
+	require.NoError(t, inner.PutObject(ctx, "b1/legacy.csv", bytes.NewReader([]byte("a,b\n1,2\n")), -1, "text/csv"))
+	_, err = store.GetObject(ctx, "b1/legacy.csv")
+	assert.ErrorIs(t, err, envelope.ErrLegacyObject)
+	_, err = store.StatObject(ctx, "b1/legacy.csv")
+	assert.ErrorIs(t, err, envelope.ErrLegacyObject)
+	migrating := envelope.NewStore(inner, keys, "default", envelope.AllowLegacyObjects())
+	rc, err = migrating.GetObject(ctx, "b1/legacy.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(readAll(t, rc)))
+
+	// 轮换：新增 k2 → 存量对象 Rewrap / 明文对象加密 → 删除 k1 后仍可解密
+	writeKeyFile(t, keyPath, map[string][]byte{"k1": oldKey, "k2": newKey}, map[string]string{"default": "k2"})
+	require.NoError(t, keys.Reload())
+	result, err := store.RotateKeys(ctx, "b1/")
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Scanned)
+	assert.Equal(t, 3, result.Rewrapped)
+	assert.Equal(t, 1, result.Encrypted)
+	assert.Zero(t, result.Failed)
+
+	writeKeyFile(t, keyPath, map[string][]byte{"k2": newKey}, map[string]string{"default": "k2"})
+	require.NoError(t, keys.Reload())
+	rc, err = store.GetObject(ctx, "b1/raw.log")
+	require.NoError(t, err)
+	assert.Equal(t, plain, readAll(t, rc))
+	rc, err = store.GetObject(ctx, "b1/legacy.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(readAll(t, rc)))
+
+	_, err = store.PresignedGetObject(ctx, "b1/raw.log", 0, "")
+	assert.ErrorIs(t, err, envelope.ErrPresignUnsupported)
+
+	// 租户路径下的存量明文用该租户的主密钥加密（轮换任务的 ctx 不带租户）
+	tenantKey := randomBytes(t, 32)
+	writeKeyFile(t, keyPath, map[string][]byte{"k2": newKey, "k3": tenantKey}, map[string]string{"default": "k2", "oem-a": "k3"})
+	require.NoError(t, keys.Reload())
+	require.NoError(t, inner.PutObject(ctx, "tenants/oem-a/b2/legacy.csv", bytes.NewReader([]byte("a,b\n")), -1, "text/csv"))
+	result, err = store.RotateKeys(ctx, "tenants/oem-a/")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Encrypted)
+
+	writeKeyFile(t, keyPath, map[string][]byte{"k3": tenantKey}, map[string]string{"default": "k3", "oem-a": "k3"})
+	require.NoError(t, keys.Reload())
+	rc, err = store.GetObject(ctx, "tenants/oem-a/b2/legacy.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", string(readAll(t, rc)))
+}
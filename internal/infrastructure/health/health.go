@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status 检查结果 / 整体状态
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // 非关键依赖故障：继续接流量，部分功能不可用
+	StatusDown     Status = "down"     // 关键依赖故障（就绪）或进程卡死（存活）
+)
+
+// CheckFunc 单项检查；返回 nil 表示正常
+type CheckFunc func(ctx context.Context) error
+
+// Kind 检查类别
+type Kind string
+
+const (
+	// KindLiveness 存活检查（/healthz）：失败意味着重启能解决问题，如 Consumer 卡死；不能包含外部依赖，
+	// 否则数据库故障会让所有副本一起重启
+	KindLiveness Kind = "liveness"
+	// KindCritical 关键依赖（/readyz）：失败时返回 503，摘除流量
+	KindCritical Kind = "critical"
+	// KindOptional 非关键依赖（/readyz）：失败时整体为 degraded，仍返回 200
+	KindOptional Kind = "optional"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = time.Second
+)
+
+// Result 单项检查结果
+type Result struct {
+	Name       string    `json:"name"`
+	Kind       Kind      `json:"kind"`
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report 一次检查的汇总
+type Report struct {
+	Service   string    `json:"service"`
+	Status    Status    `json:"status"`
+	Checks    []Result  `json:"checks"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// StatusReport 状态页：存活 + 就绪 + 进程信息
+type StatusReport struct {
+	Service   string    `json:"service"`
+	Status    Status    `json:"status"`
+	Instance  string    `json:"instance"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+	GoVersion string    `json:"go_version"`
+	Liveness  Report    `json:"liveness"`
+	Readiness Report    `json:"readiness"`
+}
+
+type check struct {
+	name string
+	kind Kind
+	fn   CheckFunc
+}
+
+// Checker 进程内注册的全部检查
+//
+// 就绪失败只摘流量，存活失败会重启容器；依赖故障时重启无济于事，反而让所有副本同时冷启动，
+// 所以依赖只进就绪，存活只检查进程自身（如 Consumer 处理卡死）
+type Checker struct {
+	service   string
+	instance  string
+	startedAt time.Time
+	timeout   time.Duration
+	cacheTTL  time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedResult // 探针频繁调用，短时间内复用结果，避免把依赖打满
+}
+
+type cachedResult struct {
+	result Result
+	at     time.Time
+}
+
+// New timeout 为单项检查的超时（<= 0 时使用默认 2s）
+func New(service string, timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &Checker{
+		service:   service,
+		instance:  fmt.Sprintf("%s-%d", host, os.Getpid()),
+		startedAt: time.Now(),
+		timeout:   timeout,
+		cacheTTL:  defaultCacheTTL,
+		cache:     make(map[string]cachedResult),
+	}
+}
+
+// Add 注册检查；同名检查以类别区分（如 Consumer 同时有存活与就绪检查）
+func (c *Checker) Add(name string, kind Kind, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, kind: kind, fn: fn})
+}
+
+// Live 存活检查（/healthz）
+func (c *Checker) Live(ctx context.Context) Report {
+	return c.run(ctx, KindLiveness)
+}
+
+// Ready 就绪检查（/readyz）：关键依赖故障为 down，只有非关键依赖故障为 degraded
+func (c *Checker) Ready(ctx context.Context) Report {
+	return c.run(ctx, KindCritical, KindOptional)
+}
+
+// Status 状态页数据；整体状态取存活与就绪中较差的一个
+func (c *Checker) Status(ctx context.Context) StatusReport {
+	live := c.Live(ctx)
+	ready := c.Ready(ctx)
+	status := ready.Status
+	if live.Status == StatusDown {
+		status = StatusDown
+	}
+	return StatusReport{
+		Service:   c.service,
+		Status:    status,
+		Instance:  c.instance,
+		StartedAt: c.startedAt,
+		Uptime:    time.Since(c.startedAt).Round(time.Second).String(),
+		GoVersion: runtime.Version(),
+		Liveness:  live,
+		Readiness: ready,
+	}
+}
+
+func (c *Checker) run(ctx context.Context, kinds ...Kind) Report {
+	c.mu.RLock()
+	var selected []check
+	for _, chk := range c.checks {
+		for _, kind := range kinds {
+			if chk.kind == kind {
+				selected = append(selected, chk)
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	// 各项并发执行，总耗时不超过单项超时
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, chk := range selected {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.cached(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Service: c.service, Status: StatusOK, Checks: results, CheckedAt: time.Now()}
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		if r.Kind == KindOptional {
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+			continue
+		}
+		report.Status = StatusDown
+	}
+	return report
+}
+
+func (c *Checker) cached(ctx context.Context, chk check) Result {
+	key := string(chk.kind) + "/" + chk.name
+	c.cacheMu.Lock()
+	if cached, ok := c.cache[key]; ok && time.Since(cached.at) < c.cacheTTL {
+		c.cacheMu.Unlock()
+		return cached.result
+	}
+	c.cacheMu.Unlock()
+
+	result := c.execute(ctx, chk)
+
+	c.cacheMu.Lock()
+	c.cache[key] = cachedResult{result: result, at: time.Now()}
+	c.cacheMu.Unlock()
+	return result
+}
+
+// execute 在独立 goroutine 中执行，不响应 ctx 的客户端（如 sarama 元数据请求）也能按时返回
+func (c *Checker) execute(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", c.timeout)
+		}
+	}
+
+	result := Result{
+		Name:       chk.name,
+		Kind:       chk.kind,
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		if chk.kind == KindOptional {
+			result.Status = StatusDegraded
+		}
+		result.Error = err.Error()
+	}
+	return result
+}
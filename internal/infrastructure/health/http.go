@@ -0,0 +1,102 @@
+package health
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 探针路径（日志、指标、链路追踪中间件跳过它们，避免探针刷屏）
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+	StatusPath    = "/status"
+)
+
+// IsProbe 是否为探针请求路径
+func IsProbe(path string) bool {
+	return path == LivenessPath || path == ReadinessPath
+}
+
+// RegisterRoutes GET /healthz、/readyz、/status（不经过认证中间件：探针来自容器编排平台，结果中没有业务数据）
+func RegisterRoutes(router gin.IRouter, checker *Checker) {
+	router.GET(LivenessPath, gin.WrapF(checker.serveLiveness))
+	router.GET(ReadinessPath, gin.WrapF(checker.serveReadiness))
+	router.GET(StatusPath, gin.WrapF(checker.serveStatus))
+}
+
+// RegisterMux 同 RegisterRoutes，用于没有 gin 的 /metrics 服务（Worker）
+func (c *Checker) RegisterMux(mux *http.ServeMux) {
+	mux.HandleFunc(LivenessPath, c.serveLiveness)
+	mux.HandleFunc(ReadinessPath, c.serveReadiness)
+	mux.HandleFunc(StatusPath, c.serveStatus)
+}
+
+func (c *Checker) serveLiveness(w http.ResponseWriter, r *http.Request) {
+	report := c.Live(r.Context())
+	writeJSON(w, httpStatus(report.Status), report)
+}
+
+// serveReadiness degraded 仍返回 200：非关键依赖故障时摘流量只会让可用的功能也不可用
+func (c *Checker) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+	writeJSON(w, httpStatus(report.Status), report)
+}
+
+// serveStatus 浏览器访问返回 HTML 页面，其他返回 JSON
+func (c *Checker) serveStatus(w http.ResponseWriter, r *http.Request) {
+	report := c.Status(r.Context())
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		writeJSON(w, httpStatus(report.Status), report)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(httpStatus(report.Status))
+	statusPage.Execute(w, report)
+}
+
+func httpStatus(status Status) int {
+	if status == StatusDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>{{.Service}} - {{.Status}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 12px; text-align: left; }
+.ok { color: #2e7d32; } .degraded { color: #ef6c00; } .down { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>{{.Service}} <span class="{{.Status}}">{{.Status}}</span></h1>
+<p>instance {{.Instance}} · started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}} · uptime {{.Uptime}} · {{.GoVersion}}</p>
+<h2>Liveness <span class="{{.Liveness.Status}}">{{.Liveness.Status}}</span></h2>
+{{template "checks" .Liveness}}
+<h2>Readiness <span class="{{.Readiness.Status}}">{{.Readiness.Status}}</span></h2>
+{{template "checks" .Readiness}}
+</body>
+</html>
+{{define "checks"}}<table>
+<tr><th>check</th><th>kind</th><th>status</th><th>duration</th><th>error</th></tr>
+{{range .Checks}}<tr><td>{{.Name}}</td><td>{{.Kind}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.DurationMs}} ms</td><td>{{.Error}}</td></tr>
+{{else}}<tr><td colspan="5">no checks</td></tr>
+{{end}}</table>{{end}}`))
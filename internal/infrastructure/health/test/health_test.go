@@ -0,0 +1,106 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func serve(t *testing.T, checker *health.Checker, path, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	health.RegisterRoutes(router, checker)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestReady_Status - 非关键依赖故障为 degraded（200），关键依赖故障为 down（503）
+func TestReady_Status(t *testing.T) {
+	checker := health.New("test", time.Second)
+	checker.Add("postgres", health.KindCritical, ok)
+	checker.Add("redis", health.KindOptional, failing)
+
+	w := serve(t, checker, health.ReadinessPath, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "postgres", report.Checks[0].Name)
+	assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+	assert.Equal(t, health.StatusDegraded, report.Checks[1].Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+
+	checker.Add("kafka", health.KindCritical, failing)
+	w = serve(t, checker, health.ReadinessPath, "")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusDown, report.Status)
+}
+
+// TestLive_IgnoresDependencies - 依赖故障不影响存活检查（否则依赖故障会让所有副本重启）
+func TestLive_IgnoresDependencies(t *testing.T) {
+	checker := health.New("test", time.Second)
+	checker.Add("postgres", health.KindCritical, failing)
+
+	assert.Equal(t, http.StatusOK, serve(t, checker, health.LivenessPath, "").Code)
+
+	checker.Add("kafka-consumer:test-group", health.KindLiveness, failing)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(t, checker, health.LivenessPath, "").Code)
+}
+
+// TestCheck_Timeout - 不响应 ctx 的检查也按超时返回
+func TestCheck_Timeout(t *testing.T) {
+	checker := health.New("test", 50*time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	checker.Add("kafka", health.KindCritical, func(context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Ready(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Contains(t, report.Checks[0].Error, "timed out")
+}
+
+// TestStatusPage - 浏览器返回 HTML，其他客户端返回 JSON
+func TestStatusPage(t *testing.T) {
+	checker := health.New("ingestor", time.Second)
+	checker.Add("postgres", health.KindCritical, ok)
+	checker.Add("redis", health.KindOptional, failing)
+
+	w := serve(t, checker, health.StatusPath, "text/html")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "connection refused")
+
+	w = serve(t, checker, health.StatusPath, "")
+	var status health.StatusReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "ingestor", status.Service)
+	assert.Equal(t, health.StatusDegraded, status.Status)
+	assert.Equal(t, health.StatusOK, status.Liveness.Status)
+}
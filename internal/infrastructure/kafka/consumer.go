@@ -2,19 +2,41 @@ package kafka
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
+var logger = logging.Component("kafka")
+
 type KafkaEventConsumer struct {
 	consumer sarama.ConsumerGroup
 	handler  messaging.MessageHandler
 	topic    string
+	groupID  string
+	gate     *priorityGate
+	state    *consumerState // 健康检查读取
+}
+
+// ConsumerOption Consumer 可选配置
+type ConsumerOption func(*KafkaEventConsumer)
+
+// WithPriorityTopics 高优先级车道：这些 Topic 有积压时，其他 Topic 的消息暂缓处理
+// （订阅时仍需把它们放进 topics）
+func WithPriorityTopics(topics ...string) ConsumerOption {
+	return func(c *KafkaEventConsumer) {
+		c.gate = newPriorityGate(topics, defaultMaxPriorityDefer)
+	}
 }
 
-func NewKafkaEventConsumer(brokers []string, groupID string) (messaging.KafkaEventConsumer, error) {
+func NewKafkaEventConsumer(brokers []string, groupID string, opts ...ConsumerOption) (messaging.KafkaEventConsumer, error) {
 	// create Saram config
 	config := sarama.NewConfig()
 	config.Version = sarama.V2_8_0_0
@@ -26,9 +48,15 @@ func NewKafkaEventConsumer(brokers []string, groupID string) (messaging.KafkaEve
 	if err != nil {
 		return nil, err
 	}
-	return &KafkaEventConsumer{
+	c := &KafkaEventConsumer{
 		consumer: consumer,
-	}, nil
+		groupID:  groupID,
+		state:    newConsumerState(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // Subscribe - 订阅 Kafka 主题并消费消息
@@ -36,11 +64,14 @@ func (c *KafkaEventConsumer) Subscribe(ctx context.Context, topics []string, han
 	c.handler = handler
 	c.topic = topics[0] // 简化实现，假设只订阅一个 topic
 
-	log.Printf("[Kafka] Starting consumer. Topics: %v", topics)
+	logger.Info("starting consumer", "topics", topics, "group", c.groupID)
 
 	// 创建 ConsumerGroupHandler 适配器
 	groupHandler := &consumerGroupHandler{
 		messageHandler: handler,
+		groupID:        c.groupID,
+		gate:           c.gate,
+		state:          c.state,
 	}
 
 	// 在后台 goroutine 中消费消息
@@ -48,38 +79,47 @@ func (c *KafkaEventConsumer) Subscribe(ctx context.Context, topics []string, han
 		for {
 			select {
 			case <-ctx.Done():
-				log.Printf("[Kafka] Consumer context cancelled")
+				logger.Info("consumer context cancelled", "group", c.groupID)
 				return
 			default:
 				if err := c.consumer.Consume(ctx, topics, groupHandler); err != nil {
-					log.Printf("[Kafka] Consumer error: %v", err)
+					logger.Error("consumer error", "group", c.groupID, "error", err)
+					c.state.consumeFailed(err)
 				}
 			}
 		}
 	}()
 
-	log.Printf("[Kafka] Consumer subscribed successfully")
+	logger.Info("consumer subscribed", "group", c.groupID)
 	return nil
 }
 
 // Close - 关闭 Kafka Consumer
 func (c *KafkaEventConsumer) Close() error {
-	log.Printf("[Kafka] Closing consumer...")
+	logger.Info("closing consumer", "group", c.groupID)
 	return c.consumer.Close()
 }
 
 // consumerGroupHandler - 实现 sarama.ConsumerGroupHandler 接口
 type consumerGroupHandler struct {
 	messageHandler messaging.MessageHandler
+	groupID        string        // 指标标签
+	gate           *priorityGate // 为 nil 时不区分车道
+	state          *consumerState
 }
 
-// Setup - 在会话开始时调用
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+// Setup - 在会话开始时调用（记录分到的分区，供就绪检查使用）
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	h.state.setup(session.Claims())
 	return nil
 }
 
-// Cleanup - 在会话结束时调用
+// Cleanup - 在会话结束时调用（Rebalance 后分区可能分给别的实例，积压记录作废）
 func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	h.state.cleanup()
+	if h.gate != nil {
+		h.gate.reset()
+	}
 	return nil
 }
 
@@ -91,13 +131,119 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 			if !ok {
 				return nil
 			}
-			if err := h.messageHandler(context.Background(), msg.Value); err != nil {
-				log.Printf("Message handler failed: %v", err)
+
+			priority := h.gate != nil && h.gate.isPriority(msg.Topic)
+			if priority {
+				// 积压 = HighWaterMark - 当前 offset（包含正在处理的这条）
+				h.gate.record(msg.Topic, msg.Partition, claim.HighWaterMarkOffset()-msg.Offset)
+			} else if h.gate != nil {
+				h.gate.wait(session.Context())
+			}
+
+			start := time.Now()
+			ctx, span := tracing.StartConsume(messageContext(msg), msg, h.groupID)
+			h.state.begin(msg.Topic, msg.Partition)
+			err := h.messageHandler(ctx, msg.Value)
+			h.state.done(msg.Topic, msg.Partition)
+			if err != nil {
+				logger.ErrorContext(ctx, "message handler failed", "group", h.groupID, "error", err)
 			}
+			tracing.End(span, err)
+			metrics.ObserveKafkaConsume(msg.Topic, h.groupID, time.Since(start), err)
+			metrics.SetKafkaConsumerLag(msg.Topic, h.groupID, msg.Partition, claim.HighWaterMarkOffset()-msg.Offset-1)
 
+			if priority {
+				h.gate.record(msg.Topic, msg.Partition, claim.HighWaterMarkOffset()-msg.Offset-1)
+			}
 			session.MarkMessage(msg, "")
 		case <-session.Context().Done():
 			return nil
 		}
 	}
-}
\ No newline at end of file
+}
+
+// messageContext 消息位置与 Batch ID 作为日志关联字段，处理过程中的日志都带上
+func messageContext(msg *sarama.ConsumerMessage) context.Context {
+	ctx := logging.With(context.Background(),
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+	)
+	if batchID, err := uuid.ParseBytes(msg.Key); err == nil {
+		ctx = logging.With(ctx, "batch_id", batchID.String())
+	}
+	return ctx
+}
+
+// defaultMaxPriorityDefer 普通车道单条消息最长让路时间（防止高优先级持续积压时普通车道饿死）
+const defaultMaxPriorityDefer = 5 * time.Second
+
+// priorityGate 记录高优先级车道各分区的积压，普通车道消费前据此让路
+//
+// 两个车道在同一个 Consumer Group 内，用 HighWaterMark 计算积压；
+// 分成两个 Group 则彼此无法感知积压，只能靠多分配实例
+type priorityGate struct {
+	topics   map[string]bool
+	maxDefer time.Duration
+
+	mu  sync.Mutex
+	lag map[string]int64 // "topic/partition" → 积压条数
+}
+
+func newPriorityGate(topics []string, maxDefer time.Duration) *priorityGate {
+	g := &priorityGate{
+		topics:   make(map[string]bool, len(topics)),
+		maxDefer: maxDefer,
+		lag:      make(map[string]int64),
+	}
+	for _, topic := range topics {
+		g.topics[topic] = true
+	}
+	return g
+}
+
+func (g *priorityGate) isPriority(topic string) bool {
+	return g.topics[topic]
+}
+
+func (g *priorityGate) record(topic string, partition int32, lag int64) {
+	if lag < 0 {
+		lag = 0
+	}
+	g.mu.Lock()
+	g.lag[fmt.Sprintf("%s/%d", topic, partition)] = lag
+	g.mu.Unlock()
+}
+
+func (g *priorityGate) backlog() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var total int64
+	for _, lag := range g.lag {
+		total += lag
+	}
+	return total
+}
+
+func (g *priorityGate) reset() {
+	g.mu.Lock()
+	g.lag = make(map[string]int64)
+	g.mu.Unlock()
+}
+
+// wait 高优先级车道有积压时阻塞，最多等待 maxDefer
+func (g *priorityGate) wait(ctx context.Context) {
+	if g.backlog() == 0 {
+		return
+	}
+	deadline := time.Now().Add(g.maxDefer)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for g.backlog() > 0 && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
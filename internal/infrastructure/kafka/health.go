@@ -0,0 +1,172 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+)
+
+// MetadataCheck 就绪检查：Broker 可达，订阅 / 发布的 Topic 存在且每个分区都有 Leader
+//
+// 使用独立的 sarama.Client（Producer / Consumer Group 不暴露内部 Client），首次检查时才连接
+type MetadataCheck struct {
+	brokers []string
+	topics  []string
+
+	mu     sync.Mutex
+	client sarama.Client
+}
+
+func NewMetadataCheck(brokers []string, topics ...string) *MetadataCheck {
+	return &MetadataCheck{brokers: brokers, topics: topics}
+}
+
+// Check 实现 health.CheckFunc
+func (m *MetadataCheck) Check(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		config := sarama.NewConfig()
+		config.Version = sarama.V2_8_0_0
+		config.Net.DialTimeout = 2 * time.Second
+		config.Net.ReadTimeout = 2 * time.Second
+		config.Metadata.Retry.Max = 0 // 由探针周期重试
+		config.Metadata.Full = false
+		client, err := sarama.NewClient(m.brokers, config)
+		if err != nil {
+			return fmt.Errorf("connect brokers: %w", err)
+		}
+		m.client = client
+	}
+	if err := m.client.RefreshMetadata(m.topics...); err != nil {
+		return fmt.Errorf("refresh metadata: %w", err)
+	}
+	if len(m.client.Brokers()) == 0 {
+		return errors.New("no brokers in cluster metadata")
+	}
+	for _, topic := range m.topics {
+		partitions, err := m.client.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("topic %s: %w", topic, err)
+		}
+		if len(partitions) == 0 {
+			return fmt.Errorf("topic %s has no partitions", topic)
+		}
+		for _, partition := range partitions {
+			if _, err := m.client.Leader(topic, partition); err != nil {
+				return fmt.Errorf("topic %s partition %d: %w", topic, partition, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MetadataCheck) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client == nil {
+		return nil
+	}
+	err := m.client.Close()
+	m.client = nil
+	return err
+}
+
+// WithHealthChecks 向 checker 注册 Consumer 的两项检查（名称 kafka-consumer:<group>）：
+//
+//   - 就绪（非关键）：当前会话分到了分区。副本数多于分区数时会有空闲副本，摘流量解决不了，只标记 degraded
+//   - 存活：单条消息处理超过 stallTimeout 视为卡死，交给容器编排重启。Consume 失败（Broker 不可达）不算，
+//     那是依赖故障，重启解决不了
+func WithHealthChecks(checker *health.Checker, stallTimeout time.Duration) ConsumerOption {
+	return func(c *KafkaEventConsumer) {
+		name := "kafka-consumer:" + c.groupID
+		checker.Add(name, health.KindOptional, c.state.checkAssigned)
+		checker.Add(name, health.KindLiveness, func(context.Context) error {
+			return c.state.checkStalled(stallTimeout)
+		})
+	}
+}
+
+// consumerState Consumer 运行状态（会话回调、各分区的 ConsumeClaim、探针并发访问）
+type consumerState struct {
+	mu        sync.Mutex
+	assigned  map[string][]int32   // 当前会话分到的分区
+	inflight  map[string]time.Time // "topic/partition" → 正在处理的消息开始时间
+	lastError error                // 最近一次 Consume 失败（新会话建立后清空）
+}
+
+func newConsumerState() *consumerState {
+	return &consumerState{inflight: make(map[string]time.Time)}
+}
+
+func (s *consumerState) setup(claims map[string][]int32) {
+	s.mu.Lock()
+	s.assigned = claims
+	s.lastError = nil
+	s.mu.Unlock()
+}
+
+func (s *consumerState) cleanup() {
+	s.mu.Lock()
+	s.assigned = nil
+	s.inflight = make(map[string]time.Time)
+	s.mu.Unlock()
+}
+
+func (s *consumerState) consumeFailed(err error) {
+	s.mu.Lock()
+	s.lastError = err
+	s.mu.Unlock()
+}
+
+func (s *consumerState) begin(topic string, partition int32) {
+	s.mu.Lock()
+	s.inflight[fmt.Sprintf("%s/%d", topic, partition)] = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *consumerState) done(topic string, partition int32) {
+	s.mu.Lock()
+	delete(s.inflight, fmt.Sprintf("%s/%d", topic, partition))
+	s.mu.Unlock()
+}
+
+func (s *consumerState) checkAssigned(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.assigned == nil {
+		if s.lastError != nil {
+			return fmt.Errorf("not in a consumer group session: %v", s.lastError)
+		}
+		return errors.New("not in a consumer group session (joining or rebalancing)")
+	}
+	for _, partitions := range s.assigned {
+		if len(partitions) > 0 {
+			return nil
+		}
+	}
+	return errors.New("no partitions assigned (more members than partitions)")
+}
+
+func (s *consumerState) checkStalled(timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stalled []string
+	for key, start := range s.inflight {
+		if elapsed := time.Since(start); elapsed > timeout {
+			stalled = append(stalled, fmt.Sprintf("%s (%s)", key, elapsed.Round(time.Second)))
+		}
+	}
+	if len(stalled) > 0 {
+		sort.Strings(stalled)
+		return fmt.Errorf("message handler stalled on %v", stalled)
+	}
+	return nil
+}
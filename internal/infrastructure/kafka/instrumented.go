@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+)
+
+// instrumentedProducer 为每次同步发送记录 Topic 维度的次数、失败与延迟（主 Producer 与 DLQ 共用）
+type instrumentedProducer struct {
+	sarama.SyncProducer
+}
+
+func instrument(producer sarama.SyncProducer) sarama.SyncProducer {
+	if producer == nil {
+		return nil
+	}
+	return &instrumentedProducer{SyncProducer: producer}
+}
+
+func (p *instrumentedProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	start := time.Now()
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+	metrics.ObserveKafkaProduce(msg.Topic, time.Since(start), err)
+	return partition, offset, err
+}
+
+func (p *instrumentedProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+	elapsed := time.Since(start)
+	for _, msg := range msgs {
+		metrics.ObserveKafkaProduce(msg.Topic, elapsed, err)
+	}
+	return err
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 	"github.com/xuewentao/argus-ota-platform/internal/messaging"
 )
 
@@ -20,11 +21,41 @@ type kafkaEventProducer struct {
 	dlqProducer sarama.SyncProducer
 	topic    	string
 	dlqTopic    string
+	priorityTopic string // 高优先级车道（为空时所有事件都走 topic）
+	pseudonymizer *domain.Pseudonymizer // 事件中的 VIN 替换为令牌（未配置时不发布 VIN）
+}
+
+// ProducerOption Producer 可选配置
+type ProducerOption func(*kafkaEventProducer)
+
+// WithPriorityTopic 紧急 Batch（elevated / safety_critical）的事件投递到独立 Topic
+func WithPriorityTopic(topic string) ProducerOption {
+	return func(k *kafkaEventProducer) {
+		k.priorityTopic = topic
+	}
+}
+
+// WithPseudonymizer 事件中的 VIN 以 vin_token 发布
+//
+// Kafka 是数据离开进程的边界（下游有诊断 Worker、分析消费者、DLQ），在这里统一替换，
+// 新增的事件类型不需要各自处理 VIN
+func WithPseudonymizer(p *domain.Pseudonymizer) ProducerOption {
+	return func(k *kafkaEventProducer) {
+		k.pseudonymizer = p
+	}
+}
+
+// vinToken 未配置假名化时返回空串（宁可不发布，也不发布明文 VIN）
+func (k *kafkaEventProducer) vinToken(vin string) string {
+	if k.pseudonymizer == nil || vin == "" {
+		return ""
+	}
+	return k.pseudonymizer.VINToken(vin)
 }
 
 // NewKafkaEventProducer - 创建 Kafka Producer
 // 返回接口类型,而不是具体实现
-func NewKafkaEventProducer(brokers []string, topic string, dlqTopic string) (messaging.KafkaEventPublisher, error) {
+func NewKafkaEventProducer(brokers []string, topic string, dlqTopic string, opts ...ProducerOption) (messaging.KafkaEventPublisher, error) {
 	config := sarama.NewConfig()
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 5
@@ -48,14 +79,30 @@ func NewKafkaEventProducer(brokers []string, topic string, dlqTopic string) (mes
 		}
 	}
 
-	log.Printf("[Kafka] Producer created successfully. Brokers: %v, Topic: %s, DLQ Topic: %s", brokers, topic, dlqTopic)
-
-	return &kafkaEventProducer{
-		producer:   producer,
-		dlqProducer: dlqProducer,
+	k := &kafkaEventProducer{
+		producer:   instrument(producer),
+		dlqProducer: instrument(dlqProducer),
 		topic:      topic,
 		dlqTopic:   dlqTopic,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+
+	log.Printf("[Kafka] Producer created successfully. Brokers: %v, Topic: %s, Priority Topic: %s, DLQ Topic: %s",
+		brokers, topic, k.priorityTopic, dlqTopic)
+	return k, nil
+}
+
+// topicFor 按 Batch 优先级选择车道
+//
+// 优先级在创建时确定且不会改变，同一个 Batch 的事件始终落在同一个 Topic，
+// 且仍以 batch_id 为 Key，分区内顺序不受影响
+func (k *kafkaEventProducer) topicFor(priority domain.BatchPriority) string {
+	if k.priorityTopic != "" && priority.IsUrgent() {
+		return k.priorityTopic
+	}
+	return k.topic
 }
 
 // PublishEvents - 批量发布领域事件（实现 messaging.KafkaEventPublisher 接口）
@@ -80,6 +127,10 @@ func (k *kafkaEventProducer) PublishEvents(ctx context.Context, events []domain.
 			if err := k.publishFileParsed(ctx, e); err != nil {
 				return fmt.Errorf("failed to publish event %d: %w", i, err)
 			}
+		case domain.GatherRequested:
+			if err := k.publishGatherRequested(ctx, e); err != nil {
+				return fmt.Errorf("failed to publish event %d: %w", i, err)
+			}
 		case domain.GatheringCompleted:
 			if err := k.publishGatheringCompleted(ctx, e); err != nil {
 				return fmt.Errorf("failed to publish event %d: %w", i, err)
@@ -117,36 +168,59 @@ func (k *kafkaEventProducer) publishWithRetry(ctx context.Context, event domain.
 	switch e := event.(type) {
 	case domain.BatchCreated:
 		kafkaMsg = &sarama.ProducerMessage{
-			Topic: k.topic,
+			Topic: k.topicFor(e.Priority),
 			Key:   sarama.StringEncoder(e.BatchID.String()),
-			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"BatchCreated","batch_id":"%s","vehicle_id":"%s","vin":"%s","timestamp":"%s"}`,
-				e.BatchID, e.VehicleID, e.VIN, e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
+			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"BatchCreated","batch_id":"%s","tenant_id":"%s","vehicle_id":"%s","vin_token":"%s","vehicle_platform":"%s","priority":"%s","timestamp":"%s"}`,
+				e.BatchID, e.TenantID, e.VehicleID, k.vinToken(e.VIN), e.VehiclePlatform, e.Priority, e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
 		}
 	case domain.BatchStatusChanged:
 		kafkaMsg = &sarama.ProducerMessage{
-			Topic: k.topic,
+			Topic: k.topicFor(e.Priority),
 			Key:   sarama.StringEncoder(e.BatchID.String()),
-			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"StatusChanged","batch_id":"%s","old_status":"%s","new_status":"%s","timestamp":"%s"}`,
-				e.BatchID, e.OldStatus.String(), e.NewStatus.String(), e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
+			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"StatusChanged","batch_id":"%s","tenant_id":"%s","old_status":"%s","new_status":"%s","priority":"%s","timestamp":"%s"}`,
+				e.BatchID, e.TenantID, e.OldStatus.String(), e.NewStatus.String(), e.Priority, e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
 		}
 	case domain.FileParsed:
 		kafkaMsg = &sarama.ProducerMessage{
-			Topic: k.topic,
+			Topic: k.topicFor(e.Priority),
+			Key:   sarama.StringEncoder(e.BatchID.String()),
+			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"FileParsed","batch_id":"%s","tenant_id":"%s","file_id":"%s","output_path":"%s","priority":"%s","timestamp":"%s"}`,
+				e.BatchID, e.TenantID, e.FileID, e.OutputPath, e.Priority, e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
+		}
+	case domain.GatherRequested:
+		data, _ := json.Marshal(map[string]interface{}{
+			"event_type":   "GatherRequested",
+			"version":      e.Version,
+			"batch_id":     e.BatchID.String(),
+			"tenant_id":    e.TenantID,
+			"priority":     e.Priority,
+			"total_files":  e.TotalFiles,
+			"parsed_files": e.ParsedFiles,
+			"timestamp":    e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+		kafkaMsg = &sarama.ProducerMessage{
+			Topic: k.topicFor(e.Priority),
 			Key:   sarama.StringEncoder(e.BatchID.String()),
-			Value: sarama.StringEncoder(fmt.Sprintf(`{"event_type":"FileParsed","batch_id":"%s","file_id":"%s","timestamp":"%s"}`,
-				e.BatchID, e.FileID, e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))),
+			Value: sarama.ByteEncoder(data),
 		}
 	case domain.GatheringCompleted:
 		data, _ := json.Marshal(map[string]interface{}{
-			"event_type":  "GatheringCompleted",
-			"version":     e.Version,
-			"batch_id":    e.BatchID.String(),
-			"total_files": e.TotalFiles,
-			"chart_files": e.ChartFiles,
-			"timestamp":   e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
+			"event_type":      "GatheringCompleted",
+			"version":         e.Version,
+			"batch_id":        e.BatchID.String(),
+			"tenant_id":       e.TenantID,
+			"vin_token":       k.vinToken(e.VIN),
+			"priority":        e.Priority,
+			"total_files":     e.TotalFiles,
+			"chart_files":     e.ChartFiles,
+			"record_count":    e.RecordCount,
+			"cpu_stats":       e.CPUStats,
+			"ram_stats":       e.RAMStats,
+			"top_error_codes": e.TopErrorCodes,
+			"timestamp":       e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 		kafkaMsg = &sarama.ProducerMessage{
-			Topic: k.topic,
+			Topic: k.topicFor(e.Priority),
 			Key:   sarama.StringEncoder(e.BatchID.String()),
 			Value: sarama.ByteEncoder(data),
 		}
@@ -155,14 +229,16 @@ func (k *kafkaEventProducer) publishWithRetry(ctx context.Context, event domain.
 			"event_type":        "DiagnosisCompleted",
 			"version":           e.Version,
 			"batch_id":          e.BatchID.String(),
+			"tenant_id":         e.TenantID,
 			"diagnosis_id":      e.DiagnosisID.String(),
+			"priority":          e.Priority,
 			"diagnosis_summary": e.DiagnosisSummary,
 			"top_error_codes":   e.TopErrorCodes,
 			"token_usage":       e.TokenUsage,
 			"timestamp":         e.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 		kafkaMsg = &sarama.ProducerMessage{
-			Topic: k.topic,
+			Topic: k.topicFor(e.Priority),
 			Key:   sarama.StringEncoder(e.BatchID.String()),
 			Value: sarama.ByteEncoder(data),
 		}
@@ -171,7 +247,7 @@ func (k *kafkaEventProducer) publishWithRetry(ctx context.Context, event domain.
 	}
 
 	// 发送消息
-	_, _, err := k.producer.SendMessage(kafkaMsg)
+	_, _, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		// ✅ 判断是否是临时性错误
 		if isTemporaryError(err) {
@@ -188,6 +264,14 @@ func (k *kafkaEventProducer) publishWithRetry(ctx context.Context, event domain.
 	return nil
 }
 
+// send 同步发送并记录 Producer Span（trace context 写入消息头，下游 Consumer 据此接上链路）
+func (k *kafkaEventProducer) send(ctx context.Context, msg *sarama.ProducerMessage) (int32, int64, error) {
+	_, span := tracing.StartProduce(ctx, msg)
+	partition, offset, err := k.producer.SendMessage(msg)
+	tracing.EndProduce(span, partition, offset, err)
+	return partition, offset, err
+}
+
 // isTemporaryError 判断错误是否是临时性的
 func isTemporaryError(err error) bool {
 	if err == nil {
@@ -197,6 +281,19 @@ func isTemporaryError(err error) bool {
 	// 这里可以根据具体需要细化判断
 	return true
 }
+// pseudonymizeEvent 携带 VIN 的事件替换为令牌后再整体序列化
+func (k *kafkaEventProducer) pseudonymizeEvent(event domain.DomainEvent) domain.DomainEvent {
+	switch e := event.(type) {
+	case domain.BatchCreated:
+		e.VIN = k.vinToken(e.VIN)
+		return e
+	case domain.GatheringCompleted:
+		e.VIN = k.vinToken(e.VIN)
+		return e
+	}
+	return event
+}
+
 // sendToDLQ 将失败的事件发送到死信队列
 func (k *kafkaEventProducer) sendToDLQ(ctx context.Context, event domain.DomainEvent, originalErr error) {
 	if k.dlqProducer == nil {
@@ -204,9 +301,9 @@ func (k *kafkaEventProducer) sendToDLQ(ctx context.Context, event domain.DomainE
 		return
 	}
 
-	// 序列化事件
+	// 序列化事件（DLQ 同样不能包含明文 VIN）
 	data, err := json.Marshal(map[string]interface{}{
-		"event":      event,
+		"event":      k.pseudonymizeEvent(event),
 		"error":      originalErr.Error(),
 		"timestamp":  time.Now().Format("2006-01-02T15:04:05Z07:00"),
 	})
@@ -230,44 +327,49 @@ func (k *kafkaEventProducer) sendToDLQ(ctx context.Context, event domain.DomainE
 }
 // publishBatchCreated - 发布 BatchCreated 事件（小写，私有方法）
 func (k *kafkaEventProducer) publishBatchCreated(ctx context.Context, event domain.BatchCreated) error {
-	message := fmt.Sprintf(`{"event_type":"BatchCreated","batch_id":"%s","vehicle_id":"%s","vin":"%s","timestamp":"%s"}`,
+	message := fmt.Sprintf(`{"event_type":"BatchCreated","batch_id":"%s","tenant_id":"%s","vehicle_id":"%s","vin_token":"%s","vehicle_platform":"%s","priority":"%s","timestamp":"%s"}`,
 		event.BatchID,
+		event.TenantID,
 		event.VehicleID,
-		event.VIN,
+		k.vinToken(event.VIN),
+		event.VehiclePlatform,
+		event.Priority,
 		event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 	)
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: k.topic,
+		Topic: k.topicFor(event.Priority),
 		Key:   sarama.StringEncoder(event.BatchID.String()),
 		Value: sarama.StringEncoder(message),
 	}
 
-	partition, offset, err := k.producer.SendMessage(kafkaMsg)
+	partition, offset, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
-	log.Printf("[Kafka] BatchCreated sent successfully. Partition: %d, Offset: %d", partition, offset)
+	log.Printf("[Kafka] BatchCreated sent successfully. Topic: %s, Partition: %d, Offset: %d", kafkaMsg.Topic, partition, offset)
 	return nil
 }
 
 // publishStatusChanged - 发布 StatusChanged 事件（小写，私有方法）
 func (k *kafkaEventProducer) publishStatusChanged(ctx context.Context, event domain.BatchStatusChanged) error {
-	message := fmt.Sprintf(`{"event_type":"StatusChanged","batch_id":"%s","old_status":"%s","new_status":"%s","timestamp":"%s"}`,
+	message := fmt.Sprintf(`{"event_type":"StatusChanged","batch_id":"%s","tenant_id":"%s","old_status":"%s","new_status":"%s","priority":"%s","timestamp":"%s"}`,
 		event.BatchID,
+		event.TenantID,
 		event.OldStatus.String(),
 		event.NewStatus.String(),
+		event.Priority,
 		event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 	)
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: k.topic,
+		Topic: k.topicFor(event.Priority),
 		Key:   sarama.StringEncoder(event.BatchID.String()),
 		Value: sarama.StringEncoder(message),
 	}
 
-	partition, offset, err := k.producer.SendMessage(kafkaMsg)
+	partition, offset, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -278,19 +380,22 @@ func (k *kafkaEventProducer) publishStatusChanged(ctx context.Context, event dom
 
 // publishFileParsed - 发布 FileParsed 事件（小写，私有方法）
 func (k *kafkaEventProducer) publishFileParsed(ctx context.Context, event domain.FileParsed) error {
-	message := fmt.Sprintf(`{"event_type":"FileParsed","batch_id":"%s","file_id":"%s","timestamp":"%s"}`,
+	message := fmt.Sprintf(`{"event_type":"FileParsed","batch_id":"%s","tenant_id":"%s","file_id":"%s","output_path":"%s","priority":"%s","timestamp":"%s"}`,
 		event.BatchID,
+		event.TenantID,
 		event.FileID,
+		event.OutputPath,
+		event.Priority,
 		event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 	)
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: k.topic,
+		Topic: k.topicFor(event.Priority),
 		Key:   sarama.StringEncoder(event.BatchID.String()),
 		Value: sarama.StringEncoder(message),
 	}
 
-	partition, offset, err := k.producer.SendMessage(kafkaMsg)
+	partition, offset, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -298,25 +403,89 @@ func (k *kafkaEventProducer) publishFileParsed(ctx context.Context, event domain
 	log.Printf("[Kafka] FileParsed sent successfully. Partition: %d, Offset: %d", partition, offset)
 	return nil
 }
+// publishGatherRequested - 发布 GatherRequested 命令（Orchestrator 在 Barrier 完成后调用）
+func (k *kafkaEventProducer) publishGatherRequested(ctx context.Context, event domain.GatherRequested) error {
+	type GatherRequestedEvent struct {
+		EventType   string                    `json:"event_type"`
+		Version     string                    `json:"version"`
+		BatchID     string                    `json:"batch_id"`
+		TenantID    string                    `json:"tenant_id"`
+		Priority    string                    `json:"priority"`
+		TotalFiles  int                       `json:"total_files"`
+		ParsedFiles []domain.ParsedFileOutput `json:"parsed_files"`
+		Timestamp   string                    `json:"timestamp"`
+	}
+
+	kafkaEvent := GatherRequestedEvent{
+		EventType:   "GatherRequested",
+		Version:     event.Version,
+		BatchID:     event.BatchID.String(),
+		TenantID:    event.TenantID,
+		Priority:    event.Priority.String(),
+		TotalFiles:  event.TotalFiles,
+		ParsedFiles: event.ParsedFiles,
+		Timestamp:   event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	data, err := json.Marshal(kafkaEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// 验证消息大小（文件列表过长时可能超限）
+	if len(data) > maxMessageSize {
+		return fmt.Errorf("message too large: %d bytes (max: %d)", len(data), maxMessageSize)
+	}
+
+	kafkaMsg := &sarama.ProducerMessage{
+		Topic: k.topicFor(event.Priority),
+		Key:   sarama.StringEncoder(event.BatchID.String()),
+		Value: sarama.ByteEncoder(data),
+	}
+
+	partition, offset, err := k.send(ctx, kafkaMsg)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	log.Printf("[Kafka] GatherRequested sent successfully. batch=%s, files=%d, partition=%d, offset=%d",
+		event.BatchID, len(event.ParsedFiles), partition, offset)
+	return nil
+}
+
 // publishGatheringCompleted - 发布 GatheringCompleted 事件（Python Worker 调用）
 func (k *kafkaEventProducer) publishGatheringCompleted(ctx context.Context, event domain.GatheringCompleted) error {
 	// 定义 JSON 序列化结构（使用结构体 + json tag，性能更好）
 	type GatheringCompletedEvent struct {
-		EventType  string   `json:"event_type"`
-		Version    string   `json:"version"`
-		BatchID    string   `json:"batch_id"`
-		TotalFiles int      `json:"total_files"`
-		ChartFiles []string `json:"chart_files"`
-		Timestamp  string   `json:"timestamp"`
+		EventType     string                    `json:"event_type"`
+		Version       string                    `json:"version"`
+		BatchID       string                    `json:"batch_id"`
+		TenantID      string                    `json:"tenant_id"`
+		VINToken      string                    `json:"vin_token"`
+		Priority      string                    `json:"priority"`
+		TotalFiles    int                       `json:"total_files"`
+		ChartFiles    []string                  `json:"chart_files"`
+		RecordCount   int                       `json:"record_count"`
+		CPUStats      *domain.CPUStats          `json:"cpu_stats"`
+		RAMStats      *domain.RAMStats          `json:"ram_stats"`
+		TopErrorCodes []domain.ErrorCodeSummary `json:"top_error_codes"`
+		Timestamp     string                    `json:"timestamp"`
 	}
 
 	kafkaEvent := GatheringCompletedEvent{
-		EventType:  "GatheringCompleted",
-		Version:    event.Version,
-		BatchID:    event.BatchID.String(),
-		TotalFiles: event.TotalFiles,
-		ChartFiles: event.ChartFiles,
-		Timestamp:  event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
+		EventType:     "GatheringCompleted",
+		Version:       event.Version,
+		BatchID:       event.BatchID.String(),
+		TenantID:      event.TenantID,
+		VINToken:      k.vinToken(event.VIN),
+		Priority:      event.Priority.String(),
+		TotalFiles:    event.TotalFiles,
+		ChartFiles:    event.ChartFiles,
+		RecordCount:   event.RecordCount,
+		CPUStats:      event.CPUStats,
+		RAMStats:      event.RAMStats,
+		TopErrorCodes: event.TopErrorCodes,
+		Timestamp:     event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 
 	// JSON 序列化
@@ -331,12 +500,12 @@ func (k *kafkaEventProducer) publishGatheringCompleted(ctx context.Context, even
 	}
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: k.topic,
+		Topic: k.topicFor(event.Priority),
 		Key:   sarama.StringEncoder(event.BatchID.String()),
 		Value: sarama.ByteEncoder(data),
 	}
 
-	partition, offset, err := k.producer.SendMessage(kafkaMsg)
+	partition, offset, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -353,7 +522,9 @@ func (k *kafkaEventProducer) publishDiagnosisCompleted(ctx context.Context, even
 		EventType          string                       `json:"event_type"`
 		Version            string                       `json:"version"`
 		BatchID            string                       `json:"batch_id"`
+		TenantID           string                       `json:"tenant_id"`
 		DiagnosisID        string                       `json:"diagnosis_id"`
+		Priority           string                       `json:"priority"`
 		DiagnosisSummary   string                       `json:"diagnosis_summary"`
 		TopErrorCodes      []domain.ErrorCodeSummary    `json:"top_error_codes"`
 		TokenUsage         domain.TokenUsageInfo        `json:"token_usage"`
@@ -364,7 +535,9 @@ func (k *kafkaEventProducer) publishDiagnosisCompleted(ctx context.Context, even
 		EventType:        "DiagnosisCompleted",
 		Version:          event.Version,
 		BatchID:          event.BatchID.String(),
+		TenantID:         event.TenantID,
 		DiagnosisID:      event.DiagnosisID.String(),
+		Priority:         event.Priority.String(),
 		DiagnosisSummary: event.DiagnosisSummary,
 		TopErrorCodes:    event.TopErrorCodes,
 		TokenUsage:       event.TokenUsage,
@@ -383,12 +556,12 @@ func (k *kafkaEventProducer) publishDiagnosisCompleted(ctx context.Context, even
 	}
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: k.topic,
+		Topic: k.topicFor(event.Priority),
 		Key:   sarama.StringEncoder(event.BatchID.String()),
 		Value: sarama.ByteEncoder(data),
 	}
 
-	partition, offset, err := k.producer.SendMessage(kafkaMsg)
+	partition, offset, err := k.send(ctx, kafkaMsg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -0,0 +1,90 @@
+package localfs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// presigner 模拟 S3 预签名：URL 中带过期时间和 HMAC 签名，由 PresignHandler 校验
+type presigner struct {
+	baseURL string
+	secret  []byte
+}
+
+func newPresigner(baseURL string, secret []byte) *presigner {
+	return &presigner{baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}
+}
+
+func (p *presigner) sign(key string, expires time.Time, downloadName string) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", exp)
+	if downloadName != "" {
+		q.Set("filename", downloadName)
+	}
+	q.Set("signature", p.signature(key, exp, downloadName))
+	return p.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode()
+}
+
+func (p *presigner) signature(key, expires, downloadName string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s", key, expires, downloadName)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PresignHandler 提供预签名链接的下载（挂载在 baseURL 对应的路由前缀下，prefix 为该前缀）
+func (s *Store) PresignHandler(prefix string) http.Handler {
+	return http.StripPrefix(strings.TrimSuffix(prefix, "/")+"/", http.HandlerFunc(s.servePresigned))
+}
+
+func (s *Store) servePresigned(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Path
+	q := r.URL.Query()
+	expires, downloadName := q.Get("expires"), q.Get("filename")
+
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		http.Error(w, "link expired", http.StatusForbidden)
+		return
+	}
+	expected := s.presign.signature(key, expires, downloadName)
+	if !hmac.Equal([]byte(expected), []byte(q.Get("signature"))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	src, info, err := s.GetObjectRange(r.Context(), key, 0, 0)
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer src.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if info.ETag != "" {
+		w.Header().Set("ETag", `"`+info.ETag+`"`)
+	}
+	if downloadName != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", downloadName))
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		log.Printf("[LocalFS] Presigned download %s interrupted: %v", key, err)
+	}
+}
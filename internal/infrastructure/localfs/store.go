@@ -0,0 +1,356 @@
+package localfs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+const (
+	objectsDir = "objects" // 对象内容：<root>/objects/<key>
+	metaDir    = "meta"    // 元数据：<root>/meta/<key>.json
+	tmpDir     = "tmp"     // 写入中的临时文件（与 objects 同一文件系统，rename 才是原子的）
+)
+
+var _ domain.ObjectStore = (*Store)(nil)
+
+// Store 本地文件系统对象存储（开发 / CI 使用，替代 MinIO）
+//
+// 写入先落到 tmp 再 rename（同一文件系统内原子）：读者要么看到旧对象，要么看到完整的新对象。
+// Content-Type、ETag 存在平行 meta 目录下的 sidecar JSON 中；ETag 与 S3 单次上传一致，为内容的 MD5
+type Store struct {
+	root    string
+	presign *presigner
+}
+
+// sidecar 元数据文件格式
+type sidecar struct {
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// NewStore root 不存在时自动创建；baseURL/secret 用于生成预签名链接（由 PresignHandler 校验并提供下载）
+func NewStore(root, baseURL string, secret []byte) (*Store, error) {
+	for _, dir := range []string{objectsDir, metaDir, tmpDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, fmt.Errorf("create storage dir: %w", err)
+		}
+	}
+	return &Store{root: root, presign: newPresigner(baseURL, secret)}, nil
+}
+
+// Ping 存储目录可访问
+func (s *Store) Ping(ctx context.Context) error {
+	for _, dir := range []string{objectsDir, tmpDir} {
+		if _, err := os.Stat(filepath.Join(s.root, dir)); err != nil {
+			return fmt.Errorf("storage dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// objectPath key → 文件路径（拒绝 ../、绝对路径等，防止逃出 root）
+func (s *Store) objectPath(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, objectsDir, filepath.FromSlash(key)), nil
+}
+
+func (s *Store) metaPath(key string) string {
+	return filepath.Join(s.root, metaDir, filepath.FromSlash(key)+".json")
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	return nil
+}
+
+func (s *Store) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	dst, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(s.root, tmpDir), "put-*")
+	if err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), &ctxReader{ctx: ctx, r: reader})
+	if err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("put object %s error: size mismatch, expected %d, got %d", key, size, written)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+
+	meta := sidecar{
+		ContentType: contentType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+		Size:        written,
+		ModTime:     time.Now().UTC(),
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+	committed = true
+	if err := s.writeMeta(key, meta); err != nil {
+		return fmt.Errorf("put object %s error: %w", key, err)
+	}
+
+	log.Printf("[LocalFS] Uploaded: %s, Size: %d", key, written)
+	return nil
+}
+
+// writeMeta 元数据同样先写临时文件再 rename
+func (s *Store) writeMeta(key string, meta sidecar) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	dst := s.metaPath(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.root, tmpDir), "meta-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *Store) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, _, err := s.GetObjectRange(ctx, key, 0, 0)
+	return rc, err
+}
+
+func (s *Store) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *domain.ObjectInfo, error) {
+	if offset < 0 {
+		return nil, nil, fmt.Errorf("get object %s: invalid offset %d", key, offset)
+	}
+	info, err := s.StatObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, _ := s.objectPath(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, wrapError("get", key, err)
+	}
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("get object %s error: %w", key, err)
+		}
+	}
+	if length <= 0 {
+		return f, info, nil
+	}
+	return &limitedFile{Reader: io.LimitReader(f, length), f: f}, info, nil
+}
+
+func (s *Store) StatObject(ctx context.Context, key string) (*domain.ObjectInfo, error) {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, wrapError("stat", key, err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("stat object %s: %w", key, domain.ErrObjectNotFound)
+	}
+	return s.objectInfo(key, fi), nil
+}
+
+// objectInfo sidecar 缺失（例如手工拷进目录的文件）时按文件属性兜底
+func (s *Store) objectInfo(key string, fi fs.FileInfo) *domain.ObjectInfo {
+	info := &domain.ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  "application/octet-stream",
+		LastModified: fi.ModTime().UTC(),
+	}
+	data, err := os.ReadFile(s.metaPath(key))
+	if err != nil {
+		return info
+	}
+	var meta sidecar
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Size != fi.Size() {
+		// 元数据与内容不一致（rename 之间崩溃），以文件本身为准
+		return info
+	}
+	info.ETag = meta.ETag
+	info.ContentType = meta.ContentType
+	info.LastModified = meta.ModTime
+	return info
+}
+
+func (s *Store) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	base := filepath.Join(s.root, objectsDir)
+	// 从 prefix 所在目录开始遍历，避免扫描整个存储
+	start := base
+	if dir := path.Dir(prefix); prefix != "" && dir != "." {
+		start = filepath.Join(base, filepath.FromSlash(dir))
+	}
+
+	var objects []domain.ObjectInfo
+	seenDirs := make(map[string]bool)
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		if !recursive {
+			if idx := strings.Index(key[len(prefix):], "/"); idx >= 0 {
+				dirKey := key[:len(prefix)+idx+1]
+				if !seenDirs[dirKey] {
+					seenDirs[dirKey] = true
+					objects = append(objects, domain.ObjectInfo{Key: dirKey})
+				}
+				return nil
+			}
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.objectInfo(key, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list objects %s error: %w", prefix, err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *Store) RemoveObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p, err := s.objectPath(key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove object %s error: %w", key, err)
+		}
+		if err := os.Remove(s.metaPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove object %s metadata error: %w", key, err)
+		}
+	}
+	if len(keys) > 0 {
+		log.Printf("[LocalFS] Removed %d objects", len(keys))
+	}
+	return nil
+}
+
+func (s *Store) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	src, info, err := s.GetObjectRange(ctx, srcKey, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return s.PutObject(ctx, dstKey, src, info.Size, info.ContentType)
+}
+
+func (s *Store) PresignedGetObject(ctx context.Context, key string, expiry time.Duration, downloadName string) (string, error) {
+	if _, err := s.StatObject(ctx, key); err != nil {
+		return "", err
+	}
+	return s.presign.sign(key, time.Now().Add(expiry), downloadName), nil
+}
+
+func wrapError(op, key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s object %s: %w", op, key, domain.ErrObjectNotFound)
+	}
+	return fmt.Errorf("%s object %s error: %w", op, key, err)
+}
+
+// ctxReader 每次 Read 前检查 ctx，长时间上传可以被取消
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+type limitedFile struct {
+	io.Reader
+	f *os.File
+}
+
+func (l *limitedFile) Close() error { return l.f.Close() }
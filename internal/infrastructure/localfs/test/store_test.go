@@ -0,0 +1,108 @@
+package localfs_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/localfs"
+)
+
+func newStore(t *testing.T, baseURL string) *localfs.Store {
+	store, err := localfs.NewStore(t.TempDir(), baseURL, []byte("test-secret"))
+	require.NoError(t, err)
+	return store
+}
+
+// TestStore_PutGetStatList - 基本读写、区间读取、元数据与列举
+func TestStore_PutGetStatList(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t, "http://localhost/storage")
+
+	require.NoError(t, store.PutObject(ctx, "batch-1/file-a", strings.NewReader("hello world"), 11, "text/plain"))
+	require.NoError(t, store.PutObject(ctx, "batch-1/charts/cpu.png", strings.NewReader("png"), -1, "image/png"))
+	require.NoError(t, store.PutObject(ctx, "batch-2/file-b", strings.NewReader("other"), -1, ""))
+
+	info, err := store.StatObject(ctx, "batch-1/file-a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", info.ETag) // md5("hello world")
+
+	rc, _, err := store.GetObjectRange(ctx, "batch-1/file-a", 6, 3)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "wor", string(data))
+
+	all, err := store.ListObjects(ctx, "batch-1/", true)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "batch-1/charts/cpu.png", all[0].Key)
+
+	top, err := store.ListObjects(ctx, "batch-1/", false)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "batch-1/charts/", top[0].Key)
+	assert.Equal(t, "batch-1/file-a", top[1].Key)
+
+	require.NoError(t, store.CopyObject(ctx, "batch-1/file-a", "batch-3/copy"))
+	require.NoError(t, store.RemoveObjects(ctx, []string{"batch-1/file-a", "missing"}))
+	_, err = store.GetObject(ctx, "batch-1/file-a")
+	assert.ErrorIs(t, err, domain.ErrObjectNotFound)
+	copied, err := store.StatObject(ctx, "batch-3/copy")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", copied.ContentType)
+}
+
+// TestStore_RejectsUnsafeKeys - 不允许通过 key 逃出存储根目录
+func TestStore_RejectsUnsafeKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t, "http://localhost/storage")
+	for _, key := range []string{"../etc/passwd", "/abs", "a/../../b", "a/", ""} {
+		err := store.PutObject(ctx, key, strings.NewReader("x"), 1, "")
+		assert.Error(t, err, key)
+	}
+}
+
+// TestStore_PresignedDownload - 预签名链接可下载，篡改签名或过期被拒绝
+func TestStore_PresignedDownload(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := newStore(t, server.URL+"/storage")
+	mux.Handle("/storage/", store.PresignHandler("/storage"))
+	require.NoError(t, store.PutObject(ctx, "batch-1/log.rec", strings.NewReader("payload"), -1, ""))
+
+	link, err := store.PresignedGetObject(ctx, "batch-1/log.rec", time.Minute, "log.rec")
+	require.NoError(t, err)
+	resp, err := http.Get(link)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "payload", string(body))
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "log.rec")
+
+	resp, err = http.Get(strings.Replace(link, "batch-1/log.rec", "batch-1/other", 1))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	expired, err := store.PresignedGetObject(ctx, "batch-1/log.rec", -time.Minute, "")
+	require.NoError(t, err)
+	resp, err = http.Get(expired)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
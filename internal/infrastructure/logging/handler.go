@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const componentKey = "component"
+
+// handler 组件级别过滤 + 旧日志格式整理 + ctx 关联字段，最终交给 JSON / Text Handler 输出
+type handler struct {
+	inner     slog.Handler
+	levels    *levels
+	component string
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.component == "" {
+		// 旧日志的组件要到 Handle 中解析前缀才知道，这里先按最低级别放行
+		return level >= h.levels.min
+	}
+	return level >= h.levels.of(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	component := h.component
+	msg, level := stripMarkers(r.Message, r.Level)
+	var legacy string
+	if component == "" {
+		// "🚀 [Kafka] ..." 与 "[Kafka] ❌ ..." 两种写法都有
+		legacy, msg = splitLegacyPrefix(msg)
+		component = legacy
+		msg, level = stripMarkers(msg, level)
+	}
+	if level < h.levels.of(component) {
+		return nil
+	}
+
+	out := slog.NewRecord(r.Time, level, msg, r.PC)
+	if legacy != "" {
+		out.AddAttrs(slog.String(componentKey, legacy))
+	}
+	// 调用方显式传入的字段优先于 ctx 中的同名字段
+	explicit := make(map[string]bool, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		explicit[a.Key] = true
+		return true
+	})
+	for _, a := range attrsFromContext(ctx) {
+		if !explicit[a.Key] {
+			out.AddAttrs(a)
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !explicit["trace_id"] {
+		out.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(a)
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		if a.Key == componentKey {
+			c.component = strings.ToLower(a.Value.String())
+		}
+	}
+	c.inner = h.inner.WithAttrs(attrs)
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithGroup(name)
+	return &c
+}
+
+// splitLegacyPrefix "[Kafka] Producer created" → ("kafka", "Producer created")
+func splitLegacyPrefix(msg string) (string, string) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg
+	}
+	end := strings.IndexByte(msg, ']')
+	if end < 2 || end > 32 || strings.ContainsAny(msg[1:end], " \t") {
+		return "", msg
+	}
+	return strings.ToLower(msg[1:end]), strings.TrimSpace(msg[end+1:])
+}
+
+// stripMarkers 去掉开头的表情符号；旧日志用 ❌ / ⚠️ 标记错误和警告，据此提升级别
+func stripMarkers(msg string, level slog.Level) (string, slog.Level) {
+	trimmed := trimSymbols(msg)
+	if level == slog.LevelInfo {
+		marker := strings.TrimSpace(msg[:len(msg)-len(trimmed)])
+		switch {
+		case strings.Contains(marker, "❌"):
+			level = slog.LevelError
+		case strings.Contains(marker, "⚠"):
+			level = slog.LevelWarn
+		}
+	}
+	return trimmed, level
+}
+
+func trimSymbols(msg string) string {
+	return strings.TrimLeftFunc(msg, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.Is(unicode.So, r) || r == '\uFE0F' // emoji 变体选择符
+	})
+}
+
+// lazyHandler Component 返回的 Logger 在写日志时才取全局 Handler（包级变量在 Init 之前就已创建）
+type lazyHandler struct {
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h lazyHandler) target() slog.Handler {
+	target := slog.Default().Handler().WithAttrs(h.attrs)
+	for _, g := range h.groups {
+		target = target.WithGroup(g)
+	}
+	return target
+}
+
+func (h lazyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.target().Enabled(ctx, level)
+}
+
+func (h lazyHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.target().Handle(ctx, r)
+}
+
+func (h lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.groups) > 0 {
+		return h.target().WithAttrs(attrs)
+	}
+	return lazyHandler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h lazyHandler) WithGroup(name string) slog.Handler {
+	return lazyHandler{attrs: h.attrs, groups: append(append([]string(nil), h.groups...), name)}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+)
+
+// RequestIDHeader 请求 ID：上游（网关、车端）传入时沿用，否则生成；响应头原样返回
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength 外部传入的请求 ID 过长时重新生成（防止日志被灌入任意内容）
+const maxRequestIDLength = 128
+
+var httpLogger = Component("http")
+
+// Middleware 替代 gin.Logger：请求 ID 写入 ctx，每个请求结束后输出一条访问日志
+//
+// 只记录路由模板，原始路径（/vehicles/:vin）与查询参数中有 VIN
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(With(c.Request.Context(), "request_id", requestID))
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "/metrics" || health.IsProbe(route) {
+			return
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.Int("response_bytes", c.Writer.Size()),
+		}
+		if err := c.Errors.Last(); err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		// c.Request 已被后续中间件（链路追踪）替换，ctx 中带有 trace_id
+		httpLogger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
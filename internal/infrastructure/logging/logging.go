@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 输出格式（LOG_FORMAT）
+const (
+	FormatJSON = "json" // 默认：每行一个 JSON 对象，便于日志平台按字段检索
+	FormatText = "text" // key=value，本地开发时阅读
+)
+
+// Init 设置全局 slog Logger，标准库 log 的输出也经过同一个 Handler
+//
+// LOG_LEVEL 默认级别；LOG_LEVELS 按组件覆盖，如 "redis=debug,kafka=warn"。
+// 组件来自 Component(name)，或旧代码日志的 "[Kafka] ..." 前缀（转为小写）
+//
+// slog.SetDefault 会把标准库 log 的输出重定向到这里的 Handler：旧代码的 "[Component]" 前缀解析为字段，
+// 去掉表情符号并做脱敏。新代码使用 Component(name) 加 *Context 方法，额外带上 ctx 中的关联 ID
+func Init(service string, getEnv func(key, defaultValue string) string) error {
+	h, err := NewHandler(os.Stderr, service, getEnv)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// NewHandler 按环境变量构造 Handler，输出到 w（Init 使用 os.Stderr）
+func NewHandler(w io.Writer, service string, getEnv func(key, defaultValue string) string) (slog.Handler, error) {
+	levels, err := parseLevels(getEnv("LOG_LEVEL", "info"), getEnv("LOG_LEVELS", ""))
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{
+		Level:       slog.LevelDebug, // 级别由外层 Handler 按组件判断
+		ReplaceAttr: redact,
+	}
+	var inner slog.Handler
+	switch format := getEnv("LOG_FORMAT", FormatJSON); format {
+	case FormatJSON:
+		inner = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		inner = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown LOG_FORMAT %q", format)
+	}
+	inner = inner.WithAttrs([]slog.Attr{slog.String("service", service)})
+	return &handler{inner: inner, levels: levels}, nil
+}
+
+// Component 组件 Logger：按组件过滤级别；包级变量也可以使用（每次写日志时才解析全局 Handler）
+func Component(name string) *slog.Logger {
+	return slog.New(lazyHandler{attrs: []slog.Attr{slog.String(componentKey, name)}})
+}
+
+type ctxKey struct{}
+
+// With 把关联字段（request_id、batch_id、file_id 等）附加到 ctx，之后的 *Context 日志都会带上；同名字段覆盖
+func With(ctx context.Context, args ...any) context.Context {
+	var r slog.Record
+	r.Add(args...)
+	attrs := append([]slog.Attr(nil), attrsFromContext(ctx)...)
+	r.Attrs(func(a slog.Attr) bool {
+		for i := range attrs {
+			if attrs[i].Key == a.Key {
+				attrs[i] = a
+				return true
+			}
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// parseLevels "kafka=warn,redis=debug"
+func parseLevels(defaultLevel, spec string) (*levels, error) {
+	l := &levels{components: make(map[string]slog.Level)}
+	if err := l.def.UnmarshalText([]byte(defaultLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q: %w", defaultLevel, err)
+	}
+	l.min = l.def
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid LOG_LEVELS entry %q, expected component=level", item)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVELS entry %q: %w", item, err)
+		}
+		l.components[strings.ToLower(strings.TrimSpace(name))] = level
+		if level < l.min {
+			l.min = level
+		}
+	}
+	return l, nil
+}
+
+type levels struct {
+	def        slog.Level
+	min        slog.Level // 所有配置中最低的级别（组件未知时的预判）
+	components map[string]slog.Level
+}
+
+func (l *levels) of(component string) slog.Level {
+	if level, ok := l.components[component]; ok {
+		return level
+	}
+	return l.def
+}
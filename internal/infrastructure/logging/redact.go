@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 值整体替换的字段名（不区分大小写；vin_token 是假名，不在其中）
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"authorization": true,
+	"cookie":        true,
+	"api_key":       true,
+	"access_key":    true,
+	"secret_key":    true,
+	"private_key":   true,
+	"dsn":           true,
+}
+
+// vinPattern 17 位 VIN 字符集（不含 I / O / Q）；纯数字串不视为 VIN
+var vinPattern = regexp.MustCompile(`\b[A-HJ-NPR-Z0-9]{17}\b`)
+
+// redact HandlerOptions.ReplaceAttr：敏感字段替换，任意字符串（包括 msg 与 error）中的 VIN 打码
+//
+// 旧代码的日志直接拼接了 VIN，只按字段名脱敏不够，所以对所有字符串做一次 VIN 匹配
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if sensitiveKeys[key] {
+		return slog.String(a.Key, redacted)
+	}
+	if key == "vin" {
+		return slog.String(a.Key, domain.MaskVIN(a.Value.String()))
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); vinPattern.MatchString(s) {
+			return slog.String(a.Key, maskVINs(s))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, maskVINs(err.Error()))
+		}
+	}
+	return a
+}
+
+func maskVINs(s string) string {
+	return vinPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.Trim(match, "0123456789") == "" {
+			return match
+		}
+		return domain.MaskVIN(match)
+	})
+}
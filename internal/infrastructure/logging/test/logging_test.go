@@ -0,0 +1,123 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+)
+
+// setup 全局 Logger 换成写入内存的 JSON Handler
+func setup(t *testing.T, env map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	h, err := logging.NewHandler(&buf, "test-service", func(key, defaultValue string) string {
+		if v, ok := env[key]; ok {
+			return v
+		}
+		return defaultValue
+	})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		out = append(out, m)
+	}
+	return out
+}
+
+// TestLegacyLog - 标准库 log 的 "[Kafka] ❌ ..." 解析为组件字段并提升为 ERROR
+func TestLegacyLog(t *testing.T) {
+	buf := setup(t, nil)
+
+	log.Printf("[Kafka] ❌ Failed to send message: %v", errors.New("broker down"))
+	log.Printf("🚀 [Orchestrator] Started")
+
+	got := lines(t, buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "ERROR", got[0]["level"])
+	assert.Equal(t, "kafka", got[0]["component"])
+	assert.Equal(t, "Failed to send message: broker down", got[0]["msg"])
+	assert.Equal(t, "test-service", got[0]["service"])
+	assert.Equal(t, "INFO", got[1]["level"])
+	assert.Equal(t, "orchestrator", got[1]["component"])
+	assert.Equal(t, "Started", got[1]["msg"])
+}
+
+// TestRedaction - 敏感字段整体替换，消息与错误中的 VIN 打码
+func TestRedaction(t *testing.T) {
+	buf := setup(t, nil)
+	const vin = "LSVAU218XN2183294"
+
+	logging.Component("auth").Info("vehicle "+vin+" uploaded",
+		"password", "hunter2",
+		"vin", vin,
+		"error", errors.New("vehicle "+vin+" not found"),
+		"order_no", "12345678901234567",
+	)
+
+	assert.NotContains(t, buf.String(), vin)
+	assert.NotContains(t, buf.String(), "hunter2")
+	got := lines(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "[REDACTED]", got[0]["password"])
+	assert.Equal(t, "12345678901234567", got[0]["order_no"]) // 纯数字串不视为 VIN
+}
+
+// TestContextAndLevels - ctx 关联字段随日志输出；组件级别覆盖默认级别
+func TestContextAndLevels(t *testing.T) {
+	buf := setup(t, map[string]string{"LOG_LEVEL": "info", "LOG_LEVELS": "redis=debug,kafka=warn"})
+
+	ctx := logging.With(context.Background(), "request_id", "req-1", "batch_id", "b-1")
+	ctx = logging.With(ctx, "batch_id", "b-2")
+	logging.Component("redis").DebugContext(ctx, "GET")
+	logging.Component("kafka").InfoContext(ctx, "dropped")
+	logging.Component("batch").DebugContext(ctx, "dropped")
+	logging.Component("batch").InfoContext(ctx, "kept", "request_id", "explicit")
+
+	got := lines(t, buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "redis", got[0]["component"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	assert.Equal(t, "b-2", got[0]["batch_id"])
+	assert.Equal(t, "kept", got[1]["msg"])
+	assert.Equal(t, "explicit", got[1]["request_id"])
+}
+
+// TestNewHandler_InvalidConfig - 配置错误在启动时返回
+func TestNewHandler_InvalidConfig(t *testing.T) {
+	for _, env := range []map[string]string{
+		{"LOG_LEVEL": "verbose"},
+		{"LOG_LEVELS": "kafka"},
+		{"LOG_FORMAT": "xml"},
+	} {
+		_, err := logging.NewHandler(&bytes.Buffer{}, "test", func(key, defaultValue string) string {
+			if v, ok := env[key]; ok {
+				return v
+			}
+			return defaultValue
+		})
+		assert.Error(t, err, env)
+	}
+}
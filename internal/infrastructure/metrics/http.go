@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/health"
+)
+
+// unmatchedRoute 没有匹配到路由的请求（404 扫描等）归为一类，避免原始路径进入标签
+const unmatchedRoute = "unmatched"
+
+// Handler Prometheus 抓取接口
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterRoutes GET /metrics（不经过认证中间件：抓取方在内网，指标中没有业务数据）
+func RegisterRoutes(router gin.IRouter) {
+	router.GET("/metrics", gin.WrapH(Handler()))
+}
+
+// Middleware 按路由模板（/api/v1/batches/:id）统计请求数与延迟，路径参数不会进入标签
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		if route == "/metrics" || health.IsProbe(route) {
+			return
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Serve 没有 HTTP 服务的进程（Worker）单独起一个 /metrics 服务；port 为空时不启动
+//
+// register 在同一端口上挂载其他运维接口（如 health.Checker.RegisterMux 的 /healthz、/readyz）
+func Serve(port string, register ...func(mux *http.ServeMux)) *http.Server {
+	if port == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	for _, r := range register {
+		r(mux)
+	}
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
+	go func() {
+		log.Printf("[Metrics] Serving /metrics on port %s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("[Metrics] Server failed: %v", err)
+		}
+	}()
+	return server
+}
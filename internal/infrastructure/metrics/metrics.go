@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// namespace 所有指标的前缀（argus_*）
+const namespace = "argus"
+
+// 结果标签
+const (
+	resultOK    = "ok"
+	resultError = "error"
+)
+
+// 报告缓存结果标签
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error" // Redis 故障，按未命中处理
+)
+
+// 补偿动作标签
+const (
+	CompensationRetry = "retry" // 重新触发当前阶段
+	CompensationFail  = "fail"  // 重试耗尽，标记 failed
+	CompensationSkip  = "skip"  // 加锁后发现已推进
+)
+
+// 指标全部注册在默认 Registry（同时带上 Go 运行时与进程指标），每个二进制通过 /metrics 暴露
+//
+// 标签不带 batch_id / VIN 等无界取值，否则时间序列数随业务量增长；单个 Batch 的排查交给日志和链路追踪
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_total",
+		Help: "HTTP requests by route template and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "HTTP request latency by route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	uploadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "upload", Name: "bytes_total",
+		Help: "Bytes of raw log files written to object storage.",
+	}, []string{"tenant"})
+	uploadFileSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "upload", Name: "file_size_bytes",
+		Help:    "Size of uploaded raw log files.",
+		Buckets: prometheus.ExponentialBuckets(64<<10, 4, 8), // 64 KiB .. 1 GiB
+	})
+	uploadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "upload", Name: "duration_seconds",
+		Help:    "Time to store an uploaded file and register it on the batch.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms .. 20s
+	}, []string{"result"})
+
+	batchTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "batch", Name: "status_transitions_total",
+		Help: "Persisted batch status transitions.",
+	}, []string{"from", "to"})
+	batchStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "batch", Name: "stage_duration_seconds",
+		Help:    "Time a batch spent in a status before leaving it.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 21600},
+	}, []string{"stage"})
+
+	barrierDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "orchestrator", Name: "barrier_duration_seconds",
+		Help:    "Latency of the Redis scatter barrier update (SADD + HSET + SCARD) per parsed file.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12), // 0.5ms .. 1s
+	}, []string{"result"})
+
+	kafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "kafka", Name: "produced_total",
+		Help: "Messages sent to Kafka.",
+	}, []string{"topic", "result"})
+	kafkaProduceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "kafka", Name: "produce_duration_seconds",
+		Help:    "Synchronous produce latency (until the broker acknowledged).",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+	}, []string{"topic"})
+	kafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "kafka", Name: "consumed_total",
+		Help: "Messages handled by consumers.",
+	}, []string{"topic", "group", "result"})
+	kafkaHandleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "kafka", Name: "handle_duration_seconds",
+		Help:    "Time spent handling a consumed message.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 16), // 1ms .. 30s
+	}, []string{"topic", "group"})
+	kafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "kafka", Name: "consumer_lag",
+		Help: "Messages behind the high water mark, per claimed partition.",
+	}, []string{"topic", "partition", "group"})
+
+	reportCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "query", Name: "report_cache_total",
+		Help: "Report cache lookups in QueryService.GetReport.",
+	}, []string{"result"})
+	reportSingleflight = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "query", Name: "report_singleflight_total",
+		Help: "GetReport calls, shared=true when merged into a concurrent call.",
+	}, []string{"shared"})
+
+	compensationActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "compensation", Name: "actions_total",
+		Help: "Actions taken by the compensation job on stuck batches.",
+	}, []string{"status", "action"})
+	compensationStuck = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "compensation", Name: "stuck_batches",
+		Help: "Batches over their stage SLA in the last compensation round.",
+	})
+)
+
+func result(err error) string {
+	if err != nil {
+		return resultError
+	}
+	return resultOK
+}
+
+// ObserveUpload 一次文件上传（写对象存储 + 登记到 Batch）
+func ObserveUpload(tenantID string, size int64, elapsed time.Duration, err error) {
+	uploadDuration.WithLabelValues(result(err)).Observe(elapsed.Seconds())
+	if err != nil {
+		return
+	}
+	uploadBytes.WithLabelValues(tenantID).Add(float64(size))
+	uploadFileSize.Observe(float64(size))
+}
+
+// ObserveBatchEvents 在 Batch 保存成功、发布事件之前调用：从事件中统计状态转换与阶段耗时
+//
+// 补偿任务重放的事件不经过这里（不是新的状态转换）
+func ObserveBatchEvents(events []domain.DomainEvent) {
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.BatchCreated:
+			observeTransition(domain.BatchStatusPending, domain.BatchStatusUploaded, e.StatusDuration)
+		case domain.BatchStatusChanged:
+			observeTransition(e.OldStatus, e.NewStatus, e.StatusDuration)
+		}
+	}
+}
+
+func observeTransition(from, to domain.BatchStatus, inStatus time.Duration) {
+	batchTransitions.WithLabelValues(from.String(), to.String()).Inc()
+	if inStatus > 0 {
+		batchStageDuration.WithLabelValues(from.String()).Observe(inStatus.Seconds())
+	}
+}
+
+// ObserveBarrier 一次 Redis Barrier 更新
+func ObserveBarrier(elapsed time.Duration, err error) {
+	barrierDuration.WithLabelValues(result(err)).Observe(elapsed.Seconds())
+}
+
+// ObserveKafkaProduce 一次同步发送
+func ObserveKafkaProduce(topic string, elapsed time.Duration, err error) {
+	kafkaProduced.WithLabelValues(topic, result(err)).Inc()
+	kafkaProduceDuration.WithLabelValues(topic).Observe(elapsed.Seconds())
+}
+
+// ObserveKafkaConsume 处理一条消息
+func ObserveKafkaConsume(topic, group string, elapsed time.Duration, err error) {
+	kafkaConsumed.WithLabelValues(topic, group, result(err)).Inc()
+	kafkaHandleDuration.WithLabelValues(topic, group).Observe(elapsed.Seconds())
+}
+
+// SetKafkaConsumerLag 分区积压（HighWaterMark - 下一条待处理的 offset）
+func SetKafkaConsumerLag(topic, group string, partition int32, lag int64) {
+	if lag < 0 {
+		lag = 0
+	}
+	kafkaConsumerLag.WithLabelValues(topic, strconv.Itoa(int(partition)), group).Set(float64(lag))
+}
+
+// ObserveReportCache 报告缓存查询结果（CacheHit / CacheMiss / CacheError）
+func ObserveReportCache(result string) {
+	reportCache.WithLabelValues(result).Inc()
+}
+
+// ObserveSingleflight shared 为 true 表示结果与并发请求共享
+func ObserveSingleflight(shared bool) {
+	reportSingleflight.WithLabelValues(strconv.FormatBool(shared)).Inc()
+}
+
+// ObserveCompensation 补偿任务对卡住的 Batch 采取的动作
+func ObserveCompensation(status domain.BatchStatus, action string) {
+	compensationActions.WithLabelValues(status.String(), action).Inc()
+}
+
+// SetStuckBatches 本轮补偿发现的超时 Batch 数
+func SetStuckBatches(n int) {
+	compensationStuck.Set(float64(n))
+}
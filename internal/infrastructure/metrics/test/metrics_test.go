@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+)
+
+// scrape 请求 /metrics 并返回文本格式的输出
+func scrape(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+// TestObserveBatchEvents - BatchCreated 计为 pending → uploaded，阶段耗时按旧状态统计
+func TestObserveBatchEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	metrics.RegisterRoutes(router)
+
+	metrics.ObserveBatchEvents([]domain.DomainEvent{
+		domain.BatchCreated{BatchID: uuid.New(), StatusDuration: 2 * time.Second},
+		domain.BatchStatusChanged{
+			BatchID:        uuid.New(),
+			OldStatus:      domain.BatchStatusGathering,
+			NewStatus:      domain.BatchStatusGathered,
+			StatusDuration: 40 * time.Second,
+		},
+		domain.FileParsed{BatchID: uuid.New()}, // 不是状态转换
+	})
+
+	body := scrape(t, router)
+	assert.Contains(t, body, `argus_batch_status_transitions_total{from="pending",to="uploaded"} 1`)
+	assert.Contains(t, body, `argus_batch_status_transitions_total{from="gathering",to="gathered"} 1`)
+	assert.Contains(t, body, `argus_batch_stage_duration_seconds_sum{stage="gathering"} 40`)
+}
+
+// TestMiddleware_RouteTemplate - 标签使用路由模板，路径参数（Batch ID、VIN）不会进入指标
+func TestMiddleware_RouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(metrics.Middleware())
+	metrics.RegisterRoutes(router)
+	router.GET("/api/v1/vehicles/:vin", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/vehicles/LSVAU218XN2183294", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	body := scrape(t, router)
+	assert.Contains(t, body, `argus_http_requests_total{method="GET",route="/api/v1/vehicles/:vin",status="204"} 1`)
+	assert.False(t, strings.Contains(body, "LSVAU218XN2183294"))
+	assert.NotContains(t, body, `route="/metrics"`)
+}
@@ -2,46 +2,228 @@ package minio
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
+	"time"
+
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
 )
+
+var _ domain.ObjectStore = (*MinIOClient)(nil)
+
 type MinIOClient struct {
 	client *minio.Client
 	bucket string
 }
-func NewMinIOClient(endpoint, bucket,accessKey,secretKey string,useSSL bool) (*MinIOClient,error) {
+
+func NewMinIOClient(endpoint, bucket, accessKey, secretKey string, useSSL bool) (*MinIOClient, error) {
 	//create minio client
-	client,err := minio.New(endpoint,&minio.Options{
-		Creds: credentials.NewStaticV4(accessKey,secretKey,""),
-		Secure:useSSL,
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
 	})
 	if err != nil {
-		return nil,fmt.Errorf("create client error by %s",err)
+		return nil, fmt.Errorf("create client error by %s", err)
 	}
 	//check bucket
 	ctx := context.Background()
-	exists,err := client.BucketExists(ctx,bucket)
+	exists, err := client.BucketExists(ctx, bucket)
 	if err != nil {
-		return nil,fmt.Errorf("check bucket exists error: %w",err)
+		return nil, fmt.Errorf("check bucket exists error: %w", err)
 	}
 	if !exists {
-		err = client.MakeBucket(ctx,bucket,minio.MakeBucketOptions{})
+		err = client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
 		if err != nil {
-			return nil,fmt.Errorf("create bucket error: %w",err)
+			return nil, fmt.Errorf("create bucket error: %w", err)
 		}
 	}
-	return &MinIOClient{client: client,bucket: bucket},nil
+	return &MinIOClient{client: client, bucket: bucket}, nil
 }
-func (m *MinIOClient) PutObject(ctx context.Context,objectKey string, reader io.Reader,size int64,contentType string) error {
-	info,err := m.client.PutObject(ctx,m.bucket,objectKey,reader,size,minio.PutObjectOptions{
+
+// PutObject 上传对象（size = -1 时按分片流式上传）
+func (m *MinIOClient) PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error {
+	info, err := m.client.PutObject(ctx, m.bucket, objectKey, reader, size, minio.PutObjectOptions{
 		ContentType: contentType,
-		PartSize: 5 * 1024 * 1024,
+		PartSize:    5 * 1024 * 1024,
 	})
+	if err != nil {
+		return fmt.Errorf("put object %s error: %w", objectKey, err)
+	}
 	log.Printf("[MinIO] Uploaded: %s, Size: %d", objectKey, info.Size)
-	return err
-	
+	return nil
+}
+
+// GetObject 流式读取对象（调用方负责 Close）
+//
+// minio-go 的 GetObject 是惰性的，对象不存在也不报错。GetObjectRange 打开后立即 Stat，
+// 在第一次 Read 之前就发出请求，让“不存在”在打开时以 domain.ErrObjectNotFound 返回，而不是在读到一半时
+func (m *MinIOClient) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, error) {
+	rc, _, err := m.GetObjectRange(ctx, objectKey, 0, 0)
+	return rc, err
+}
+
+// GetObjectRange 读取 [offset, offset+length) 区间；length <= 0 表示读到末尾
+//
+// 返回的 ObjectInfo 描述整个对象（Size 为对象总大小，不是区间长度）
+func (m *MinIOClient) GetObjectRange(ctx context.Context, objectKey string, offset, length int64) (io.ReadCloser, *domain.ObjectInfo, error) {
+	opts := minio.GetObjectOptions{}
+	if offset < 0 {
+		return nil, nil, fmt.Errorf("get object %s: invalid offset %d", objectKey, offset)
+	}
+	if offset > 0 || length > 0 {
+		end := int64(0)
+		if length > 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, nil, fmt.Errorf("get object %s: %w", objectKey, err)
+		}
+	}
+
+	obj, err := m.client.GetObject(ctx, m.bucket, objectKey, opts)
+	if err != nil {
+		return nil, nil, wrapError("get", objectKey, err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, wrapError("get", objectKey, err)
+	}
+	return obj, toObjectInfo(stat), nil
+}
+
+// Ping Bucket 存在且凭证有效（HEAD Bucket）
+func (m *MinIOClient) Ping(ctx context.Context) error {
+	exists, err := m.client.BucketExists(ctx, m.bucket)
+	if err != nil {
+		return fmt.Errorf("check bucket %s: %w", m.bucket, err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", m.bucket)
+	}
+	return nil
+}
+
+// StatObject 只读取元数据（HEAD）
+func (m *MinIOClient) StatObject(ctx context.Context, objectKey string) (*domain.ObjectInfo, error) {
+	stat, err := m.client.StatObject(ctx, m.bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, wrapError("stat", objectKey, err)
+	}
+	return toObjectInfo(stat), nil
+}
 
-}
\ No newline at end of file
+// ListObjects 列出前缀下的对象（recursive = false 时只列一层，子目录以 "/" 结尾返回）
+func (m *MinIOClient) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	var objects []domain.ObjectInfo
+	for obj := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("list objects %s error: %w", prefix, obj.Err)
+		}
+		objects = append(objects, *toObjectInfo(obj))
+	}
+	return objects, nil
+}
+
+// RemoveObjects 批量删除（S3 DeleteObjects，每次请求最多 1000 个，由 SDK 分批）
+//
+// 不存在的对象视为删除成功（幂等）；返回第一个失败的对象及失败总数
+func (m *MinIOClient) RemoveObjects(ctx context.Context, objectKeys []string) error {
+	if len(objectKeys) == 0 {
+		return nil
+	}
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		for _, key := range objectKeys {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: key}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var firstErr error
+	failed := 0
+	for result := range m.client.RemoveObjects(ctx, m.bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+		if result.Err == nil || isNotFound(result.Err) {
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = fmt.Errorf("remove object %s error: %w", result.ObjectName, result.Err)
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d objects failed to remove, first: %w", failed, len(objectKeys), firstErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log.Printf("[MinIO] Removed %d objects", len(objectKeys))
+	return nil
+}
+
+// CopyObject 服务端复制（数据不经过本服务）
+func (m *MinIOClient) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	_, err := m.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: m.bucket, Object: dstKey},
+		minio.CopySrcOptions{Bucket: m.bucket, Object: srcKey},
+	)
+	if err != nil {
+		return wrapError("copy", srcKey, err)
+	}
+	return nil
+}
+
+// PresignedGetObject 生成限时下载链接，客户端直接从 MinIO 下载大文件，不占用服务带宽
+//
+// downloadName 非空时浏览器按该文件名保存（Content-Disposition: attachment）
+func (m *MinIOClient) PresignedGetObject(ctx context.Context, objectKey string, expiry time.Duration, downloadName string) (string, error) {
+	params := url.Values{}
+	if downloadName != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", downloadName))
+	}
+	u, err := m.client.PresignedGetObject(ctx, m.bucket, objectKey, expiry, params)
+	if err != nil {
+		return "", wrapError("presign", objectKey, err)
+	}
+	return u.String(), nil
+}
+
+func toObjectInfo(obj minio.ObjectInfo) *domain.ObjectInfo {
+	return &domain.ObjectInfo{
+		Key:          obj.Key,
+		Size:         obj.Size,
+		ETag:         obj.ETag,
+		ContentType:  obj.ContentType,
+		LastModified: obj.LastModified,
+	}
+}
+
+// isNotFound S3 错误码 → 是否为“不存在”
+func isNotFound(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchObject":
+		return true
+	}
+	return false
+}
+
+// wrapError 不存在统一包装为 domain.ErrObjectNotFound，其余保留原始错误
+func wrapError(op, objectKey string, err error) error {
+	if isNotFound(err) {
+		return fmt.Errorf("%s object %s: %w", op, objectKey, domain.ErrObjectNotFound)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s object %s error: %w", op, objectKey, err)
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// scopeCondition 把数据范围转换为 SQL 条件（规则之间 OR，规则内部 AND），参数追加到 args
+//
+// 车队不在 batches / 汇总表上（车辆会换车队），按 VIN 子查询车辆注册表；scope 为 nil 返回空串
+func scopeCondition(scope domain.DataScope, platformColumn, vinColumn string, args *[]interface{}) string {
+	if scope == nil {
+		return ""
+	}
+	if len(scope) == 0 {
+		return "FALSE"
+	}
+	rules := make([]string, 0, len(scope))
+	for _, s := range scope {
+		var parts []string
+		if len(s.Platforms) > 0 {
+			*args = append(*args, pq.Array(s.Platforms))
+			parts = append(parts, fmt.Sprintf("%s = ANY($%d)", platformColumn, len(*args)))
+		}
+		if len(s.Fleets) > 0 {
+			*args = append(*args, pq.Array(s.Fleets))
+			parts = append(parts, fmt.Sprintf("%s IN (SELECT vin FROM vehicles WHERE owner_fleet = ANY($%d))", vinColumn, len(*args)))
+		}
+		if len(parts) == 0 {
+			return ""
+		}
+		rules = append(rules, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(rules, " OR ") + ")"
+}
+
+// PostgresAccessAuditRepository 访问拒绝审计
+type PostgresAccessAuditRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAccessAuditRepository(db *sql.DB) domain.AccessAuditRepository {
+	return &PostgresAccessAuditRepository{db: db}
+}
+
+func (r *PostgresAccessAuditRepository) RecordDenial(ctx context.Context, d *domain.AccessDenial) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO access_denials (
+			id, occurred_at, principal_kind, subject, name, vin,
+			method, path, permission, status, reason, client_ip, tenant_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, d.ID, d.OccurredAt, string(d.Kind), d.Subject, d.Name, d.VIN,
+		d.Method, d.Path, string(d.Permission), d.Status, d.Reason, d.ClientIP, tenantOrDefault(d.TenantID))
+	return err
+}
+
+func (r *PostgresAccessAuditRepository) ListDenials(ctx context.Context, subject string, since time.Time, limit int) ([]*domain.AccessDenial, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, occurred_at, principal_kind, subject, name, vin,
+			method, path, permission, status, reason, client_ip, tenant_id
+		FROM access_denials
+		WHERE occurred_at >= $1 AND ($2::text = '' OR subject = $2)
+			AND ($4::text = '' OR tenant_id = $4)
+		ORDER BY occurred_at DESC
+		LIMIT $3
+	`, since, subject, limit, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var denials []*domain.AccessDenial
+	for rows.Next() {
+		d := &domain.AccessDenial{}
+		var kind, permission string
+		if err := rows.Scan(&d.ID, &d.OccurredAt, &kind, &d.Subject, &d.Name, &d.VIN,
+			&d.Method, &d.Path, &permission, &d.Status, &d.Reason, &d.ClientIP, &d.TenantID); err != nil {
+			return nil, err
+		}
+		d.Kind = domain.PrincipalKind(kind)
+		d.Permission = domain.Permission(permission)
+		denials = append(denials, d)
+	}
+	return denials, rows.Err()
+}
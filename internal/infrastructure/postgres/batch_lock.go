@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// RowBatchLocker 基于 SELECT ... FOR UPDATE 的 Batch 锁（Redis 不可用时的降级方案）
+//
+// 锁随事务存在：LockBatch 开启事务并锁住 batches 行，返回的 context 携带该事务，
+// Release 时根据锁内操作结果提交或回滚
+//
+// 加锁时同样推进 fencing token：降级前通过 Redis 拿到锁、尚未过期的持有者随后的写入会被拒绝
+type RowBatchLocker struct {
+	db *sql.DB
+}
+
+func NewRowBatchLocker(db *sql.DB) *RowBatchLocker {
+	return &RowBatchLocker{db: db}
+}
+
+func (l *RowBatchLocker) LockBatch(ctx context.Context, batchID uuid.UUID) (context.Context, domain.BatchLock, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("%w: begin tx: %v", domain.ErrLockUnavailable, err)
+	}
+
+	// 先推进 fencing_tokens 再锁 batches 行，与 withFencing 的加锁顺序一致，避免死锁
+	token, err := nextFencingToken(ctx, tx, domain.BatchLockResource(batchID))
+	if err != nil {
+		tx.Rollback()
+		return ctx, nil, fmt.Errorf("%w: %v", domain.ErrLockUnavailable, err)
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT id FROM batches WHERE id = $1 FOR UPDATE`, batchID).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		tx.Rollback()
+		return ctx, nil, fmt.Errorf("%w: select for update: %v", domain.ErrLockUnavailable, err)
+	}
+	// 行不存在时也返回锁（后续 FindByID 会得到 nil，由业务层处理 not found）
+
+	lockCtx := domain.WithFencingToken(contextWithTx(ctx, tx), domain.BatchLockResource(batchID), token)
+	return lockCtx, &rowBatchLock{tx: tx, token: token}, nil
+}
+
+type rowBatchLock struct {
+	tx    *sql.Tx
+	token int64
+}
+
+func (l *rowBatchLock) FencingToken() int64 {
+	return l.token
+}
+
+func (l *rowBatchLock) Release(ctx context.Context, opErr error) error {
+	if opErr != nil {
+		return l.tx.Rollback()
+	}
+	return l.tx.Commit()
+}
@@ -0,0 +1,364 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// PostgresCampaignRepository OTA 活动（campaigns）
+type PostgresCampaignRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCampaignRepository(db *sql.DB) domain.CampaignRepository {
+	return &PostgresCampaignRepository{db: db}
+}
+
+// campaignColumns campaigns 表的查询列（与 scanCampaign 的顺序保持一致）
+const campaignColumns = `
+	id, name, vehicle_platform, target_firmware, cohort, waves, gate,
+	current_wave, status, status_reason, last_gate, wave_started_at,
+	created_by, version, created_at, updated_at, started_at, completed_at, tenant_id`
+
+func scanCampaign(row rowScanner) (*domain.Campaign, error) {
+	c := &domain.Campaign{}
+	var status string
+	var cohort, waves, gate, lastGate []byte
+	err := row.Scan(
+		&c.ID, &c.Name, &c.Platform, &c.TargetFirmware, &cohort, &waves, &gate,
+		&c.CurrentWave, &status, &c.StatusReason, &lastGate, &c.WaveStartedAt,
+		&c.CreatedBy, &c.Version, &c.CreatedAt, &c.UpdatedAt, &c.StartedAt, &c.CompletedAt, &c.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.Status = domain.CampaignStatus(status)
+	if err := json.Unmarshal(cohort, &c.Cohort); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cohort: %w", err)
+	}
+	if err := json.Unmarshal(waves, &c.Waves); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal waves: %w", err)
+	}
+	if err := json.Unmarshal(gate, &c.Gate); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal gate: %w", err)
+	}
+	if lastGate != nil {
+		if err := json.Unmarshal(lastGate, &c.LastGate); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal last_gate: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Save Version 为 0 表示新建；否则只有版本一致才更新，并把版本 +1
+//
+// 暂停接口和 Leader 的门禁任务可能同时修改同一个活动，后提交的一方影响 0 行、返回 ErrCampaignConflict，
+// 重新读取后再决定（例如活动已被暂停就不再推进波次）
+func (r *PostgresCampaignRepository) Save(ctx context.Context, c *domain.Campaign) error {
+	cohort, err := json.Marshal(c.Cohort)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cohort: %w", err)
+	}
+	waves, err := json.Marshal(c.Waves)
+	if err != nil {
+		return fmt.Errorf("failed to marshal waves: %w", err)
+	}
+	gate, err := json.Marshal(c.Gate)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gate: %w", err)
+	}
+	var lastGate []byte
+	if c.LastGate != nil {
+		if lastGate, err = json.Marshal(c.LastGate); err != nil {
+			return fmt.Errorf("failed to marshal last_gate: %w", err)
+		}
+	}
+
+	if c.Version == 0 {
+		_, err := conn(ctx, r.db).ExecContext(ctx, `
+			INSERT INTO campaigns (`+campaignColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 1, $14, $15, $16, $17, $18)
+		`, c.ID, c.Name, c.Platform, c.TargetFirmware, cohort, waves, gate,
+			c.CurrentWave, string(c.Status), c.StatusReason, lastGate, c.WaveStartedAt,
+			c.CreatedBy, c.CreatedAt, c.UpdatedAt, c.StartedAt, c.CompletedAt, tenantOrDefault(c.TenantID))
+		if err != nil {
+			return err
+		}
+		c.Version = 1
+		return nil
+	}
+
+	// Leader 门禁任务会在 context 中携带 fencing token，旧 Leader 的延迟写入会被拒绝
+	return withFencing(ctx, r.db, func(db execer) error {
+		res, err := db.ExecContext(ctx, `
+			UPDATE campaigns SET
+				cohort = $3, waves = $4, gate = $5, current_wave = $6, status = $7, status_reason = $8,
+				last_gate = $9, wave_started_at = $10, updated_at = $11, started_at = $12, completed_at = $13,
+				version = version + 1
+			WHERE id = $1 AND version = $2 AND ($14::text = '' OR tenant_id = $14)
+		`, c.ID, c.Version, cohort, waves, gate, c.CurrentWave, string(c.Status), c.StatusReason,
+			lastGate, c.WaveStartedAt, c.UpdatedAt, c.StartedAt, c.CompletedAt, contextTenant(ctx))
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrCampaignConflict
+		}
+		c.Version++
+		return nil
+	})
+}
+
+func (r *PostgresCampaignRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Campaign, error) {
+	c, err := scanCampaign(conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT`+campaignColumns+` FROM campaigns WHERE id = $1 AND ($2::text = '' OR tenant_id = $2)`,
+		id, contextTenant(ctx)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *PostgresCampaignRepository) List(ctx context.Context, status domain.CampaignStatus, limit int) ([]*domain.Campaign, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT`+campaignColumns+` FROM campaigns
+		WHERE ($1::text = '' OR status = $1)
+			AND ($3::text = '' OR tenant_id = $3)
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, string(status), limit, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []*domain.Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, rows.Err()
+}
+
+// PostgresDeploymentRepository 单车升级记录（deployments）
+type PostgresDeploymentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDeploymentRepository(db *sql.DB) domain.DeploymentRepository {
+	return &PostgresDeploymentRepository{db: db}
+}
+
+// deploymentColumns deployments 表的查询列（与 scanDeployment 的顺序保持一致）
+const deploymentColumns = `
+	id, campaign_id, vin, wave, from_version, target_version, status, error_message,
+	released_at, installed_at, created_at, updated_at, tenant_id`
+
+func scanDeployment(row rowScanner) (*domain.Deployment, error) {
+	d := &domain.Deployment{}
+	var status string
+	err := row.Scan(
+		&d.ID, &d.CampaignID, &d.VIN, &d.Wave, &d.FromVersion, &d.TargetVersion, &status, &d.ErrorMessage,
+		&d.ReleasedAt, &d.InstalledAt, &d.CreatedAt, &d.UpdatedAt, &d.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Status = domain.DeploymentStatus(status)
+	return d, nil
+}
+
+// CreateAll 在一个事务内逐条插入；ON CONFLICT DO NOTHING 同时覆盖 (活动, VIN) 唯一约束
+// 和"一辆车只能有一个进行中的升级"的部分唯一索引
+func (r *PostgresDeploymentRepository) CreateAll(ctx context.Context, deployments []*domain.Deployment) (int, error) {
+	created := 0
+	err := inTx(ctx, r.db, func(tx querier) error {
+		for _, d := range deployments {
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO deployments (`+deploymentColumns+`)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				ON CONFLICT DO NOTHING
+			`, d.ID, d.CampaignID, d.VIN, d.Wave, d.FromVersion, d.TargetVersion, string(d.Status), d.ErrorMessage,
+				d.ReleasedAt, d.InstalledAt, d.CreatedAt, d.UpdatedAt, tenantOrDefault(d.TenantID))
+			if err != nil {
+				return fmt.Errorf("failed to insert deployment for %s: %w", d.VIN, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func (r *PostgresDeploymentRepository) FindActiveByVIN(ctx context.Context, vin string) (*domain.Deployment, error) {
+	d, err := scanDeployment(conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT`+deploymentColumns+` FROM deployments WHERE vin = $1 AND status = $2 AND ($3::text = '' OR tenant_id = $3)`,
+		vin, string(domain.DeploymentStatusScheduled), contextTenant(ctx)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+func (r *PostgresDeploymentRepository) Save(ctx context.Context, d *domain.Deployment) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE deployments
+		SET status = $2, error_message = $3, released_at = $4, installed_at = $5, updated_at = $6
+		WHERE id = $1 AND ($7::text = '' OR tenant_id = $7)
+	`, d.ID, string(d.Status), d.ErrorMessage, d.ReleasedAt, d.InstalledAt, d.UpdatedAt, contextTenant(ctx))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrDeploymentNotFound
+	}
+	return nil
+}
+
+func (r *PostgresDeploymentRepository) ListByCampaign(ctx context.Context, campaignID uuid.UUID, status domain.DeploymentStatus, limit, offset int) ([]*domain.Deployment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT`+deploymentColumns+` FROM deployments
+		WHERE campaign_id = $1 AND ($2::text = '' OR status = $2)
+			AND ($5::text = '' OR tenant_id = $5)
+		ORDER BY wave, vin
+		LIMIT $3 OFFSET $4
+	`, campaignID, string(status), limit, offset, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deployments []*domain.Deployment
+	for rows.Next() {
+		d, err := scanDeployment(rows)
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, d)
+	}
+	return deployments, rows.Err()
+}
+
+func (r *PostgresDeploymentRepository) CountByStatus(ctx context.Context, campaignID uuid.UUID) (map[domain.DeploymentStatus]int, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT status, COUNT(*) FROM deployments
+		WHERE campaign_id = $1 AND ($2::text = '' OR tenant_id = $2)
+		GROUP BY status
+	`, campaignID, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.DeploymentStatus]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[domain.DeploymentStatus(status)] = n
+	}
+	return counts, rows.Err()
+}
+
+func (r *PostgresDeploymentRepository) ReleaseWave(ctx context.Context, campaignID uuid.UUID, wave int) (int, error) {
+	return r.update(ctx, `
+		UPDATE deployments SET status = 'scheduled', released_at = NOW(), updated_at = NOW()
+		WHERE campaign_id = $1 AND wave <= $2 AND status = 'pending'
+			AND ($3::text = '' OR tenant_id = $3)
+	`, campaignID, wave, contextTenant(ctx))
+}
+
+// SyncInstalled 车辆注册表的版本由车端上报（PATCH /vehicles/:vin）更新，这里据此补记安装完成
+func (r *PostgresDeploymentRepository) SyncInstalled(ctx context.Context, campaignID uuid.UUID) (int, error) {
+	return r.update(ctx, `
+		UPDATE deployments d SET status = 'installed', installed_at = NOW(), updated_at = NOW()
+		FROM vehicles v
+		WHERE d.vin = v.vin AND d.tenant_id = v.tenant_id AND d.campaign_id = $1 AND d.status = 'scheduled'
+			AND v.firmware_version = d.target_version
+			AND ($2::text = '' OR d.tenant_id = $2)
+	`, campaignID, contextTenant(ctx))
+}
+
+func (r *PostgresDeploymentRepository) CancelOpen(ctx context.Context, campaignID uuid.UUID) (int, error) {
+	return r.update(ctx, `
+		UPDATE deployments SET status = 'cancelled', updated_at = NOW()
+		WHERE campaign_id = $1 AND status IN ('pending', 'scheduled')
+			AND ($2::text = '' OR tenant_id = $2)
+	`, campaignID, contextTenant(ctx))
+}
+
+func (r *PostgresDeploymentRepository) update(ctx context.Context, query string, args ...interface{}) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// GateMetrics 实验组：已安装车辆在安装之后、以目标版本上传的终态 Batch；
+// 对照组：同一活动中尚未安装的车辆在活动开始之后上传的终态 Batch
+func (r *PostgresDeploymentRepository) GateMetrics(ctx context.Context, c *domain.Campaign) (domain.GateMetrics, error) {
+	var m domain.GateMetrics
+	counts, err := r.CountByStatus(ctx, c.ID)
+	if err != nil {
+		return m, err
+	}
+	m.Installed = counts[domain.DeploymentStatusInstalled]
+	m.InstallFailed = counts[domain.DeploymentStatusFailed]
+
+	startedAt := c.CreatedAt
+	if c.StartedAt != nil {
+		startedAt = *c.StartedAt
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		WITH samples AS (
+			SELECT
+				d.status = 'installed' AS treated,
+				b.status = $4 AS failed,
+				COALESCE((rp.report_data ->> 'RecordCount')::int, 0) AS records,
+				CASE WHEN jsonb_typeof(rp.report_data -> 'TopErrorCodes') = 'array' THEN
+					(SELECT COALESCE(SUM((e ->> 'count')::int), 0) FROM jsonb_array_elements(rp.report_data -> 'TopErrorCodes') e)
+				ELSE 0 END AS errors,
+				EXISTS (SELECT 1 FROM ai_diagnoses a WHERE a.batch_id = b.id AND a.severity = 'critical') AS critical
+			FROM deployments d
+			JOIN batches b ON b.vin = d.vin AND b.tenant_id = d.tenant_id
+			LEFT JOIN reports rp ON rp.batch_id = b.id AND rp.report_type = $2
+			WHERE d.campaign_id = $1 AND b.status IN ($3, $4) AND (
+				(d.status = 'installed' AND b.created_at >= d.installed_at AND b.firmware_version = d.target_version)
+				OR (d.status IN ('pending', 'scheduled') AND b.created_at >= $5)
+			)
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE treated),
+			COUNT(*) FILTER (WHERE treated AND failed),
+			COALESCE(SUM(records) FILTER (WHERE treated), 0),
+			COALESCE(SUM(errors) FILTER (WHERE treated), 0),
+			COUNT(*) FILTER (WHERE treated AND critical),
+			COALESCE(SUM(records) FILTER (WHERE NOT treated), 0),
+			COALESCE(SUM(errors) FILTER (WHERE NOT treated), 0)
+		FROM samples
+	`, c.ID, reportTypeSystemHealth, domain.BatchStatusCompleted.String(), domain.BatchStatusFailed.String(), startedAt,
+	).Scan(&m.Batches, &m.FailedBatches, &m.Records, &m.ErrorOccurrences, &m.CriticalDiagnoses,
+		&m.ControlRecords, &m.ControlErrors)
+	return m, err
+}
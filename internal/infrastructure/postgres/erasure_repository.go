@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// PostgresErasureRepository VIN 数据删除请求：Batch 之外的记录与执行记录
+type PostgresErasureRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresErasureRepository(db *sql.DB) domain.ErasureRepository {
+	return &PostgresErasureRepository{db: db}
+}
+
+// erasureDeletes 与车辆直接相关、没有审计价值的记录（$1 = VIN）；升级记录引用车辆注册表，必须先删
+var erasureDeletes = []string{"fleet_vehicle_daily", "deployments", "vehicles"}
+
+// erasureUpdates 需要保留的记录中把 VIN 替换为令牌（$1 = VIN，$2 = 令牌）
+//
+// access_denials.vin 只有 17 位放不下令牌，清空后由 subject / path 保留令牌
+var erasureUpdates = map[string]string{
+	"batch_tombstones": `UPDATE batch_tombstones SET vin = $2, vehicle_id = $2 WHERE vin = $1`,
+	"legal_holds":      `UPDATE legal_holds SET value = $2 WHERE scope = 'vin' AND value = $1 AND released_at IS NOT NULL`,
+	"access_denials": `
+		UPDATE access_denials SET
+			vin = '',
+			subject = CASE WHEN subject = $1 THEN $2 ELSE subject END,
+			path = REPLACE(path, $1, $2)
+		WHERE vin = $1 OR subject = $1 OR STRPOS(path, $1) > 0`,
+}
+
+// EraseVehicleRecords 全部语句与执行记录在同一事务内：要么全部生效，要么重试时从头再来
+func (r *PostgresErasureRepository) EraseVehicleRecords(ctx context.Context, vin string, erasure *domain.VINErasure) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range erasureDeletes {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE vin = $1`, vin); err != nil {
+			return fmt.Errorf("failed to erase %s: %w", table, err)
+		}
+	}
+	for table, query := range erasureUpdates {
+		if _, err := tx.ExecContext(ctx, query, vin, erasure.VINToken); err != nil {
+			return fmt.Errorf("failed to anonymize %s: %w", table, err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO vin_erasures (
+			id, vin_token, requested_by, reason, batches_deleted, objects_deleted, erased_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, erasure.ID, erasure.VINToken, erasure.RequestedBy, erasure.Reason,
+		erasure.BatchesDeleted, erasure.ObjectsDeleted, erasure.ErasedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save erasure record: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresErasureRepository) FindByToken(ctx context.Context, vinToken string) ([]*domain.VINErasure, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, vin_token, requested_by, reason, batches_deleted, objects_deleted, erased_at
+		FROM vin_erasures
+		WHERE vin_token = $1
+		ORDER BY erased_at DESC
+	`, vinToken)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var erasures []*domain.VINErasure
+	for rows.Next() {
+		var e domain.VINErasure
+		if err := rows.Scan(&e.ID, &e.VINToken, &e.RequestedBy, &e.Reason,
+			&e.BatchesDeleted, &e.ObjectsDeleted, &e.ErasedAt); err != nil {
+			return nil, err
+		}
+		erasures = append(erasures, &e)
+	}
+	return erasures, rows.Err()
+}
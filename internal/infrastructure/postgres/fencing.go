@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// execer 兼容 *sql.DB 和 *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// FencingTokenIssuer 在 fencing_tokens 上递增分配 token，与写入时的校验共用同一张表
+type FencingTokenIssuer struct {
+	db *sql.DB
+}
+
+func NewFencingTokenIssuer(db *sql.DB) *FencingTokenIssuer {
+	return &FencingTokenIssuer{db: db}
+}
+
+// NextFencingToken 实现 domain.FencingTokenIssuer
+func (i *FencingTokenIssuer) NextFencingToken(ctx context.Context, resource string) (int64, error) {
+	return nextFencingToken(ctx, conn(ctx, i.db), resource)
+}
+
+// CurrentFencingToken 实现 domain.FencingTokenIssuer
+func (i *FencingTokenIssuer) CurrentFencingToken(ctx context.Context, resource string) (int64, error) {
+	var token int64
+	err := conn(ctx, i.db).QueryRowContext(ctx, `SELECT token FROM fencing_tokens WHERE resource = $1`, resource).Scan(&token)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("current fencing token: %w", err)
+	}
+	return token, nil
+}
+
+func nextFencingToken(ctx context.Context, q querier, resource string) (int64, error) {
+	query := `
+		INSERT INTO fencing_tokens (resource, token, updated_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (resource) DO UPDATE SET
+			token = fencing_tokens.token + 1,
+			updated_at = NOW()
+		RETURNING token
+	`
+	var token int64
+	if err := q.QueryRowContext(ctx, query, resource).Scan(&token); err != nil {
+		return 0, fmt.Errorf("next fencing token: %w", err)
+	}
+	return token, nil
+}
+
+// withFencing 在写入前校验 context 中的 fencing token
+//
+// 没有 token 时直接使用 db；有 token 时在同一事务中先推进 fencing_tokens，再执行写入，
+// token 小于已记录的最大值则返回 domain.ErrStaleFencingToken 并回滚
+// 如果 context 中已经有事务（数据库行锁降级），直接在该事务内校验和写入
+func withFencing(ctx context.Context, db *sql.DB, write func(execer) error) error {
+	fences := domain.FencingTokensFromContext(ctx)
+	if tx, inTx := txFromContext(ctx); inTx {
+		if err := checkFencingTokens(ctx, tx, fences); err != nil {
+			return err
+		}
+		return write(tx)
+	}
+	if len(fences) == 0 {
+		return write(db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin fencing tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFencingTokens(ctx, tx, fences); err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func checkFencingTokens(ctx context.Context, tx *sql.Tx, fences []domain.FencingToken) error {
+	for _, fence := range fences {
+		if err := checkFencingToken(ctx, tx, fence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFencingToken(ctx context.Context, tx *sql.Tx, fence domain.FencingToken) error {
+	query := `
+		INSERT INTO fencing_tokens (resource, token, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (resource) DO UPDATE SET
+			token = EXCLUDED.token,
+			updated_at = NOW()
+		WHERE fencing_tokens.token <= EXCLUDED.token
+	`
+	result, err := tx.ExecContext(ctx, query, fence.Resource, fence.Token)
+	if err != nil {
+		return fmt.Errorf("check fencing token: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: resource=%s token=%d", domain.ErrStaleFencingToken, fence.Resource, fence.Token)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// PostgresFirmwareRepository 版本样本（batches + reports）与检测到的回归（firmware_regressions）
+type PostgresFirmwareRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresFirmwareRepository(db *sql.DB) domain.FirmwareRepository {
+	return &PostgresFirmwareRepository{db: db}
+}
+
+// versionExpr ECU 参数为空时取整车版本，否则取该 ECU 的版本
+const versionExpr = `CASE WHEN $2::text = '' THEN b.firmware_version ELSE COALESCE(b.ecu_versions ->> $2::text, '') END`
+
+func (r *PostgresFirmwareRepository) ListVersions(ctx context.Context, tenantID, platform, ecu string) ([]*domain.FirmwareVersionSummary, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT version, COUNT(*), COUNT(DISTINCT vin), MIN(created_at), MAX(created_at)
+		FROM (
+			SELECT `+versionExpr+` AS version, b.vin, b.created_at
+			FROM batches b
+			WHERE b.vehicle_platform = $1 AND b.tenant_id = $3
+		) v
+		WHERE version <> ''
+		GROUP BY version
+		ORDER BY MIN(created_at) DESC
+	`, platform, ecu, tenantOrDefault(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*domain.FirmwareVersionSummary
+	for rows.Next() {
+		v := &domain.FirmwareVersionSummary{Platform: platform, ECU: ecu}
+		if err := rows.Scan(&v.Version, &v.Batches, &v.Vehicles, &v.FirstSeen, &v.LastSeen); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *PostgresFirmwareRepository) ListPlatforms(ctx context.Context) ([]domain.TenantPlatform, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT DISTINCT tenant_id, vehicle_platform FROM batches
+		WHERE firmware_version <> '' AND vehicle_platform <> ''
+		  AND ($1::text = '' OR tenant_id = $1)
+		ORDER BY tenant_id, vehicle_platform
+	`, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var platforms []domain.TenantPlatform
+	for rows.Next() {
+		var p domain.TenantPlatform
+		if err := rows.Scan(&p.TenantID, &p.Platform); err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, rows.Err()
+}
+
+// FindSamples 取最近的终态 Batch；报告不存在（聚合前失败）时样本只有失败标记
+func (r *PostgresFirmwareRepository) FindSamples(ctx context.Context, sel domain.VersionSelector, limit int) ([]domain.ReleaseSample, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT b.id, b.vin, b.status, rp.report_data
+		FROM batches b
+		LEFT JOIN reports rp ON rp.batch_id = b.id AND rp.report_type = $4
+		WHERE b.vehicle_platform = $1 AND `+versionExpr+` = $3
+			AND b.status IN ($5, $6) AND b.tenant_id = $8
+		ORDER BY b.updated_at DESC
+		LIMIT $7
+	`, sel.Platform, sel.ECU, sel.Version, reportTypeSystemHealth,
+		domain.BatchStatusCompleted.String(), domain.BatchStatusFailed.String(), limit, tenantOrDefault(sel.TenantID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []domain.ReleaseSample
+	for rows.Next() {
+		var sample domain.ReleaseSample
+		var status string
+		var data []byte
+		if err := rows.Scan(&sample.BatchID, &sample.VIN, &status, &data); err != nil {
+			return nil, err
+		}
+		sample.Failed = domain.BatchStatus(status) == domain.BatchStatusFailed
+		if data != nil {
+			var report domain.Report
+			if err := json.Unmarshal(data, &report); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal report of batch %s: %w", sample.BatchID, err)
+			}
+			sample.RecordCount = report.RecordCount
+			sample.ErrorCodes = report.TopErrorCodes
+			sample.CPU = report.CPUStats
+			sample.RAM = report.RAMStats
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
+// regressionColumns firmware_regressions 表的查询列（与 scanRegression 的顺序保持一致）
+const regressionColumns = `
+	id, vehicle_platform, ecu, baseline_version, candidate_version, metric, error_code,
+	baseline_value, candidate_value, relative_change, p_value,
+	status, acknowledged_by, note, acknowledged_at, detected_at, updated_at, tenant_id`
+
+func scanRegression(row rowScanner) (*domain.FirmwareRegression, error) {
+	reg := &domain.FirmwareRegression{}
+	var metric, status string
+	err := row.Scan(
+		&reg.ID, &reg.Platform, &reg.ECU, &reg.Baseline, &reg.Candidate, &metric, &reg.ErrorCode,
+		&reg.BaselineValue, &reg.CandidateValue, &reg.RelativeChange, &reg.PValue,
+		&status, &reg.AcknowledgedBy, &reg.Note, &reg.AcknowledgedAt, &reg.DetectedAt, &reg.UpdatedAt, &reg.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reg.Metric = domain.RegressionMetric(metric)
+	reg.Status = domain.RegressionStatus(status)
+	return reg, nil
+}
+
+// SaveRegression 冲突时只刷新数值，状态与确认信息保持不变（确认过的回归不会被周期检测重新打开）
+//
+// xmax = 0 表示这一行是本次 INSERT 新建的，而不是 ON CONFLICT 更新的
+func (r *PostgresFirmwareRepository) SaveRegression(ctx context.Context, reg *domain.FirmwareRegression) (bool, error) {
+	var created bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO firmware_regressions (`+regressionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (tenant_id, vehicle_platform, ecu, baseline_version, candidate_version, metric, error_code) DO UPDATE SET
+			baseline_value = EXCLUDED.baseline_value,
+			candidate_value = EXCLUDED.candidate_value,
+			relative_change = EXCLUDED.relative_change,
+			p_value = EXCLUDED.p_value,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, (xmax = 0)
+	`,
+		reg.ID, reg.Platform, reg.ECU, reg.Baseline, reg.Candidate, string(reg.Metric), reg.ErrorCode,
+		reg.BaselineValue, reg.CandidateValue, reg.RelativeChange, reg.PValue,
+		string(reg.Status), reg.AcknowledgedBy, reg.Note, reg.AcknowledgedAt, reg.DetectedAt, reg.UpdatedAt, tenantOrDefault(reg.TenantID),
+	).Scan(&reg.ID, &created)
+	return created, err
+}
+
+func (r *PostgresFirmwareRepository) FindRegression(ctx context.Context, id uuid.UUID) (*domain.FirmwareRegression, error) {
+	reg, err := scanRegression(conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT`+regressionColumns+` FROM firmware_regressions WHERE id = $1 AND ($2::text = '' OR tenant_id = $2)`,
+		id, contextTenant(ctx)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return reg, err
+}
+
+// UpdateRegression 只更新处理状态（确认）
+func (r *PostgresFirmwareRepository) UpdateRegression(ctx context.Context, reg *domain.FirmwareRegression) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE firmware_regressions
+		SET status = $2, acknowledged_by = $3, note = $4, acknowledged_at = $5, updated_at = $6
+		WHERE id = $1 AND ($7::text = '' OR tenant_id = $7)
+	`, reg.ID, string(reg.Status), reg.AcknowledgedBy, reg.Note, reg.AcknowledgedAt, reg.UpdatedAt, contextTenant(ctx))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrRegressionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresFirmwareRepository) ListRegressions(ctx context.Context, opts domain.RegressionListOptions) ([]*domain.FirmwareRegression, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT`+regressionColumns+` FROM firmware_regressions
+		WHERE ($1::text = '' OR vehicle_platform = $1)
+			AND ($2::text = '' OR status = $2)
+			AND ($4::text[] IS NULL OR vehicle_platform = ANY($4))
+			AND ($5::text = '' OR tenant_id = $5)
+		ORDER BY detected_at DESC
+		LIMIT $3
+	`, opts.Platform, string(opts.Status), opts.Limit, pq.Array(opts.Scope.Platforms()), contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regressions []*domain.FirmwareRegression
+	for rows.Next() {
+		reg, err := scanRegression(rows)
+		if err != nil {
+			return nil, err
+		}
+		regressions = append(regressions, reg)
+	}
+	return regressions, rows.Err()
+}
@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// PostgresFleetRollupRepository 车队汇总表（fleet_*）
+type PostgresFleetRollupRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresFleetRollupRepository(db *sql.DB) domain.FleetRollupRepository {
+	return &PostgresFleetRollupRepository{db: db}
+}
+
+// Apply 在一个事务内：写 ledger → 累加三张汇总表
+//
+// ledger 的主键插入是第一步：并发处理同一个 Batch 的第二个事务阻塞在主键冲突上，
+// 第一个提交后它影响 0 行直接返回（不重复累加），第一个回滚则它继续累加
+func (r *PostgresFleetRollupRepository) Apply(ctx context.Context, rollup *domain.BatchRollup) (bool, error) {
+	applied := false
+	err := inTx(ctx, r.db, func(tx querier) error {
+		var err error
+		applied, err = r.apply(ctx, tx, rollup)
+		return err
+	})
+	return applied, err
+}
+
+func (r *PostgresFleetRollupRepository) apply(ctx context.Context, tx querier, rollup *domain.BatchRollup) (bool, error) {
+	tenantID := tenantOrDefault(rollup.TenantID)
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO fleet_rollup_ledger (batch_id, tenant_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		rollup.BatchID, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert ledger: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	completed, failed := 1, 0
+	if rollup.Failed {
+		completed, failed = 0, 1
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO fleet_throughput_hourly AS t (tenant_id, bucket, vehicle_platform, completed, failed, total_files, duration_seconds_sum)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id, bucket, vehicle_platform) DO UPDATE SET
+			completed = t.completed + EXCLUDED.completed,
+			failed = t.failed + EXCLUDED.failed,
+			total_files = t.total_files + EXCLUDED.total_files,
+			duration_seconds_sum = t.duration_seconds_sum + EXCLUDED.duration_seconds_sum
+	`, tenantID, rollup.Hour(), rollup.Platform, completed, failed, rollup.TotalFiles, rollup.Duration.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to update throughput rollup: %w", err)
+	}
+
+	for _, code := range rollup.ErrorCodes {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO fleet_error_codes_daily AS t (tenant_id, day, vehicle_platform, firmware_version, error_code, occurrences, batches)
+			VALUES ($1, $2, $3, $4, $5, $6, 1)
+			ON CONFLICT (tenant_id, day, vehicle_platform, firmware_version, error_code) DO UPDATE SET
+				occurrences = t.occurrences + EXCLUDED.occurrences,
+				batches = t.batches + 1
+		`, tenantID, rollup.Day(), rollup.Platform, rollup.FirmwareVersion, code.Code, code.Count)
+		if err != nil {
+			return false, fmt.Errorf("failed to update error code rollup: %w", err)
+		}
+	}
+
+	statsBatches := 0
+	var cpu domain.CPUStats
+	var ram domain.RAMStats
+	if rollup.CPU != nil && rollup.RAM != nil {
+		statsBatches, cpu, ram = 1, *rollup.CPU, *rollup.RAM
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO fleet_vehicle_daily AS t (
+			day, vin, vehicle_platform, batches, failed, error_occurrences, critical_diagnoses, stats_batches,
+			cpu_avg_sum, cpu_p95_sum, cpu_p99_max, cpu_max,
+			ram_avg_sum, ram_p95_sum, ram_p99_max, ram_max, tenant_id
+		) VALUES (
+			$1, $2, $3, 1, $4, $5,
+			(SELECT COUNT(*) FROM ai_diagnoses WHERE batch_id = $6 AND severity = 'critical'),
+			$7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+		)
+		ON CONFLICT (tenant_id, day, vin) DO UPDATE SET
+			vehicle_platform = EXCLUDED.vehicle_platform,
+			batches = t.batches + 1,
+			failed = t.failed + EXCLUDED.failed,
+			error_occurrences = t.error_occurrences + EXCLUDED.error_occurrences,
+			critical_diagnoses = t.critical_diagnoses + EXCLUDED.critical_diagnoses,
+			stats_batches = t.stats_batches + EXCLUDED.stats_batches,
+			cpu_avg_sum = t.cpu_avg_sum + EXCLUDED.cpu_avg_sum,
+			cpu_p95_sum = t.cpu_p95_sum + EXCLUDED.cpu_p95_sum,
+			cpu_p99_max = GREATEST(t.cpu_p99_max, EXCLUDED.cpu_p99_max),
+			cpu_max = GREATEST(t.cpu_max, EXCLUDED.cpu_max),
+			ram_avg_sum = t.ram_avg_sum + EXCLUDED.ram_avg_sum,
+			ram_p95_sum = t.ram_p95_sum + EXCLUDED.ram_p95_sum,
+			ram_p99_max = GREATEST(t.ram_p99_max, EXCLUDED.ram_p99_max),
+			ram_max = GREATEST(t.ram_max, EXCLUDED.ram_max)
+	`, rollup.Day(), rollup.VIN, rollup.Platform, failed, rollup.ErrorOccurrences(), rollup.BatchID,
+		statsBatches, cpu.AvgUtilization, cpu.P95Utilization, cpu.P99Utilization, cpu.MaxUtilization,
+		ram.AvgUsageMB, ram.P95UsageMB, ram.P99UsageMB, ram.MaxUsageMB, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update vehicle rollup: %w", err)
+	}
+	return true, nil
+}
+
+func (r *PostgresFleetRollupRepository) FindUnapplied(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT b.id FROM batches b
+		LEFT JOIN fleet_rollup_ledger l ON l.batch_id = b.id
+		WHERE b.status IN ($1, $2) AND l.batch_id IS NULL
+		  AND ($4::text = '' OR b.tenant_id = $4)
+		ORDER BY COALESCE(b.completed_at, b.updated_at)
+		LIMIT $3
+	`, domain.BatchStatusCompleted.String(), domain.BatchStatusFailed.String(), limit, contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Throughput 小时表按 interval 再聚合（day 粒度用 date_trunc 合并）
+func (r *PostgresFleetRollupRepository) Throughput(ctx context.Context, q domain.FleetQuery) ([]domain.ThroughputPoint, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT date_trunc($1, bucket) AS b,
+			SUM(completed), SUM(failed), SUM(total_files), SUM(duration_seconds_sum)
+		FROM fleet_throughput_hourly
+		WHERE bucket >= $2 AND bucket < $3
+		  AND ($4::text = '' OR vehicle_platform = $4)
+		  AND ($5::text[] IS NULL OR vehicle_platform = ANY($5))
+		  AND ($6::text = '' OR tenant_id = $6)
+		GROUP BY b
+		ORDER BY b
+	`, string(q.Interval), q.From.UTC(), q.To.UTC(), q.Platform, pq.Array(q.Scope.Platforms()), contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []domain.ThroughputPoint
+	for rows.Next() {
+		var p domain.ThroughputPoint
+		var durationSum float64
+		if err := rows.Scan(&p.Bucket, &p.Completed, &p.Failed, &p.TotalFiles, &durationSum); err != nil {
+			return nil, err
+		}
+		if finished := p.Completed + p.Failed; finished > 0 {
+			p.FailureRate = float64(p.Failed) / float64(finished)
+			p.AvgDurationSeconds = durationSum / float64(finished)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// TopErrorCodes 每个（平台, 软件版本）组合各取前 Limit 个异常码
+//
+// 按天汇总的表以日期过滤：包含 From、To 所在的整天
+func (r *PostgresFleetRollupRepository) TopErrorCodes(ctx context.Context, q domain.FleetQuery) ([]domain.ErrorCodeRank, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT vehicle_platform, firmware_version, error_code, occurrences, batches FROM (
+			SELECT vehicle_platform, firmware_version, error_code,
+				SUM(occurrences) AS occurrences, SUM(batches) AS batches,
+				ROW_NUMBER() OVER (
+					PARTITION BY vehicle_platform, firmware_version
+					ORDER BY SUM(occurrences) DESC, error_code
+				) AS rank
+			FROM fleet_error_codes_daily
+			WHERE day >= $1::date AND day <= $2::date
+			  AND ($3::text = '' OR vehicle_platform = $3)
+			  AND ($4::text = '' OR firmware_version = $4)
+			  AND ($6::text[] IS NULL OR vehicle_platform = ANY($6))
+			  AND ($7::text = '' OR tenant_id = $7)
+			GROUP BY vehicle_platform, firmware_version, error_code
+		) ranked
+		WHERE rank <= $5
+		ORDER BY vehicle_platform, firmware_version, occurrences DESC
+	`, q.From.UTC(), q.To.UTC(), q.Platform, q.FirmwareVersion, q.Limit, pq.Array(q.Scope.Platforms()), contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranks []domain.ErrorCodeRank
+	for rows.Next() {
+		var e domain.ErrorCodeRank
+		if err := rows.Scan(&e.Platform, &e.FirmwareVersion, &e.Code, &e.Occurrences, &e.Batches); err != nil {
+			return nil, err
+		}
+		ranks = append(ranks, e)
+	}
+	return ranks, rows.Err()
+}
+
+func (r *PostgresFleetRollupRepository) VehicleTrend(ctx context.Context, vin string, q domain.FleetQuery) ([]domain.VehicleResourcePoint, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT day, batches, stats_batches,
+			cpu_avg_sum, cpu_p95_sum, cpu_p99_max, cpu_max,
+			ram_avg_sum, ram_p95_sum, ram_p99_max, ram_max
+		FROM fleet_vehicle_daily
+		WHERE vin = $1 AND day >= $2::date AND day <= $3::date
+		  AND ($4::text = '' OR tenant_id = $4)
+		ORDER BY day
+	`, domain.NormalizeVIN(vin), q.From.UTC(), q.To.UTC(), contextTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []domain.VehicleResourcePoint
+	for rows.Next() {
+		var p domain.VehicleResourcePoint
+		var statsBatches int
+		var cpuAvgSum, cpuP95Sum, ramAvgSum, ramP95Sum float64
+		if err := rows.Scan(&p.Day, &p.Batches, &statsBatches,
+			&cpuAvgSum, &cpuP95Sum, &p.CPUP99Max, &p.CPUMax,
+			&ramAvgSum, &ramP95Sum, &p.RAMP99Max, &p.RAMMaxMB); err != nil {
+			return nil, err
+		}
+		if statsBatches > 0 {
+			n := float64(statsBatches)
+			p.CPUAvg, p.CPUP95 = cpuAvgSum/n, cpuP95Sum/n
+			p.RAMAvgMB, p.RAMP95MB = ramAvgSum/n, ramP95Sum/n
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// anomalyExpressions 排行指标对应的聚合表达式（白名单，防止 SQL 注入）
+var anomalyExpressions = map[domain.AnomalyMetric]string{
+	domain.AnomalyMetricErrors:            "SUM(error_occurrences)",
+	domain.AnomalyMetricFailures:          "SUM(failed)",
+	domain.AnomalyMetricCPUP99:            "MAX(cpu_p99_max)",
+	domain.AnomalyMetricRAMP99:            "MAX(ram_p99_max)",
+	domain.AnomalyMetricCriticalDiagnoses: "SUM(critical_diagnoses)",
+}
+
+func (r *PostgresFleetRollupRepository) Anomalies(ctx context.Context, metric domain.AnomalyMetric, q domain.FleetQuery) ([]domain.VehicleAnomaly, error) {
+	expr, ok := anomalyExpressions[metric]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown anomaly metric %q", domain.ErrInvalidFleetQuery, metric)
+	}
+	args := []interface{}{q.From.UTC(), q.To.UTC(), q.Platform, q.Limit, contextTenant(ctx)}
+	scope := ""
+	if cond := scopeCondition(q.Scope, "vehicle_platform", "vin", &args); cond != "" {
+		scope = " AND " + cond
+	}
+	query := `
+		SELECT vin, MAX(vehicle_platform), ` + expr + ` AS value, SUM(batches)
+		FROM fleet_vehicle_daily
+		WHERE day >= $1::date AND day <= $2::date
+		  AND ($3::text = '' OR vehicle_platform = $3)
+		  AND ($5::text = '' OR tenant_id = $5)` + scope + `
+		GROUP BY vin
+		HAVING ` + expr + ` > 0
+		ORDER BY value DESC, vin
+		LIMIT $4
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anomalies []domain.VehicleAnomaly
+	for rows.Next() {
+		var a domain.VehicleAnomaly
+		if err := rows.Scan(&a.VIN, &a.Platform, &a.Value, &a.Batches); err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
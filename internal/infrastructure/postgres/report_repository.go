@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// reportTypeSystemHealth 聚合阶段生成的系统健康报告（reports.report_type）
+const reportTypeSystemHealth = "system_health"
+
+// PostgresReportRepository 报告整体以 JSONB 存储在 reports.report_data 中
+type PostgresReportRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReportRepository(db *sql.DB) domain.ReportRepository {
+	return &PostgresReportRepository{db: db}
+}
+
+// Save 每个 Batch 只有一份系统健康报告，重复聚合时覆盖
+func (r *PostgresReportRepository) Save(ctx context.Context, report *domain.Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	query := `
+		INSERT INTO reports (id, batch_id, report_type, report_data, created_at, updated_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (batch_id, report_type) DO UPDATE SET
+			report_data = EXCLUDED.report_data,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		report.ID, report.BatchID, reportTypeSystemHealth, data, report.CreatedAt, report.UpdatedAt,
+		tenantOrDefault(report.TenantID))
+	return err
+}
+
+func (r *PostgresReportRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Report, error) {
+	return r.findOne(ctx, `SELECT report_data FROM reports WHERE id = $1`, id)
+}
+
+func (r *PostgresReportRepository) FindByBatchID(ctx context.Context, batchID uuid.UUID) (*domain.Report, error) {
+	return r.findOne(ctx, `SELECT report_data FROM reports WHERE batch_id = $1 AND report_type = $2`,
+		batchID, reportTypeSystemHealth)
+}
+
+func (r *PostgresReportRepository) findOne(ctx context.Context, query string, args ...interface{}) (*domain.Report, error) {
+	var data []byte
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report domain.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal report: %w", err)
+	}
+	return &report, nil
+}
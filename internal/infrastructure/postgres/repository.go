@@ -3,9 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
 )
 
@@ -16,98 +21,222 @@ func NewPostgresBatchRepository(db *sql.DB) domain.BatchRepository {
 	return &PostgresBatchRepository{db: db}
 }
 
-func (r *PostgresBatchRepository)FindByVIN(ctx context.Context, vin string) ([]*domain.Batch, error) {
-	return nil,nil
+// batchColumns batches 表的查询列（与 scanBatch 的顺序保持一致）
+const batchColumns = `
+	id, tenant_id, vehicle_id, vin, status, upload_time,
+	total_files, processed_files, expected_worker_count,
+	completed_worker_count, minio_bucket, minio_prefix,
+	vehicle_platform, priority, compensation_attempts,
+	error_message, completed_at, created_at, updated_at,
+	firmware_version, ecu_versions, status_changed_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBatch(row rowScanner) (*domain.Batch, error) {
+	batch := &domain.Batch{}
+	var statusStr, priorityStr string
+	var minioBucket, minioPrefix, errorMessage sql.NullString
+	var ecuVersions []byte
+	err := row.Scan(
+		&batch.ID, &batch.TenantID, &batch.VehicleID, &batch.VIN, &statusStr, &batch.UploadTime,
+		&batch.TotalFiles, &batch.ProcessedFiles, &batch.ExpectedWorkerCount,
+		&batch.CompletedWorkerCount, &minioBucket, &minioPrefix,
+		&batch.VehiclePlatform, &priorityStr, &batch.CompensationAttempts,
+		&errorMessage, &batch.CompletedAt, &batch.CreatedAt, &batch.UpdatedAt,
+		&batch.FirmwareVersion, &ecuVersions, &batch.StatusChangedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(ecuVersions, &batch.ECUVersions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ecu_versions: %w", err)
+	}
+	batch.Status = domain.BatchStatus(statusStr)
+	batch.Priority = domain.BatchPriority(priorityStr)
+	batch.MinIOBucket = minioBucket.String
+	batch.MiniIOPrefix = minioPrefix.String
+	batch.ErrorMessage = errorMessage.String
+	return batch, nil
+}
+
+func scanBatches(rows *sql.Rows) ([]*domain.Batch, error) {
+	defer rows.Close()
+	var batches []*domain.Batch
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, rows.Err()
+}
+
+// FindByVIN 该车辆的所有 Batch（最新的在前）
+func (r *PostgresBatchRepository) FindByVIN(ctx context.Context, vin string) ([]*domain.Batch, error) {
+	query := `SELECT` + batchColumns + `
+		FROM batches
+		WHERE vin = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, domain.NormalizeVIN(vin))
+	if err != nil {
+		return nil, err
+	}
+	return scanBatches(rows)
 }
+// listSortColumns 允许排序的列（白名单，防止 SQL 注入）
+var listSortColumns = map[string]string{
+	"":           "created_at",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"status":     "status",
+	"priority":   "priority",
+}
+
+// List 按条件分页查询
+//
+// 按 priority 排序时使用业务权重（safety_critical > elevated > routine），而不是字符串顺序
 func (r *PostgresBatchRepository)List(ctx context.Context, opts domain.ListOptions) ([]*domain.Batch,error) {
-	return nil,nil
+	var where []string
+	var args []interface{}
+	filter := func(column string, value *string) {
+		if value == nil || *value == "" {
+			return
+		}
+		args = append(args, *value)
+		where = append(where, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	filter("vehicle_id", opts.VehicleID)
+	filter("vin", opts.VIN)
+	filter("status", opts.Status)
+	filter("priority", opts.Priority)
+	filter("vehicle_platform", opts.Platform)
+	filter("tenant_id", opts.TenantID)
+	if cond := scopeCondition(opts.Scope, "vehicle_platform", "vin", &args); cond != "" {
+		where = append(where, cond)
+	}
+
+	column, ok := listSortColumns[opts.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort field: %s", opts.SortBy)
+	}
+	if column == "priority" {
+		column = `CASE priority WHEN 'safety_critical' THEN 2 WHEN 'elevated' THEN 1 ELSE 0 END`
+	}
+	order := "DESC"
+	if strings.EqualFold(opts.SortOrder, "asc") {
+		order = "ASC"
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT` + batchColumns + `
+		FROM batches`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY %s %s, created_at DESC LIMIT $%d OFFSET $%d", column, order, len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBatches(rows)
+}
+// statusChangedAt 不是经 NewBatch 创建的 Batch（测试数据、旧代码路径）没有状态时间，按 updated_at 处理
+func statusChangedAt(batch *domain.Batch) time.Time {
+	if batch.StatusChangedAt.IsZero() {
+		return batch.UpdatedAt
+	}
+	return batch.StatusChangedAt
 }
+
 func (r *PostgresBatchRepository) Save(ctx context.Context,batch *domain.Batch) error {
 	query := `
           INSERT INTO batches (
               id, vehicle_id, vin, status, upload_time,
               total_files, processed_files, expected_worker_count,
               completed_worker_count, minio_bucket, minio_prefix,
-              error_message, completed_at, created_at, updated_at
-          ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+              vehicle_platform, priority, compensation_attempts,
+              error_message, completed_at, created_at, updated_at,
+              firmware_version, ecu_versions, tenant_id, status_changed_at
+          ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
           ON CONFLICT (id) DO UPDATE SET
               status = EXCLUDED.status,
               total_files = EXCLUDED.total_files,
               processed_files = EXCLUDED.processed_files,
               completed_worker_count = EXCLUDED.completed_worker_count,
-              updated_at = EXCLUDED.updated_at
+              vehicle_platform = EXCLUDED.vehicle_platform,
+              firmware_version = EXCLUDED.firmware_version,
+              ecu_versions = EXCLUDED.ecu_versions,
+              priority = EXCLUDED.priority,
+              compensation_attempts = EXCLUDED.compensation_attempts,
+              error_message = EXCLUDED.error_message,
+              completed_at = EXCLUDED.completed_at,
+              updated_at = EXCLUDED.updated_at,
+              status_changed_at = EXCLUDED.status_changed_at
       `
-	_, err := r.db.ExecContext(ctx,query,
-		batch.ID, batch.VehicleID, batch.VIN, batch.Status.String(), batch.UploadTime,
-		batch.TotalFiles, batch.ProcessedFiles, batch.ExpectedWorkerCount,
-		batch.CompletedWorkerCount, batch.MinIOBucket, batch.MiniIOPrefix,
-		batch.ErrorMessage, batch.CompletedAt, batch.CreatedAt, batch.UpdatedAt,
-	)
+	ecuVersions := batch.ECUVersions
+	if ecuVersions == nil {
+		ecuVersions = map[string]string{}
+	}
+	ecuVersionsJSON, err := json.Marshal(ecuVersions)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to marshal ecu_versions: %w", err)
 	}
-	return nil
+	// Leader 任务（补偿等）会在 context 中携带 fencing token，旧 Leader 的延迟写入会被拒绝
+	return withFencing(ctx, r.db, func(db execer) error {
+		_, err := db.ExecContext(ctx,query,
+			batch.ID, batch.VehicleID, batch.VIN, batch.Status.String(), batch.UploadTime,
+			batch.TotalFiles, batch.ProcessedFiles, batch.ExpectedWorkerCount,
+			batch.CompletedWorkerCount, batch.MinIOBucket, batch.MiniIOPrefix,
+			batch.VehiclePlatform, batch.Priority.String(), batch.CompensationAttempts,
+			batch.ErrorMessage, batch.CompletedAt, batch.CreatedAt, batch.UpdatedAt,
+			batch.FirmwareVersion, ecuVersionsJSON, tenantOrDefault(batch.TenantID), statusChangedAt(batch),
+		)
+		return err
+	})
 }
 func (r *PostgresBatchRepository) FindByID(ctx context.Context,id uuid.UUID) (*domain.Batch , error) {
-	query := `
-		SELECT id, vehicle_id,vin,status,upload_time,
-			   total_files,processed_files,expected_worker_count,
-			   completed_worker_count,minio_bucket,minio_prefix,
-			   error_message,completed_at,created_at,updated_at
+	query := `SELECT` + batchColumns + `
 		FROM batches
 		WHERE id = $1
 	`
-	var batch domain.Batch
-	var statusStr string
-	err := r.db.QueryRowContext(ctx,query,id).Scan(
-		&batch.ID, &batch.VehicleID, &batch.VIN, &statusStr, &batch.UploadTime,
-		&batch.TotalFiles, &batch.ProcessedFiles, &batch.ExpectedWorkerCount,
-		&batch.CompletedWorkerCount, &batch.MinIOBucket, &batch.MiniIOPrefix,
-		&batch.ErrorMessage, &batch.CompletedAt, &batch.CreatedAt, &batch.UpdatedAt,
-	)
-	batch.Status = domain.BatchStatus(statusStr)
+	batch, err := scanBatch(conn(ctx, r.db).QueryRowContext(ctx,query,id))
 	if err == sql.ErrNoRows {
 		return nil,nil
 	}
 	if err != nil {
 		return nil,err
 	}
-	return &batch,nil
+	return batch,nil
 }
 func (r *PostgresBatchRepository) FindByStatus(ctx context.Context,status domain.BatchStatus) ([]*domain.Batch, error) {
-	query := `
-		SELECT id, vehicle_id,vin,status,upload_time,
-			   total_files,processed_files,expected_worker_count,
-			   completed_worker_count,minio_bucket,minio_prefix,
-			   error_message,completed_at,created_at,updated_at
+	query := `SELECT` + batchColumns + `
 		FROM batches
 		WHERE status = $1
 		ORDER BY created_at DESC
 	`
-	rows, err := r.db.QueryContext(ctx,query,status.String())
+	rows, err := conn(ctx, r.db).QueryContext(ctx,query,status.String())
 	if err != nil {
 		return nil,err
 	}
-	defer rows.Close()
-	var batches []*domain.Batch
-	for rows.Next() {
-		batch := &domain.Batch{}
-		var statusStr string
-
-		err := rows.Scan(
-			&batch.ID, &batch.VehicleID, &batch.VIN, &statusStr, &batch.UploadTime,
-			&batch.TotalFiles, &batch.ProcessedFiles, &batch.ExpectedWorkerCount,
-			&batch.CompletedWorkerCount, &batch.MinIOBucket, &batch.MiniIOPrefix,
-			&batch.ErrorMessage, &batch.CompletedAt, &batch.CreatedAt, &batch.UpdatedAt,
-		)
-		if err != nil{
-			return nil,err
-		}
-
-		batch.Status = domain.BatchStatus(statusStr)
-		batches = append(batches,batch)
-	}
-	return batches,nil
+	return scanBatches(rows)
 }
 
 func (r *PostgresBatchRepository) Delete (ctx context.Context,id uuid.UUID) error {
@@ -115,63 +244,43 @@ func (r *PostgresBatchRepository) Delete (ctx context.Context,id uuid.UUID) erro
 		DELETE  FROM batches
 		WHERE id = $1
 	`
-	result,err := r.db.ExecContext(ctx,query,id)
-	if err != nil {
-		return err
-	}
-	rowAffected, err := result.RowsAffected()
-	if err != nil {
+	return withFencing(ctx, r.db, func(db execer) error {
+		result,err := db.ExecContext(ctx,query,id)
+		if err != nil {
+			return err
+		}
+		rowAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowAffected == 0  {
+			return errors.New("batch not found")
+		}
+		// 清理该 Batch 锁的 fencing 记录
+		_, err = db.ExecContext(ctx, `DELETE FROM fencing_tokens WHERE resource = $1`, domain.BatchLockResource(id))
 		return err
-	}
-	if rowAffected == 0  {
-		return errors.New("batch not found")
-	}
-	return nil
+	})
 }
 
-// FindStuckBatches 查询状态卡住的批次（用于补偿任务）
-// - scattering 状态超过 5 分钟未更新
-// - diagnosing 状态超过 10 分钟未更新
-func (r *PostgresBatchRepository) FindStuckBatches(ctx context.Context) ([]*domain.Batch, error) {
-	query := `
-		SELECT id, vehicle_id, vin, status, upload_time,
-			   total_files, processed_files, expected_worker_count,
-			   completed_worker_count, minio_bucket, minio_prefix,
-			   error_message, completed_at, created_at, updated_at
+// FindStuckBatches 查询可能卡住的批次（用于补偿任务）
+// 只做粗筛：所有非终态且 updated_at 早于 olderThan，分阶段超时由 domain.SLAPolicy 判断
+func (r *PostgresBatchRepository) FindStuckBatches(ctx context.Context, olderThan time.Duration) ([]*domain.Batch, error) {
+	statuses := make([]string, 0, len(domain.NonTerminalBatchStatuses()))
+	for _, status := range domain.NonTerminalBatchStatuses() {
+		statuses = append(statuses, status.String())
+	}
+
+	query := `SELECT` + batchColumns + `
 		FROM batches
-		WHERE (
-			(status = 'scattering' AND updated_at < NOW() - INTERVAL '5 minutes')
-			OR
-			(status = 'diagnosing' AND updated_at < NOW() - INTERVAL '10 minutes')
-		)
+		WHERE status = ANY($1)
+		  AND updated_at < NOW() - make_interval(secs => $2)
 		ORDER BY updated_at ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(statuses), olderThan.Seconds())
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var batches []*domain.Batch
-	for rows.Next() {
-		batch := &domain.Batch{}
-		var statusStr string
-
-		err := rows.Scan(
-			&batch.ID, &batch.VehicleID, &batch.VIN, &statusStr, &batch.UploadTime,
-			&batch.TotalFiles, &batch.ProcessedFiles, &batch.ExpectedWorkerCount,
-			&batch.CompletedWorkerCount, &batch.MinIOBucket, &batch.MiniIOPrefix,
-			&batch.ErrorMessage, &batch.CompletedAt, &batch.CreatedAt, &batch.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		batch.Status = domain.BatchStatus(statusStr)
-		batches = append(batches, batch)
-	}
-
-	return batches, nil
+	return scanBatches(rows)
 }
 
 // ============================================================================
@@ -191,8 +300,8 @@ func (r *PostgresFileRepository) Save(ctx context.Context, file *domain.File) er
 		INSERT INTO files (
 			id, batch_id, filename, original_filename, file_size, file_type,
 			upload_time, minio_path, minio_etag, processing_status,
-			parse_duration_ms, record_count, error_message, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			parse_duration_ms, record_count, error_message, created_at, updated_at, tenant_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		ON CONFLICT (id) DO UPDATE SET
 			processing_status = EXCLUDED.processing_status,
 			parse_duration_ms = EXCLUDED.parse_duration_ms,
@@ -200,10 +309,10 @@ func (r *PostgresFileRepository) Save(ctx context.Context, file *domain.File) er
 			error_message = EXCLUDED.error_message,
 			updated_at = EXCLUDED.updated_at
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		file.ID, file.BatchID, file.Filename, file.OriginalFilename, file.FileSize, file.FileType,
 		file.UploadTime, file.MinIOPath, file.MinIOETag, file.ProcessingStatus.String(),
-		file.ParseDurationMs, file.RecordCount, file.ErrorMessage, file.CreatedAt, file.UpdatedAt,
+		file.ParseDurationMs, file.RecordCount, file.ErrorMessage, file.CreatedAt, file.UpdatedAt, tenantOrDefault(file.TenantID),
 	)
 	if err != nil {
 		return err
@@ -215,16 +324,16 @@ func (r *PostgresFileRepository) FindByID(ctx context.Context, id uuid.UUID) (*d
 	query := `
 		SELECT id, batch_id, filename, original_filename, file_size, file_type,
 			   upload_time, minio_path, minio_etag, processing_status,
-			   parse_duration_ms, record_count, error_message, created_at, updated_at
+			   parse_duration_ms, record_count, error_message, created_at, updated_at, tenant_id
 		FROM files
 		WHERE id = $1
 	`
 	var file domain.File
 	var statusStr string
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&file.ID, &file.BatchID, &file.Filename, &file.OriginalFilename, &file.FileSize, &file.FileType,
 		&file.UploadTime, &file.MinIOPath, &file.MinIOETag, &statusStr,
-		&file.ParseDurationMs, &file.RecordCount, &file.ErrorMessage, &file.CreatedAt, &file.UpdatedAt,
+		&file.ParseDurationMs, &file.RecordCount, &file.ErrorMessage, &file.CreatedAt, &file.UpdatedAt, &file.TenantID,
 	)
 	file.ProcessingStatus = domain.ProcessingStatus(statusStr)
 	if err == sql.ErrNoRows {
@@ -240,12 +349,12 @@ func (r *PostgresFileRepository) FindByBatchID(ctx context.Context, batchID uuid
 	query := `
 		SELECT id, batch_id, filename, original_filename, file_size, file_type,
 			   upload_time, minio_path, minio_etag, processing_status,
-			   parse_duration_ms, record_count, error_message, created_at, updated_at
+			   parse_duration_ms, record_count, error_message, created_at, updated_at, tenant_id
 		FROM files
 		WHERE batch_id = $1
 		ORDER BY upload_time DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, batchID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, batchID)
 	if err != nil {
 		return nil, err
 	}
@@ -259,7 +368,7 @@ func (r *PostgresFileRepository) FindByBatchID(ctx context.Context, batchID uuid
 		err := rows.Scan(
 			&file.ID, &file.BatchID, &file.Filename, &file.OriginalFilename, &file.FileSize, &file.FileType,
 			&file.UploadTime, &file.MinIOPath, &file.MinIOETag, &statusStr,
-			&file.ParseDurationMs, &file.RecordCount, &file.ErrorMessage, &file.CreatedAt, &file.UpdatedAt,
+			&file.ParseDurationMs, &file.RecordCount, &file.ErrorMessage, &file.CreatedAt, &file.UpdatedAt, &file.TenantID,
 		)
 		if err != nil {
 			return nil, err
@@ -277,7 +386,7 @@ func (r *PostgresFileRepository) UpdateProcessingStatus(ctx context.Context, id
 		SET processing_status = $1, updated_at = NOW()
 		WHERE id = $2
 	`
-	result, err := r.db.ExecContext(ctx, query, status.String(), id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, status.String(), id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// PostgresRetentionRepository 生命周期进度与墓碑
+type PostgresRetentionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRetentionRepository(db *sql.DB) domain.RetentionRepository {
+	return &PostgresRetentionRepository{db: db}
+}
+
+// extraScanner 在 Batch 列之后追加扫描额外的列（复用 scanBatch）
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// FindDue 首次评估（没有 retention_state）或 next_action_at 已到期的终态 Batch
+//
+// 生效中的法务保留在 SQL 中直接排除，被保留的 Batch 不会每轮都被取出来占用 limit
+func (r *PostgresRetentionRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*domain.RetentionCandidate, error) {
+	query := `SELECT` + batchColumns + `, raw_purged_at, derived_purged_at, next_action_at
+		FROM (
+			SELECT b.*, rs.batch_id AS rs_batch_id,
+				rs.raw_purged_at, rs.derived_purged_at, rs.next_action_at
+			FROM batches b
+			LEFT JOIN retention_state rs ON rs.batch_id = b.id
+			WHERE b.status IN ($1, $2)
+		) c
+		WHERE (rs_batch_id IS NULL OR next_action_at <= $3)
+		  AND NOT EXISTS (
+			SELECT 1 FROM legal_holds h
+			WHERE h.released_at IS NULL
+			  AND ((h.scope = 'batch' AND h.value = c.id::text)
+			    OR (h.scope = 'vin' AND h.value = UPPER(c.vin)))
+		  )
+		ORDER BY COALESCE(completed_at, updated_at)
+		LIMIT $4
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query,
+		domain.BatchStatusCompleted.String(), domain.BatchStatusFailed.String(), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*domain.RetentionCandidate
+	for rows.Next() {
+		var state domain.RetentionState
+		batch, err := scanBatch(extraScanner{row: rows, extra: []interface{}{
+			&state.RawPurgedAt, &state.DerivedPurgedAt, &state.NextActionAt,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		state.BatchID = batch.ID
+		candidates = append(candidates, &domain.RetentionCandidate{Batch: batch, State: state})
+	}
+	return candidates, rows.Err()
+}
+
+// SaveState 生命周期任务由 Leader 执行，写入经过 fencing 校验
+func (r *PostgresRetentionRepository) SaveState(ctx context.Context, state *domain.RetentionState) error {
+	query := `
+		INSERT INTO retention_state (batch_id, raw_purged_at, derived_purged_at, next_action_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (batch_id) DO UPDATE SET
+			raw_purged_at = EXCLUDED.raw_purged_at,
+			derived_purged_at = EXCLUDED.derived_purged_at,
+			next_action_at = EXCLUDED.next_action_at,
+			updated_at = NOW()
+	`
+	return withFencing(ctx, r.db, func(db execer) error {
+		_, err := db.ExecContext(ctx, query, state.BatchID, state.RawPurgedAt, state.DerivedPurgedAt, state.NextActionAt)
+		return err
+	})
+}
+
+// SaveTombstone 重复删除（上一轮删除中途失败）时覆盖墓碑
+func (r *PostgresRetentionRepository) SaveTombstone(ctx context.Context, t *domain.BatchTombstone) error {
+	query := `
+		INSERT INTO batch_tombstones (
+			batch_id, vehicle_id, vin, vehicle_platform, status, total_files,
+			objects_deleted, reason, batch_created_at, deleted_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (batch_id) DO UPDATE SET
+			objects_deleted = batch_tombstones.objects_deleted + EXCLUDED.objects_deleted,
+			reason = EXCLUDED.reason,
+			deleted_at = EXCLUDED.deleted_at
+	`
+	return withFencing(ctx, r.db, func(db execer) error {
+		_, err := db.ExecContext(ctx, query,
+			t.BatchID, t.VehicleID, t.VIN, t.VehiclePlatform, t.Status.String(), t.TotalFiles,
+			t.ObjectsDeleted, t.Reason, t.BatchCreatedAt, t.DeletedAt)
+		return err
+	})
+}
+
+func (r *PostgresRetentionRepository) FindTombstone(ctx context.Context, batchID uuid.UUID) (*domain.BatchTombstone, error) {
+	query := `
+		SELECT batch_id, vehicle_id, vin, COALESCE(vehicle_platform, ''), status, total_files,
+			objects_deleted, reason, batch_created_at, deleted_at
+		FROM batch_tombstones
+		WHERE batch_id = $1
+	`
+	var t domain.BatchTombstone
+	var status string
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, batchID).Scan(
+		&t.BatchID, &t.VehicleID, &t.VIN, &t.VehiclePlatform, &status, &t.TotalFiles,
+		&t.ObjectsDeleted, &t.Reason, &t.BatchCreatedAt, &t.DeletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Status = domain.BatchStatus(status)
+	return &t, nil
+}
+
+// PostgresLegalHoldRepository 法务保留记录
+type PostgresLegalHoldRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLegalHoldRepository(db *sql.DB) domain.LegalHoldRepository {
+	return &PostgresLegalHoldRepository{db: db}
+}
+
+const legalHoldColumns = `id, scope, value, reason, created_by, created_at, released_at`
+
+func scanLegalHold(row rowScanner) (*domain.LegalHold, error) {
+	var h domain.LegalHold
+	var scope string
+	if err := row.Scan(&h.ID, &scope, &h.Value, &h.Reason, &h.CreatedBy, &h.CreatedAt, &h.ReleasedAt); err != nil {
+		return nil, err
+	}
+	h.Scope = domain.LegalHoldScope(scope)
+	return &h, nil
+}
+
+func (r *PostgresLegalHoldRepository) Save(ctx context.Context, h *domain.LegalHold) error {
+	query := `
+		INSERT INTO legal_holds (` + legalHoldColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			released_at = EXCLUDED.released_at
+	`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		h.ID, string(h.Scope), h.Value, h.Reason, h.CreatedBy, h.CreatedAt, h.ReleasedAt)
+	return err
+}
+
+func (r *PostgresLegalHoldRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.LegalHold, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+legalHoldColumns+` FROM legal_holds WHERE id = $1`, id)
+	hold, err := scanLegalHold(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return hold, err
+}
+
+func (r *PostgresLegalHoldRepository) ListActive(ctx context.Context) ([]*domain.LegalHold, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		`SELECT `+legalHoldColumns+` FROM legal_holds WHERE released_at IS NULL ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []*domain.LegalHold
+	for rows.Next() {
+		hold, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+func (r *PostgresLegalHoldRepository) IsHeld(ctx context.Context, batchID uuid.UUID, vin string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM legal_holds
+			WHERE released_at IS NULL
+			  AND ((scope = 'batch' AND value = $1) OR (scope = 'vin' AND value = UPPER($2)))
+		)
+	`
+	var held bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, batchID.String(), vin).Scan(&held)
+	return held, err
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// tenantRole 受 RLS 约束的数据库角色（见 13-tenants.sql）
+const tenantRole = "argus_tenant"
+
+// tenantOrDefault 写入时没有租户的记录（旧客户端、内部任务）归属 default 租户
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return domain.DefaultTenantID
+	}
+	return tenantID
+}
+
+// contextTenant 调用方所属租户；内部调用（Worker、Leader 任务）没有租户，返回空串，查询不按租户过滤
+//
+// 查询服务的请求还在 RunAsTenant 事务内受 RLS 约束；显式条件让不走 RLS 的服务（Ingestor）同样只看到本租户的数据
+func contextTenant(ctx context.Context) string {
+	tenantID, _ := domain.TenantFromContext(ctx)
+	return tenantID
+}
+
+// PostgresTenantScoper 在事务内切换到 argus_tenant 角色并设置 app.tenant_id，RLS 策略据此过滤行
+//
+// 用 SET LOCAL / set_config(..., true)：只在当前事务内有效，连接归还连接池后不会带到下一个请求（可能是另一个租户）
+//
+// 服务自身的连接是表的 owner，不受 RLS 约束（Worker、Orchestrator 需要跨租户处理）；
+// 只有经过 RunAsTenant 的请求才被限制在一个租户内
+type PostgresTenantScoper struct {
+	db *sql.DB
+}
+
+func NewPostgresTenantScoper(db *sql.DB) domain.TenantScoper {
+	return &PostgresTenantScoper{db: db}
+}
+
+func (s *PostgresTenantScoper) RunAsTenant(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tenant transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+tenantRole); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("switch to tenant role: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("set tenant: %w", err)
+	}
+
+	if err := fn(contextWithTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("[Tenant] Failed to rollback tenant transaction: %v", rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// PostgresTenantUsageRepository 存储总量统计
+type PostgresTenantUsageRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTenantUsageRepository(db *sql.DB) domain.TenantUsageRepository {
+	return &PostgresTenantUsageRepository{db: db}
+}
+
+func (r *PostgresTenantUsageRepository) StorageBytes(ctx context.Context, tenantID string) (int64, error) {
+	var total int64
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(file_size), 0) FROM files WHERE tenant_id = $1`,
+		tenantID).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier 兼容 *sql.DB 和 *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// contextWithTx 将事务附加到 context，锁内的 Repository 调用会复用该事务
+func contextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func txFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// inTx 在 context 中已有的事务（租户事务、行锁事务）内执行 fn，没有时开启新事务，fn 成功后提交
+func inTx(ctx context.Context, db *sql.DB, fn func(q querier) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn 优先使用 context 中的事务（如行锁降级时持有的 SELECT ... FOR UPDATE 事务），否则使用连接池
+//
+// 注意：持有行锁的事务之外的连接再去写同一行（或插入引用该行的外键）会被阻塞，
+// 所以锁内的所有 Repository 调用都必须经过 conn()
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
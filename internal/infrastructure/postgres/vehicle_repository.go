@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+type PostgresVehicleRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresVehicleRepository(db *sql.DB) domain.VehicleRepository {
+	return &PostgresVehicleRepository{db: db}
+}
+
+// vehicleColumns vehicles 表的查询列（与 scanVehicle 的顺序保持一致）
+const vehicleColumns = `
+	vin, vehicle_id, platform, model, model_year, manufacturer,
+	firmware_version, ecus, owner_fleet, status, decommissioned_at,
+	created_at, updated_at, tenant_id`
+
+func scanVehicle(row rowScanner) (*domain.Vehicle, error) {
+	v := &domain.Vehicle{}
+	var status string
+	var ecus []byte
+	err := row.Scan(
+		&v.VIN, &v.VehicleID, &v.Platform, &v.Model, &v.ModelYear, &v.Manufacturer,
+		&v.FirmwareVersion, &ecus, &v.OwnerFleet, &status, &v.DecommissionedAt,
+		&v.CreatedAt, &v.UpdatedAt, &v.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	v.Status = domain.VehicleStatus(status)
+	if err := json.Unmarshal(ecus, &v.ECUs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ecus: %w", err)
+	}
+	return v, nil
+}
+
+// Save VIN 是主键，重复注册时更新（vehicle_id 不允许修改）；VIN 已被其他租户注册时返回 ErrVehicleExists
+func (r *PostgresVehicleRepository) Save(ctx context.Context, v *domain.Vehicle) error {
+	ecus := v.ECUs
+	if ecus == nil {
+		ecus = []domain.ECU{}
+	}
+	ecusJSON, err := json.Marshal(ecus)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ecus: %w", err)
+	}
+
+	query := `
+		INSERT INTO vehicles (` + vehicleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (vin) DO UPDATE SET
+			platform = EXCLUDED.platform,
+			model = EXCLUDED.model,
+			model_year = EXCLUDED.model_year,
+			firmware_version = EXCLUDED.firmware_version,
+			ecus = EXCLUDED.ecus,
+			owner_fleet = EXCLUDED.owner_fleet,
+			status = EXCLUDED.status,
+			decommissioned_at = EXCLUDED.decommissioned_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE vehicles.tenant_id = EXCLUDED.tenant_id
+	`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
+		v.VIN, v.VehicleID, v.Platform, v.Model, v.ModelYear, v.Manufacturer,
+		v.FirmwareVersion, ecusJSON, v.OwnerFleet, string(v.Status), v.DecommissionedAt,
+		v.CreatedAt, v.UpdatedAt, tenantOrDefault(v.TenantID))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrVehicleExists, v.VIN)
+	}
+	return nil
+}
+
+func (r *PostgresVehicleRepository) FindByVIN(ctx context.Context, vin string) (*domain.Vehicle, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT `+vehicleColumns+` FROM vehicles WHERE vin = $1 AND ($2::text = '' OR tenant_id = $2)`,
+		domain.NormalizeVIN(vin), contextTenant(ctx))
+	v, err := scanVehicle(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (r *PostgresVehicleRepository) List(ctx context.Context, opts domain.VehicleListOptions) ([]*domain.Vehicle, error) {
+	var where []string
+	var args []interface{}
+	filter := func(column string, value *string) {
+		if value == nil || *value == "" {
+			return
+		}
+		args = append(args, *value)
+		where = append(where, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	filter("platform", opts.Platform)
+	filter("owner_fleet", opts.OwnerFleet)
+	filter("status", opts.Status)
+	tenantID := contextTenant(ctx)
+	filter("tenant_id", &tenantID)
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT ` + vehicleColumns + ` FROM vehicles`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY vin LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vehicles []*domain.Vehicle
+	for rows.Next() {
+		v, err := scanVehicle(rows)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, v)
+	}
+	return vehicles, rows.Err()
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// BatchLocker 基于 Redis SET NX PX 的 Batch 互斥锁
+// - 锁值为随机 owner，释放时比较 owner（不会误删别人的锁）
+// - 每次加锁从 tokens 分配 fencing token，写库时校验（锁过期后旧持有者的写入会被拒绝）
+// - token 由数据库分配而不是 Redis INCR：Redis 被清空后计数器从头开始，会小于数据库已记录的值
+type BatchLocker struct {
+	redis  *RedisClient
+	tokens domain.FencingTokenIssuer
+	ttl    time.Duration // 锁的最长持有时间
+	wait   time.Duration // 最长等待时间
+
+	acquired  atomic.Int64
+	contended atomic.Int64
+	timeouts  atomic.Int64
+	waitNanos atomic.Int64
+}
+
+func NewBatchLocker(client *RedisClient, tokens domain.FencingTokenIssuer, ttl, wait time.Duration) *BatchLocker {
+	return &BatchLocker{
+		redis:  client,
+		tokens: tokens,
+		ttl:    ttl,
+		wait:   wait,
+	}
+}
+
+func lockKey(batchID uuid.UUID) string {
+	return fmt.Sprintf("lock:batch:%s", batchID)
+}
+
+// LockBatch 获取锁，等待超过 wait 返回 domain.ErrLockTimeout；Redis 出错返回 domain.ErrLockUnavailable
+func (l *BatchLocker) LockBatch(ctx context.Context, batchID uuid.UUID) (context.Context, domain.BatchLock, error) {
+	owner := uuid.NewString()
+	start := time.Now()
+	deadline := start.Add(l.wait)
+	backoff := 10 * time.Millisecond
+	contended := false
+
+	for {
+		ok, err := l.redis.TryAcquireLease(ctx, lockKey(batchID), owner, l.ttl)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("%w: %v", domain.ErrLockUnavailable, err)
+		}
+		if ok {
+			break
+		}
+
+		if !contended {
+			contended = true
+			l.contended.Add(1)
+		}
+		if time.Now().After(deadline) {
+			l.timeouts.Add(1)
+			l.waitNanos.Add(int64(time.Since(start)))
+			return ctx, nil, fmt.Errorf("%w: batch=%s waited %s", domain.ErrLockTimeout, batchID, l.wait)
+		}
+
+		// 指数退避 + 抖动，避免多个等待者同时重试
+		sleep := backoff + time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return ctx, nil, ctx.Err()
+		case <-time.After(sleep):
+		}
+		if backoff < 200*time.Millisecond {
+			backoff *= 2
+		}
+	}
+
+	token, err := l.tokens.NextFencingToken(ctx, domain.BatchLockResource(batchID))
+	if err != nil {
+		l.redis.ReleaseLease(context.Background(), lockKey(batchID), owner)
+		return ctx, nil, fmt.Errorf("%w: fencing token: %v", domain.ErrLockUnavailable, err)
+	}
+
+	l.acquired.Add(1)
+	l.waitNanos.Add(int64(time.Since(start)))
+	if contended {
+		log.Printf("[Lock] Batch %s acquired after %s (contended)", batchID, time.Since(start))
+	}
+
+	lockCtx := domain.WithFencingToken(ctx, domain.BatchLockResource(batchID), token)
+	return lockCtx, &redisBatchLock{locker: l, batchID: batchID, owner: owner, token: token}, nil
+}
+
+// Stats 锁竞争统计
+func (l *BatchLocker) Stats() domain.BatchLockStats {
+	return domain.BatchLockStats{
+		Acquired:  l.acquired.Load(),
+		Contended: l.contended.Load(),
+		Timeouts:  l.timeouts.Load(),
+		TotalWait: time.Duration(l.waitNanos.Load()),
+	}
+}
+
+type redisBatchLock struct {
+	locker  *BatchLocker
+	batchID uuid.UUID
+	owner   string
+	token   int64
+}
+
+func (l *redisBatchLock) FencingToken() int64 {
+	return l.token
+}
+
+func (l *redisBatchLock) Release(ctx context.Context, opErr error) error {
+	return l.locker.redis.ReleaseLease(ctx, lockKey(l.batchID), l.owner)
+}
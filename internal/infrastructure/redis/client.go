@@ -3,12 +3,17 @@ package redis
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/logging"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 )
 
+// logger 命令日志只在 debug 级别输出（LOG_LEVELS=redis=debug），只记录 Key 与计数，不记录读写的值：
+// 报告缓存、配额计数中有车辆数据，逐条打印既泄露数据又淹没其他日志
+var logger = logging.Component("redis")
+
 // RedisClient 封装 Redis 客户端，提供分布式计数和缓存功能
 type RedisClient struct {
 	client *redis.Client
@@ -31,8 +36,9 @@ func NewRedisClient(ctx context.Context, addr string, password string, db int) (
 	if err != nil {
 		return nil, fmt.Errorf("redis ping failed: %w", err)
 	}
+	client.AddHook(tracing.RedisHook{})
 
-	log.Printf("[Redis] Connected to %s (DB: %d)", addr, db)
+	logger.Info("connected", "addr", addr, "db", db)
 	return &RedisClient{client: client}, nil
 }
 
@@ -43,7 +49,7 @@ func (r *RedisClient) INCR(ctx context.Context, key string) (int64, error) {
 		return 0, fmt.Errorf("redis incr failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] INCR: %s -> %d", key, result)
+	logger.DebugContext(ctx, "INCR", "key", key, "result", result)
 	return result, nil
 }
 
@@ -58,7 +64,7 @@ func (r *RedisClient) GET(ctx context.Context, key string) (string, error) {
 		return "", fmt.Errorf("redis get failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] GET: %s -> %s", key, value)
+	logger.DebugContext(ctx, "GET", "key", key, "bytes", len(value))
 	return value, nil
 }
 
@@ -69,7 +75,7 @@ func (r *RedisClient) DEL(ctx context.Context, key string) error {
 		return fmt.Errorf("redis del failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] DEL: %s", key)
+	logger.DebugContext(ctx, "DEL", "key", key)
 	return nil
 }
 
@@ -80,7 +86,15 @@ func (r *RedisClient) SET(ctx context.Context, key string, value interface{}, ex
 		return fmt.Errorf("redis set failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] SET: %s -> %v (TTL: %s)", key, value, expiration)
+	logger.DebugContext(ctx, "SET", "key", key, "ttl", expiration)
+	return nil
+}
+
+// Ping 健康检查（PING）
+func (r *RedisClient) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
 	return nil
 }
 
@@ -91,7 +105,7 @@ func (r *RedisClient) Close() error {
 		return fmt.Errorf("redis close failed: %w", err)
 	}
 
-	log.Println("[Redis] Connection closed")
+	logger.Info("connection closed")
 	return nil
 }
 func (r *RedisClient) SADD(ctx context.Context, key string, members ...interface{}) (int64, error) {
@@ -100,7 +114,7 @@ func (r *RedisClient) SADD(ctx context.Context, key string, members ...interface
 		return 0, fmt.Errorf("redis sadd failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] SADD: %s -> %d members added", key, result)
+	logger.DebugContext(ctx, "SADD", "key", key, "added", result)
 	return result, nil
 }
 
@@ -111,7 +125,7 @@ func (r *RedisClient) SCARD(ctx context.Context, key string) (int64, error) {
 		return 0, fmt.Errorf("redis scard failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] SCARD: %s -> %d", key, result)
+	logger.DebugContext(ctx, "SCARD", "key", key, "result", result)
 	return result, nil
 }
 
@@ -131,7 +145,7 @@ func (r *RedisClient) SADDWithTTL(ctx context.Context, key string, ttl time.Dura
 		return fmt.Errorf("redis pipeline failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] SADD+EXPIRE: %s (TTL: %s)", key, ttl)
+	logger.DebugContext(ctx, "SADD+EXPIRE", "key", key, "ttl", ttl)
 	return nil
 }
 
@@ -142,6 +156,86 @@ func (r *RedisClient) EXPIRE(ctx context.Context, key string, expiration time.Du
 		return fmt.Errorf("redis expire failed: key=%s, error=%w", key, err)
 	}
 
-	log.Printf("[Redis] EXPIRE: %s -> %s", key, expiration)
+	logger.DebugContext(ctx, "EXPIRE", "key", key, "ttl", expiration)
 	return nil
 }
+
+// SMEMBERS 获取集合全部成员
+func (r *RedisClient) SMEMBERS(ctx context.Context, key string) ([]string, error) {
+	result, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis smembers failed: key=%s, error=%w", key, err)
+	}
+
+	logger.DebugContext(ctx, "SMEMBERS", "key", key, "members", len(result))
+	return result, nil
+}
+
+// HSET 设置 Hash 字段
+func (r *RedisClient) HSET(ctx context.Context, key string, field string, value interface{}) error {
+	err := r.client.HSet(ctx, key, field, value).Err()
+	if err != nil {
+		return fmt.Errorf("redis hset failed: key=%s, error=%w", key, err)
+	}
+
+	logger.DebugContext(ctx, "HSET", "key", key, "field", field)
+	return nil
+}
+
+// HGETALL 读取 Hash 全部字段
+func (r *RedisClient) HGETALL(ctx context.Context, key string) (map[string]string, error) {
+	result, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis hgetall failed: key=%s, error=%w", key, err)
+	}
+
+	logger.DebugContext(ctx, "HGETALL", "key", key, "fields", len(result))
+	return result, nil
+}
+
+// releaseLeaseScript 只有持有者才能释放租约（避免误删其他实例的租约）
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewLeaseScript 只有持有者才能续约
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// TryAcquireLease 尝试获取租约（SET NX PX），返回是否获取成功
+func (r *RedisClient) TryAcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis setnx failed: key=%s, error=%w", key, err)
+	}
+
+	logger.DebugContext(ctx, "LEASE acquire", "key", key, "owner", owner, "acquired", ok, "ttl", ttl)
+	return ok, nil
+}
+
+// ReleaseLease 释放租约（仅当 owner 匹配时删除）
+func (r *RedisClient) ReleaseLease(ctx context.Context, key string, owner string) error {
+	err := releaseLeaseScript.Run(ctx, r.client, []string{key}, owner).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("redis release lease failed: key=%s, error=%w", key, err)
+	}
+
+	logger.DebugContext(ctx, "LEASE release", "key", key, "owner", owner)
+	return nil
+}
+
+// RenewLease 续约（仅当 owner 匹配时延长 TTL），返回租约是否仍由 owner 持有
+func (r *RedisClient) RenewLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	result, err := renewLeaseScript.Run(ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("redis renew lease failed: key=%s, error=%w", key, err)
+	}
+	return result == 1, nil
+}
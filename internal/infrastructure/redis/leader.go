@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// LeaderCallbacks Leader 身份变化时的回调
+// - OnStartedLeading: 成为 Leader 后在独立 goroutine 中调用，ctx 在失去 Leader 时取消
+// - OnStoppedLeading: 失去 Leader（续约失败或退出）后调用
+type LeaderCallbacks struct {
+	OnStartedLeading func(ctx context.Context, fencingToken int64)
+	OnStoppedLeading func()
+}
+
+// LeaderStatus 当前选举状态（用于状态接口）
+type LeaderStatus struct {
+	Name         string `json:"name"`
+	Identity     string `json:"identity"`
+	IsLeader     bool   `json:"is_leader"`
+	LeaderID     string `json:"leader_id"`
+	FencingToken int64  `json:"fencing_token"`
+}
+
+// LeaderElector 基于 Redis 租约（SET NX PX + 续约）的 Leader 选举
+//
+// 续约失败立即放弃 Leader，不等租约过期：无法区分"Redis 不可达"和"租约已被别人拿走"，
+// 宁可短暂无 Leader；即使出现短暂重叠，写入也会被 fencing token 拦截
+//
+// fencing token 由 tokens 分配（与 Leader 任务写入时的校验同一处），Redis 只负责租约
+type LeaderElector struct {
+	redis     *RedisClient
+	tokens    domain.FencingTokenIssuer
+	name      string
+	identity  string
+	ttl       time.Duration
+	callbacks LeaderCallbacks
+
+	mu       sync.RWMutex
+	isLeader bool
+	token    int64
+}
+
+// NewLeaderElector 创建选举器；name 区分不同的选举（如 "orchestrator"），identity 标识当前副本
+func NewLeaderElector(client *RedisClient, tokens domain.FencingTokenIssuer, name, identity string, ttl time.Duration, callbacks LeaderCallbacks) *LeaderElector {
+	return &LeaderElector{
+		redis:     client,
+		tokens:    tokens,
+		name:      name,
+		identity:  identity,
+		ttl:       ttl,
+		callbacks: callbacks,
+	}
+}
+
+// FencingResource fencing token 对应的资源名，Leader 任务写库时使用
+func (e *LeaderElector) FencingResource() string {
+	return "leader:" + e.name
+}
+
+func (e *LeaderElector) leaseKey() string {
+	return fmt.Sprintf("leader:%s:lease", e.name)
+}
+
+// Run 参与选举，阻塞直到 ctx 取消；退出时主动释放租约
+func (e *LeaderElector) Run(ctx context.Context) {
+	retryPeriod := e.ttl / 3
+	for {
+		acquired, err := e.redis.TryAcquireLease(ctx, e.leaseKey(), e.identity, e.ttl)
+		if err != nil {
+			log.Printf("[Leader] %s: failed to acquire lease: %v", e.name, err)
+		} else if acquired {
+			token, err := e.tokens.NextFencingToken(ctx, e.FencingResource())
+			if err != nil {
+				log.Printf("[Leader] %s: failed to issue fencing token: %v", e.name, err)
+				e.redis.ReleaseLease(context.Background(), e.leaseKey(), e.identity)
+			} else {
+				e.lead(ctx, token, retryPeriod)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// lead 持有 Leader 期间定期续约，续约失败或 ctx 取消时退出
+func (e *LeaderElector) lead(ctx context.Context, token int64, renewPeriod time.Duration) {
+	log.Printf("[Leader] %s: %s became leader (fencing token %d)", e.name, e.identity, token)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	e.setLeader(true, token)
+	if e.callbacks.OnStartedLeading != nil {
+		go e.callbacks.OnStartedLeading(leaderCtx, token)
+	}
+
+	ticker := time.NewTicker(renewPeriod)
+	defer ticker.Stop()
+renew:
+	for {
+		select {
+		case <-ctx.Done():
+			break renew
+		case <-ticker.C:
+			held, err := e.redis.RenewLease(ctx, e.leaseKey(), e.identity, e.ttl)
+			if err != nil || !held {
+				log.Printf("[Leader] %s: lost lease (held=%t, err=%v)", e.name, held, err)
+				break renew
+			}
+		}
+	}
+
+	cancel()
+	e.setLeader(false, 0)
+	if e.callbacks.OnStoppedLeading != nil {
+		e.callbacks.OnStoppedLeading()
+	}
+
+	// 用独立 context 释放，保证 ctx 取消（优雅关闭）时也能让出 Leader
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer releaseCancel()
+	if err := e.redis.ReleaseLease(releaseCtx, e.leaseKey(), e.identity); err != nil {
+		log.Printf("[Leader] %s: failed to release lease: %v", e.name, err)
+	}
+	log.Printf("[Leader] %s: %s stepped down", e.name, e.identity)
+}
+
+func (e *LeaderElector) setLeader(isLeader bool, token int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.isLeader = isLeader
+	e.token = token
+}
+
+// IsLeader 当前副本是否为 Leader
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// Status 查询当前 Leader（任意副本都能返回集群视角的 Leader）
+func (e *LeaderElector) Status(ctx context.Context) (LeaderStatus, error) {
+	e.mu.RLock()
+	status := LeaderStatus{
+		Name:         e.name,
+		Identity:     e.identity,
+		IsLeader:     e.isLeader,
+		FencingToken: e.token,
+	}
+	e.mu.RUnlock()
+
+	leaderID, err := e.redis.GET(ctx, e.leaseKey())
+	if err != nil {
+		return status, err
+	}
+	status.LeaderID = leaderID
+
+	if !status.IsLeader && leaderID != "" {
+		// 最新发出的 token 属于当前 Leader
+		token, err := e.tokens.CurrentFencingToken(ctx, e.FencingResource())
+		if err != nil {
+			return status, err
+		}
+		status.FencingToken = token
+	}
+	return status, nil
+}
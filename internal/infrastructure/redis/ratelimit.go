@@ -0,0 +1,182 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// tokenBucketScript 多个令牌桶一起扣减：全部足够才扣，否则返回需要等待的毫秒数
+//
+// KEYS: 桶；ARGV[1]: cost；ARGV[2i], ARGV[2i+1]: 第 i 个桶每毫秒补充的令牌数与容量
+// 桶状态为 Hash {tokens, ts}，时间取 Redis 服务端 TIME（各 Ingestor 实例时钟不一致也不影响）
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local cost = tonumber(ARGV[1])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local tokens = {}
+local wait = 0
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local cap = tonumber(ARGV[2 * i + 1])
+	local state = redis.call("HMGET", key, "tokens", "ts")
+	local tk = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if tk == nil or ts == nil then
+		tk = cap
+		ts = now
+	end
+	tk = math.min(cap, tk + math.max(0, now - ts) * rate)
+	tokens[i] = tk
+	if tk < cost then
+		local w = math.ceil((cost - tk) / rate)
+		if w > wait then
+			wait = w
+		end
+	end
+end
+if wait > 0 then
+	return wait
+end
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local cap = tonumber(ARGV[2 * i + 1])
+	redis.call("HSET", key, "tokens", tostring(tokens[i] - cost), "ts", now)
+	redis.call("PEXPIRE", key, math.ceil(cap / rate) + 1000)
+end
+return 0
+`)
+
+// RedisRateLimiter 基于 Redis Lua 脚本的令牌桶
+type RedisRateLimiter struct {
+	redis *RedisClient
+}
+
+func NewRateLimiter(client *RedisClient) *RedisRateLimiter {
+	return &RedisRateLimiter{redis: client}
+}
+
+// Take 不限流的规则直接跳过
+//
+// 所有桶在同一个脚本里判断和扣减，要么都扣要么都不扣：先扣车辆桶、租户桶再拒绝，车辆会被白白扣掉令牌
+func (l *RedisRateLimiter) Take(ctx context.Context, rules []domain.RateLimitRule, cost int) (time.Duration, error) {
+	keys := make([]string, 0, len(rules))
+	args := []interface{}{cost}
+	for _, rule := range rules {
+		if rule.Bucket.Unlimited() {
+			continue
+		}
+		keys = append(keys, rule.Key)
+		args = append(args, rule.Bucket.PerMinute/float64(time.Minute/time.Millisecond), rule.Bucket.Capacity())
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	waitMs, err := tokenBucketScript.Run(ctx, l.redis.client, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis rate limit failed: keys=%v, error=%w", keys, err)
+	}
+	return time.Duration(waitMs) * time.Millisecond, nil
+}
+
+// usageLedgerScript 多个每日计数一起记账：任一超限则都不记，返回超限计数的下标（从 1 开始）
+//
+// KEYS: 计数 Hash {batches, bytes}；ARGV[1..3]: batches、bytes、TTL 秒；ARGV[2i+2], ARGV[2i+3]: 第 i 个计数的上限
+var usageLedgerScript = redis.NewScript(`
+local batches = tonumber(ARGV[1])
+local bytes = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+for i, key in ipairs(KEYS) do
+	local maxBatches = tonumber(ARGV[2 * i + 2])
+	local maxBytes = tonumber(ARGV[2 * i + 3])
+	local state = redis.call("HMGET", key, "batches", "bytes")
+	local usedBatches = tonumber(state[1]) or 0
+	local usedBytes = tonumber(state[2]) or 0
+	if batches > 0 and maxBatches > 0 and usedBatches + batches > maxBatches then
+		return i
+	end
+	if bytes > 0 and maxBytes > 0 and usedBytes + bytes > maxBytes then
+		return i
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call("HINCRBY", key, "batches", batches)
+	redis.call("HINCRBY", key, "bytes", bytes)
+	redis.call("EXPIRE", key, ttl)
+end
+return 0
+`)
+
+// usageRefundScript 退回用量；Key 已过期（跨天）时不重新创建，计数不低于 0
+//
+// KEYS: 计数 Hash {batches, bytes}；ARGV[1..2]: batches、bytes
+var usageRefundScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		for i, field in ipairs({"batches", "bytes"}) do
+			local left = redis.call("HINCRBY", key, field, -tonumber(ARGV[i]))
+			if left < 0 then
+				redis.call("HSET", key, field, 0)
+			end
+		end
+	end
+end
+return 0
+`)
+
+// RedisUsageLedger 每日用量计数（Key 中带日期，过期后自动清理）
+type RedisUsageLedger struct {
+	redis *RedisClient
+}
+
+func NewUsageLedger(client *RedisClient) *RedisUsageLedger {
+	return &RedisUsageLedger{redis: client}
+}
+
+func (l *RedisUsageLedger) Consume(ctx context.Context, charges []domain.UsageCharge, batches, bytes int64, ttl time.Duration) (string, error) {
+	if len(charges) == 0 {
+		return "", nil
+	}
+	keys := make([]string, len(charges))
+	args := []interface{}{batches, bytes, int64(ttl / time.Second)}
+	for i, charge := range charges {
+		keys[i] = charge.Key
+		args = append(args, charge.Limits.MaxBatches, charge.Limits.MaxBytes)
+	}
+
+	exceeded, err := usageLedgerScript.Run(ctx, l.redis.client, keys, args...).Int64()
+	if err != nil {
+		return "", fmt.Errorf("redis usage ledger failed: keys=%v, error=%w", keys, err)
+	}
+	if exceeded > 0 {
+		return keys[exceeded-1], nil
+	}
+	return "", nil
+}
+
+func (l *RedisUsageLedger) Refund(ctx context.Context, keys []string, batches, bytes int64) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := usageRefundScript.Run(ctx, l.redis.client, keys, batches, bytes).Err(); err != nil {
+		return fmt.Errorf("redis usage refund failed: keys=%v, error=%w", keys, err)
+	}
+	return nil
+}
+
+func (l *RedisUsageLedger) Get(ctx context.Context, key string) (domain.DailyUsage, error) {
+	fields, err := l.redis.HGETALL(ctx, key)
+	if err != nil {
+		return domain.DailyUsage{}, err
+	}
+	var usage domain.DailyUsage
+	usage.Batches, _ = strconv.ParseInt(fields["batches"], 10, 64)
+	usage.Bytes, _ = strconv.ParseInt(fields["bytes"], 10, 64)
+	return usage, nil
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// Batch 相关的 Span 属性（不包含 VIN）
+var (
+	attrBatchID  = attribute.Key("argus.batch.id")
+	attrTenantID = attribute.Key("argus.tenant.id")
+)
+
+// Batch 级链路：Trace ID 就是 Batch ID，根 Span ID 由 Batch ID 派生
+//
+// 一个 Batch 跨越多次上传、多个 Worker 和数小时：任何服务不需要查询就能构造出父上下文，
+// 发往 Kafka 的阶段 Span 都挂在这个父 Span 下，Batch 进入终态时 Orchestrator 以创建时间为起点补发根 Span。
+// 在 Jaeger / Tempo 中用 Batch ID（去掉连字符）搜索 Trace ID 即可看到全部阶段
+func BatchTraceID(batchID uuid.UUID) trace.TraceID {
+	return trace.TraceID(batchID)
+}
+
+func batchSpanID(batchID uuid.UUID) trace.SpanID {
+	sum := sha256.Sum256(batchID[:])
+	var id trace.SpanID
+	copy(id[:], sum[:8])
+	return id
+}
+
+// BatchSpanContext Batch 根 Span 的上下文（远程、已采样）
+func BatchSpanContext(batchID uuid.UUID) trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    BatchTraceID(batchID),
+		SpanID:     batchSpanID(batchID),
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+// StartBatchSpan 在 Batch 链路下创建 Span；ctx 中原有的链路（HTTP 请求、上游消息）以 Link 保留
+func StartBatchSpan(ctx context.Context, batchID uuid.UUID, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	current := trace.SpanContextFromContext(ctx)
+	if current.IsValid() && current.TraceID() == BatchTraceID(batchID) {
+		return tracer().Start(ctx, name, opts...)
+	}
+	if current.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: current}))
+	}
+	opts = append(opts, trace.WithAttributes(attrBatchID.String(batchID.String())))
+	return tracer().Start(trace.ContextWithRemoteSpanContext(ctx, BatchSpanContext(batchID)), name, opts...)
+}
+
+// LinkBatch 当前 Span（如上传请求）关联到 Batch 链路
+func LinkBatch(ctx context.Context, batchID uuid.UUID) {
+	span := trace.SpanFromContext(ctx)
+	span.AddLink(trace.Link{SpanContext: BatchSpanContext(batchID)})
+	span.SetAttributes(attrBatchID.String(batchID.String()))
+}
+
+// batchRootKey 标记 ctx 正在创建 Batch 根 Span，idGenerator 据此返回确定性 ID
+type batchRootKey struct{}
+
+// RecordBatchSpan Batch 进入终态后补发根 Span（起点为创建时间），非终态直接返回
+//
+// 补偿任务重放事件时可能重复调用，导出的 Span ID 相同，后端按同一个 Span 处理
+func RecordBatchSpan(ctx context.Context, batch *domain.Batch) {
+	if !batch.Status.IsTerminal() {
+		return
+	}
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithTimestamp(batch.CreatedAt),
+		trace.WithAttributes(
+			attrBatchID.String(batch.ID.String()),
+			attrTenantID.String(batch.TenantID),
+			attribute.String("argus.batch.status", batch.Status.String()),
+			attribute.String("argus.batch.priority", batch.Priority.String()),
+			attribute.Int("argus.batch.total_files", batch.TotalFiles),
+		),
+	}
+	if current := trace.SpanContextFromContext(ctx); current.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: current}))
+	}
+	_, span := tracer().Start(context.WithValue(ctx, batchRootKey{}, batch.ID), "batch", opts...)
+	if batch.Status == domain.BatchStatusFailed {
+		span.SetStatus(codes.Error, batch.ErrorMessage)
+	}
+	span.End()
+}
+
+// idGenerator 默认随机生成；创建 Batch 根 Span 时返回由 Batch ID 派生的 ID
+type idGenerator struct{}
+
+func (idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if batchID, ok := ctx.Value(batchRootKey{}).(uuid.UUID); ok {
+		return BatchTraceID(batchID), batchSpanID(batchID)
+	}
+	var traceID trace.TraceID
+	rand.Read(traceID[:])
+	return traceID, newSpanID()
+}
+
+func (idGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var id trace.SpanID
+	rand.Read(id[:])
+	return id
+}
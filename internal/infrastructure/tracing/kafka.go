@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// producerCarrier 把 traceparent / baggage 写进 Kafka 消息头
+type producerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set 覆盖同名消息头（重试时同一条消息会被再次注入）
+func (c producerCarrier) Set(key, value string) {
+	headers := c.msg.Headers[:0]
+	for _, h := range c.msg.Headers {
+		if string(h.Key) != key {
+			headers = append(headers, h)
+		}
+	}
+	c.msg.Headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerCarrier 只读
+type consumerCarrier []*sarama.RecordHeader
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
+
+// messageBatchID 业务消息都以 batch_id 为 Key
+func messageBatchID(key []byte) (uuid.UUID, bool) {
+	id, err := uuid.ParseBytes(key)
+	return id, err == nil
+}
+
+// StartProduce 发送前创建 Producer Span 并把上下文注入消息头
+//
+// Key 是 Batch ID 的消息挂到 Batch 链路下，发起方（上传请求、上游消息）以 Link 关联
+func StartProduce(ctx context.Context, msg *sarama.ProducerMessage) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(msg.Topic),
+		),
+	}
+	name := msg.Topic + " publish"
+
+	var span trace.Span
+	var key []byte
+	if msg.Key != nil {
+		key, _ = msg.Key.Encode()
+	}
+	if batchID, ok := messageBatchID(key); ok {
+		ctx, span = StartBatchSpan(ctx, batchID, name, opts...)
+	} else {
+		ctx, span = tracer().Start(ctx, name, opts...)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, producerCarrier{msg: msg})
+	return ctx, span
+}
+
+// EndProduce 记录写入位置后结束 Span
+func EndProduce(span trace.Span, partition int32, offset int64, err error) {
+	if err == nil {
+		span.SetAttributes(
+			semconv.MessagingDestinationPartitionID(itoa(partition)),
+			semconv.MessagingKafkaMessageOffset(int(offset)),
+		)
+	}
+	End(span, err)
+}
+
+// StartConsume 从消息头恢复上游链路并创建 Consumer Span
+//
+// 没有 traceparent 的消息（Python Worker、旧版本 Producer）按 Key 挂到 Batch 链路下
+func StartConsume(ctx context.Context, msg *sarama.ConsumerMessage, group string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingKafkaConsumerGroup(group),
+			semconv.MessagingDestinationPartitionID(itoa(msg.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		),
+	}
+	name := msg.Topic + " process"
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, consumerCarrier(msg.Headers))
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if batchID, ok := messageBatchID(msg.Key); ok {
+			return StartBatchSpan(ctx, batchID, name, opts...)
+		}
+	}
+	return tracer().Start(ctx, name, opts...)
+}
+
+func itoa(n int32) string {
+	return strconv.Itoa(int(n))
+}
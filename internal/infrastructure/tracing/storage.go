@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+var attrObjectKeys = attribute.Key("argus.storage.keys")
+
+// tracedStore 对象存储客户端 Span（包在信封加密内层，只统计访问存储服务本身的耗时）
+//
+// 对象 Key 按租户 / Batch ID 组织，不含 VIN，可以作为属性记录
+type tracedStore struct {
+	inner   domain.ObjectStore
+	backend string
+}
+
+// WrapObjectStore backend 为 Span 名前缀（如 minio）
+func WrapObjectStore(inner domain.ObjectStore, backend string) domain.ObjectStore {
+	return &tracedStore{inner: inner, backend: backend}
+}
+
+func (s *tracedStore) start(ctx context.Context, op, key string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, s.backend+" "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("argus.storage.key", key)),
+	)
+}
+
+func (s *tracedStore) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	ctx, span := s.start(ctx, "PutObject", key)
+	span.SetAttributes(attribute.Int64("argus.storage.size", size))
+	err := s.inner.PutObject(ctx, key, reader, size, contentType)
+	End(span, err)
+	return err
+}
+
+func (s *tracedStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	ctx, span := s.start(ctx, "GetObject", key)
+	rc, err := s.inner.GetObject(ctx, key)
+	End(span, notFoundOK(err))
+	return rc, err
+}
+
+func (s *tracedStore) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *domain.ObjectInfo, error) {
+	ctx, span := s.start(ctx, "GetObjectRange", key)
+	span.SetAttributes(attribute.Int64("argus.storage.offset", offset), attribute.Int64("argus.storage.length", length))
+	rc, info, err := s.inner.GetObjectRange(ctx, key, offset, length)
+	End(span, notFoundOK(err))
+	return rc, info, err
+}
+
+func (s *tracedStore) StatObject(ctx context.Context, key string) (*domain.ObjectInfo, error) {
+	ctx, span := s.start(ctx, "StatObject", key)
+	info, err := s.inner.StatObject(ctx, key)
+	End(span, notFoundOK(err))
+	return info, err
+}
+
+func (s *tracedStore) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	ctx, span := s.start(ctx, "ListObjects", prefix)
+	objects, err := s.inner.ListObjects(ctx, prefix, recursive)
+	span.SetAttributes(attribute.Int("argus.storage.count", len(objects)))
+	End(span, err)
+	return objects, err
+}
+
+func (s *tracedStore) RemoveObjects(ctx context.Context, keys []string) error {
+	ctx, span := tracer().Start(ctx, s.backend+" RemoveObjects",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("argus.storage.count", len(keys))),
+	)
+	if len(keys) <= 10 {
+		span.SetAttributes(attrObjectKeys.StringSlice(keys))
+	}
+	err := s.inner.RemoveObjects(ctx, keys)
+	End(span, err)
+	return err
+}
+
+func (s *tracedStore) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	ctx, span := s.start(ctx, "CopyObject", srcKey)
+	span.SetAttributes(attribute.String("argus.storage.dst_key", dstKey))
+	err := s.inner.CopyObject(ctx, srcKey, dstKey)
+	End(span, err)
+	return err
+}
+
+func (s *tracedStore) PresignedGetObject(ctx context.Context, key string, expiry time.Duration, downloadName string) (string, error) {
+	ctx, span := s.start(ctx, "PresignedGetObject", key)
+	url, err := s.inner.PresignedGetObject(ctx, key, expiry, downloadName)
+	End(span, err)
+	return url, err
+}
+
+// Ping 探针频繁调用，不产生 Span
+func (s *tracedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(domain.ObjectStorePinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// notFoundOK 对象不存在是调用方要处理的正常分支，不标记为失败
+func notFoundOK(err error) error {
+	if errors.Is(err, domain.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
+)
+
+// setup 全局 Provider 换成内存记录器
+func setup(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracing.NewProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+// TestRecordBatchSpan - 根 Span 的 ID 由 Batch ID 派生，起点为创建时间；非终态不产生 Span
+func TestRecordBatchSpan(t *testing.T) {
+	recorder := setup(t)
+	batch, err := domain.NewBatch("vehicle-001", "LSVAU218XN2183294", 1)
+	require.NoError(t, err)
+
+	tracing.RecordBatchSpan(context.Background(), batch)
+	assert.Empty(t, recorder.Ended())
+
+	batch.Status = domain.BatchStatusCompleted
+	tracing.RecordBatchSpan(context.Background(), batch)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	root := tracing.BatchSpanContext(batch.ID)
+	assert.Equal(t, root.TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, root.SpanID(), spans[0].SpanContext().SpanID())
+	assert.Equal(t, batch.CreatedAt, spans[0].StartTime())
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "LSVAU218XN2183294")
+	}
+}
+
+// TestKafkaPropagation - Producer Span 挂在 Batch 链路下并关联发起请求，Consumer 从消息头接上链路
+func TestKafkaPropagation(t *testing.T) {
+	recorder := setup(t)
+	batch, err := domain.NewBatch("vehicle-001", "LSVAU218XN2183294", 1)
+	require.NoError(t, err)
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "POST /api/v1/batches/:id/complete")
+	msg := &sarama.ProducerMessage{
+		Topic: "batch-events",
+		Key:   sarama.StringEncoder(batch.ID.String()),
+		Value: sarama.StringEncoder(`{}`),
+	}
+	_, produce := tracing.StartProduce(ctx, msg)
+	tracing.EndProduce(produce, 0, 42, nil)
+	request.End()
+
+	root := tracing.BatchSpanContext(batch.ID)
+	producer := recorder.Ended()[0]
+	assert.Equal(t, root.TraceID(), producer.SpanContext().TraceID())
+	assert.Equal(t, root.SpanID(), producer.Parent().SpanID())
+	require.Len(t, producer.Links(), 1)
+	assert.Equal(t, request.SpanContext().TraceID(), producer.Links()[0].SpanContext.TraceID())
+
+	headers := make([]*sarama.RecordHeader, 0, len(msg.Headers))
+	for i := range msg.Headers {
+		headers = append(headers, &msg.Headers[i])
+	}
+	_, consume := tracing.StartConsume(context.Background(), &sarama.ConsumerMessage{
+		Topic:   msg.Topic,
+		Key:     []byte(batch.ID.String()),
+		Headers: headers,
+	}, "orchestrator-group")
+	tracing.End(consume, nil)
+
+	consumer := recorder.Ended()[2]
+	assert.Equal(t, root.TraceID(), consumer.SpanContext().TraceID())
+	assert.Equal(t, producer.SpanContext().SpanID(), consumer.Parent().SpanID())
+}
+
+// TestMiddleware_RouteTemplate - Span 名使用路由模板，VIN 不会进入 Span；响应头返回 Trace ID
+func TestMiddleware_RouteTemplate(t *testing.T) {
+	recorder := setup(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/api/v1/vehicles/:vin", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/vehicles/LSVAU218XN2183294", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/v1/vehicles/:vin", spans[0].Name())
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), w.Header().Get(tracing.TraceIDHeader))
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "LSVAU218XN2183294")
+	}
+}
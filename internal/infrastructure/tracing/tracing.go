@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 所有 Span 的 instrumentation scope
+const instrumentationName = "github.com/xuewentao/argus-ota-platform"
+
+// 导出方式（OTEL_TRACES_EXPORTER）
+const (
+	ExporterNone   = "none"   // 默认：不导出，但仍然透传上游的 trace context
+	ExporterOTLP   = "otlp"   // OTLP/HTTP，地址由 OTEL_EXPORTER_OTLP_ENDPOINT 等标准变量配置
+	ExporterStdout = "stdout" // 同步打印到标准输出，用于离线调试
+)
+
+// Init 初始化全局 TracerProvider 与 W3C 传播器，返回的函数在退出前调用（刷出未导出的 Span）
+//
+// 采样使用 SDK 默认的 parentbased_always_on，可以用 OTEL_TRACES_SAMPLER 覆盖；
+// 注意 Batch 链路的根 Span 上下文固定为已采样，按比例采样时只影响非 Batch 请求
+func Init(ctx context.Context, serviceName string, getEnv func(key, defaultValue string) string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporterName := getEnv("OTEL_TRACES_EXPORTER", ExporterNone)
+	var opt sdktrace.TracerProviderOption
+	switch exporterName {
+	case ExporterNone, "":
+		log.Printf("[Tracing] Exporter disabled, propagating trace context only")
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		opt = sdktrace.WithBatcher(exporter)
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		opt = sdktrace.WithSyncer(exporter)
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", exporterName)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	provider := NewProvider(opt, sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	log.Printf("[Tracing] Exporting spans via %s (service=%s)", exporterName, serviceName)
+	return provider.Shutdown, nil
+}
+
+// NewProvider 创建 TracerProvider 并使用 Batch 感知的 ID 生成器（测试中配合内存导出器使用）
+func NewProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(append(opts, sdktrace.WithIDGenerator(idGenerator{}))...)
+}
+
+// tracer 每次从全局 Provider 获取（Init 之前创建的 Tracer 也会委托到之后设置的 Provider）
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End 结束 Span，err 非空时记录错误并标记失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
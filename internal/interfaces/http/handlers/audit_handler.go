@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// AuditHandler 访问拒绝审计查询（仅 admin）
+type AuditHandler struct {
+	audit domain.AccessAuditRepository
+}
+
+func NewAuditHandler(audit domain.AccessAuditRepository) *AuditHandler {
+	return &AuditHandler{
+		audit: audit,
+	}
+}
+
+func (h *AuditHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/api/v1/audit/denials", h.ListDenials)
+}
+
+// ListDenials 最近被拒绝的访问（默认最近 24 小时）
+// GET /api/v1/audit/denials?subject=&since=2025-06-01&limit=100
+func (h *AuditHandler) ListDenials(c *gin.Context) {
+	since, err := queryTime(c, "since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().Add(-24 * time.Hour)
+	}
+	limit, err := queryInt(c, "limit", 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit == 0 || limit > 1000 {
+		limit = 100
+	}
+
+	denials, err := h.audit.ListDenials(c.Request.Context(), c.Query("subject"), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": denials, "since": since})
+}
@@ -1,34 +1,40 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
 	"github.com/xuewentao/argus-ota-platform/internal/domain"
-	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/minio"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/metrics"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/tracing"
 )
+// batchContextKey authorizeBatch 校验通过后把 Batch 放进 gin.Context，后续处理不再重复查询
+const batchContextKey = "batch"
+
 type batchHandler struct {
 	batchService *application.BatchService
-	minioClient  *minio.MinIOClient
+	storage      domain.ObjectStore
 }
 
 func NewBatchHandler (
 	batchService   *application.BatchService,
-	minioClient    *minio.MinIOClient,
+	storage        domain.ObjectStore,
 ) *batchHandler {
 	return &batchHandler{
 		batchService: batchService,
-		minioClient:  minioClient,
+		storage:      storage,
 	}
 }
 func (h *batchHandler) CreateBatch(c *gin.Context) {
-    var req struct {
-        VehicleID       string `json:"vehicle_id" binding:"required"`
-        VIN             string `json:"vin" binding:"required"`
-        ExpectedWorkers int    `json:"expected_workers" binding:"required"`
-    }
+    var req dto.CreateBatchRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(400, gin.H{
             "error": err.Error(),
@@ -36,26 +42,63 @@ func (h *batchHandler) CreateBatch(c *gin.Context) {
         return
     }
 
-    batch, err := h.batchService.CreateBatch(c.Request.Context(), req.VehicleID, req.VIN,
-    	req.ExpectedWorkers)
+    batch, err := h.batchService.CreateBatch(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(500,gin.H{"error":err.Error()})
+		setRetryAfter(c, err)
+		c.JSON(createBatchStatus(err),gin.H{"error":err.Error()})
 		return
 	}
+	tracing.LinkBatch(c.Request.Context(), batch.ID)
 
 	c.JSON(201,gin.H{
 		"batch_id": batch.ID,
 		"status"  : batch.Status,
+		"priority": batch.Priority,
 	})
 }
 
+// createBatchStatus 车辆校验失败属于请求问题，不应返回 500
+func createBatchStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrTenantNotFound),
+		errors.Is(err, domain.ErrTenantSuspended):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrQuotaExceeded), errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrInvalidVIN):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrVehicleNotRegistered),
+		errors.Is(err, domain.ErrVehicleDecommissioned),
+		errors.Is(err, domain.ErrVehicleMismatch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *batchHandler) UploadFile(c *gin.Context) {
-	batchIDStr := c.Param("id")
-	batchID, err := uuid.Parse(batchIDStr)
+	batch := c.MustGet(batchContextKey).(*domain.Batch)
+
+	// 配额与限流在解析表单之前校验：FormFile 会把整个请求体读进内存 / 临时文件，
+	// 先解析再校验等于超限的请求也要全部收下。请求体大小（含 multipart 边界，略大于文件）是文件大小的上界，
+	// 按它预占每日上传字节数；文件写入并登记成功后按实际大小结算，任一步失败则全部退回
+	size := c.Request.ContentLength
+	if size < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+		return
+	}
+	reservation, err := h.batchService.CheckUploadQuota(c.Request.Context(), batch, size)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid batch id"})
+		setRetryAfter(c, err)
+		c.JSON(uploadQuotaStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	var accepted int64
+	defer func() {
+		h.batchService.SettleUploadQuota(context.WithoutCancel(c.Request.Context()), reservation, accepted)
+	}()
+	// 读取量不超过已做过配额校验的长度（不依赖具体的 Server 实现按 Content-Length 截断请求体）
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, size)
 
 	//stream read
 	fileHeader,err := c.FormFile("file")
@@ -72,9 +115,10 @@ func (h *batchHandler) UploadFile(c *gin.Context) {
 	defer file.Close()
 
 	fileID := uuid.New()
-	objectKey := fmt.Sprintf("%s/%s", batchIDStr, fileID)
+	objectKey := domain.RawObjectPath(batch.TenantID, batch.ID, fileID)
 
-	err = h.minioClient.PutObject(
+	start := time.Now()
+	err = h.storage.PutObject(
 		c.Request.Context(),
 		objectKey,
 		file,
@@ -82,6 +126,7 @@ func (h *batchHandler) UploadFile(c *gin.Context) {
 		"application/octet-stream",
 	)
 	if err != nil {
+		metrics.ObserveUpload(batch.TenantID, fileHeader.Size, time.Since(start), err)
 		c.JSON(500,gin.H{"error":err.Error()})
 		return
 	}
@@ -89,22 +134,50 @@ func (h *batchHandler) UploadFile(c *gin.Context) {
 	// 调用 BatchService.AddFile，传入完整的文件信息
 	err = h.batchService.AddFile(
 		c.Request.Context(),
-		batchID,
+		batch.ID,
 		fileID,
 		fileHeader.Filename,    // 原始文件名
 		fileHeader.Size,         // 文件大小
 		objectKey,               // MinIO 路径
 	)
+	metrics.ObserveUpload(batch.TenantID, fileHeader.Size, time.Since(start), err)
 	if err != nil {
 		c.JSON(500,gin.H{"error":err.Error()})
 		return
 	}
+	accepted = fileHeader.Size
 	c.JSON(201,gin.H{
 		"file_id" : fileID,
 		"size"	  : fileHeader.Size,
 	})
 }
 
+// uploadQuotaStatus 单文件超限 413，存储总量、每日配额超限与限流 429
+func uploadQuotaStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrQuotaExceeded), errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrTenantNotFound), errors.Is(err, domain.ErrTenantSuspended):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// setRetryAfter 限流与每日配额的拒绝带上 Retry-After（秒，向上取整），车端据此退避而不是立即重试
+func setRetryAfter(c *gin.Context, err error) {
+	var retry *domain.RetryAfterError
+	if !errors.As(err, &retry) {
+		return
+	}
+	seconds := int64(math.Ceil(retry.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
 func (h *batchHandler) CompleteUpload(c *gin.Context) {
 	batchIDStr := c.Param("id")
 	batchID, err := uuid.Parse(batchIDStr)
@@ -125,12 +198,37 @@ func (h *batchHandler) CompleteUpload(c *gin.Context) {
 	c.JSON(200,gin.H{"message": "Batch completed,processing started"})
 }
 
-func (h *batchHandler) RegisterRoutes(r *gin.Engine) {
+// authorizeBatch 车辆只能向自己的 Batch 上传文件、提交完成（先于写对象存储执行）
+func (h *batchHandler) authorizeBatch(c *gin.Context) {
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid batch id"})
+		return
+	}
+	batch, err := h.batchService.AuthorizeBatch(c.Request.Context(), batchID)
+	if err != nil {
+		if errors.Is(err, domain.ErrForbidden) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if batch == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "batch not found"})
+		return
+	}
+	// 上传、提交请求各自是一条链路，以 Link 关联到 Batch 链路
+	tracing.LinkBatch(c.Request.Context(), batch.ID)
+	c.Set(batchContextKey, batch)
+	c.Next()
+}
+
+func (h *batchHandler) RegisterRoutes(r gin.IRouter) {
 	v1 := r.Group("/api/v1")
 	{
 		v1.POST("/batches", h.CreateBatch)
-		v1.POST("/batches/:id/files", h.UploadFile)
-		v1.POST("/batches/:id/complete", h.CompleteUpload)
+		v1.POST("/batches/:id/files", h.authorizeBatch, h.UploadFile)
+		v1.POST("/batches/:id/complete", h.authorizeBatch, h.CompleteUpload)
 	}
 }
-
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// CampaignHandler OTA 活动管理（发布经理）与车端升级接口
+type CampaignHandler struct {
+	campaignService *application.CampaignService
+}
+
+func NewCampaignHandler(campaignService *application.CampaignService) *CampaignHandler {
+	return &CampaignHandler{
+		campaignService: campaignService,
+	}
+}
+
+func (h *CampaignHandler) RegisterRoutes(router gin.IRouter) {
+	campaigns := router.Group("/api/v1/campaigns")
+	{
+		campaigns.POST("", h.CreateCampaign)
+		campaigns.GET("", h.ListCampaigns)
+		campaigns.GET("/:id", h.GetCampaign)
+		campaigns.GET("/:id/deployments", h.ListDeployments)
+		campaigns.POST("/:id/start", h.StartCampaign)
+		campaigns.POST("/:id/pause", h.PauseCampaign)
+		campaigns.POST("/:id/resume", h.ResumeCampaign)
+		campaigns.POST("/:id/abort", h.AbortCampaign)
+	}
+}
+
+// RegisterVehicleRoutes 车端：轮询待安装的升级、上报安装结果（与管理接口的认证要求不同，单独挂载）
+func (h *CampaignHandler) RegisterVehicleRoutes(router gin.IRouter) {
+	router.GET("/api/v1/vehicles/:vin/deployment", h.PendingDeployment)
+	router.POST("/api/v1/vehicles/:vin/deployment/result", h.ReportDeployment)
+}
+
+// CreateCampaign 创建活动（draft，调用 start 后才开始放量）
+// POST /api/v1/campaigns {"name": "J7 2025.07", "platform": "J7", "target_firmware": "J7-2025.07", "waves": [1, 10, 50, 100]}
+func (h *CampaignHandler) CreateCampaign(c *gin.Context) {
+	var req dto.CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaign, err := h.campaignService.CreateCampaign(c.Request.Context(), req)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// ListCampaigns GET /api/v1/campaigns?status=running&limit=50
+func (h *CampaignHandler) ListCampaigns(c *gin.Context) {
+	limit, err := queryInt(c, "limit", 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaigns, err := h.campaignService.ListCampaigns(c.Request.Context(), domain.CampaignStatus(c.Query("status")), limit)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": campaigns})
+}
+
+// GetCampaign 活动详情、最近一次门禁结果与各状态车辆数
+func (h *CampaignHandler) GetCampaign(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	detail, err := h.campaignService.GetCampaign(c.Request.Context(), id)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"campaign": detail.Campaign, "deployments": detail.Deployments})
+}
+
+// ListDeployments GET /api/v1/campaigns/:id/deployments?status=failed&limit=100&offset=0
+func (h *CampaignHandler) ListDeployments(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	limit, err := queryInt(c, "limit", 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deployments, err := h.campaignService.ListDeployments(c.Request.Context(), id,
+		domain.DeploymentStatus(c.Query("status")), limit, offset)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": deployments})
+}
+
+func (h *CampaignHandler) StartCampaign(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	campaign, err := h.campaignService.StartCampaign(c.Request.Context(), id)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *CampaignHandler) PauseCampaign(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	var req dto.CampaignActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaign, err := h.campaignService.PauseCampaign(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *CampaignHandler) ResumeCampaign(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	campaign, err := h.campaignService.ResumeCampaign(c.Request.Context(), id)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *CampaignHandler) AbortCampaign(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	var req dto.CampaignActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaign, err := h.campaignService.AbortCampaign(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// PendingDeployment 车端轮询；没有待安装的升级时返回 204
+// GET /api/v1/vehicles/:vin/deployment
+func (h *CampaignHandler) PendingDeployment(c *gin.Context) {
+	deployment, err := h.campaignService.PendingDeployment(c.Request.Context(), c.Param("vin"))
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	if deployment == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, deployment)
+}
+
+// ReportDeployment 车端上报安装结果
+// POST /api/v1/vehicles/:vin/deployment/result {"success": false, "message": "flash verify failed"}
+func (h *CampaignHandler) ReportDeployment(c *gin.Context) {
+	var req dto.DeploymentResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deployment, err := h.campaignService.ReportDeployment(c.Request.Context(), c.Param("vin"), *req.Success, req.Message)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deployment)
+}
+
+func campaignID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func campaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCampaign):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrCampaignNotFound), errors.Is(err, domain.ErrDeploymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrCampaignConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/chart"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// chartCacheControl 图表需要认证且按租户隔离，只允许浏览器自己缓存（共享代理不能缓存），过期后用 ETag 重新校验
+const chartCacheControl = "private, max-age=300"
+
+type ChartHandler struct {
+	queryService *application.QueryService
+	storage      domain.ObjectStore
+}
+
+func NewChartHandler(queryService *application.QueryService, storage domain.ObjectStore) *ChartHandler {
+	return &ChartHandler{
+		queryService: queryService,
+		storage:      storage,
+	}
+}
+
+// ListCharts 列出报告中的图表文件名
+// GET /api/v1/batches/:id/charts
+func (h *ChartHandler) ListCharts(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(report.ChartFiles))
+	for _, p := range report.ChartFiles {
+		names = append(names, path.Base(p))
+	}
+	c.JSON(http.StatusOK, gin.H{"batch_id": report.BatchID, "charts": names})
+}
+
+// GetChart 返回图表内容（支持 If-None-Match 协商缓存）
+// GET /api/v1/batches/:id/charts/:name，例如 cpu_utilization.png
+//
+// name 必须出现在报告的 ChartFiles 中，不直接拼对象路径：防止路径穿越（../ 读到别的 Batch 的对象），
+// 不存在的图表也不会打到对象存储
+func (h *ChartHandler) GetChart(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+
+	name := c.Param("name")
+	objectPath := domain.ChartObjectPath(report.TenantID, report.BatchID, name)
+	found := false
+	for _, p := range report.ChartFiles {
+		if p == objectPath {
+			found = true
+			break
+		}
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chart not found"})
+		return
+	}
+
+	// 先 HEAD 拿 ETag：客户端缓存有效时直接 304，不下载对象
+	info, err := h.storage.StatObject(c.Request.Context(), objectPath)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	etag := `"` + strings.Trim(info.ETag, `"`) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", chartCacheControl)
+	c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	src, err := h.storage.GetObject(c.Request.Context(), objectPath)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	defer src.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = chart.Format(strings.TrimPrefix(path.Ext(name), ".")).ContentType()
+	}
+	c.DataFromReader(http.StatusOK, info.Size, contentType, src, nil)
+}
+
+// storageError 对象不存在（报告里有记录但对象已被清理）返回 404
+func storageError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrObjectNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chart not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (h *ChartHandler) loadReport(c *gin.Context) (*domain.Report, bool) {
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch id"})
+		return nil, false
+	}
+	report, err := h.queryService.GetReport(c.Request.Context(), batchID)
+	if err != nil {
+		c.JSON(queryErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return nil, false
+	}
+	return report, true
+}
+
+// etagMatches If-None-Match 可能是逗号分隔的多个值或 *
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
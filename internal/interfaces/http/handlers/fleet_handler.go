@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+type FleetHandler struct {
+	fleetService *application.FleetService
+}
+
+func NewFleetHandler(fleetService *application.FleetService) *FleetHandler {
+	return &FleetHandler{
+		fleetService: fleetService,
+	}
+}
+
+func (h *FleetHandler) RegisterRoutes(router gin.IRouter) {
+	fleet := router.Group("/api/v1/fleet")
+	{
+		fleet.GET("/throughput", h.Throughput)
+		fleet.GET("/error-codes", h.TopErrorCodes)
+		fleet.GET("/vehicles/:vin/resources", h.VehicleTrend)
+		fleet.GET("/anomalies", h.Anomalies)
+	}
+}
+
+// Throughput 吞吐量与失败率
+// GET /api/v1/fleet/throughput?from=2025-06-01&to=2025-06-08&platform=J7&interval=day
+func (h *FleetHandler) Throughput(c *gin.Context) {
+	q, ok := parseFleetQuery(c)
+	if !ok {
+		return
+	}
+	points, err := h.fleetService.Throughput(c.Request.Context(), q)
+	if err != nil {
+		fleetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": points, "interval": q.Interval})
+}
+
+// TopErrorCodes 各平台 / 软件版本的高频异常码
+// GET /api/v1/fleet/error-codes?platform=J7&firmware_version=J7-2025.06&limit=10
+func (h *FleetHandler) TopErrorCodes(c *gin.Context) {
+	q, ok := parseFleetQuery(c)
+	if !ok {
+		return
+	}
+	ranks, err := h.fleetService.TopErrorCodes(c.Request.Context(), q)
+	if err != nil {
+		fleetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": ranks})
+}
+
+// VehicleTrend 单车 CPU/RAM 分位数趋势（按天）
+// GET /api/v1/fleet/vehicles/:vin/resources?from=2025-05-01
+func (h *FleetHandler) VehicleTrend(c *gin.Context) {
+	q, ok := parseFleetQuery(c)
+	if !ok {
+		return
+	}
+	points, err := h.fleetService.VehicleTrend(c.Request.Context(), c.Param("vin"), q)
+	if err != nil {
+		fleetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"vin": domain.NormalizeVIN(c.Param("vin")), "items": points})
+}
+
+// Anomalies 异常车辆排行榜
+// GET /api/v1/fleet/anomalies?metric=errors|failures|cpu_p99|ram_p99|critical_diagnoses&platform=J7&limit=20
+func (h *FleetHandler) Anomalies(c *gin.Context) {
+	metric, err := domain.ParseAnomalyMetric(c.Query("metric"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, ok := parseFleetQuery(c)
+	if !ok {
+		return
+	}
+	anomalies, err := h.fleetService.Anomalies(c.Request.Context(), metric, q)
+	if err != nil {
+		fleetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"metric": metric, "items": anomalies})
+}
+
+// parseFleetQuery 解析公共查询参数，出错时直接返回 400
+func parseFleetQuery(c *gin.Context) (domain.FleetQuery, bool) {
+	q := domain.FleetQuery{
+		Platform:        c.Query("platform"),
+		FirmwareVersion: c.Query("firmware_version"),
+		Interval:        domain.FleetInterval(c.Query("interval")),
+	}
+	var err error
+	if q.From, err = queryTime(c, "from"); err == nil {
+		if q.To, err = queryTime(c, "to"); err == nil {
+			q.Limit, err = queryInt(c, "limit", 0)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return q, false
+	}
+	return q, true
+}
+
+// queryTime 支持 RFC3339 和 YYYY-MM-DD（UTC）
+func queryTime(c *gin.Context, key string) (time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s (expected RFC3339 or YYYY-MM-DD)", key, v)
+	}
+	return t, nil
+}
+
+func fleetError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidFleetQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+type PrivacyHandler struct {
+	erasureService *application.ErasureService
+}
+
+func NewPrivacyHandler(erasureService *application.ErasureService) *PrivacyHandler {
+	return &PrivacyHandler{
+		erasureService: erasureService,
+	}
+}
+
+// RegisterRoutes VIN 放在请求体 / 查询参数而不是路径中（路径会进入访问日志和审计记录）
+func (h *PrivacyHandler) RegisterRoutes(router gin.IRouter) {
+	v1 := router.Group("/api/v1")
+	{
+		v1.POST("/privacy/erasures", h.EraseVIN)
+		v1.GET("/privacy/erasures", h.ListErasures)
+	}
+}
+
+// EraseVIN 删除或匿名化 VIN 的全部数据（不可恢复）
+// POST /api/v1/privacy/erasures {"vin": "LSVAU218XN2183294", "reason": "GDPR request #1024"}
+func (h *PrivacyHandler) EraseVIN(c *gin.Context) {
+	var req dto.EraseVINRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	erasure, err := h.erasureService.EraseVIN(c.Request.Context(), req.VIN, req.RequestedBy, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidVIN):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrErasureBlocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, erasure)
+}
+
+// ListErasures 某个 VIN 的删除记录
+// GET /api/v1/privacy/erasures?vin=LSVAU218XN2183294
+func (h *PrivacyHandler) ListErasures(c *gin.Context) {
+	erasures, err := h.erasureService.ListErasures(c.Request.Context(), c.Query("vin"))
+	if errors.Is(err, domain.ErrInvalidVIN) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"erasures": erasures})
+}
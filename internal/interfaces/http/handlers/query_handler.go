@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
 )
 
 type QueryHandler struct {
@@ -28,7 +35,7 @@ func NewQueryHandler(queryService *application.QueryService) *QueryHandler {
 
 	report, err := h.queryService.GetReport(c.Request.Context(), batchID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -47,10 +54,88 @@ func (h *QueryHandler) GetProgress(c *gin.Context) {
 
 	progress, err := h.queryService.GetProgress(c.Request.Context(), batchID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(200, progress)
 }
 
+// ListBatches 按条件查询 Batch 列表
+// GET /api/v1/batches?priority=safety_critical&status=diagnosing&vehicle_id=&vin=&platform=&sort_by=priority&order=desc&limit=20&offset=0
+func (h *QueryHandler) ListBatches(c *gin.Context) {
+	opts := domain.ListOptions{
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("order"),
+	}
+	optional := func(key string) *string {
+		if v := c.Query(key); v != "" {
+			return &v
+		}
+		return nil
+	}
+	opts.VehicleID = optional("vehicle_id")
+	opts.VIN = optional("vin")
+	opts.Platform = optional("platform")
+
+	if status := optional("status"); status != nil {
+		if !domain.BatchStatus(*status).IsValid() {
+			c.JSON(400, gin.H{"error": "invalid status"})
+			return
+		}
+		opts.Status = status
+	}
+	if priority := optional("priority"); priority != nil {
+		if _, err := domain.ParseBatchPriority(*priority); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		opts.Priority = priority
+	}
+
+	var err error
+	if opts.Limit, err = queryInt(c, "limit", 20); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Offset, err = queryInt(c, "offset", 0); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	batches, err := h.queryService.ListBatches(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]dto.BatchResponse, 0, len(batches))
+	for _, batch := range batches {
+		items = append(items, dto.NewBatchResponse(batch))
+	}
+	c.JSON(200, gin.H{
+		"items":  items,
+		"limit":  opts.Limit,
+		"offset": opts.Offset,
+	})
+}
+
+// queryErrorStatus 超出调用方数据范围返回 403，其余按服务端错误处理
+func queryErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func queryInt(c *gin.Context, key string, defaultValue int) (int, error) {
+	v := c.Query(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, v)
+	}
+	return n, nil
+}
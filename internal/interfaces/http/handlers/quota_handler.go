@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// QuotaHandler 当天限流 / 配额用量查询（车辆与运维人员都可调用）
+type QuotaHandler struct {
+	quotas *application.QuotaService
+}
+
+func NewQuotaHandler(quotas *application.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		quotas: quotas,
+	}
+}
+
+func (h *QuotaHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/api/v1/quota", h.GetUsage)
+}
+
+// GetUsage 调用方租户当天的用量；车辆默认查询自己，运维人员可用 ?vin= 查看某辆车
+// GET /api/v1/quota?vin=LSVAU2180N2183294
+func (h *QuotaHandler) GetUsage(c *gin.Context) {
+	vin := c.Query("vin")
+	if p := domain.PrincipalFromContext(c.Request.Context()); vin == "" && p != nil && p.Kind == domain.PrincipalVehicle {
+		vin = p.VIN
+	}
+
+	usage, err := h.quotas.Usage(c.Request.Context(), vin)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrInvalidVIN):
+			status = http.StatusBadRequest
+		case errors.Is(err, domain.ErrForbidden),
+			errors.Is(err, domain.ErrTenantNotFound),
+			errors.Is(err, domain.ErrTenantSuspended):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
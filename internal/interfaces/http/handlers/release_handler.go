@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// ReleaseHandler 软件版本对比与回归（面向发布经理）
+type ReleaseHandler struct {
+	releaseService *application.ReleaseAnalysisService
+}
+
+func NewReleaseHandler(releaseService *application.ReleaseAnalysisService) *ReleaseHandler {
+	return &ReleaseHandler{
+		releaseService: releaseService,
+	}
+}
+
+func (h *ReleaseHandler) RegisterRoutes(router gin.IRouter) {
+	firmware := router.Group("/api/v1/firmware")
+	{
+		firmware.GET("/versions", h.ListVersions)
+		firmware.GET("/compare", h.Compare)
+		firmware.GET("/regressions", h.ListRegressions)
+		firmware.POST("/regressions/:id/acknowledge", h.Acknowledge)
+	}
+}
+
+// ListVersions 平台上出现过的软件版本（最新发布的在前）
+// GET /api/v1/firmware/versions?platform=J7&ecu=ADCU
+func (h *ReleaseHandler) ListVersions(c *gin.Context) {
+	versions, err := h.releaseService.ListVersions(c.Request.Context(), c.Query("platform"), c.Query("ecu"))
+	if err != nil {
+		releaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": versions})
+}
+
+// Compare 对比两个版本
+// GET /api/v1/firmware/compare?platform=J7&baseline=J7-2025.05&candidate=J7-2025.06&ecu=ADCU
+func (h *ReleaseHandler) Compare(c *gin.Context) {
+	platform, ecu := c.Query("platform"), c.Query("ecu")
+	comparison, err := h.releaseService.Compare(c.Request.Context(),
+		domain.VersionSelector{Platform: platform, ECU: ecu, Version: c.Query("baseline")},
+		domain.VersionSelector{Platform: platform, ECU: ecu, Version: c.Query("candidate")},
+	)
+	if err != nil {
+		releaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comparison)
+}
+
+// ListRegressions 检测到的回归
+// GET /api/v1/firmware/regressions?platform=J7&status=open&limit=50
+func (h *ReleaseHandler) ListRegressions(c *gin.Context) {
+	limit, err := queryInt(c, "limit", 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	regressions, err := h.releaseService.ListRegressions(c.Request.Context(), domain.RegressionListOptions{
+		Platform: c.Query("platform"),
+		Status:   domain.RegressionStatus(c.Query("status")),
+		Limit:    limit,
+	})
+	if err != nil {
+		releaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": regressions})
+}
+
+// Acknowledge 确认回归
+// POST /api/v1/firmware/regressions/:id/acknowledge
+func (h *ReleaseHandler) Acknowledge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid regression id"})
+		return
+	}
+	var req dto.AcknowledgeRegressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	regression, err := h.releaseService.Acknowledge(c.Request.Context(), id, req.AcknowledgedBy, req.Note)
+	if err != nil {
+		releaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, regression)
+}
+
+func releaseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidVersionSelector):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrRegressionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
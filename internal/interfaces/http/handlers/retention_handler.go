@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+type RetentionHandler struct {
+	retentionService *application.RetentionService
+}
+
+func NewRetentionHandler(retentionService *application.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+func (h *RetentionHandler) RegisterRoutes(router gin.IRouter) {
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/legal-holds", h.ListLegalHolds)
+		v1.POST("/legal-holds", h.PlaceLegalHold)
+		v1.DELETE("/legal-holds/:id", h.ReleaseLegalHold)
+		v1.GET("/tombstones/:batch_id", h.GetTombstone)
+	}
+}
+
+// PlaceLegalHold 加法务保留
+// POST /api/v1/legal-holds {"scope": "vin", "value": "LSVAU218XN2183294", "reason": "litigation #42"}
+func (h *RetentionHandler) PlaceLegalHold(c *gin.Context) {
+	var req dto.CreateLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hold, err := h.retentionService.PlaceLegalHold(c.Request.Context(),
+		domain.LegalHoldScope(req.Scope), req.Value, req.Reason, req.CreatedBy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ListLegalHolds 生效中的法务保留
+// GET /api/v1/legal-holds
+func (h *RetentionHandler) ListLegalHolds(c *gin.Context) {
+	holds, err := h.retentionService.ListLegalHolds(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"legal_holds": holds})
+}
+
+// ReleaseLegalHold 解除法务保留
+// DELETE /api/v1/legal-holds/:id
+func (h *RetentionHandler) ReleaseLegalHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid legal hold id"})
+		return
+	}
+	hold, err := h.retentionService.ReleaseLegalHold(c.Request.Context(), id)
+	if errors.Is(err, domain.ErrLegalHoldReleased) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if hold == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "legal hold not found"})
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+// GetTombstone 查询被生命周期任务删除的 Batch
+// GET /api/v1/tombstones/:batch_id
+func (h *RetentionHandler) GetTombstone(c *gin.Context) {
+	batchID, err := uuid.Parse(c.Param("batch_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch id"})
+		return
+	}
+	tombstone, err := h.retentionService.GetTombstone(c.Request.Context(), batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tombstone == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tombstone not found"})
+		return
+	}
+	c.JSON(http.StatusOK, tombstone)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/application"
+	"github.com/xuewentao/argus-ota-platform/internal/application/dto"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+type VehicleHandler struct {
+	vehicleService *application.VehicleService
+}
+
+func NewVehicleHandler(vehicleService *application.VehicleService) *VehicleHandler {
+	return &VehicleHandler{
+		vehicleService: vehicleService,
+	}
+}
+
+func (h *VehicleHandler) RegisterRoutes(router gin.IRouter) {
+	v1 := router.Group("/api/v1")
+	{
+		v1.POST("/vehicles", h.RegisterVehicle)
+		v1.GET("/vehicles", h.ListVehicles)
+		v1.GET("/vehicles/:vin", h.GetVehicle)
+		v1.PATCH("/vehicles/:vin", h.UpdateVehicle)
+		v1.POST("/vehicles/:vin/decommission", h.DecommissionVehicle)
+		v1.GET("/vehicles/:vin/batches", h.ListVehicleBatches)
+		v1.GET("/vins/:vin/decode", h.DecodeVIN)
+	}
+}
+
+// RegisterVehicle 注册车辆
+// POST /api/v1/vehicles {"vin": "LFWSRXSJ5M1A00001", "vehicle_id": "truck-001", "platform": "J7", "model_year": 2021}
+func (h *VehicleHandler) RegisterVehicle(c *gin.Context) {
+	var req dto.RegisterVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	vehicle, err := h.vehicleService.RegisterVehicle(c.Request.Context(), req)
+	if errors.Is(err, domain.ErrVehicleExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, vehicle)
+}
+
+// ListVehicles 按平台 / 车队 / 状态查询
+// GET /api/v1/vehicles?platform=J7&owner_fleet=&status=active&limit=50&offset=0
+func (h *VehicleHandler) ListVehicles(c *gin.Context) {
+	optional := func(key string) *string {
+		if v := c.Query(key); v != "" {
+			return &v
+		}
+		return nil
+	}
+	opts := domain.VehicleListOptions{
+		Platform:   optional("platform"),
+		OwnerFleet: optional("owner_fleet"),
+		Status:     optional("status"),
+	}
+
+	var err error
+	if opts.Limit, err = queryInt(c, "limit", 50); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Offset, err = queryInt(c, "offset", 0); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicles, err := h.vehicleService.ListVehicles(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": vehicles, "limit": opts.Limit, "offset": opts.Offset})
+}
+
+// GetVehicle GET /api/v1/vehicles/:vin
+func (h *VehicleHandler) GetVehicle(c *gin.Context) {
+	vehicle, err := h.vehicleService.GetVehicle(c.Request.Context(), c.Param("vin"))
+	h.respondVehicle(c, vehicle, err)
+}
+
+// UpdateVehicle 同步 ECU 清单 / 软件版本 / 所属车队
+// PATCH /api/v1/vehicles/:vin {"firmware_version": "J7-2025.06", "ecus": [{"name": "ADAS", "software_version": "3.2.1"}]}
+func (h *VehicleHandler) UpdateVehicle(c *gin.Context) {
+	var req dto.UpdateVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	vehicle, err := h.vehicleService.UpdateVehicle(c.Request.Context(), c.Param("vin"), req)
+	if errors.Is(err, domain.ErrInvalidInventory) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondVehicle(c, vehicle, err)
+}
+
+// DecommissionVehicle POST /api/v1/vehicles/:vin/decommission
+func (h *VehicleHandler) DecommissionVehicle(c *gin.Context) {
+	vehicle, err := h.vehicleService.DecommissionVehicle(c.Request.Context(), c.Param("vin"))
+	if errors.Is(err, domain.ErrVehicleDecommissioned) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondVehicle(c, vehicle, err)
+}
+
+// ListVehicleBatches GET /api/v1/vehicles/:vin/batches
+func (h *VehicleHandler) ListVehicleBatches(c *gin.Context) {
+	batches, err := h.vehicleService.ListVehicleBatches(c.Request.Context(), c.Param("vin"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]dto.BatchResponse, 0, len(batches))
+	for _, batch := range batches {
+		items = append(items, dto.NewBatchResponse(batch))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// DecodeVIN 校验 VIN 并解码制造商（不要求已注册）
+// GET /api/v1/vins/:vin/decode
+func (h *VehicleHandler) DecodeVIN(c *gin.Context) {
+	vin := domain.NormalizeVIN(c.Param("vin"))
+	if err := domain.ValidateVIN(vin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, domain.DecodeWMI(vin))
+}
+
+func (h *VehicleHandler) respondVehicle(c *gin.Context, vehicle *domain.Vehicle, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if vehicle == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vehicle not found"})
+		return
+	}
+	c.JSON(http.StatusOK, vehicle)
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+const (
+	permissionKey   = "auth.permission"
+	denialReasonKey = "auth.denial_reason"
+)
+
+// auditTimeout 写审计记录的超时（不能因为审计库变慢拖住请求）
+const auditTimeout = 2 * time.Second
+
+// deny 中止请求并记录拒绝原因（由 Audit 中间件写入审计表）
+func deny(c *gin.Context, status int, perm domain.Permission, reason string) {
+	c.Set(denialReasonKey, reason)
+	if perm != "" {
+		c.Set(permissionKey, perm)
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": http.StatusText(status)})
+}
+
+// Audit 记录所有被拒绝的访问（401 / 403），包括应用层范围校验返回的 403
+//
+// 必须挂在 Authenticate 之前（最外层），这样认证失败也能被记录；repo 为 nil 时只写日志
+func Audit(repo domain.AccessAuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status != http.StatusUnauthorized && status != http.StatusForbidden {
+			return
+		}
+		denial := &domain.AccessDenial{
+			ID:         uuid.New(),
+			OccurredAt: time.Now(),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Status:     status,
+			Reason:     c.GetString(denialReasonKey),
+			ClientIP:   c.ClientIP(),
+		}
+		if perm, ok := c.Get(permissionKey); ok {
+			denial.Permission, _ = perm.(domain.Permission)
+		}
+		if denial.Reason == "" {
+			denial.Reason = "denied by handler" // 应用层的归属 / 数据范围校验
+		}
+		if p := domain.PrincipalFromContext(c.Request.Context()); p != nil {
+			denial.Kind, denial.Subject, denial.Name, denial.VIN = p.Kind, p.Subject, p.Name, p.VIN
+			denial.TenantID = p.TenantID
+		}
+		// Tenant 中间件解析过的租户优先（凭证未携带租户时为 default）
+		if tenantID, ok := domain.TenantFromContext(c.Request.Context()); ok {
+			denial.TenantID = tenantID
+		}
+		log.Printf("[Audit] Access denied: status=%d %s %s subject=%q kind=%s permission=%s reason=%q ip=%s",
+			status, denial.Method, denial.Path, denial.Subject, denial.Kind, denial.Permission, denial.Reason, denial.ClientIP)
+
+		if repo == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+		defer cancel()
+		if err := repo.RecordDenial(ctx, denial); err != nil {
+			log.Printf("[Audit] Failed to record access denial: %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/auth"
+)
+
+// Authenticate 认证调用方并把 Principal 放进请求 context；kinds 为空表示车辆与运维人员都可以访问
+//
+// authenticator 为 nil（未配置认证）时直接放行，应用层拿不到 Principal，不做归属校验
+func Authenticate(authenticator *auth.Authenticator, kinds ...domain.PrincipalKind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticator == nil {
+			c.Next()
+			return
+		}
+		principal, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			deny(c, http.StatusUnauthorized, "", err.Error())
+			return
+		}
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), principal))
+		if !allowedKind(principal.Kind, kinds) {
+			deny(c, http.StatusForbidden, "", fmt.Sprintf("%s principals cannot access this route", principal.Kind))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOwnVIN 路径参数中的 VIN 必须与车辆凭证一致（运维人员不受限制）
+func RequireOwnVIN(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := domain.AuthorizeVIN(c.Request.Context(), c.Param(param)); err != nil {
+			AbortWithAuthError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortWithAuthError 认证 / 授权错误映射为 401 / 403
+func AbortWithAuthError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrUnauthenticated) {
+		deny(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	deny(c, http.StatusForbidden, "", err.Error())
+}
+
+func allowedKind(kind domain.PrincipalKind, kinds []domain.PrincipalKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// routePermissions 每个运维接口需要的权限（"METHOD 路由模板"）
+//
+// 未登记的路由一律拒绝：新增接口忘记登记时宁可 403，也不能默认放行
+var routePermissions = map[string]domain.Permission{
+	// Ingestor
+	"POST /api/v1/batches":                         domain.PermBatchesWrite,
+	"POST /api/v1/batches/:id/files":               domain.PermBatchesWrite,
+	"POST /api/v1/batches/:id/complete":            domain.PermBatchesWrite,
+	"GET /api/v1/quota":                            domain.PermBatchesRead,
+	"POST /api/v1/vehicles":                        domain.PermVehiclesWrite,
+	"GET /api/v1/vehicles":                         domain.PermVehiclesRead,
+	"GET /api/v1/vehicles/:vin":                    domain.PermVehiclesRead,
+	"PATCH /api/v1/vehicles/:vin":                  domain.PermVehiclesWrite,
+	"POST /api/v1/vehicles/:vin/decommission":      domain.PermVehiclesWrite,
+	"GET /api/v1/vehicles/:vin/batches":            domain.PermVehiclesRead,
+	"GET /api/v1/vins/:vin/decode":                 domain.PermVehiclesRead,
+	"POST /api/v1/campaigns":                       domain.PermCampaignsWrite,
+	"GET /api/v1/campaigns":                        domain.PermCampaignsRead,
+	"GET /api/v1/campaigns/:id":                    domain.PermCampaignsRead,
+	"GET /api/v1/campaigns/:id/deployments":        domain.PermCampaignsRead,
+	"POST /api/v1/campaigns/:id/start":             domain.PermCampaignsWrite,
+	"POST /api/v1/campaigns/:id/pause":             domain.PermCampaignsWrite,
+	"POST /api/v1/campaigns/:id/resume":            domain.PermCampaignsWrite,
+	"POST /api/v1/campaigns/:id/abort":             domain.PermCampaignsWrite,
+	"GET /api/v1/vehicles/:vin/deployment":         domain.PermCampaignsRead,
+	"POST /api/v1/vehicles/:vin/deployment/result": domain.PermCampaignsWrite,
+	// Query Service
+	"GET /api/v1/batches":                               domain.PermBatchesRead,
+	"GET /api/v1/batches/:id/progress":                  domain.PermBatchesRead,
+	"GET /api/v1/batches/:id/report":                    domain.PermReportsRead,
+	"GET /api/v1/batches/:id/charts":                    domain.PermReportsRead,
+	"GET /api/v1/batches/:id/charts/:name":              domain.PermReportsRead,
+	"GET /api/v1/fleet/throughput":                      domain.PermFleetRead,
+	"GET /api/v1/fleet/error-codes":                     domain.PermFleetRead,
+	"GET /api/v1/fleet/vehicles/:vin/resources":         domain.PermFleetRead,
+	"GET /api/v1/fleet/anomalies":                       domain.PermFleetRead,
+	"GET /api/v1/firmware/versions":                     domain.PermFirmwareRead,
+	"GET /api/v1/firmware/compare":                      domain.PermFirmwareRead,
+	"GET /api/v1/firmware/regressions":                  domain.PermFirmwareRead,
+	"POST /api/v1/firmware/regressions/:id/acknowledge": domain.PermRegressionsWrite,
+	"GET /api/v1/audit/denials":                         domain.PermAuditRead,
+	// Orchestrator
+	"GET /api/v1/legal-holds":          domain.PermLegalHoldsRead,
+	"POST /api/v1/legal-holds":         domain.PermLegalHoldsWrite,
+	"DELETE /api/v1/legal-holds/:id":   domain.PermLegalHoldsWrite,
+	"GET /api/v1/tombstones/:batch_id": domain.PermLegalHoldsRead,
+	"POST /api/v1/privacy/erasures":    domain.PermPrivacyErase,
+	"GET /api/v1/privacy/erasures":     domain.PermPrivacyErase,
+}
+
+// RoutePermission 路由需要的权限
+func RoutePermission(method, fullPath string) (domain.Permission, bool) {
+	perm, ok := routePermissions[method+" "+fullPath]
+	return perm, ok
+}
+
+// Authorize 按角色校验运维人员的接口权限，并把数据范围放进请求 context（QueryService 与 Repository 据此过滤）
+//
+// 必须挂在 Authenticate 之后。车辆不走角色授权（只能访问车端接口，归属由 VIN 校验保证）；
+// 未启用认证时没有 Principal，直接放行
+func Authorize(policy *domain.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := domain.PrincipalFromContext(c.Request.Context())
+		if principal == nil || principal.Kind != domain.PrincipalOperator {
+			c.Next()
+			return
+		}
+		perm, ok := RoutePermission(c.Request.Method, c.FullPath())
+		if !ok {
+			deny(c, http.StatusForbidden, "", fmt.Sprintf("route %s %s has no permission mapping", c.Request.Method, c.FullPath()))
+			return
+		}
+		c.Set(permissionKey, perm)
+		access := policy.Authorize(principal, perm)
+		if !access.Allowed {
+			deny(c, http.StatusForbidden, perm, fmt.Sprintf("missing permission %s", perm))
+			return
+		}
+		if access.Scope.Restricted() {
+			c.Request = c.Request.WithContext(domain.WithDataScope(c.Request.Context(), access.Scope))
+		}
+		c.Next()
+	}
+}
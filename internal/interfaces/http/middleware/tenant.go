@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/infrastructure/envelope"
+)
+
+// Tenant 确定请求所属租户（挂在 Authenticate 之后）：凭证没有携带租户、未启用认证时属于 default 租户
+//
+// 租户同时决定对象加密使用的主密钥 scope；scoper 非 nil 时整个请求在租户事务内执行，
+// 数据库查询受 RLS 约束（用于只读为主的查询服务，写路径自己管理事务与行锁）
+func Tenant(registry *domain.TenantRegistry, scoper domain.TenantScoper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := domain.DefaultTenantID
+		if p := domain.PrincipalFromContext(c.Request.Context()); p != nil && p.TenantID != "" {
+			tenantID = p.TenantID
+		}
+		tenant, err := registry.Get(tenantID)
+		if err != nil {
+			deny(c, http.StatusForbidden, "", err.Error())
+			return
+		}
+
+		ctx := domain.WithTenant(c.Request.Context(), tenant.ID)
+		ctx = envelope.WithScope(ctx, tenant.ID)
+		c.Request = c.Request.WithContext(ctx)
+		if scoper == nil {
+			c.Next()
+			return
+		}
+
+		req := c.Request
+		err = scoper.RunAsTenant(ctx, tenant.ID, func(ctx context.Context) error {
+			c.Request = req.WithContext(ctx)
+			c.Next()
+			if c.Writer.Status() >= http.StatusInternalServerError {
+				return errRollback
+			}
+			return nil
+		})
+		// 事务已结束，外层中间件（审计）不能再拿到带事务的 context
+		c.Request = req
+		if err != nil && !errors.Is(err, errRollback) {
+			log.Printf("[Tenant] Tenant transaction for %s failed: %v", tenant.ID, err)
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+		}
+	}
+}
+
+// errRollback 处理失败（5xx）时回滚租户事务，不需要额外记录日志
+var errRollback = errors.New("rollback tenant transaction")
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// CSVDecoder 带表头的 CSV 日志（列名按 columnAliases 映射，未知列进入 Extra）
+type CSVDecoder struct{}
+
+func NewCSVDecoder() *CSVDecoder {
+	return &CSVDecoder{}
+}
+
+func (d *CSVDecoder) Decode(ctx context.Context, r io.Reader, emit func(Record) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.FieldsPerRecord = -1 // 行尾缺列按空值处理
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil // 空文件
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	known := make([]bool, len(header))
+	for i, name := range header {
+		columns[i], known[i] = canonicalColumn(name)
+		if !known[i] {
+			columns[i] = name
+		}
+	}
+
+	for line := 2; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var record Record
+		for i, value := range fields {
+			if i >= len(columns) {
+				break
+			}
+			if !known[i] {
+				if value != "" {
+					record.setExtra(columns[i], value)
+				}
+				continue
+			}
+			if err := record.set(columns[i], value); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+}
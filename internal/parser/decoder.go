@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedFileType 没有为该文件类型注册解码器
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// RecordDecoder 把一种车端日志格式解码为 Record 流
+//
+// 以回调（emit）输出而不是返回 []Record：单个 rec 文件可达数 GB，边解码边写出，内存占用与文件大小无关
+type RecordDecoder interface {
+	// Decode 逐条解码并调用 emit；emit 返回错误时立即中止并返回该错误
+	Decode(ctx context.Context, r io.Reader, emit func(Record) error) error
+}
+
+// Registry 按 File.FileType 选择解码器
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[string]RecordDecoder
+}
+
+func NewRegistry() *Registry {
+	return &Registry{decoders: make(map[string]RecordDecoder)}
+}
+
+// NewDefaultRegistry 内置纯 Go 解码器：csv / jsonl
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("csv", NewCSVDecoder())
+	r.Register("jsonl", NewJSONLDecoder())
+	r.Register("ndjson", NewJSONLDecoder())
+	return r
+}
+
+// Register 注册（或覆盖）某个文件类型的解码器
+func (r *Registry) Register(fileType string, decoder RecordDecoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[normalizeFileType(fileType)] = decoder
+}
+
+// For 查找解码器
+func (r *Registry) For(fileType string) (RecordDecoder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	decoder, ok := r.decoders[normalizeFileType(fileType)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFileType, fileType)
+	}
+	return decoder, nil
+}
+
+func normalizeFileType(fileType string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(fileType)), ".")
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxLineSize 单行 JSON 上限（超长行视为损坏）
+const maxLineSize = 4 * 1024 * 1024
+
+// JSONLDecoder 每行一个 JSON 对象（外部 C++ 解析器的标准输出也使用该格式）
+type JSONLDecoder struct{}
+
+func NewJSONLDecoder() *JSONLDecoder {
+	return &JSONLDecoder{}
+}
+
+func (d *JSONLDecoder) Decode(ctx context.Context, r io.Reader, emit func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var fields map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber() // 保留原始数字精度（毫秒时间戳）
+		if err := decoder.Decode(&fields); err != nil {
+			return fmt.Errorf("line %d: invalid json: %w", line, err)
+		}
+
+		var record Record
+		for key, value := range fields {
+			text := jsonValueString(value)
+			column, ok := canonicalColumn(key)
+			if !ok {
+				record.setExtra(key, text)
+				continue
+			}
+			if err := record.set(column, text); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func jsonValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 解码后的统一记录（与车端日志格式无关）
+//
+// 下游聚合只依赖这几个规范列，各格式特有的字段放进 Extra
+type Record struct {
+	Timestamp      time.Time
+	ECU            string
+	CPUUtilization float64 // 百分比 0-100
+	RAMUsageMB     float64
+	HasCPU         bool // 该列为空或不存在时为 false：缺失值不能当成 0 参与统计
+	HasRAM         bool
+	ErrorCode      string // 空字符串表示无异常
+	Extra          map[string]string
+}
+
+// Columns 规范列（CSV 表头顺序）
+var Columns = []string{"timestamp", "ecu", "cpu_utilization", "ram_usage_mb", "error_code", "extra"}
+
+// columnAliases 各厂商日志中常见的列名别名 → 规范列
+var columnAliases = map[string]string{
+	"timestamp":       "timestamp",
+	"ts":              "timestamp",
+	"time":            "timestamp",
+	"ecu":             "ecu",
+	"ecu_id":          "ecu",
+	"cpu":             "cpu_utilization",
+	"cpu_utilization": "cpu_utilization",
+	"cpu_usage":       "cpu_utilization",
+	"ram":             "ram_usage_mb",
+	"ram_usage_mb":    "ram_usage_mb",
+	"mem_mb":          "ram_usage_mb",
+	"error_code":      "error_code",
+	"dtc":             "error_code",
+	"extra":           "extra", // CSVWriter 输出的 JSON 列（读回规范 CSV 时使用）
+}
+
+// canonicalColumn 规范化列名；未知列返回 false（放进 Extra）
+func canonicalColumn(name string) (string, bool) {
+	column, ok := columnAliases[strings.ToLower(strings.TrimSpace(name))]
+	return column, ok
+}
+
+// set 按规范列赋值，解析失败返回错误
+func (r *Record) set(column, value string) error {
+	value = strings.TrimSpace(value)
+	switch column {
+	case "timestamp":
+		ts, err := parseTimestamp(value)
+		if err != nil {
+			return err
+		}
+		r.Timestamp = ts
+	case "ecu":
+		r.ECU = value
+	case "cpu_utilization":
+		if value == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid cpu_utilization %q: %w", value, err)
+		}
+		r.CPUUtilization, r.HasCPU = v, true
+	case "ram_usage_mb":
+		if value == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid ram_usage_mb %q: %w", value, err)
+		}
+		r.RAMUsageMB, r.HasRAM = v, true
+	case "error_code":
+		r.ErrorCode = value
+	case "extra":
+		if value == "" {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(value), &r.Extra); err != nil {
+			return fmt.Errorf("invalid extra %q: %w", value, err)
+		}
+	}
+	return nil
+}
+
+func (r *Record) setExtra(key, value string) {
+	if r.Extra == nil {
+		r.Extra = make(map[string]string)
+	}
+	r.Extra[key] = value
+}
+
+// parseTimestamp 支持 RFC3339 和 Unix 时间戳（秒 / 毫秒）
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty timestamp")
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+	// 大于 1e12 视为毫秒（1e12 秒已是 33658 年）
+	if n > 1e12 {
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultGPSDecimals GPS 坐标默认保留 2 位小数（约 1.1 km）：诊断仍能区分地区与气候，但定位不到具体地址
+const DefaultGPSDecimals = 2
+
+// gpsCoordinateFields 单个坐标值，按精度截断
+var gpsCoordinateFields = map[string]bool{
+	"lat": true, "latitude": true, "gps_lat": true, "gps_latitude": true,
+	"lon": true, "lng": true, "long": true, "longitude": true,
+	"gps_lon": true, "gps_lng": true, "gps_long": true, "gps_longitude": true,
+}
+
+// gpsContainerFields 整体是位置信息（嵌套对象、NMEA 语句），无法逐项截断，直接删除
+var gpsContainerFields = map[string]bool{
+	"gps": true, "location": true, "position": true, "coordinates": true,
+	"geo": true, "geolocation": true, "nmea": true,
+}
+
+// piiFields 与诊断无关的个人信息，直接删除
+var piiFields = map[string]bool{
+	"driver": true, "driver_id": true, "driver_name": true, "first_name": true, "last_name": true,
+	"user_id": true, "account_id": true, "phone": true, "phone_number": true, "mobile": true, "msisdn": true,
+	"email": true, "address": true, "license_plate": true, "plate": true, "plate_number": true,
+	"ip": true, "ip_address": true, "mac": true, "mac_address": true, "ssid": true,
+	"imei": true, "imsi": true, "iccid": true,
+}
+
+var (
+	// vinPattern 17 位 VIN（不含 I、O、Q）；还要求同时有字母和数字，避免误伤 17 位纯数字的计数器
+	vinPattern = regexp.MustCompile(`\b[A-HJ-NPR-Z0-9]{17}\b`)
+	// emailPattern 自由文本里的邮箱
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// coordinatePattern 自由文本里的 "纬度,经度"（至少 4 位小数才像是定位结果）
+	coordinatePattern = regexp.MustCompile(`-?\d{1,3}\.\d{4,}\s*,\s*-?\d{1,3}\.\d{4,}`)
+)
+
+// Redactor 解析产物写出之前清理 Extra 中的个人信息
+//
+// 规范列（时间、ECU、CPU、内存、异常码）不含个人信息，只处理厂商特有的 Extra 字段：
+//   - GPS 坐标截断精度（gpsDecimals < 0 时删除），位置对象整体删除
+//   - 驾驶员、联系方式、车牌、设备标识等字段删除
+//   - vin 字段与自由文本中的 VIN 替换为令牌（tokenize 为 nil 时删除 / 打码）
+//
+// 解析产物是所有下游（聚合、图表、诊断、分析导出）的唯一数据源，在这里脱敏一次，下游不会遗漏
+type Redactor struct {
+	gpsDecimals int
+	tokenize    func(vin string) string
+}
+
+func NewRedactor(gpsDecimals int, tokenize func(vin string) string) *Redactor {
+	return &Redactor{
+		gpsDecimals: gpsDecimals,
+		tokenize:    tokenize,
+	}
+}
+
+// Wrap 包装 emit：记录先脱敏再交给下游（解码器 → Redactor → Writer）
+func (r *Redactor) Wrap(emit func(Record) error) func(Record) error {
+	return func(record Record) error {
+		r.Redact(&record)
+		return emit(record)
+	}
+}
+
+// Redact 原地修改 record.Extra
+func (r *Redactor) Redact(record *Record) {
+	for key, value := range record.Extra {
+		field := redactionField(key)
+		switch {
+		case gpsCoordinateFields[field]:
+			if coarse, ok := r.coarsen(value); ok {
+				record.Extra[key] = coarse
+			} else {
+				delete(record.Extra, key)
+			}
+		case gpsContainerFields[field], piiFields[field]:
+			delete(record.Extra, key)
+		case field == "vin":
+			if r.tokenize == nil {
+				delete(record.Extra, key)
+			} else {
+				record.Extra[key] = r.tokenize(value)
+			}
+		default:
+			record.Extra[key] = r.scrubText(value)
+		}
+	}
+}
+
+// redactionField 规范化字段名：小写、连字符与空格视为下划线，嵌套字段（gps.lat）取最后一段
+func redactionField(key string) string {
+	field := strings.ToLower(strings.TrimSpace(key))
+	if i := strings.LastIndexAny(field, "./"); i >= 0 {
+		field = field[i+1:]
+	}
+	return strings.NewReplacer("-", "_", " ", "_").Replace(field)
+}
+
+// coarsen 截断坐标精度；无法解析的值返回 false（宁可删除也不原样保留）
+func (r *Redactor) coarsen(value string) (string, bool) {
+	if r.gpsDecimals < 0 {
+		return "", false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return "", false
+	}
+	scale := math.Pow(10, float64(r.gpsDecimals))
+	return strconv.FormatFloat(math.Round(v*scale)/scale, 'f', r.gpsDecimals, 64), true
+}
+
+// scrubText 自由文本（诊断消息、备注）中的 VIN、邮箱与坐标
+func (r *Redactor) scrubText(value string) string {
+	value = vinPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if !strings.ContainsAny(match, "0123456789") || strings.Trim(match, "0123456789") == "" {
+			return match
+		}
+		if r.tokenize == nil {
+			return "[vin]"
+		}
+		return r.tokenize(match)
+	})
+	value = emailPattern.ReplaceAllString(value, "[email]")
+	return coordinatePattern.ReplaceAllString(value, "[gps]")
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxStderrSize 保留外部解析器 stderr 的最大字节数（用于错误信息）
+const maxStderrSize = 4 * 1024
+
+// SubprocessDecoder 调用外部解析器（例如厂商提供的 C++ rec 解析程序）
+//
+// 约定：原始文件从 stdin 输入，解析结果以 JSONL 写到 stdout，诊断信息写到 stderr，
+// 非 0 退出码视为解析失败
+//
+// 用子进程而不是 cgo：解析器崩溃（段错误、内存泄漏）只影响单个文件，不会拖垮 Worker，
+// 也不需要为每个平台交叉编译
+type SubprocessDecoder struct {
+	Path    string
+	Args    []string
+	Timeout time.Duration // 单个文件的最长解析时间（0 表示不限制）
+}
+
+func NewSubprocessDecoder(path string, args []string, timeout time.Duration) *SubprocessDecoder {
+	return &SubprocessDecoder{Path: path, Args: args, Timeout: timeout}
+}
+
+func (d *SubprocessDecoder) Decode(ctx context.Context, r io.Reader, emit func(Record) error) error {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, d.Path, d.Args...)
+	cmd.Stdin = r
+	stderr := &limitedBuffer{limit: maxStderrSize}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start parser %s: %w", d.Path, err)
+	}
+
+	decodeErr := NewJSONLDecoder().Decode(ctx, stdout, emit)
+	if decodeErr != nil {
+		// 下游出错时不再读 stdout，结束子进程避免其阻塞在写管道上
+		_ = cmd.Process.Kill()
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	waitErr := cmd.Wait()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("parser %s timed out after %s: %w", d.Path, d.Timeout, context.DeadlineExceeded)
+	}
+	if decodeErr != nil {
+		return decodeErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("parser %s failed: %w: %s", d.Path, waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// limitedBuffer 只保留前 limit 个字节（子进程可能输出大量日志）
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
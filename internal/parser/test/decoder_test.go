@@ -0,0 +1,96 @@
+package parser_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+func decodeAll(t *testing.T, decoder parser.RecordDecoder, input string) ([]parser.Record, error) {
+	t.Helper()
+	var records []parser.Record
+	err := decoder.Decode(context.Background(), strings.NewReader(input), func(r parser.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+// TestCSVDecoder_AliasesAndExtra - 列名别名映射到规范列，未知列进入 Extra
+func TestCSVDecoder_AliasesAndExtra(t *testing.T) {
+	input := "ts,ECU_ID,cpu,mem_mb,dtc,gear\n" +
+		"1700000000,BCM,42.5,512,,D\n" +
+		"1700000000123,VCU,99,2048,P0A80,\n"
+
+	records, err := decodeAll(t, parser.NewCSVDecoder(), input)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Equal(t, int64(1700000000), records[0].Timestamp.Unix())
+	assert.Equal(t, "BCM", records[0].ECU)
+	assert.Equal(t, 42.5, records[0].CPUUtilization)
+	assert.Equal(t, 512.0, records[0].RAMUsageMB)
+	assert.Equal(t, map[string]string{"gear": "D"}, records[0].Extra)
+
+	// 毫秒时间戳
+	assert.Equal(t, int64(1700000000123), records[1].Timestamp.UnixMilli())
+	assert.Equal(t, "P0A80", records[1].ErrorCode)
+	assert.Nil(t, records[1].Extra)
+}
+
+// TestCSVDecoder_InvalidValue - 非法数值报告行号
+func TestCSVDecoder_InvalidValue(t *testing.T) {
+	_, err := decodeAll(t, parser.NewCSVDecoder(), "timestamp,cpu\n1700000000,abc\n")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+// TestDecoder_MissingMetrics - 空值 / null 标记为缺失，写出规范 CSV 时保持为空（不能变成 0）
+func TestDecoder_MissingMetrics(t *testing.T) {
+	records, err := decodeAll(t, parser.NewCSVDecoder(), "timestamp,cpu,ram\n1700000000,,0\n")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.False(t, records[0].HasCPU)
+	assert.True(t, records[0].HasRAM)
+
+	var out bytes.Buffer
+	writer := parser.NewCSVWriter(&out)
+	err = parser.NewJSONLDecoder().Decode(context.Background(),
+		strings.NewReader(`{"timestamp":"2024-01-01T00:00:00Z","cpu_utilization":null,"ram_usage_mb":0}`), writer.Write)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Contains(t, out.String(), "2024-01-01T00:00:00Z,,,0,,\n")
+}
+
+// TestJSONLDecoder_ToCSV - JSONL 解码后写出规范 CSV
+func TestJSONLDecoder_ToCSV(t *testing.T) {
+	input := `{"timestamp":"2024-01-01T00:00:00Z","ecu":"ADAS","cpu_utilization":87.5,"ram_usage_mb":1024,"error_code":"U0100","speed":120}` + "\n\n"
+
+	var out bytes.Buffer
+	writer := parser.NewCSVWriter(&out)
+	err := parser.NewJSONLDecoder().Decode(context.Background(), strings.NewReader(input), writer.Write)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, 1, writer.Count())
+	assert.Equal(t,
+		"timestamp,ecu,cpu_utilization,ram_usage_mb,error_code,extra\n"+
+			`2024-01-01T00:00:00Z,ADAS,87.5,1024,U0100,"{""speed"":""120""}"`+"\n",
+		out.String())
+}
+
+// TestRegistry_UnsupportedFileType - 未注册的类型返回 ErrUnsupportedFileType
+func TestRegistry_UnsupportedFileType(t *testing.T) {
+	registry := parser.NewDefaultRegistry()
+
+	_, err := registry.For(".CSV")
+	assert.NoError(t, err)
+
+	_, err = registry.For("rec")
+	assert.True(t, errors.Is(err, parser.ErrUnsupportedFileType))
+}
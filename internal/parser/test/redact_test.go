@@ -0,0 +1,64 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+func fakeToken(vin string) string {
+	return "vt_" + vin[len(vin)-4:]
+}
+
+// TestRedactor_GPSAndPII - 坐标截断精度，位置对象与个人信息字段删除，其余字段保留
+func TestRedactor_GPSAndPII(t *testing.T) {
+	record := parser.Record{ECU: "TBOX", Extra: map[string]string{
+		"GPS.Lat":      "31.230416",
+		"longitude":    "121.473701",
+		"location":     `{"lat":31.23,"lon":121.47}`,
+		"Driver-Name":  "Zhang San",
+		"phone_number": "+86 138 0000 0000",
+		"imei":         "490154203237518",
+		"gear":         "D",
+	}}
+	parser.NewRedactor(parser.DefaultGPSDecimals, fakeToken).Redact(&record)
+
+	assert.Equal(t, map[string]string{
+		"GPS.Lat":   "31.23",
+		"longitude": "121.47",
+		"gear":      "D",
+	}, record.Extra)
+	assert.Equal(t, "TBOX", record.ECU)
+
+	// 精度为负数时坐标整体删除；无法解析的坐标也删除
+	record = parser.Record{Extra: map[string]string{"lat": "31.230416", "lon": "n/a"}}
+	parser.NewRedactor(-1, fakeToken).Redact(&record)
+	assert.Empty(t, record.Extra)
+}
+
+// TestRedactor_VINAndText - vin 字段与自由文本中的 VIN 替换为令牌，邮箱与坐标打码
+func TestRedactor_VINAndText(t *testing.T) {
+	var records []parser.Record
+	emit := parser.NewRedactor(parser.DefaultGPSDecimals, fakeToken).Wrap(func(r parser.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	err := emit(parser.Record{Extra: map[string]string{
+		"VIN":     "LSVAU218XN2183294",
+		"message": "LSVAU218XN2183294 reported by ops@example.com at 31.230416, 121.473701",
+		"counter": "12345678901234567",
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, map[string]string{
+		"VIN":     "vt_3294",
+		"message": "vt_3294 reported by [email] at [gps]",
+		"counter": "12345678901234567",
+	}, records[0].Extra)
+
+	// 没有假名化服务时 VIN 字段删除，文本中的 VIN 打码
+	record := parser.Record{Extra: map[string]string{"vin": "LSVAU218XN2183294", "note": "VIN LSVAU218XN2183294"}}
+	parser.NewRedactor(parser.DefaultGPSDecimals, nil).Redact(&record)
+	assert.Equal(t, map[string]string{"note": "VIN [vin]"}, record.Extra)
+}
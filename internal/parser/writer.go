@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RecordWriter 把 Record 流写成列式输出
+type RecordWriter interface {
+	Write(record Record) error
+	// Close 刷新缓冲区（不关闭底层 io.Writer）
+	Close() error
+	// Count 已写出的记录数
+	Count() int
+}
+
+// CSVWriter 以规范列（Columns）输出 CSV，Extra 序列化为 JSON 放在最后一列
+type CSVWriter struct {
+	w      *csv.Writer
+	row    []string
+	count  int
+	header bool
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		w:   csv.NewWriter(w),
+		row: make([]string, len(Columns)),
+	}
+}
+
+// ContentType 输出文件的 MIME 类型
+func (c *CSVWriter) ContentType() string {
+	return "text/csv"
+}
+
+func (c *CSVWriter) Write(record Record) error {
+	if !c.header {
+		if err := c.w.Write(Columns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	c.row[0] = ""
+	if !record.Timestamp.IsZero() {
+		c.row[0] = record.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	c.row[1] = record.ECU
+	c.row[2], c.row[3] = "", "" // 缺失值保持为空，读回时仍是缺失
+	if record.HasCPU {
+		c.row[2] = strconv.FormatFloat(record.CPUUtilization, 'f', -1, 64)
+	}
+	if record.HasRAM {
+		c.row[3] = strconv.FormatFloat(record.RAMUsageMB, 'f', -1, 64)
+	}
+	c.row[4] = record.ErrorCode
+	c.row[5] = encodeExtra(record.Extra)
+
+	if err := c.w.Write(c.row); err != nil {
+		return err
+	}
+	c.count++
+	return nil
+}
+
+func (c *CSVWriter) Close() error {
+	if !c.header {
+		// 没有任何记录也输出表头，下游无需特殊处理空文件
+		if err := c.w.Write(Columns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSVWriter) Count() int {
+	return c.count
+}
+
+// encodeExtra Extra 序列化为 JSON（encoding/json 对 map 按 key 排序，同一输入输出稳定，重复解析幂等）
+func encodeExtra(extra map[string]string) string {
+	if len(extra) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(extra)
+	return string(data)
+}
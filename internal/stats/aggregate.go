@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+)
+
+const (
+	cpuResolution = 0.01 // CPU 利用率精确到 0.01%
+	ramResolution = 0.1  // 内存精确到 0.1MB
+)
+
+// Aggregate 一个文件（或整个 Batch）的统计结果
+//
+// 每个文件独立统计后再 Merge，文件之间可以并行处理
+type Aggregate struct {
+	Records    int
+	CPU        *Histogram
+	RAM        *Histogram
+	ErrorCodes map[string]int
+	Series     *TimeSeries // 按分钟降采样，用于画趋势图
+	First      time.Time
+	Last       time.Time
+}
+
+func NewAggregate() *Aggregate {
+	return &Aggregate{
+		CPU:        NewHistogram(cpuResolution),
+		RAM:        NewHistogram(ramResolution),
+		ErrorCodes: make(map[string]int),
+		Series:     NewTimeSeries(DefaultSeriesInterval),
+	}
+}
+
+// Add 累加一条记录；缺失的 CPU / 内存值不进入分布和时间序列（记为 0 会拉低均值和分位数）
+func (a *Aggregate) Add(record parser.Record) {
+	a.Records++
+	cpu, ram := math.NaN(), math.NaN()
+	if record.HasCPU {
+		cpu = record.CPUUtilization
+		a.CPU.Record(cpu)
+	}
+	if record.HasRAM {
+		ram = record.RAMUsageMB
+		a.RAM.Record(ram)
+	}
+	if record.ErrorCode != "" {
+		a.ErrorCodes[record.ErrorCode]++
+	}
+	a.Series.Add(record.Timestamp, cpu, ram)
+	if !record.Timestamp.IsZero() {
+		if a.First.IsZero() || record.Timestamp.Before(a.First) {
+			a.First = record.Timestamp
+		}
+		if record.Timestamp.After(a.Last) {
+			a.Last = record.Timestamp
+		}
+	}
+}
+
+// Merge 合并另一个文件的统计结果
+func (a *Aggregate) Merge(other *Aggregate) {
+	a.Records += other.Records
+	a.CPU.Merge(other.CPU)
+	a.RAM.Merge(other.RAM)
+	a.Series.Merge(other.Series)
+	for code, count := range other.ErrorCodes {
+		a.ErrorCodes[code] += count
+	}
+	if !other.First.IsZero() && (a.First.IsZero() || other.First.Before(a.First)) {
+		a.First = other.First
+	}
+	if other.Last.After(a.Last) {
+		a.Last = other.Last
+	}
+}
+
+// CPUStats 没有记录时返回 nil（报告中显示为“无数据”，而不是全 0）
+func (a *Aggregate) CPUStats() *domain.CPUStats {
+	if a.CPU.Count() == 0 {
+		return nil
+	}
+	return &domain.CPUStats{
+		AvgUtilization: a.CPU.Mean(),
+		P95Utilization: a.CPU.Quantile(0.95),
+		P99Utilization: a.CPU.Quantile(0.99),
+		MaxUtilization: a.CPU.Max(),
+	}
+}
+
+func (a *Aggregate) RAMStats() *domain.RAMStats {
+	if a.RAM.Count() == 0 {
+		return nil
+	}
+	return &domain.RAMStats{
+		AvgUsageMB: a.RAM.Mean(),
+		P95UsageMB: a.RAM.Quantile(0.95),
+		P99UsageMB: a.RAM.Quantile(0.99),
+		MaxUsageMB: a.RAM.Max(),
+	}
+}
+
+// TopErrorCodes 出现次数最多的 k 个异常码（次数相同按异常码排序，结果稳定）
+//
+// Severity 留空，由诊断阶段结合知识库判定
+func (a *Aggregate) TopErrorCodes(k int) []domain.ErrorCodeSummary {
+	summaries := make([]domain.ErrorCodeSummary, 0, len(a.ErrorCodes))
+	for code, count := range a.ErrorCodes {
+		summaries = append(summaries, domain.ErrorCodeSummary{Code: code, Count: count})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].Code < summaries[j].Code
+	})
+	if k > 0 && len(summaries) > k {
+		summaries = summaries[:k]
+	}
+	return summaries
+}
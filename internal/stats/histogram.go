@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+)
+
+// subBucketBits 每个数量级内的子桶精度（2^8 = 256 个子桶，相对误差 < 1%）
+const subBucketBits = 8
+
+// Histogram HDR 风格的对数-线性直方图，用于流式计算分位数
+//
+// 内存固定（只与值域有关），相对误差 < 1/2^(subBucketBits-1)，多个直方图可以直接合并（按文件并行统计）。
+// 小于 2^subBucketBits 的值每个整数一个桶（精确）；更大的值按二进制数量级分段，
+// 每段内再等分成 2^(subBucketBits-1) 个子桶，桶宽随数值等比增长
+type Histogram struct {
+	resolution float64 // 最小分辨率，浮点值先除以它再取整（例如 0.01 表示精确到 0.01）
+	counts     []uint64
+
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// NewHistogram resolution <= 0 时按 1 处理
+func NewHistogram(resolution float64) *Histogram {
+	if resolution <= 0 {
+		resolution = 1
+	}
+	return &Histogram{resolution: resolution}
+}
+
+// Record 记录一个值（负数和 NaN 按 0 处理，这里的指标都是非负的）
+func (h *Histogram) Record(value float64) {
+	if value < 0 || math.IsNaN(value) {
+		value = 0
+	}
+
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+
+	idx := bucketIndex(h.toUnits(value))
+	if idx >= len(h.counts) {
+		grown := make([]uint64, idx+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+}
+
+// Merge 合并另一个直方图（两者的 resolution 必须一致）
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		grown := make([]uint64, len(other.counts))
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() uint64 { return h.count }
+
+func (h *Histogram) Min() float64 { return h.min }
+
+func (h *Histogram) Max() float64 { return h.max }
+
+func (h *Histogram) Mean() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / float64(h.count)
+}
+
+// Quantile 返回第 q 分位数（0 < q <= 1），取所在桶的中点并限制在 [min, max] 内
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	var seen uint64
+	for idx, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			low, width := bucketRange(idx)
+			mid := (float64(low) + float64(width-1)/2) * h.resolution
+			return math.Min(math.Max(mid, h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) toUnits(value float64) uint64 {
+	units := math.Round(value / h.resolution)
+	if units >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return uint64(units)
+}
+
+// bucketIndex 值 → 桶下标
+func bucketIndex(v uint64) int {
+	const subBuckets = 1 << subBucketBits
+	if v < subBuckets {
+		return int(v)
+	}
+	// shift >= 1；mantissa 落在 [2^(subBucketBits-1), 2^subBucketBits)
+	shift := bits.Len64(v) - subBucketBits
+	mantissa := v >> uint(shift)
+	return subBuckets + (shift-1)*(subBuckets/2) + int(mantissa-subBuckets/2)
+}
+
+// bucketRange 桶下标 → [low, low+width)
+func bucketRange(idx int) (low uint64, width uint64) {
+	const subBuckets = 1 << subBucketBits
+	if idx < subBuckets {
+		return uint64(idx), 1
+	}
+	offset := idx - subBuckets
+	shift := offset/(subBuckets/2) + 1
+	mantissa := uint64(offset%(subBuckets/2) + subBuckets/2)
+	return mantissa << uint(shift), 1 << uint(shift)
+}
+
+// Bin 等宽分布的一个区间 [Low, High)
+type Bin struct {
+	Low   float64
+	High  float64
+	Count uint64
+}
+
+// Distribution 把直方图重新划分为 [min, max] 上的 bins 个等宽区间（用于画分布图）
+//
+// 每个内部桶按中点归入等宽区间，误差与 Quantile 相同
+func (h *Histogram) Distribution(bins int) []Bin {
+	if h.count == 0 || bins <= 0 {
+		return nil
+	}
+	width := (h.max - h.min) / float64(bins)
+	if width <= 0 {
+		return []Bin{{Low: h.min, High: h.max, Count: h.count}}
+	}
+
+	out := make([]Bin, bins)
+	for i := range out {
+		out[i].Low = h.min + float64(i)*width
+		out[i].High = out[i].Low + width
+	}
+	for idx, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		low, w := bucketRange(idx)
+		mid := math.Min(math.Max((float64(low)+float64(w-1)/2)*h.resolution, h.min), h.max)
+		i := int((mid - h.min) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		out[i].Count += c
+	}
+	return out
+}
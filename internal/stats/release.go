@@ -0,0 +1,199 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+)
+
+// minCodeOccurrences 单个异常码两组合计少于该次数时不单独检验（避免大量低频码稀释多重检验）
+const minCodeOccurrences = 10
+
+// CompareReleases 逐指标比较基线版本与候选版本的样本
+//
+// 所有指标都是"越大越差"，只有显著且候选版本变差超过阈值才判定为回归；
+// 任一组样本少于 MinSamples 时照常计算指标，但不做显著性判定（insufficient = true）
+func CompareReleases(baseline, candidate []domain.ReleaseSample, th domain.RegressionThresholds) (metrics []domain.MetricComparison, insufficient bool) {
+	insufficient = len(baseline) < th.MinSamples || len(candidate) < th.MinSamples
+
+	metrics = append(metrics, compareFailureRate(baseline, candidate))
+	metrics = append(metrics, compareErrorRate(baseline, candidate))
+	metrics = append(metrics, compareErrorCodes(baseline, candidate)...)
+	metrics = append(metrics,
+		compareDistribution(domain.MetricCPUP95, cpuP95(baseline), cpuP95(candidate), th.MinSamples),
+		compareDistribution(domain.MetricRAMP95, ramP95(baseline), ramP95(candidate), th.MinSamples),
+	)
+
+	pValues := make([]float64, len(metrics))
+	for i, m := range metrics {
+		pValues[i] = m.PValue
+	}
+	for i, adjusted := range BenjaminiHochberg(pValues) {
+		m := &metrics[i]
+		m.AdjustedPValue = adjusted
+		if insufficient {
+			continue
+		}
+		m.Significant = adjusted < th.Alpha
+		m.Regression = m.Significant && m.RelativeChange >= th.MinRelativeChange
+	}
+	return metrics, insufficient
+}
+
+func compareFailureRate(baseline, candidate []domain.ReleaseSample) domain.MetricComparison {
+	failed := func(samples []domain.ReleaseSample) int {
+		n := 0
+		for _, s := range samples {
+			if s.Failed {
+				n++
+			}
+		}
+		return n
+	}
+	x1, x2 := failed(baseline), failed(candidate)
+	result := TwoProportionZTest(x1, len(baseline), x2, len(candidate))
+	return newMetric(domain.MetricFailureRate, "two_proportion_z",
+		ratio(float64(x1), float64(len(baseline))), ratio(float64(x2), float64(len(candidate))), result)
+}
+
+// exposure 记录数（千条）；没有报告的 Batch（失败在聚合前）不计入暴露量
+func exposure(samples []domain.ReleaseSample) float64 {
+	total := 0
+	for _, s := range samples {
+		total += s.RecordCount
+	}
+	return float64(total) / 1000
+}
+
+func compareErrorRate(baseline, candidate []domain.ReleaseSample) domain.MetricComparison {
+	count := func(samples []domain.ReleaseSample) int {
+		n := 0
+		for _, s := range samples {
+			if s.RecordCount > 0 {
+				n += s.ErrorOccurrences()
+			}
+		}
+		return n
+	}
+	c1, c2 := count(baseline), count(candidate)
+	t1, t2 := exposure(baseline), exposure(candidate)
+	return newMetric(domain.MetricErrorRate, "poisson_rate_z",
+		ratio(float64(c1), t1), ratio(float64(c2), t2), PoissonRateTest(c1, t1, c2, t2))
+}
+
+// compareErrorCodes 逐个异常码比较每千条记录的出现率
+//
+// 报告只保留 Top-K 异常码，K 之外的低频码在这里不可见，所以单码检验只对常见码有意义
+func compareErrorCodes(baseline, candidate []domain.ReleaseSample) []domain.MetricComparison {
+	countByCode := func(samples []domain.ReleaseSample) map[string]int {
+		counts := make(map[string]int)
+		for _, s := range samples {
+			if s.RecordCount == 0 {
+				continue
+			}
+			for _, code := range s.ErrorCodes {
+				counts[code.Code] += code.Count
+			}
+		}
+		return counts
+	}
+	before, after := countByCode(baseline), countByCode(candidate)
+	t1, t2 := exposure(baseline), exposure(candidate)
+
+	codes := make(map[string]struct{})
+	for code := range before {
+		codes[code] = struct{}{}
+	}
+	for code := range after {
+		codes[code] = struct{}{}
+	}
+	sorted := make([]string, 0, len(codes))
+	for code := range codes {
+		if before[code]+after[code] >= minCodeOccurrences {
+			sorted = append(sorted, code)
+		}
+	}
+	sort.Strings(sorted)
+
+	metrics := make([]domain.MetricComparison, 0, len(sorted))
+	for _, code := range sorted {
+		c1, c2 := before[code], after[code]
+		m := newMetric(domain.MetricErrorCode, "poisson_rate_z",
+			ratio(float64(c1), t1), ratio(float64(c2), t2), PoissonRateTest(c1, t1, c2, t2))
+		m.ErrorCode = code
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// compareDistribution 比较 Batch 级指标的分布，Baseline / Candidate 取中位数
+func compareDistribution(metric domain.RegressionMetric, baseline, candidate []float64, minSamples int) domain.MetricComparison {
+	result := noEvidence
+	// 有报告的 Batch 可能明显少于总样本数，不足时不检验（p = 1）
+	if len(baseline) >= minSamples && len(candidate) >= minSamples {
+		result = MannWhitneyU(baseline, candidate)
+	}
+	return newMetric(metric, "mann_whitney_u", median(baseline), median(candidate), result)
+}
+
+func cpuP95(samples []domain.ReleaseSample) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if s.CPU != nil {
+			values = append(values, s.CPU.P95Utilization)
+		}
+	}
+	return values
+}
+
+func ramP95(samples []domain.ReleaseSample) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if s.RAM != nil {
+			values = append(values, s.RAM.P95UsageMB)
+		}
+	}
+	return values
+}
+
+func newMetric(metric domain.RegressionMetric, test string, baseline, candidate float64, result TestResult) domain.MetricComparison {
+	return domain.MetricComparison{
+		Metric:         metric,
+		Test:           test,
+		Baseline:       baseline,
+		Candidate:      candidate,
+		RelativeChange: relativeChange(baseline, candidate),
+		PValue:         result.PValue,
+	}
+}
+
+// relativeChange 基线为 0 时没有相对变化可言：候选版本出现了就记为 +100%（JSON 不能编码 Inf）
+func relativeChange(baseline, candidate float64) float64 {
+	if baseline == 0 {
+		if candidate > 0 {
+			return 1
+		}
+		return 0
+	}
+	return (candidate - baseline) / baseline
+}
+
+func ratio(num, den float64) float64 {
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
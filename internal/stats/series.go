@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultSeriesInterval 时间序列默认按分钟降采样
+const DefaultSeriesInterval = time.Minute
+
+// SeriesPoint 一个时间窗口内的平均值和峰值（窗口内某项指标全部缺失时为 NaN）
+type SeriesPoint struct {
+	Time   time.Time
+	AvgCPU float64
+	MaxCPU float64
+	AvgRAM float64
+	MaxRAM float64
+	Count  int
+}
+
+type seriesBucket struct {
+	count    int
+	cpuCount int // 只统计有值的记录，缺失值不参与平均
+	cpuSum   float64
+	cpuMax   float64
+	ramCount int
+	ramSum   float64
+	ramMax   float64
+}
+
+// TimeSeries 按固定窗口降采样的 CPU / 内存时间序列
+//
+// 按窗口降采样，内存与时长成正比而不是与记录数成正比；同一窗口的 sum/count/max 可直接累加，
+// 与直方图一样可以按文件并行统计再合并
+type TimeSeries struct {
+	interval time.Duration
+	buckets  map[int64]*seriesBucket // 窗口起点（Unix 纳秒 / interval）→ 累计值
+}
+
+// NewTimeSeries interval <= 0 时按 DefaultSeriesInterval 处理
+func NewTimeSeries(interval time.Duration) *TimeSeries {
+	if interval <= 0 {
+		interval = DefaultSeriesInterval
+	}
+	return &TimeSeries{interval: interval, buckets: make(map[int64]*seriesBucket)}
+}
+
+// Add 记录一个采样点（没有时间戳的记录不进入时间序列；缺失的指标传 NaN）
+func (s *TimeSeries) Add(ts time.Time, cpu, ram float64) {
+	if ts.IsZero() {
+		return
+	}
+	key := ts.UnixNano() / int64(s.interval)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &seriesBucket{}
+		s.buckets[key] = b
+	}
+	b.count++
+	if !math.IsNaN(cpu) {
+		if b.cpuCount == 0 || cpu > b.cpuMax {
+			b.cpuMax = cpu
+		}
+		b.cpuCount++
+		b.cpuSum += cpu
+	}
+	if !math.IsNaN(ram) {
+		if b.ramCount == 0 || ram > b.ramMax {
+			b.ramMax = ram
+		}
+		b.ramCount++
+		b.ramSum += ram
+	}
+}
+
+// Merge 合并另一个时间序列（两者的 interval 必须一致）
+func (s *TimeSeries) Merge(other *TimeSeries) {
+	if other == nil {
+		return
+	}
+	for key, ob := range other.buckets {
+		b, ok := s.buckets[key]
+		if !ok {
+			copied := *ob
+			s.buckets[key] = &copied
+			continue
+		}
+		b.count += ob.count
+		if ob.cpuCount > 0 && (b.cpuCount == 0 || ob.cpuMax > b.cpuMax) {
+			b.cpuMax = ob.cpuMax
+		}
+		b.cpuCount += ob.cpuCount
+		b.cpuSum += ob.cpuSum
+		if ob.ramCount > 0 && (b.ramCount == 0 || ob.ramMax > b.ramMax) {
+			b.ramMax = ob.ramMax
+		}
+		b.ramCount += ob.ramCount
+		b.ramSum += ob.ramSum
+	}
+}
+
+// Len 窗口个数
+func (s *TimeSeries) Len() int { return len(s.buckets) }
+
+// Points 按时间升序返回各窗口的统计值
+func (s *TimeSeries) Points() []SeriesPoint {
+	keys := make([]int64, 0, len(s.buckets))
+	for key := range s.buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	points := make([]SeriesPoint, 0, len(keys))
+	for _, key := range keys {
+		b := s.buckets[key]
+		point := SeriesPoint{
+			Time:   time.Unix(0, key*int64(s.interval)).UTC(),
+			AvgCPU: math.NaN(),
+			MaxCPU: math.NaN(),
+			AvgRAM: math.NaN(),
+			MaxRAM: math.NaN(),
+			Count:  b.count,
+		}
+		if b.cpuCount > 0 {
+			point.AvgCPU, point.MaxCPU = b.cpuSum/float64(b.cpuCount), b.cpuMax
+		}
+		if b.ramCount > 0 {
+			point.AvgRAM, point.MaxRAM = b.ramSum/float64(b.ramCount), b.ramMax
+		}
+		points = append(points, point)
+	}
+	return points
+}
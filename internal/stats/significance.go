@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// TestResult 假设检验结果（Statistic 为 z 统计量，正值表示第二组更大）
+type TestResult struct {
+	Statistic float64
+	PValue    float64 // 双侧 p 值
+}
+
+// noEvidence 无法检验（样本为空、方差为 0）时的结果
+var noEvidence = TestResult{Statistic: 0, PValue: 1}
+
+// NormalCDF 标准正态分布的累积分布函数
+func NormalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func twoSided(z float64) TestResult {
+	return TestResult{Statistic: z, PValue: 2 * (1 - NormalCDF(math.Abs(z)))}
+}
+
+// MannWhitneyU 比较两组样本的分布（正态近似 + 并列秩校正 + 连续性校正）
+//
+// 资源占用是长尾分布，t 检验假设正态、对离群值敏感；Mann-Whitney 只用秩，比较的是一组是否系统性地偏大
+func MannWhitneyU(a, b []float64) TestResult {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return noEvidence
+	}
+
+	type ranked struct {
+		value float64
+		first bool
+	}
+	all := make([]ranked, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, ranked{value: v, first: true})
+	}
+	for _, v := range b {
+		all = append(all, ranked{value: v})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// 并列值取平均秩，同时累计并列校正项 Σ(t³ - t)
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		avgRank := float64(i+j+1) / 2 // 秩从 1 开始：(i+1 + j) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSumA += avgRank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u := rankSumA - fn1*(fn1+1)/2
+	mean := fn1 * fn2 / 2
+	variance := fn1 * fn2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return noEvidence
+	}
+
+	// U 是第一组"赢"的次数，第二组偏大时 U 偏小，取反让正值表示第二组更大
+	diff := mean - u
+	switch {
+	case diff > 0.5:
+		diff -= 0.5
+	case diff < -0.5:
+		diff += 0.5
+	default:
+		diff = 0
+	}
+	return twoSided(diff / math.Sqrt(variance))
+}
+
+// TwoProportionZTest 比较两组的比例 x1/n1 与 x2/n2（合并方差）
+func TwoProportionZTest(x1, n1, x2, n2 int) TestResult {
+	if n1 == 0 || n2 == 0 {
+		return noEvidence
+	}
+	p1 := float64(x1) / float64(n1)
+	p2 := float64(x2) / float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return noEvidence
+	}
+	return twoSided((p2 - p1) / se)
+}
+
+// PoissonRateTest 比较两组的事件率 c1/t1 与 c2/t2（t 为暴露量，例如千条记录）
+//
+// 异常码是计数数据，每个 Batch 的记录数不同，直接比较次数没有意义，要按暴露量折算成率再比较
+func PoissonRateTest(c1 int, t1 float64, c2 int, t2 float64) TestResult {
+	if t1 <= 0 || t2 <= 0 {
+		return noEvidence
+	}
+	pooled := float64(c1+c2) / (t1 + t2)
+	se := math.Sqrt(pooled * (1/t1 + 1/t2))
+	if se == 0 {
+		return noEvidence
+	}
+	return twoSided((float64(c2)/t2 - float64(c1)/t1) / se)
+}
+
+// BenjaminiHochberg 多重检验校正，返回与输入顺序一致的校正后 p 值（控制错误发现率 FDR）
+//
+// 一次对比检验几十个指标（每个异常码一个），不校正时纯随机也会有几个显著；
+// BH 控制的是被标记的回归中误报的比例，比 Bonferroni 宽松
+func BenjaminiHochberg(pValues []float64) []float64 {
+	m := len(pValues)
+	adjusted := make([]float64, m)
+	if m == 0 {
+		return adjusted
+	}
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return pValues[order[i]] < pValues[order[j]] })
+
+	// 从最大的 p 值往前取累计最小值，保证校正后单调
+	minSoFar := 1.0
+	for rank := m; rank >= 1; rank-- {
+		idx := order[rank-1]
+		v := pValues[idx] * float64(m) / float64(rank)
+		if v < minSoFar {
+			minSoFar = v
+		}
+		adjusted[idx] = minSoFar
+	}
+	return adjusted
+}
@@ -0,0 +1,38 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuewentao/argus-ota-platform/internal/parser"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// TestAggregate_SkipsMissingMetrics - 缺失的 CPU / 内存值不计入分布和时间序列
+func TestAggregate_SkipsMissingMetrics(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	agg := stats.NewAggregate()
+	agg.Add(parser.Record{Timestamp: ts, CPUUtilization: 80, HasCPU: true})
+	agg.Add(parser.Record{Timestamp: ts.Add(time.Second), RAMUsageMB: 1024, HasRAM: true})
+
+	assert.Equal(t, 2, agg.Records)
+	require.NotNil(t, agg.CPUStats())
+	assert.InDelta(t, 80, agg.CPUStats().AvgUtilization, 0.01)
+	assert.InDelta(t, 1024, agg.RAMStats().AvgUsageMB, 0.1)
+
+	points := agg.Series.Points()
+	require.Len(t, points, 1)
+	assert.Equal(t, 80.0, points[0].AvgCPU)
+	assert.Equal(t, 1024.0, points[0].AvgRAM)
+	assert.Equal(t, 2, points[0].Count)
+
+	// 整个 Batch 都没有内存数据：报告显示“无数据”，时间序列为 NaN
+	cpuOnly := stats.NewAggregate()
+	cpuOnly.Add(parser.Record{Timestamp: ts, CPUUtilization: 10, HasCPU: true})
+	assert.Nil(t, cpuOnly.RAMStats())
+	assert.True(t, math.IsNaN(cpuOnly.Series.Points()[0].AvgRAM))
+}
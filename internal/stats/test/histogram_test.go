@@ -0,0 +1,50 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// TestHistogram_QuantileWithinRelativeError - 分位数相对误差 < 1%
+func TestHistogram_QuantileWithinRelativeError(t *testing.T) {
+	h := stats.NewHistogram(0.01)
+	for i := 1; i <= 100000; i++ {
+		h.Record(float64(i) / 10) // 0.1 ~ 10000
+	}
+
+	assert.Equal(t, uint64(100000), h.Count())
+	assert.InDelta(t, 5000.05, h.Mean(), 0.001)
+	assert.Equal(t, 10000.0, h.Max())
+	assert.InEpsilon(t, 9500.0, h.Quantile(0.95), 0.01)
+	assert.InEpsilon(t, 9900.0, h.Quantile(0.99), 0.01)
+	assert.InEpsilon(t, 5000.0, h.Quantile(0.50), 0.01)
+}
+
+// TestHistogram_Merge - 分文件统计后合并，与整体统计结果一致
+func TestHistogram_Merge(t *testing.T) {
+	whole := stats.NewHistogram(1)
+	a, b := stats.NewHistogram(1), stats.NewHistogram(1)
+	for i := 0; i < 1000; i++ {
+		whole.Record(float64(i))
+		if i%2 == 0 {
+			a.Record(float64(i))
+		} else {
+			b.Record(float64(i))
+		}
+	}
+	a.Merge(b)
+
+	assert.Equal(t, whole.Count(), a.Count())
+	assert.Equal(t, whole.Min(), a.Min())
+	assert.Equal(t, whole.Max(), a.Max())
+	assert.Equal(t, whole.Quantile(0.99), a.Quantile(0.99))
+}
+
+// TestHistogram_Empty - 空直方图返回 0
+func TestHistogram_Empty(t *testing.T) {
+	h := stats.NewHistogram(1)
+	assert.Equal(t, 0.0, h.Quantile(0.99))
+	assert.Equal(t, 0.0, h.Mean())
+}
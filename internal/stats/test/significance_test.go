@@ -0,0 +1,74 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuewentao/argus-ota-platform/internal/domain"
+	"github.com/xuewentao/argus-ota-platform/internal/stats"
+)
+
+// TestSignificance_KnownValues - 与手算结果对照
+func TestSignificance_KnownValues(t *testing.T) {
+	assert.InDelta(t, 0.975, stats.NormalCDF(1.96), 0.0001)
+
+	// p1 = 0.1, p2 = 0.2, 合并比例 0.15 → z ≈ 1.98
+	prop := stats.TwoProportionZTest(10, 100, 20, 100)
+	assert.InDelta(t, 1.980, prop.Statistic, 0.001)
+	assert.InDelta(t, 0.0477, prop.PValue, 0.001)
+
+	// 两组完全分离：U = 0，方差 = 10*10*21/12
+	a := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	b := []float64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	mw := stats.MannWhitneyU(a, b)
+	assert.Greater(t, mw.Statistic, 0.0, "second group is larger")
+	assert.InDelta(t, 0.00018, mw.PValue, 0.00002)
+
+	// 完全相同（全部并列）时没有证据
+	assert.Equal(t, 1.0, stats.MannWhitneyU([]float64{5, 5, 5}, []float64{5, 5}).PValue)
+
+	// 率相同 → p = 1
+	assert.InDelta(t, 1.0, stats.PoissonRateTest(50, 10, 100, 20).PValue, 1e-9)
+
+	adjusted := stats.BenjaminiHochberg([]float64{0.01, 0.04, 0.03, 0.5})
+	assert.InDeltaSlice(t, []float64{0.04, 0.04 * 4 / 3, 0.04 * 4 / 3, 0.5}, adjusted, 1e-9)
+}
+
+func releaseSamples(n int, cpuBase float64, errorsPerBatch int) []domain.ReleaseSample {
+	samples := make([]domain.ReleaseSample, n)
+	for i := range samples {
+		samples[i] = domain.ReleaseSample{
+			RecordCount: 1000,
+			ErrorCodes:  []domain.ErrorCodeSummary{{Code: "E1001", Count: errorsPerBatch}},
+			CPU:         &domain.CPUStats{P95Utilization: cpuBase + float64(i%5)},
+			RAM:         &domain.RAMStats{P95UsageMB: 2048 + float64(i%7)},
+		}
+	}
+	return samples
+}
+
+// TestCompareReleases_FlagsCPURegression - CPU 明显升高判定为回归，未变化的指标不误报
+func TestCompareReleases_FlagsCPURegression(t *testing.T) {
+	th := domain.DefaultRegressionThresholds()
+	metrics, insufficient := stats.CompareReleases(releaseSamples(40, 50, 2), releaseSamples(40, 70, 2), th)
+	require.False(t, insufficient)
+
+	byMetric := make(map[domain.RegressionMetric]domain.MetricComparison)
+	for _, m := range metrics {
+		byMetric[m.Metric] = m
+	}
+	cpu := byMetric[domain.MetricCPUP95]
+	assert.True(t, cpu.Regression)
+	assert.InDelta(t, 20.0/52, cpu.RelativeChange, 1e-9) // 中位数 52 → 72
+	assert.False(t, byMetric[domain.MetricRAMP95].Regression)
+	assert.False(t, byMetric[domain.MetricErrorRate].Regression)
+	assert.False(t, byMetric[domain.MetricFailureRate].Regression)
+
+	// 样本不足时不做判定
+	metrics, insufficient = stats.CompareReleases(releaseSamples(5, 50, 2), releaseSamples(5, 90, 20), th)
+	assert.True(t, insufficient)
+	for _, m := range metrics {
+		assert.False(t, m.Regression, m.Metric)
+	}
+}